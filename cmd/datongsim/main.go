@@ -0,0 +1,183 @@
+// datongsim simulates a DaTong chain in memory, to study ticket selection,
+// block times and mining rewards under different ticket distributions.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/FusionFoundation/efsn/internal/flags"
+	"github.com/FusionFoundation/efsn/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+var gitDate = ""
+
+var app *cli.App
+
+var (
+	blocksFlag = cli.IntFlag{
+		Name:  "blocks",
+		Usage: "number of blocks to simulate",
+		Value: 1000,
+	}
+	ticketsFlag = cli.StringFlag{
+		Name:  "tickets",
+		Usage: "comma separated genesis ticket counts, one miner per entry",
+		Value: "10,10,10,10,10",
+	}
+	offlineFlag = cli.StringFlag{
+		Name:  "offline",
+		Usage: "comma separated offline miners, given as <index> or <index>:<fromblock>:<toblock>",
+	}
+	buyFlag = cli.StringFlag{
+		Name:  "buy",
+		Usage: "comma separated buy patterns, one per miner or one for all: keep, none or <n> (one ticket every n blocks)",
+		Value: "keep",
+	}
+	periodFlag = cli.Uint64Flag{
+		Name:  "period",
+		Usage: "DaTong block period in seconds",
+		Value: 15,
+	}
+	latencyFlag = cli.Uint64Flag{
+		Name:  "latency",
+		Usage: "seconds between the release of a block and the start of sealing its child",
+		Value: 1,
+	}
+	seedFlag = cli.Uint64Flag{
+		Name:  "seed",
+		Usage: "seed of the miner keys, different seeds give different ticket ids",
+		Value: 1,
+	}
+	jsonFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "output JSON instead of human-readable format",
+	}
+	verbosityFlag = cli.IntFlag{
+		Name:  "verbosity",
+		Usage: "log verbosity of the simulated chain (0-5)",
+		Value: 0,
+	}
+)
+
+func init() {
+	app = flags.NewApp(gitCommit, gitDate, "a DaTong chain simulator")
+	app.Flags = []cli.Flag{
+		blocksFlag,
+		ticketsFlag,
+		offlineFlag,
+		buyFlag,
+		periodFlag,
+		latencyFlag,
+		seedFlag,
+		jsonFlag,
+		verbosityFlag,
+	}
+	app.Action = simulate
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func simulate(ctx *cli.Context) error {
+	if verbosity := ctx.Int(verbosityFlag.Name); verbosity > 0 {
+		log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(verbosity), log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+	}
+	config, err := makeConfig(ctx)
+	if err != nil {
+		return err
+	}
+	sim, err := newSimulator(config)
+	if err != nil {
+		return err
+	}
+	// a failed simulation still reports the blocks simulated so far
+	report, err := sim.run(ctx.Int(blocksFlag.Name))
+	if report == nil {
+		return err
+	}
+	if ctx.Bool(jsonFlag.Name) {
+		if err := report.printJSON(os.Stdout); err != nil {
+			return err
+		}
+	} else {
+		report.print(os.Stdout)
+	}
+	if err != nil {
+		return fmt.Errorf("simulation stopped: %v", err)
+	}
+	return nil
+}
+
+func makeConfig(ctx *cli.Context) (*simConfig, error) {
+	config := &simConfig{
+		period:  ctx.Uint64(periodFlag.Name),
+		latency: ctx.Uint64(latencyFlag.Name),
+		seed:    ctx.Uint64(seedFlag.Name),
+	}
+	if config.period == 0 {
+		return nil, fmt.Errorf("period must be greater than 0")
+	}
+	for _, item := range strings.Split(ctx.String(ticketsFlag.Name), ",") {
+		count, err := strconv.ParseUint(strings.TrimSpace(item), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket count %q: %v", item, err)
+		}
+		config.miners = append(config.miners, &minerConfig{tickets: count})
+	}
+
+	buys := strings.Split(ctx.String(buyFlag.Name), ",")
+	if len(buys) != 1 && len(buys) != len(config.miners) {
+		return nil, fmt.Errorf("got %d buy patterns for %d miners", len(buys), len(config.miners))
+	}
+	for i, miner := range config.miners {
+		pattern := buys[0]
+		if len(buys) > 1 {
+			pattern = buys[i]
+		}
+		switch pattern = strings.TrimSpace(pattern); pattern {
+		case "keep":
+			miner.keep = true
+		case "none":
+		default:
+			every, err := strconv.ParseUint(pattern, 10, 64)
+			if err != nil || every == 0 {
+				return nil, fmt.Errorf("invalid buy pattern %q", pattern)
+			}
+			miner.buyEvery = every
+		}
+	}
+
+	if offline := ctx.String(offlineFlag.Name); offline != "" {
+		for _, item := range strings.Split(offline, ",") {
+			parts := strings.Split(strings.TrimSpace(item), ":")
+			if len(parts) != 1 && len(parts) != 3 {
+				return nil, fmt.Errorf("invalid offline miner %q", item)
+			}
+			index, err := strconv.Atoi(parts[0])
+			if err != nil || index < 0 || index >= len(config.miners) {
+				return nil, fmt.Errorf("invalid offline miner index %q", parts[0])
+			}
+			period := offlinePeriod{from: 0, to: ^uint64(0)}
+			if len(parts) == 3 {
+				if period.from, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+					return nil, fmt.Errorf("invalid offline block %q: %v", parts[1], err)
+				}
+				if period.to, err = strconv.ParseUint(parts[2], 10, 64); err != nil {
+					return nil, fmt.Errorf("invalid offline block %q: %v", parts[2], err)
+				}
+			}
+			config.miners[index].offline = append(config.miners[index].offline, period)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/params"
+)
+
+// report aggregates the block stats of a simulation.
+type report struct {
+	Blocks        uint64            `json:"blocks"`
+	Duration      uint64            `json:"duration"`
+	AvgBlockTime  float64           `json:"avgBlockTime"`
+	BlockTimes    map[uint64]uint64 `json:"blockTimes"`    // block interval in seconds => blocks
+	Orders        map[uint64]uint64 `json:"orders"`        // selection order of the sealer => blocks
+	RetreatBlocks uint64            `json:"retreatBlocks"` // blocks retreating tickets
+	Retreated     uint64            `json:"retreated"`     // retreated tickets
+	Miners        []*minerReport    `json:"miners"`
+
+	byAddress map[common.Address]*minerReport
+	sumReward *big.Int
+}
+
+type minerReport struct {
+	Address         common.Address `json:"address"`
+	GenesisTickets  uint64         `json:"genesisTickets"`
+	Tickets         uint64         `json:"tickets"`
+	AvgTickets      float64        `json:"avgTickets"`
+	Bought          uint64         `json:"bought"`
+	Blocks          uint64         `json:"blocks"`
+	Retreated       uint64         `json:"retreated"`
+	Reward          *big.Int       `json:"reward"`
+	ExpectedReward  *big.Int       `json:"expectedReward"` // reward in proportion to the tickets held
+	BlockShare      float64        `json:"blockShare"`
+	TicketShare     float64        `json:"ticketShare"`
+	ticketSum       uint64
+	expectedRewardF *big.Float
+}
+
+func newReport(sim *simulator) *report {
+	rep := &report{
+		BlockTimes: make(map[uint64]uint64),
+		Orders:     make(map[uint64]uint64),
+		byAddress:  make(map[common.Address]*minerReport),
+		sumReward:  new(big.Int),
+	}
+	for _, miner := range sim.miners {
+		mr := &minerReport{
+			Address:         miner.address,
+			GenesisTickets:  miner.tickets,
+			Reward:          new(big.Int),
+			expectedRewardF: new(big.Float),
+		}
+		rep.Miners = append(rep.Miners, mr)
+		rep.byAddress[miner.address] = mr
+	}
+	return rep
+}
+
+func (rep *report) add(stats *blockStats) {
+	rep.Blocks++
+	rep.Duration += stats.interval
+	rep.BlockTimes[stats.interval]++
+	rep.Orders[stats.order]++
+	if len(stats.retreat) > 0 {
+		rep.RetreatBlocks++
+		rep.Retreated += uint64(len(stats.retreat))
+	}
+	rep.sumReward.Add(rep.sumReward, stats.reward)
+
+	sealer := rep.byAddress[stats.miner]
+	sealer.Blocks++
+	sealer.Reward.Add(sealer.Reward, stats.reward)
+	for _, owner := range stats.retreat {
+		if mr := rep.byAddress[owner]; mr != nil {
+			mr.Retreated++
+		}
+	}
+	for _, buyer := range stats.bought {
+		rep.byAddress[buyer].Bought++
+	}
+
+	var total uint64
+	for _, count := range stats.tickets {
+		total += count
+	}
+	if total == 0 {
+		return
+	}
+	reward := new(big.Float).SetInt(stats.reward)
+	for addr, count := range stats.tickets {
+		mr := rep.byAddress[addr]
+		mr.ticketSum += count
+		share := new(big.Float).Mul(reward, big.NewFloat(float64(count)/float64(total)))
+		mr.expectedRewardF.Add(mr.expectedRewardF, share)
+	}
+}
+
+func (rep *report) finish(sim *simulator) *report {
+	if tickets, err := sim.ticketsAt(sim.head); err == nil {
+		for addr, count := range tickets {
+			rep.byAddress[addr].Tickets = count
+		}
+	}
+	if rep.Blocks == 0 {
+		return rep
+	}
+	rep.AvgBlockTime = float64(rep.Duration) / float64(rep.Blocks)
+
+	var ticketSum uint64
+	for _, mr := range rep.Miners {
+		ticketSum += mr.ticketSum
+	}
+	for _, mr := range rep.Miners {
+		mr.AvgTickets = float64(mr.ticketSum) / float64(rep.Blocks)
+		mr.BlockShare = float64(mr.Blocks) / float64(rep.Blocks)
+		if ticketSum > 0 {
+			mr.TicketShare = float64(mr.ticketSum) / float64(ticketSum)
+		}
+		mr.ExpectedReward, _ = mr.expectedRewardF.Int(nil)
+	}
+	return rep
+}
+
+func (rep *report) printJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+func (rep *report) print(w io.Writer) {
+	fmt.Fprintf(w, "blocks: %d, duration: %ds, average block time: %.2fs\n", rep.Blocks, rep.Duration, rep.AvgBlockTime)
+	fmt.Fprintf(w, "retreats: %d tickets in %d blocks\n", rep.Retreated, rep.RetreatBlocks)
+	fmt.Fprintf(w, "total reward: %s FSN\n\n", formatFSN(rep.sumReward))
+
+	fmt.Fprintf(w, "%-5s %-42s %8s %8s %8s %7s %7s %9s %8s %8s %14s %14s\n",
+		"miner", "address", "genesis", "tickets", "average", "bought", "blocks", "retreated", "blocks%", "tickets%", "reward", "expected")
+	for i, mr := range rep.Miners {
+		fmt.Fprintf(w, "%-5d %-42s %8d %8d %8.2f %7d %7d %9d %7.2f%% %7.2f%% %14s %14s\n",
+			i, mr.Address.Hex(), mr.GenesisTickets, mr.Tickets, mr.AvgTickets, mr.Bought, mr.Blocks, mr.Retreated,
+			mr.BlockShare*100, mr.TicketShare*100, formatFSN(mr.Reward), formatFSN(mr.ExpectedReward))
+	}
+
+	fmt.Fprintf(w, "\nblock times:\n")
+	printHistogram(w, rep.BlockTimes, rep.Blocks, "%5ds")
+	fmt.Fprintf(w, "\nsealer orders:\n")
+	printHistogram(w, rep.Orders, rep.Blocks, "%6d")
+}
+
+func printHistogram(w io.Writer, counts map[uint64]uint64, total uint64, keyFormat string) {
+	keys := make([]uint64, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		fmt.Fprintf(w, keyFormat+" %8d %7.2f%%\n", key, counts[key], float64(counts[key])*100/float64(total))
+	}
+}
+
+func formatFSN(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	fsn := new(big.Float).Quo(new(big.Float).SetInt(value), big.NewFloat(params.Ether))
+	return fsn.Text('f', 4)
+}
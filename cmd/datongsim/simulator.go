@@ -0,0 +1,326 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/consensus/misc"
+	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/core/vm"
+	"github.com/FusionFoundation/efsn/crypto"
+	"github.com/FusionFoundation/efsn/ethdb"
+	"github.com/FusionFoundation/efsn/params"
+)
+
+const (
+	genesisTime    = 1561852800 // June 30 2019
+	ticketLifetime = 30 * 24 * 3600
+	buyTicketGas   = 90000
+)
+
+// minerFunds is the genesis balance of every miner, enough to keep buying tickets.
+var minerFunds = new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18))
+
+type simConfig struct {
+	miners  []*minerConfig
+	period  uint64
+	latency uint64
+	seed    uint64
+}
+
+type minerConfig struct {
+	tickets  uint64          // number of genesis tickets
+	keep     bool            // buy tickets to keep the genesis ticket count
+	buyEvery uint64          // buy a ticket every buyEvery blocks
+	offline  []offlinePeriod // blocks during which the miner doesn't seal
+}
+
+// offlinePeriod is a range of blocks [from, to).
+type offlinePeriod struct {
+	from, to uint64
+}
+
+type simMiner struct {
+	*minerConfig
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func (m *simMiner) isOnline(number uint64) bool {
+	for _, period := range m.offline {
+		if number >= period.from && number < period.to {
+			return false
+		}
+	}
+	return true
+}
+
+// simulator drives core.GenerateChain with the DaTong engine, one block at a
+// time, on top of an in-memory database.
+type simulator struct {
+	config      *simConfig
+	chainConfig *params.ChainConfig
+	db          ethdb.Database
+	engine      *datong.DaTong
+	chain       *core.BlockChain
+	miners      []*simMiner
+	byAddress   map[common.Address]*simMiner
+
+	head     *types.Block
+	headTd   *big.Int
+	sealTime uint64 // when the head block was released
+}
+
+func newSimulator(config *simConfig) (*simulator, error) {
+	if len(config.miners) == 0 {
+		return nil, fmt.Errorf("no miners to simulate")
+	}
+	chainConfig := *params.DevnetChainConfig
+	chainConfig.DaTong = &params.DaTongConfig{Period: config.period}
+
+	sim := &simulator{
+		config:      config,
+		chainConfig: &chainConfig,
+		db:          rawdb.NewMemoryDatabase(),
+		byAddress:   make(map[common.Address]*simMiner),
+	}
+	genesis := &core.Genesis{
+		Config:     sim.chainConfig,
+		Nonce:      1,
+		Timestamp:  genesisTime,
+		GasLimit:   params.GenesisGasLimit * 10,
+		Difficulty: big.NewInt(1),
+		Alloc:      make(core.GenesisAlloc),
+	}
+	for i, mc := range config.miners {
+		seed := make([]byte, 16)
+		binary.BigEndian.PutUint64(seed, config.seed)
+		binary.BigEndian.PutUint64(seed[8:], uint64(i))
+		key, err := crypto.ToECDSA(crypto.Keccak256(seed))
+		if err != nil {
+			return nil, err
+		}
+		miner := &simMiner{minerConfig: mc, key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+		sim.miners = append(sim.miners, miner)
+		sim.byAddress[miner.address] = miner
+
+		genesis.Alloc[miner.address] = core.GenesisAccount{Balance: minerFunds}
+		if mc.tickets > 0 {
+			genesis.Tickets = append(genesis.Tickets, core.TicketsCreate{
+				Owner: miner.address,
+				Count: mc.tickets,
+				Time:  genesisTime,
+			})
+		}
+	}
+	block, err := genesis.Commit(sim.db)
+	if err != nil {
+		return nil, err
+	}
+	sim.engine = datong.New(sim.chainConfig.DaTong, sim.db)
+	sim.engine.SetStateCache(state.NewDatabase(sim.db))
+	if sim.chain, err = core.NewBlockChain(sim.db, nil, sim.chainConfig, sim.engine, vm.Config{}, nil); err != nil {
+		return nil, err
+	}
+	sim.head, sim.headTd, sim.sealTime = block, block.Difficulty(), block.Time()
+	return sim, nil
+}
+
+// run simulates the given number of blocks. On failure it returns the report
+// of the blocks simulated so far along with the error.
+func (sim *simulator) run(blocks int) (*report, error) {
+	defer sim.chain.Stop()
+
+	rep := newReport(sim)
+	for i := 0; i < blocks; i++ {
+		stats, err := sim.next()
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			return rep.finish(sim), fmt.Errorf("block %d: %v", sim.head.NumberU64()+1, err)
+		}
+		rep.add(stats)
+	}
+	return rep.finish(sim), nil
+}
+
+// blockStats is what the simulator learns about each sealed block.
+type blockStats struct {
+	number   uint64
+	miner    common.Address
+	order    uint64
+	interval uint64                    // seconds since the parent was released
+	tickets  map[common.Address]uint64 // tickets of the miners at the parent block
+	retreat  []common.Address
+	bought   []common.Address
+	reward   *big.Int
+}
+
+// next seals a block on top of the head, by the first online miner in the
+// selection order of the DaTong engine.
+func (sim *simulator) next() (*blockStats, error) {
+	parent := sim.head
+	number := parent.NumberU64() + 1
+
+	order, err := sim.engine.SelectionOrder(sim.chain, parent.Header())
+	if err != nil {
+		return nil, err
+	}
+	var sealer *simMiner
+	for _, ticket := range order {
+		if miner := sim.byAddress[ticket.Owner]; miner != nil && miner.isOnline(number) {
+			sealer = miner
+			break
+		}
+	}
+	if sealer == nil {
+		return nil, fmt.Errorf("no online miner has a ticket")
+	}
+	tickets, err := sim.ticketsAt(parent)
+	if err != nil {
+		return nil, err
+	}
+	buyers := sim.ticketBuyers(tickets, number)
+
+	// the sealer starts working once the parent arrives, Prepare delays
+	// the block time of sealers which are not first in order.
+	start := sim.sealTime + sim.config.latency
+	if start <= parent.Time() {
+		start = parent.Time() + 1
+	}
+	var (
+		genErr error
+		bought []common.Address
+	)
+	blocks, receipts := core.GenerateChain(sim.chainConfig, parent, sim.engine, sim.db, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(sealer.address)
+		b.OffsetTime(int64(start) - int64(parent.Time()+10))
+		if genErr = b.Prepare(); genErr != nil {
+			return
+		}
+		for _, miner := range buyers {
+			tx, err := sim.buyTicketTx(miner, b.TxNonce(miner.address), parent)
+			if err != nil {
+				genErr = err
+				return
+			}
+			b.AddTxWithChain(sim.chain, tx)
+			bought = append(bought, miner.address)
+		}
+	})
+	if genErr != nil {
+		return nil, genErr
+	}
+	block := blocks[0]
+	if block == nil {
+		return nil, fmt.Errorf("block not finalized, the tickets are probably used up")
+	}
+	sim.writeBlock(block, receipts[0])
+
+	header := block.Header()
+	sealTime, err := sim.engine.SealTime(sim.chain, header)
+	if err != nil {
+		return nil, err
+	}
+	interval := uint64(sealTime.Unix()) - sim.sealTime
+	sim.sealTime = uint64(sealTime.Unix())
+
+	stats := &blockStats{
+		number:   number,
+		miner:    sealer.address,
+		order:    header.Nonce.Uint64(),
+		interval: interval,
+		tickets:  tickets,
+		bought:   bought,
+		reward:   datong.CalcRewards(header.Number),
+	}
+	snap, err := datong.NewSnapshotFromHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[common.Hash]common.Address, len(order))
+	for _, ticket := range order {
+		owners[ticket.ID] = ticket.Owner
+	}
+	for _, id := range snap.Retreat {
+		stats.retreat = append(stats.retreat, owners[id])
+	}
+	return stats, nil
+}
+
+// ticketBuyers returns the miners buying a ticket in the given block, an
+// account can buy only one ticket per block.
+func (sim *simulator) ticketBuyers(tickets map[common.Address]uint64, number uint64) []*simMiner {
+	var buyers []*simMiner
+	for _, miner := range sim.miners {
+		switch {
+		case miner.keep:
+			if tickets[miner.address] < miner.tickets {
+				buyers = append(buyers, miner)
+			}
+		case miner.buyEvery > 0:
+			if number%miner.buyEvery == 0 {
+				buyers = append(buyers, miner)
+			}
+		}
+	}
+	return buyers
+}
+
+func (sim *simulator) buyTicketTx(miner *simMiner, nonce uint64, parent *types.Block) (*types.Transaction, error) {
+	param := common.BuyTicketParam{Start: parent.Time(), End: parent.Time() + ticketLifetime}
+	funcData, err := param.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	input, err := (&common.FSNCallParam{Func: common.BuyTicketFunc, Data: funcData}).ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	number := new(big.Int).Add(parent.Number(), common.Big1)
+	gasPrice := big.NewInt(params.GWei)
+	if sim.chainConfig.IsLondon(number) {
+		if baseFee := misc.CalcBaseFee(sim.chainConfig, parent.Header()); baseFee.Cmp(gasPrice) > 0 {
+			gasPrice = baseFee
+		}
+	}
+	tx := types.NewTransaction(nonce, common.FSNCallAddress, new(big.Int), buyTicketGas, gasPrice, input)
+	return types.SignTx(tx, types.MakeSigner(sim.chainConfig, number), miner.key)
+}
+
+// writeBlock stores the block as the new head, GenerateChain already wrote its state.
+func (sim *simulator) writeBlock(block *types.Block, receipts types.Receipts) {
+	td := new(big.Int).Add(sim.headTd, block.Difficulty())
+	rawdb.WriteTd(sim.db, block.Hash(), block.NumberU64(), td)
+	rawdb.WriteBlock(sim.db, block)
+	rawdb.WriteReceipts(sim.db, block.Hash(), block.NumberU64(), receipts)
+	rawdb.WriteCanonicalHash(sim.db, block.Hash(), block.NumberU64())
+	rawdb.WriteHeadBlockHash(sim.db, block.Hash())
+	rawdb.WriteHeadHeaderHash(sim.db, block.Hash())
+	sim.head, sim.headTd = block, td
+}
+
+// ticketsAt returns the number of tickets of every miner at the given block.
+func (sim *simulator) ticketsAt(block *types.Block) (map[common.Address]uint64, error) {
+	statedb, err := state.New(block.Root(), block.MixDigest(), state.NewDatabase(sim.db))
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := statedb.AllTickets()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[common.Address]uint64)
+	for _, miner := range sim.miners {
+		counts[miner.address] = tickets.NumberOfTicketsByAddress(miner.address)
+	}
+	return counts, nil
+}
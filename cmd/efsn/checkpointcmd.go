@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	"github.com/FusionFoundation/efsn/cmd/utils"
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/ethdb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	checkpointIntervalFlag = cli.Uint64Flag{
+		Name:  "interval",
+		Usage: "Number of blocks between two check points",
+		Value: 10000,
+	}
+	checkpointCommand = cli.Command{
+		Name:     "checkpoint",
+		Usage:    "Export and verify check points",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Export the check points of a trusted node to a file for --checkpoints, and
+verify a chain against such a file.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the check points of the local chain",
+				Action:    utils.MigrateFlags(exportCheckPoints),
+				ArgsUsage: "[<filename>]",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.SyncModeFlag,
+					checkpointIntervalFlag,
+				},
+				Description: `
+efsn checkpoint export --interval 10000 [<filename>]
+
+Exports the hash of every interval-th canonical block of the local chain, with
+the chain id, to the file or stdout. The node should be trusted and stopped.`,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Verify the local chain against a check points file",
+				Action:    utils.MigrateFlags(verifyCheckPoints),
+				ArgsUsage: "<filename>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.SyncModeFlag,
+				},
+				Description: `
+efsn checkpoint verify <filename>
+
+Checks the canonical blocks of the local chain against the built in check
+points of the network and the ones of the file. Check points above the head
+of the chain are skipped. Exits with an error on any mismatch or any block
+missing below the head.`,
+			},
+		},
+	}
+)
+
+// openCheckPointsChain opens the chain database read only and returns its
+// chain id and the number of its head block.
+func openCheckPointsChain(ctx *cli.Context) (ethdb.Database, *big.Int, uint64, func()) {
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	closer := func() {
+		db.Close()
+		stack.Close()
+	}
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	config := rawdb.ReadChainConfig(db, genesis)
+	if config == nil {
+		closer()
+		utils.Fatalf("No chain found in the database")
+	}
+	head := rawdb.ReadHeadBlockHash(db)
+	number := rawdb.ReadHeaderNumber(db, head)
+	if number == nil {
+		closer()
+		utils.Fatalf("No head block found in the database")
+	}
+	return db, config.ChainID, *number, closer
+}
+
+func exportCheckPoints(ctx *cli.Context) error {
+	interval := ctx.Uint64(checkpointIntervalFlag.Name)
+	if interval == 0 {
+		utils.Fatalf("Check point interval must be greater than 0")
+	}
+	db, chainID, head, closer := openCheckPointsChain(ctx)
+	defer closer()
+
+	cpf := &datong.CheckPointsFile{
+		ChainID:     chainID.Uint64(),
+		CheckPoints: make(map[uint64]common.Hash),
+	}
+	for number := interval; number <= head; number += interval {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			utils.Fatalf("Canonical block %d not found", number)
+		}
+		cpf.CheckPoints[number] = hash
+	}
+	data, err := json.MarshalIndent(cpf, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if ctx.NArg() == 0 {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := ioutil.WriteFile(ctx.Args().First(), data, 0644); err != nil {
+		utils.Fatalf("Could not write check points file: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d check points up to block %d\n", len(cpf.CheckPoints), head)
+	return nil
+}
+
+func verifyCheckPoints(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	if ctx.GlobalBool(utils.DevnetFlag.Name) {
+		common.InitDevnet()
+	} else if ctx.GlobalBool(utils.TestnetFlag.Name) {
+		common.InitTestnet()
+	}
+	if err := datong.LoadCheckPoints(ctx.Args().First()); err != nil {
+		utils.Fatalf("Could not load check points file: %v", err)
+	}
+	db, chainID, head, closer := openCheckPointsChain(ctx)
+	defer closer()
+
+	verified, mismatches, missing, err := datong.VerifyCheckPoints(chainID, head, func(number uint64) *types.Block {
+		return rawdb.ReadBlock(db, rawdb.ReadCanonicalHash(db, number), number)
+	})
+	if err != nil {
+		utils.Fatalf("Verify error: %v", err)
+	}
+	for _, m := range mismatches {
+		fmt.Printf("Check point mismatch: number=%d have=%x want=%x\n", m.Number, m.Have, m.Want)
+	}
+	for _, number := range missing {
+		fmt.Printf("Check point block missing: number=%d\n", number)
+	}
+	if len(mismatches) > 0 || len(missing) > 0 {
+		utils.Fatalf("%d of %d check points mismatch, %d blocks missing", len(mismatches), verified, len(missing))
+	}
+	fmt.Printf("Verified %d check points up to block %d\n", verified, head)
+	return nil
+}
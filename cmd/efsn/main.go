@@ -99,6 +99,7 @@ var (
 		utils.MinerExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerSignerFlag,
 		utils.AutoBuyTicketsEnabledFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
@@ -121,6 +122,7 @@ var (
 		utils.GpoPercentileFlag,
 		utils.ResyncFromHeightFlag,
 		utils.CheckPointsFileFlag,
+		utils.FsnIndexFlag,
 		configFileFlag,
 	}
 
@@ -184,6 +186,8 @@ func init() {
 		dbCommand,
 		// See rawtx.go
 		rawTxCommand,
+		// See checkpointcmd.go
+		checkpointCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -322,8 +326,11 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 			}
 		}
 	}()
-	// Start auto buy tickets
-	go ethapi.AutoBuyTicket(ctx.GlobalBool(utils.AutoBuyTicketsEnabledFlag.Name))
+	// Let the miner buy tickets through the fusion transaction api
+	var ethereum *eth.Ethereum
+	if stack.Service(&ethereum) == nil {
+		ethereum.Miner().SetTicketBuyFunc(ethapi.BuyTicketForMiner)
+	}
 	// Start report illegal
 	go ethapi.ReportIllegal()
 	// Start auxiliary services if enabled
@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/FusionFoundation/efsn/accounts/keystore"
 	"github.com/FusionFoundation/efsn/cmd/utils"
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/common/math"
 	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core/fsnlog"
 	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/rlp"
 
@@ -24,7 +30,12 @@ var (
 		Category: "RAWTX COMMANDS",
 		Description: `
 
-Process raw transaction.`,
+Process raw transaction.
+
+The create subcommands print the unsigned transaction input by default.
+If a keystore file is given with --keystore, the transaction is signed
+offline with the decrypted key and the signed raw transaction is printed.
+Signing needs the --nonce and --chainid of the transaction to be set.`,
 		Subcommands: []cli.Command{
 			{
 				Name:      "decodeRawTx",
@@ -50,15 +61,18 @@ rawtx decodeTxInput <hexstr>`,
 				Usage:     "Decode log data from tx receipt log hex data",
 				Action:    utils.MigrateFlags(decodeLogData),
 				Flags:     []cli.Flag{},
-				ArgsUsage: "<hexstr>",
+				ArgsUsage: "<hexstr> [topics...]",
 				Description: `
-rawtx decodeLogData <hexstr>`,
+rawtx decodeLogData <hexstr> [topics...]
+
+The topics of the log are needed to decode the structured logs
+of the FSN calls after the FSN log v2 fork.`,
 			},
 			{
 				Name:      "sendAsset",
 				Usage:     "Create a 'sendAsset' raw transaction",
 				Action:    utils.MigrateFlags(createSendAssetRawTx),
-				Flags:     []cli.Flag{},
+				Flags:     rawTxSignFlags,
 				ArgsUsage: "<asset> <to> <value>",
 				Description: `
 rawtx sendAsset <asset> <to> <value>`,
@@ -67,7 +81,7 @@ rawtx sendAsset <asset> <to> <value>`,
 				Name:      "assetToTimeLock",
 				Usage:     "Create a 'assetToTimeLock' raw transaction",
 				Action:    utils.MigrateFlags(createAssetToTimeLockRawTx),
-				Flags:     []cli.Flag{},
+				Flags:     rawTxSignFlags,
 				ArgsUsage: "<asset> <to> <start> <end> <value>",
 				Description: `
 rawtx assetToTimeLock <asset> <to> <start> <end> <value>`,
@@ -76,7 +90,7 @@ rawtx assetToTimeLock <asset> <to> <start> <end> <value>`,
 				Name:      "timeLockToTimeLock",
 				Usage:     "Create a 'timeLockToTimeLock' raw transaction",
 				Action:    utils.MigrateFlags(createTimeLockToTimeLockRawTx),
-				Flags:     []cli.Flag{},
+				Flags:     rawTxSignFlags,
 				ArgsUsage: "<asset> <to> <start> <end> <value>",
 				Description: `
 rawtx timeLockToTimeLock <asset> <to> <start> <end> <value>`,
@@ -85,7 +99,7 @@ rawtx timeLockToTimeLock <asset> <to> <start> <end> <value>`,
 				Name:      "timeLockToAsset",
 				Usage:     "Create a 'timeLockToAsset' raw transaction",
 				Action:    utils.MigrateFlags(createTimeLockToAssetRawTx),
-				Flags:     []cli.Flag{},
+				Flags:     rawTxSignFlags,
 				ArgsUsage: "<asset> <to> <value>",
 				Description: `
 rawtx timeLockToAsset <asset> <to> <value>`,
@@ -94,22 +108,210 @@ rawtx timeLockToAsset <asset> <to> <value>`,
 				Name:      "genNotation",
 				Usage:     "Create a 'genNotation' raw transaction",
 				Action:    utils.MigrateFlags(createGenNotationRawTx),
-				Flags:     []cli.Flag{},
+				Flags:     rawTxSignFlags,
 				ArgsUsage: "",
 				Description: `
 rawtx genNotation`,
+			},
+			{
+				Name:      "transferNotation",
+				Usage:     "Create a 'transferNotation' raw transaction",
+				Action:    utils.MigrateFlags(createTransferNotationRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<notation> <to>",
+				Description: `
+rawtx transferNotation <notation> <to>
+The notation must be the sender's one and the receiver must have none.`,
+			},
+			{
+				Name:      "releaseNotation",
+				Usage:     "Create a 'releaseNotation' raw transaction",
+				Action:    utils.MigrateFlags(createReleaseNotationRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<notation>",
+				Description: `
+rawtx releaseNotation <notation>
+The notation must be the sender's one, it is never assigned again.`,
 			},
 			{
 				Name:      "buyTicket",
 				Usage:     "Create a 'buyTicket' raw transaction",
 				Action:    utils.MigrateFlags(createBuyTicketRawTx),
-				Flags:     []cli.Flag{},
+				Flags:     rawTxSignFlags,
 				ArgsUsage: "<start> <end>",
 				Description: `
 rawtx buyTicket <start> <end>`,
 			},
+			{
+				Name:      "sendTimeLock",
+				Usage:     "Create a 'sendTimeLock' raw transaction",
+				Action:    utils.MigrateFlags(createSendTimeLockRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<asset> <to> <start> <end> <value>",
+				Description: `
+rawtx sendTimeLock <asset> <to> <start> <end> <value>
+The time lock is sent from the time lock balance if it covers the period,
+and from the asset balance otherwise.`,
+			},
+			{
+				Name:      "genAsset",
+				Usage:     "Create a 'genAsset' raw transaction",
+				Action:    utils.MigrateFlags(createGenAssetRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<name> <symbol> <decimals> <total> [canchange] [description]",
+				Description: `
+rawtx genAsset <name> <symbol> <decimals> <total> [canchange] [description]
+canchange defaults to false.`,
+			},
+			{
+				Name:      "incAsset",
+				Usage:     "Create a 'incAsset' raw transaction",
+				Action:    utils.MigrateFlags(createIncAssetRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<asset> <to> <value> [transacdata]",
+				Description: `
+rawtx incAsset <asset> <to> <value> [transacdata]`,
+			},
+			{
+				Name:      "decAsset",
+				Usage:     "Create a 'decAsset' raw transaction",
+				Action:    utils.MigrateFlags(createDecAssetRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<asset> <to> <value> [transacdata]",
+				Description: `
+rawtx decAsset <asset> <to> <value> [transacdata]`,
+			},
+			{
+				Name:      "transferAssetOwner",
+				Usage:     "Create a 'transferAssetOwner' raw transaction",
+				Action:    utils.MigrateFlags(createTransferAssetOwnerRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<asset> <to>",
+				Description: `
+rawtx transferAssetOwner <asset> <to>`,
+			},
+			{
+				Name:      "updateAsset",
+				Usage:     "Create a 'updateAsset' raw transaction",
+				Action:    utils.MigrateFlags(createUpdateAssetRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<asset> <canchange> [description]",
+				Description: `
+rawtx updateAsset <asset> <canchange> [description]
+The description replaces the current one, canchange can only be turned off.`,
+			},
+			{
+				Name:      "makeSwap",
+				Usage:     "Create a 'makeSwap' raw transaction",
+				Action:    utils.MigrateFlags(createMakeSwapRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<fromasset> <fromstart> <fromend> <minfromamount> <toasset> <tostart> <toend> <mintoamount> <swapsize> [targets] [description]",
+				Description: `
+rawtx makeSwap <fromasset> <fromstart> <fromend> <minfromamount> <toasset> <tostart> <toend> <mintoamount> <swapsize> [targets] [description]
+A start of 0 and an end of 0xffffffffffffffff swap asset, other ranges swap time lock.
+targets is a comma separated list of the addresses allowed to take the swap.`,
+			},
+			{
+				Name:      "recallSwap",
+				Usage:     "Create a 'recallSwap' raw transaction",
+				Action:    utils.MigrateFlags(createRecallSwapRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<swapid>",
+				Description: `
+rawtx recallSwap <swapid>`,
+			},
+			{
+				Name:      "takeSwap",
+				Usage:     "Create a 'takeSwap' raw transaction",
+				Action:    utils.MigrateFlags(createTakeSwapRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<swapid> <size>",
+				Description: `
+rawtx takeSwap <swapid> <size>`,
+			},
+			{
+				Name:      "makeMultiSwap",
+				Usage:     "Create a 'makeMultiSwap' raw transaction",
+				Action:    utils.MigrateFlags(createMakeMultiSwapRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<fromassets> <fromstarts> <fromends> <minfromamounts> <toassets> <tostarts> <toends> <mintoamounts> <swapsize> [targets] [description]",
+				Description: `
+rawtx makeMultiSwap <fromassets> <fromstarts> <fromends> <minfromamounts> <toassets> <tostarts> <toends> <mintoamounts> <swapsize> [targets] [description]
+All list arguments are comma separated, the from and to lists must have the same lengths.`,
+			},
+			{
+				Name:      "recallMultiSwap",
+				Usage:     "Create a 'recallMultiSwap' raw transaction",
+				Action:    utils.MigrateFlags(createRecallMultiSwapRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<swapid>",
+				Description: `
+rawtx recallMultiSwap <swapid>`,
+			},
+			{
+				Name:      "takeMultiSwap",
+				Usage:     "Create a 'takeMultiSwap' raw transaction",
+				Action:    utils.MigrateFlags(createTakeMultiSwapRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<swapid> <size>",
+				Description: `
+rawtx takeMultiSwap <swapid> <size>`,
+			},
+			{
+				Name:      "batchSendAsset",
+				Usage:     "Create a 'batchSendAsset' raw transaction",
+				Action:    utils.MigrateFlags(createBatchSendAssetRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<assets> <tos> <starts> <ends> <values>",
+				Description: `
+rawtx batchSendAsset <assets> <tos> <starts> <ends> <values>
+All arguments are comma separated lists of the same length, one item per entry.
+Each entry is sent like sendTimeLock, use start 0 and end 0xffffffffffffffff
+to send an asset. Either all of the entries are applied or none.`,
+			},
+			{
+				Name:      "reportIllegal",
+				Usage:     "Create a 'reportIllegal' raw transaction",
+				Action:    utils.MigrateFlags(createReportIllegalRawTx),
+				Flags:     rawTxSignFlags,
+				ArgsUsage: "<hexstr>",
+				Description: `
+rawtx reportIllegal <hexstr>
+hexstr is the report content, the rlp encoding of the two conflicting headers.`,
+			},
 		},
 	}
+
+	rawTxKeyStoreFlag = cli.StringFlag{
+		Name:  "keystore",
+		Usage: "Keystore file to sign the raw transaction with",
+	}
+	rawTxNonceFlag = cli.Uint64Flag{
+		Name:  "nonce",
+		Usage: "Nonce of the signed raw transaction (required with --keystore)",
+	}
+	rawTxGasFlag = cli.Uint64Flag{
+		Name:  "gas",
+		Usage: "Gas limit of the signed raw transaction",
+		Value: 90000,
+	}
+	rawTxGasPriceFlag = cli.StringFlag{
+		Name:  "gasprice",
+		Usage: "Gas price of the signed raw transaction",
+		Value: "1000000000",
+	}
+	rawTxChainIDFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain id of the signed raw transaction, 0 means no replay protection (required with --keystore)",
+	}
+	rawTxSignFlags = []cli.Flag{
+		rawTxKeyStoreFlag,
+		utils.PasswordFileFlag,
+		rawTxNonceFlag,
+		rawTxGasFlag,
+		rawTxGasPriceFlag,
+		rawTxChainIDFlag,
+	}
 )
 
 func printTx(tx *types.Transaction, decodeInput bool) error {
@@ -156,6 +358,69 @@ func printRawTx(tx *types.Transaction) error {
 	return nil
 }
 
+// outputRawTx prints the unsigned transaction input, or signs the
+// transaction with the key of the keystore file if one is given.
+func outputRawTx(ctx *cli.Context, tx *types.Transaction) error {
+	keyfile := ctx.String(rawTxKeyStoreFlag.Name)
+	if keyfile == "" {
+		return printRawTx(tx)
+	}
+	// an offline signer can't look up the nonce nor the chain, and a
+	// default would silently sign an unusable transaction
+	for _, name := range []string{rawTxNonceFlag.Name, rawTxChainIDFlag.Name} {
+		if !ctx.IsSet(name) {
+			return fmt.Errorf("--%s is required to sign a raw transaction", name)
+		}
+	}
+	keyjson, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return fmt.Errorf("failed to read the keyfile at '%s': %v", keyfile, err)
+	}
+	passphrase := utils.GetPassPhraseWithList("", false, 0, utils.MakePasswordList(ctx))
+	key, err := keystore.DecryptKey(keyjson, passphrase)
+	if err != nil {
+		return fmt.Errorf("error decrypting key: %v", err)
+	}
+	gasPrice, ok := math.ParseBig256(ctx.String(rawTxGasPriceFlag.Name))
+	if !ok {
+		return fmt.Errorf(ctx.String(rawTxGasPriceFlag.Name) + " is not a right big number")
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID := ctx.Uint64(rawTxChainIDFlag.Name); chainID != 0 {
+		signer = types.NewEIP155Signer(new(big.Int).SetUint64(chainID))
+	}
+	tx = types.NewTransaction(
+		ctx.Uint64(rawTxNonceFlag.Name),
+		*tx.To(),
+		tx.Value(),
+		ctx.Uint64(rawTxGasFlag.Name),
+		gasPrice,
+		tx.Data(),
+	)
+	signedTx, err := types.SignTx(tx, signer, key.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("sign tx err %v", err)
+	}
+	rawTx, err := signedTx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	bs, err := json.Marshal(&struct {
+		From  common.Address     `json:"from"`
+		RawTx hexutil.Bytes      `json:"rawTx"`
+		Tx    *types.Transaction `json:"tx"`
+	}{
+		From:  key.Address,
+		RawTx: rawTx,
+		Tx:    signedTx,
+	})
+	if err != nil {
+		return fmt.Errorf("json marshal err %v", err)
+	}
+	fmt.Println(string(bs))
+	return nil
+}
+
 func decodeRawTx(ctx *cli.Context) error {
 	args := ctx.Args()
 	if len(args) < 1 || len(args) > 2 {
@@ -200,15 +465,29 @@ func decodeTxInput(ctx *cli.Context) error {
 
 func decodeLogData(ctx *cli.Context) error {
 	args := ctx.Args()
-	if len(args) != 1 {
+	if len(args) < 1 {
 		return fmt.Errorf("wrong number of arguments")
 	}
 	data, err := hexutil.Decode(args.First())
 	if err != nil {
 		return fmt.Errorf("wrong arguments %v", err)
 	}
-	res, err := datong.DecodeLogData(data)
-	if err != nil {
+	l := &types.Log{Address: common.FSNCallAddress, Data: data}
+	for _, arg := range args.Tail() {
+		topic, err := hexutil.Decode(arg)
+		if err != nil || len(topic) != common.HashLength {
+			return fmt.Errorf("wrong topic %v", arg)
+		}
+		l.Topics = append(l.Topics, common.BytesToHash(topic))
+	}
+	var res interface{}
+	if fsnlog.IsStructured(l) {
+		decoded, err := fsnlog.Decode(l)
+		if err != nil {
+			return fmt.Errorf("decode log data err %v", err)
+		}
+		res = decoded.Fields
+	} else if res, err = datong.DecodeLogData(data); err != nil {
 		return fmt.Errorf("decode log data err %v", err)
 	}
 	bs, err := json.Marshal(res)
@@ -265,7 +544,7 @@ func createSendAssetRawTx(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return printRawTx(tx)
+	return outputRawTx(ctx, tx)
 }
 
 func createAssetToTimeLockRawTx(ctx *cli.Context) error {
@@ -300,7 +579,7 @@ func createAssetToTimeLockRawTx(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return printRawTx(tx)
+	return outputRawTx(ctx, tx)
 }
 
 func createTimeLockToTimeLockRawTx(ctx *cli.Context) error {
@@ -335,7 +614,7 @@ func createTimeLockToTimeLockRawTx(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return printRawTx(tx)
+	return outputRawTx(ctx, tx)
 }
 
 func createTimeLockToAssetRawTx(ctx *cli.Context) error {
@@ -362,7 +641,7 @@ func createTimeLockToAssetRawTx(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return printRawTx(tx)
+	return outputRawTx(ctx, tx)
 }
 
 func createGenNotationRawTx(ctx *cli.Context) error {
@@ -370,7 +649,52 @@ func createGenNotationRawTx(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return printRawTx(tx)
+	return outputRawTx(ctx, tx)
+}
+
+func createTransferNotationRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	notation, err := strconv.ParseUint(args[0], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid notation number: %v", err)
+	}
+	param := common.TransferNotationParam{
+		Notation: notation,
+		To:       common.HexToAddress(args[1]),
+	}
+	if err := param.Check(common.BigMaxUint64); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.TransferNotationFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createReleaseNotationRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	notation, err := strconv.ParseUint(args[0], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid notation number: %v", err)
+	}
+	param := common.ReleaseNotationParam{
+		Notation: notation,
+	}
+	if err := param.Check(common.BigMaxUint64); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.ReleaseNotationFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
 }
 
 func createBuyTicketRawTx(ctx *cli.Context) error {
@@ -395,5 +719,490 @@ func createBuyTicketRawTx(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return printRawTx(tx)
+	return outputRawTx(ctx, tx)
+}
+
+func createSendTimeLockRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 5 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	asset := common.HexToHash(args[0])
+	to := common.HexToAddress(args[1])
+	start, err := strconv.ParseUint(args[2], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid start number: %v", err)
+	}
+	end, err := strconv.ParseUint(args[3], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid end number: %v", err)
+	}
+	value, ok := new(big.Int).SetString(args[4], 0)
+	if !ok {
+		return fmt.Errorf(args[4] + " is not a right big number")
+	}
+
+	param := common.TimeLockParam{
+		Type:      common.SmartTransfer,
+		AssetID:   asset,
+		To:        to,
+		StartTime: start,
+		EndTime:   end,
+		Value:     value,
+	}
+	tx, err := toRawTx(common.TimeLockFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createGenAssetRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 4 || len(args) > 6 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	decimals, err := strconv.ParseUint(args[2], 0, 8)
+	if err != nil {
+		return fmt.Errorf("Invalid decimals number: %v", err)
+	}
+	total, ok := new(big.Int).SetString(args[3], 0)
+	if !ok {
+		return fmt.Errorf(args[3] + " is not a right big number")
+	}
+	canChange := false
+	if len(args) > 4 {
+		canChange, err = strconv.ParseBool(args[4])
+		if err != nil {
+			return fmt.Errorf("Invalid canchange flag: %v", err)
+		}
+	}
+	description := ""
+	if len(args) > 5 {
+		description = args[5]
+	}
+
+	param := common.GenAssetParam{
+		Name:        args[0],
+		Symbol:      args[1],
+		Decimals:    uint8(decimals),
+		Total:       total,
+		CanChange:   canChange,
+		Description: description,
+	}
+	if err := param.Check(common.BigMaxUint64); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.GenAssetFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createIncAssetRawTx(ctx *cli.Context) error {
+	return createAssetValueChangeRawTx(ctx, true)
+}
+
+func createDecAssetRawTx(ctx *cli.Context) error {
+	return createAssetValueChangeRawTx(ctx, false)
+}
+
+func createAssetValueChangeRawTx(ctx *cli.Context, isInc bool) error {
+	args := ctx.Args()
+	if len(args) < 3 || len(args) > 4 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	asset := common.HexToHash(args[0])
+	to := common.HexToAddress(args[1])
+	value, ok := new(big.Int).SetString(args[2], 0)
+	if !ok {
+		return fmt.Errorf(args[2] + " is not a right big number")
+	}
+	transacData := ""
+	if len(args) > 3 {
+		transacData = args[3]
+	}
+
+	param := common.AssetValueChangeExParam{
+		AssetID:     asset,
+		To:          to,
+		Value:       value,
+		IsInc:       isInc,
+		TransacData: transacData,
+	}
+	if err := param.Check(common.BigMaxUint64); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.AssetValueChangeFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createTransferAssetOwnerRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	param := common.TransferAssetOwnerParam{
+		AssetID: common.HexToHash(args[0]),
+		To:      common.HexToAddress(args[1]),
+	}
+	if err := param.Check(common.BigMaxUint64); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.TransferAssetOwnerFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createUpdateAssetRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	canChange, err := strconv.ParseBool(args[1])
+	if err != nil {
+		return fmt.Errorf("Invalid canchange flag: %v", err)
+	}
+	description := ""
+	if len(args) > 2 {
+		description = args[2]
+	}
+	param := common.UpdateAssetParam{
+		AssetID:     common.HexToHash(args[0]),
+		Description: description,
+		CanChange:   canChange,
+	}
+	if err := param.Check(common.BigMaxUint64); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.UpdateAssetFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createMakeSwapRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 9 || len(args) > 11 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	fromStart, err := strconv.ParseUint(args[1], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid from start number: %v", err)
+	}
+	fromEnd, err := strconv.ParseUint(args[2], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid from end number: %v", err)
+	}
+	minFromAmount, ok := new(big.Int).SetString(args[3], 0)
+	if !ok {
+		return fmt.Errorf(args[3] + " is not a right big number")
+	}
+	toStart, err := strconv.ParseUint(args[5], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid to start number: %v", err)
+	}
+	toEnd, err := strconv.ParseUint(args[6], 0, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid to end number: %v", err)
+	}
+	minToAmount, ok := new(big.Int).SetString(args[7], 0)
+	if !ok {
+		return fmt.Errorf(args[7] + " is not a right big number")
+	}
+	swapSize, ok := new(big.Int).SetString(args[8], 0)
+	if !ok {
+		return fmt.Errorf(args[8] + " is not a right big number")
+	}
+	var targets []common.Address
+	if len(args) > 9 {
+		targets = parseAddressList(args[9])
+	}
+	description := ""
+	if len(args) > 10 {
+		description = args[10]
+	}
+
+	now := uint64(time.Now().Unix())
+	param := common.MakeSwapParam{
+		FromAssetID:   common.HexToHash(args[0]),
+		FromStartTime: fromStart,
+		FromEndTime:   fromEnd,
+		MinFromAmount: minFromAmount,
+		ToAssetID:     common.HexToHash(args[4]),
+		ToStartTime:   toStart,
+		ToEndTime:     toEnd,
+		MinToAmount:   minToAmount,
+		SwapSize:      swapSize,
+		Targes:        targets,
+		Time:          new(big.Int).SetUint64(now),
+		Description:   description,
+	}
+	if err := param.Check(common.BigMaxUint64, now); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.MakeSwapFuncExt, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createRecallSwapRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+
+	param := common.RecallSwapParam{
+		SwapID: common.HexToHash(args[0]),
+	}
+	tx, err := toRawTx(common.RecallSwapFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createTakeSwapRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	size, ok := new(big.Int).SetString(args[1], 0)
+	if !ok {
+		return fmt.Errorf(args[1] + " is not a right big number")
+	}
+
+	param := common.TakeSwapParam{
+		SwapID: common.HexToHash(args[0]),
+		Size:   size,
+	}
+	tx, err := toRawTx(common.TakeSwapFuncExt, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createMakeMultiSwapRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 9 || len(args) > 11 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	fromStarts, err := parseUint64List(args[1])
+	if err != nil {
+		return fmt.Errorf("Invalid from start number: %v", err)
+	}
+	fromEnds, err := parseUint64List(args[2])
+	if err != nil {
+		return fmt.Errorf("Invalid from end number: %v", err)
+	}
+	minFromAmounts, err := parseBigList(args[3])
+	if err != nil {
+		return err
+	}
+	toStarts, err := parseUint64List(args[5])
+	if err != nil {
+		return fmt.Errorf("Invalid to start number: %v", err)
+	}
+	toEnds, err := parseUint64List(args[6])
+	if err != nil {
+		return fmt.Errorf("Invalid to end number: %v", err)
+	}
+	minToAmounts, err := parseBigList(args[7])
+	if err != nil {
+		return err
+	}
+	swapSize, ok := new(big.Int).SetString(args[8], 0)
+	if !ok {
+		return fmt.Errorf(args[8] + " is not a right big number")
+	}
+	var targets []common.Address
+	if len(args) > 9 {
+		targets = parseAddressList(args[9])
+	}
+	description := ""
+	if len(args) > 10 {
+		description = args[10]
+	}
+
+	now := uint64(time.Now().Unix())
+	param := common.MakeMultiSwapParam{
+		FromAssetID:   parseHashList(args[0]),
+		FromStartTime: fromStarts,
+		FromEndTime:   fromEnds,
+		MinFromAmount: minFromAmounts,
+		ToAssetID:     parseHashList(args[4]),
+		ToStartTime:   toStarts,
+		ToEndTime:     toEnds,
+		MinToAmount:   minToAmounts,
+		SwapSize:      swapSize,
+		Targes:        targets,
+		Time:          new(big.Int).SetUint64(now),
+		Description:   description,
+	}
+	if err := param.Check(common.BigMaxUint64, now); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.MakeMultiSwapFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createRecallMultiSwapRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+
+	param := common.RecallMultiSwapParam{
+		SwapID: common.HexToHash(args[0]),
+	}
+	tx, err := toRawTx(common.RecallMultiSwapFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createTakeMultiSwapRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	size, ok := new(big.Int).SetString(args[1], 0)
+	if !ok {
+		return fmt.Errorf(args[1] + " is not a right big number")
+	}
+
+	param := common.TakeMultiSwapParam{
+		SwapID: common.HexToHash(args[0]),
+		Size:   size,
+	}
+	tx, err := toRawTx(common.TakeMultiSwapFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+// reportParam wraps the report content, which is not rlp encoded again.
+type reportParam []byte
+
+func (p reportParam) ToBytes() ([]byte, error) {
+	return p, nil
+}
+
+func createBatchSendAssetRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 5 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	assets := parseHashList(args[0])
+	tos := parseAddressList(args[1])
+	starts, err := parseUint64List(args[2])
+	if err != nil {
+		return fmt.Errorf("Invalid start number: %v", err)
+	}
+	ends, err := parseUint64List(args[3])
+	if err != nil {
+		return fmt.Errorf("Invalid end number: %v", err)
+	}
+	values, err := parseBigList(args[4])
+	if err != nil {
+		return err
+	}
+	if len(tos) != len(assets) || len(starts) != len(assets) || len(ends) != len(assets) || len(values) != len(assets) {
+		return fmt.Errorf("list arguments must have the same lengths")
+	}
+
+	param := common.BatchSendAssetParam{}
+	for i := range assets {
+		param.Entries = append(param.Entries, common.BatchSendAssetEntry{
+			To:        tos[i],
+			AssetID:   assets[i],
+			Value:     values[i],
+			StartTime: starts[i],
+			EndTime:   ends[i],
+		})
+	}
+	if err := param.Check(common.BigMaxUint64, uint64(time.Now().Unix())); err != nil {
+		return err
+	}
+	tx, err := toRawTx(common.BatchSendAssetFunc, &param)
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func createReportIllegalRawTx(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 1 {
+		return fmt.Errorf("wrong number of arguments")
+	}
+	data, err := hexutil.Decode(args.First())
+	if err != nil {
+		return fmt.Errorf("wrong arguments %v", err)
+	}
+	if _, _, err := datong.DecodeReport(data); err != nil {
+		return fmt.Errorf("DecodeReport err %v", err)
+	}
+	tx, err := toRawTx(common.ReportIllegalFunc, reportParam(data))
+	if err != nil {
+		return err
+	}
+	return outputRawTx(ctx, tx)
+}
+
+func parseHashList(s string) []common.Hash {
+	var res []common.Hash
+	for _, item := range strings.Split(s, ",") {
+		res = append(res, common.HexToHash(item))
+	}
+	return res
+}
+
+func parseAddressList(s string) []common.Address {
+	var res []common.Address
+	for _, item := range strings.Split(s, ",") {
+		if item != "" {
+			res = append(res, common.HexToAddress(item))
+		}
+	}
+	return res
+}
+
+func parseUint64List(s string) ([]uint64, error) {
+	var res []uint64
+	for _, item := range strings.Split(s, ",") {
+		n, err := strconv.ParseUint(item, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+func parseBigList(s string) ([]*big.Int, error) {
+	var res []*big.Int
+	for _, item := range strings.Split(s, ",") {
+		n, ok := new(big.Int).SetString(item, 0)
+		if !ok {
+			return nil, fmt.Errorf(item + " is not a right big number")
+		}
+		res = append(res, n)
+	}
+	return res, nil
 }
@@ -82,6 +82,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.LightKDFFlag,
 			utils.ResyncFromHeightFlag,
 			utils.CheckPointsFileFlag,
+			utils.FsnIndexFlag,
 		},
 	},
 	{
@@ -178,6 +179,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerfiyFlag,
+			utils.MinerSignerFlag,
 			utils.AutoBuyTicketsEnabledFlag,
 		},
 	},
@@ -30,9 +30,9 @@ import (
 	"github.com/FusionFoundation/efsn/log"
 )
 
-// nodeDockerfile is the Dockerfile required to run an Ethereum node.
+// nodeDockerfile is the Dockerfile required to run a Fusion node.
 var nodeDockerfile = `
-FROM ethereum/client-go:latest
+FROM fusionnetwork/efsn:latest
 
 ADD genesis.json /genesis.json
 {{if .Unlock}}
@@ -42,7 +42,7 @@ ADD genesis.json /genesis.json
 RUN \
   echo 'efsn --cache 512 init /genesis.json' > efsn.sh && \{{if .Unlock}}
 	echo 'mkdir -p /root/.ethereum/keystore/ && cp /signer.json /root/.ethereum/keystore/' >> efsn.sh && \{{end}}
-	echo $'exec efsn --networkid {{.NetworkID}} --cache 512 --port {{.Port}} --maxpeers {{.Peers}} {{.LightFlag}} --ethstats \'{{.Ethstats}}\' {{if .Bootnodes}}--bootnodes {{.Bootnodes}}{{end}} {{if .Etherbase}}--miner.etherbase {{.Etherbase}} --mine --miner.threads 1{{end}} {{if .Unlock}}--unlock 0 --password /signer.pass --mine{{end}} --miner.gastarget {{.GasTarget}} --miner.gaslimit {{.GasLimit}} --miner.gasprice {{.GasPrice}}' >> efsn.sh
+	echo $'exec efsn --networkid {{.NetworkID}} --cache 512 --port {{.Port}} --maxpeers {{.Peers}} {{.LightFlag}} --ethstats \'{{.Ethstats}}\' {{if .Bootnodes}}--bootnodes {{.Bootnodes}}{{end}} {{if .Etherbase}}--miner.etherbase {{.Etherbase}} --mine --miner.threads 1{{end}} {{if .Unlock}}--unlock 0 --password /signer.pass --mine{{end}} {{if .AutoBuy}}--autobt{{end}} --miner.gastarget {{.GasTarget}} --miner.gaslimit {{.GasLimit}} --miner.gasprice {{.GasPrice}}' >> efsn.sh
 
 ENTRYPOINT ["/bin/sh", "efsn.sh"]
 `
@@ -70,6 +70,7 @@ services:
       - GAS_TARGET={{.GasTarget}}
       - GAS_LIMIT={{.GasLimit}}
       - GAS_PRICE={{.GasPrice}}
+      - AUTO_BUY_TICKETS={{.AutoBuy}}
     logging:
       driver: "json-file"
       options:
@@ -108,6 +109,7 @@ func deployNode(client *sshClient, network string, bootnodes []string, config *n
 		"GasLimit":  uint64(1000000 * config.gasLimit),
 		"GasPrice":  uint64(1000000000 * config.gasPrice),
 		"Unlock":    config.keyJSON != "",
+		"AutoBuy":   config.autoBuy,
 	})
 	files[filepath.Join(workdir, "Dockerfile")] = dockerfile.Bytes()
 
@@ -126,6 +128,7 @@ func deployNode(client *sshClient, network string, bootnodes []string, config *n
 		"GasTarget":  config.gasTarget,
 		"GasLimit":   config.gasLimit,
 		"GasPrice":   config.gasPrice,
+		"AutoBuy":    config.autoBuy,
 	})
 	files[filepath.Join(workdir, "docker-compose.yaml")] = composefile.Bytes()
 
@@ -165,6 +168,7 @@ type nodeInfos struct {
 	gasTarget  float64
 	gasLimit   float64
 	gasPrice   float64
+	autoBuy    bool
 }
 
 // Report converts the typed struct into a plain string->string map, containing
@@ -189,7 +193,7 @@ func (info *nodeInfos) Report() map[string]string {
 			report["Miner account"] = info.etherbase
 		}
 		if info.keyJSON != "" {
-			// Clique proof-of-authority signer
+			// Clique proof-of-authority signer or DaTong ticket holder
 			var key struct {
 				Address string `json:"address"`
 			}
@@ -198,6 +202,9 @@ func (info *nodeInfos) Report() map[string]string {
 			} else {
 				log.Error("Failed to retrieve signer address", "err", err)
 			}
+			if info.autoBuy {
+				report["Tickets (auto buy)"] = "enabled"
+			}
 		}
 	}
 	return report
@@ -224,6 +231,7 @@ func checkNode(client *sshClient, network string, boot bool) (*nodeInfos, error)
 	gasTarget, _ := strconv.ParseFloat(infos.envvars["GAS_TARGET"], 64)
 	gasLimit, _ := strconv.ParseFloat(infos.envvars["GAS_LIMIT"], 64)
 	gasPrice, _ := strconv.ParseFloat(infos.envvars["GAS_PRICE"], 64)
+	autoBuy, _ := strconv.ParseBool(infos.envvars["AUTO_BUY_TICKETS"])
 
 	// Container available, retrieve its node ID and its genesis json
 	var out []byte
@@ -264,6 +272,7 @@ func checkNode(client *sshClient, network string, boot bool) (*nodeInfos, error)
 		gasTarget:  gasTarget,
 		gasLimit:   gasLimit,
 		gasPrice:   gasPrice,
+		autoBuy:    autoBuy,
 	}
 	stats.enode = fmt.Sprintf("enode://%s@%s:%d", id, client.address, stats.port)
 
@@ -49,9 +49,10 @@ func (w *wizard) makeGenesis() {
 	}
 	// Figure out which consensus engine to choose
 	fmt.Println()
-	fmt.Println("Which consensus engine to use? (default = clique)")
+	fmt.Println("Which consensus engine to use? (default = datong)")
 	fmt.Println(" 1. Ethash - proof-of-work")
 	fmt.Println(" 2. Clique - proof-of-authority")
+	fmt.Println(" 3. DaTong - ticket proof-of-stake")
 
 	choice := w.read()
 	switch {
@@ -60,7 +61,7 @@ func (w *wizard) makeGenesis() {
 		genesis.Config.Ethash = new(params.EthashConfig)
 		genesis.ExtraData = make([]byte, 32)
 
-	case choice == "" || choice == "2":
+	case choice == "2":
 		// In the case of clique, configure the consensus parameters
 		genesis.Difficulty = big.NewInt(1)
 		genesis.Config.Clique = &params.CliqueConfig{
@@ -98,6 +99,56 @@ func (w *wizard) makeGenesis() {
 			copy(genesis.ExtraData[32+i*common.AddressLength:], signer[:])
 		}
 
+	case choice == "" || choice == "3":
+		// In the case of datong, start from a chain with every fork enabled
+		config := *params.DeveloperChainConfig
+		config.DaTong = &params.DaTongConfig{Period: 15}
+		genesis.Config = &config
+		genesis.Difficulty = big.NewInt(1)
+		genesis.ExtraData = make([]byte, 32)
+
+		fmt.Println()
+		fmt.Println("How many seconds should blocks take? (default = 15)")
+		for {
+			if period := w.readDefaultInt(15); period > 0 {
+				genesis.Config.DaTong.Period = uint64(period)
+				break
+			}
+			log.Error("Block period must be positive")
+		}
+		// We also need the initial tickets to mine the first blocks with
+		fmt.Println()
+		fmt.Println("Which accounts should own the genesis tickets? (mandatory at least one)")
+
+		var owners []common.Address
+		for {
+			if address := w.readAddress(); address != nil {
+				owners = append(owners, *address)
+				continue
+			}
+			if len(owners) > 0 {
+				break
+			}
+		}
+		for _, owner := range owners {
+			fmt.Println()
+			fmt.Printf("How many tickets should %s own? (default = 5)\n", owner.Hex())
+			count := w.readDefaultInt(5)
+
+			fmt.Println()
+			fmt.Printf("When should the tickets of %s start (unix time)? (default = %d)\n", owner.Hex(), genesis.Timestamp)
+			start := w.readDefaultInt(int(genesis.Timestamp))
+
+			if count > 0 && start >= 0 {
+				genesis.Tickets = append(genesis.Tickets, core.TicketsCreate{
+					Owner: owner,
+					Count: uint64(count),
+					Time:  uint64(start),
+				})
+			}
+		}
+		log.Warn("Genesis tickets expire 30 days after their start, sealers should buy tickets by then")
+
 	default:
 		log.Crit("Invalid consensus engine choice", "choice", choice)
 	}
@@ -114,6 +165,13 @@ func (w *wizard) makeGenesis() {
 		}
 		break
 	}
+	if genesis.Config.DaTong != nil {
+		// Fusion chains may also vest some of the funds over time
+		w.makeGenesisTimeLocks(genesis)
+
+		// Ask for the Fusion fork heights, all enabled from genesis by default
+		w.readFusionForks(genesis.Config)
+	}
 	// Add a batch of precompile balances to avoid them getting deleted
 	for i := int64(0); i < 256; i++ {
 		genesis.Alloc[common.BigToAddress(big.NewInt(i))] = core.GenesisAccount{Balance: big.NewInt(1)}
@@ -163,6 +221,9 @@ func (w *wizard) manageGenesis() {
 		fmt.Printf("Which block should Byzantium come into effect? (default = %v)\n", w.conf.Genesis.Config.ByzantiumBlock)
 		w.conf.Genesis.Config.ByzantiumBlock = w.readDefaultBigInt(w.conf.Genesis.Config.ByzantiumBlock)
 
+		if w.conf.Genesis.Config.DaTong != nil {
+			w.readFusionForks(w.conf.Genesis.Config)
+		}
 		out, _ := json.MarshalIndent(w.conf.Genesis.Config, "", "  ")
 		fmt.Printf("Chain configuration updated:\n\n%s\n", out)
 
@@ -191,3 +252,72 @@ func (w *wizard) manageGenesis() {
 		log.Error("That's not something I can do")
 	}
 }
+
+// makeGenesisTimeLocks asks for the FSN to allocate as time lock balances in a
+// DaTong genesis.
+func (w *wizard) makeGenesisTimeLocks(genesis *core.Genesis) {
+	fmt.Println()
+	fmt.Println("Which accounts should get time locked FSN? (advisable none)")
+
+	items := make(map[common.Address][]*common.TimeLockItem)
+	for {
+		address := w.readAddress()
+		if address == nil {
+			break
+		}
+		fmt.Println()
+		fmt.Printf("How many FSN should be locked for %s?\n", address.Hex())
+		value := new(big.Int).Mul(big.NewInt(int64(w.readInt())), big.NewInt(params.Ether))
+
+		fmt.Println()
+		fmt.Printf("When should the lock start (unix time)? (default = %d)\n", genesis.Timestamp)
+		start := uint64(w.readDefaultInt(int(genesis.Timestamp)))
+
+		fmt.Println()
+		fmt.Println("When should the lock end (unix time, 0 = forever)? (default = forever)")
+		end := uint64(w.readDefaultInt(0))
+		if end == 0 {
+			end = common.TimeLockForever
+		}
+		if value.Sign() <= 0 || start > end {
+			log.Error("Invalid time lock, skipping", "value", value, "start", start, "end", end)
+		} else {
+			items[*address] = append(items[*address], &common.TimeLockItem{StartTime: start, EndTime: end, Value: value})
+		}
+		fmt.Println()
+		fmt.Println("Which other accounts should get time locked FSN? (advisable none)")
+	}
+	for address, locks := range items {
+		account, ok := genesis.Alloc[address]
+		if !ok {
+			account.Balance = new(big.Int)
+		}
+		account.TimeLocks = map[common.Hash]*common.TimeLock{
+			common.SystemAssetID: common.NewTimeLock(locks...),
+		}
+		genesis.Alloc[address] = account
+	}
+}
+
+// readFusionForks asks for the block heights of the Fusion hard forks.
+func (w *wizard) readFusionForks(config *params.ChainConfig) {
+	forks := []struct {
+		name  string
+		block **big.Int
+	}{
+		{"PoS v2", &config.PosV2Block},
+		{"PoS v3", &config.PosV3Block},
+		{"smart transfers", &config.SmartTransferBlock},
+		{"per ticket storage", &config.TicketStorageBlock},
+		{"FSN contract v2", &config.FsnContractV2Block},
+		{"FSN log v2", &config.FsnLogV2Block},
+		{"batch send asset", &config.BatchSendAssetBlock},
+		{"asset owner transfer and update", &config.AssetUpdateBlock},
+		{"notation transfer and release", &config.NotationTransferBlock},
+	}
+	for _, fork := range forks {
+		fmt.Println()
+		fmt.Printf("Which block should %s come into effect? (default = %v)\n", fork.name, *fork.block)
+		*fork.block = w.readDefaultBigInt(*fork.block)
+	}
+}
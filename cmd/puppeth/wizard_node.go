@@ -118,7 +118,7 @@ func (w *wizard) deployNode(boot bool) {
 				fmt.Printf("What address should the miner use? (default = %s)\n", infos.etherbase)
 				infos.etherbase = w.readDefaultAddress(common.HexToAddress(infos.etherbase)).Hex()
 			}
-		} else if w.conf.Genesis.Config.Clique != nil {
+		} else if w.conf.Genesis.Config.Clique != nil || w.conf.Genesis.Config.DaTong != nil {
 			// If a previous signer was already set, offer to reuse it
 			if infos.keyJSON != "" {
 				if key, err := keystore.DecryptKey([]byte(infos.keyJSON), infos.keyPass); err != nil {
@@ -131,7 +131,7 @@ func (w *wizard) deployNode(boot bool) {
 					}
 				}
 			}
-			// Clique based signers need a keyfile and unlock password, ask if unavailable
+			// Clique and DaTong based signers need a keyfile and unlock password, ask if unavailable
 			if infos.keyJSON == "" {
 				fmt.Println()
 				fmt.Println("Please paste the signer's key JSON:")
@@ -147,6 +147,17 @@ func (w *wizard) deployNode(boot bool) {
 				}
 			}
 		}
+		if w.conf.Genesis.Config.DaTong != nil {
+			// DaTong signers mine with their tickets, which need renewing
+			fmt.Println()
+			if infos.autoBuy || !existed {
+				fmt.Println("Should the signer automatically buy tickets (y/n)? (default = yes)")
+				infos.autoBuy = w.readDefaultString("y") == "y"
+			} else {
+				fmt.Println("Should the signer automatically buy tickets (y/n)? (default = no)")
+				infos.autoBuy = w.readDefaultString("n") == "y"
+			}
+		}
 		// Establish the gas dynamics to be enforced by the signer
 		fmt.Println()
 		fmt.Printf("What gas limit should empty blocks target (MGas)? (default = %0.3f)\n", infos.gasTarget)
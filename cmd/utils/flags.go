@@ -35,6 +35,7 @@ import (
 	"github.com/FusionFoundation/efsn/consensus"
 	"github.com/FusionFoundation/efsn/consensus/datong"
 	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/core/vm"
 	"github.com/FusionFoundation/efsn/crypto"
 	"github.com/FusionFoundation/efsn/eth"
@@ -140,11 +141,11 @@ var (
 	}
 	DeveloperFlag = cli.BoolFlag{
 		Name:  "dev",
-		Usage: "Ephemeral proof-of-authority network with a pre-funded developer account, mining enabled",
+		Usage: "Ephemeral DaTong network with a pre-funded developer account holding the tickets, mining enabled",
 	}
 	DeveloperPeriodFlag = cli.IntFlag{
 		Name:  "dev.period",
-		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
+		Usage: fmt.Sprintf("Block period to use in developer mode (0 = seal a block as soon as a transaction arrives, up to the %d genesis tickets)", core.DeveloperTickets),
 	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
@@ -332,6 +333,10 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerSignerFlag = cli.StringFlag{
+		Name:  "miner.signer",
+		Usage: "External signer (clef) IPC path or URL sealing the blocks, instead of the unlocked etherbase account",
+	}
 	// Auto buy tickets
 	AutoBuyTicketsEnabledFlag = cli.BoolFlag{
 		Name:  "autobt",
@@ -575,9 +580,13 @@ var (
 	}
 	CheckPointsFileFlag = cli.StringFlag{
 		Name:  "checkpoints",
-		Usage: "Specify check points custom file",
+		Usage: "Specify check points custom file (see 'efsn checkpoint export')",
 		Value: "",
 	}
+	FsnIndexFlag = cli.BoolFlag{
+		Name:  "fsnindex",
+		Usage: "Enable the local asset, swap and report index (serves fsn_allAssets, fsn_allSwaps and fsn_getReports)",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1030,6 +1039,12 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.Noverify = ctx.GlobalBool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerSignerFlag.Name) {
+		cfg.Signer = ctx.GlobalString(MinerSignerFlag.Name)
+	}
+	if ctx.GlobalBool(AutoBuyTicketsEnabledFlag.Name) {
+		cfg.Tickets.Enabled = true
+	}
 }
 
 // checkExclusive verifies that only a single instance of the provided flags was
@@ -1147,6 +1162,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.GlobalFloat64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.GlobalIsSet(FsnIndexFlag.Name) {
+		cfg.FsnIndex = ctx.GlobalBool(FsnIndexFlag.Name)
+	}
 	// Override any default configs for hard coded networks.
 	switch {
 	case ctx.GlobalBool(TestnetFlag.Name):
@@ -1168,7 +1186,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		}
 	case ctx.GlobalBool(DeveloperFlag.Name):
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
-			cfg.NetworkId = params.AllCliqueProtocolChanges.ChainID.Uint64()
+			cfg.NetworkId = params.DeveloperChainConfig.ChainID.Uint64()
 		}
 		// Create new developer account or reuse existing one
 		var (
@@ -1187,8 +1205,29 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			Fatalf("Failed to unlock developer account: %v", err)
 		}
 		log.Info("Using developer account", "address", developer.Address)
+		// the developer account holds the tickets, so it has to mine
+		if !ctx.GlobalIsSet(MinerEtherbaseFlag.Name) {
+			cfg.Miner.Etherbase = developer.Address
+		}
 
 		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		if ctx.GlobalIsSet(DataDirFlag.Name) {
+			// the developer genesis is timestamped, resume an existing
+			// developer chain from its own genesis
+			chaindb := MakeChainDatabase(ctx, stack, true)
+			if rawdb.ReadCanonicalHash(chaindb, 0) != (common.Hash{}) {
+				cfg.Genesis = nil
+			}
+			chaindb.Close()
+		}
+		// each block uses up a ticket, keep the developer tickets topped up on
+		// a periodic chain. An instant sealing chain would seal a block for
+		// every ticket bought, so it stops once the genesis tickets are used.
+		if ctx.GlobalInt(DeveloperPeriodFlag.Name) > 0 && !cfg.Miner.Tickets.Enabled {
+			cfg.Miner.Tickets.Enabled = true
+			cfg.Miner.Tickets.Target = core.DeveloperTickets
+		}
+		log.Info("Developer chain test assets", "DUSD", core.DeveloperUSDAssetID, "DTK", core.DeveloperTokenAssetID, "tickets", core.DeveloperTickets)
 		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) {
 			cfg.Miner.GasPrice = big.NewInt(1)
 		}
@@ -3,6 +3,7 @@ package common
 import (
 	"math"
 	"math/big"
+	"sync"
 )
 
 var (
@@ -14,16 +15,47 @@ var (
 	DevnetConstantinopleEnableHeight  *big.Int = big.NewInt(0)
 )
 
-// mainnet fork heights
-var MAINNET_FORKS = []uint64{
-	739500,  // fork 1
-	1818300, // fork 2
+// ForkConfig is the Fusion hard fork schedule consulted by the fork checks
+// below. It mirrors the Fusion fork fields of params.ChainConfig, which are
+// installed with SetForkConfig once the chain config is known. A nil block
+// means the fork is not scheduled.
+type ForkConfig struct {
+	PosV2Block            *big.Int
+	PosV3Block            *big.Int
+	SmartTransferBlock    *big.Int
+	TicketStorageBlock    *big.Int
+	FsnContractV2Block    *big.Int
+	BatchSendAssetBlock   *big.Int
+	AssetUpdateBlock      *big.Int
+	NotationTransferBlock *big.Int
+	Vote1FreezeEnd        *big.Int
 }
 
-// testnet fork heights
-var TESTNET_FORKS = []uint64{
-	534500,  // fork 1
-	1577000, // fork 2
+// activeForks defaults to the mainnet schedule until a chain config is installed.
+var (
+	activeForks = ForkConfig{
+		PosV2Block:         big.NewInt(739500),
+		PosV3Block:         big.NewInt(1818300),
+		SmartTransferBlock: big.NewInt(1818300),
+		Vote1FreezeEnd:     big.NewInt(786000),
+	}
+	activeForksLock sync.RWMutex
+)
+
+// SetForkConfig installs the hard fork schedule of the active chain.
+func SetForkConfig(cfg ForkConfig) {
+	activeForksLock.Lock()
+	defer activeForksLock.Unlock()
+
+	activeForks = cfg
+}
+
+// GetForkConfig returns the hard fork schedule of the active chain.
+func GetForkConfig() ForkConfig {
+	activeForksLock.RLock()
+	defer activeForksLock.RUnlock()
+
+	return activeForks
 }
 
 const (
@@ -32,24 +64,79 @@ const (
 	PosV3
 )
 
+// GetForkHeight returns the activation height of the n-th numbered hard fork,
+// or math.MaxUint64 if it is not scheduled.
 func GetForkHeight(n int) uint64 {
-	if UseDevnetRule || n <= 0 {
+	if n <= 0 {
 		return 0
 	}
-	forkArray := MAINNET_FORKS
-	if UseTestnetRule {
-		forkArray = TESTNET_FORKS
+	var block *big.Int
+	switch cfg := GetForkConfig(); n {
+	case 1:
+		block = cfg.PosV2Block
+	case 2:
+		block = cfg.PosV3Block
 	}
-	if n <= len(forkArray) {
-		return forkArray[n-1]
+	if block == nil {
+		return math.MaxUint64
 	}
-	return math.MaxUint64
+	return block.Uint64()
 }
 
 func IsHardFork(n int, blockNumber *big.Int) bool {
 	return blockNumber == nil || blockNumber.Uint64() >= GetForkHeight(n)
 }
 
+// Fork names a Fusion hard fork scheduled at a block of the ForkConfig.
+type Fork int
+
+const (
+	// SmartTransferFork spends the time locks of a sender before its balance.
+	SmartTransferFork Fork = iota
+	// TicketStorageFork stores tickets per ticket in the ticket key account's
+	// storage instead of a single compressed blob.
+	TicketStorageFork
+	// FsnContractV2Fork enables the query and swap functions of the FSN
+	// precompiled contract and prices them per function.
+	FsnContractV2Fork
+	// BatchSendAssetFork enables the BatchSendAssetFunc FSN call.
+	BatchSendAssetFork
+	// AssetUpdateFork enables the TransferAssetOwnerFunc and UpdateAssetFunc
+	// FSN calls.
+	AssetUpdateFork
+	// NotationTransferFork enables the TransferNotationFunc and
+	// ReleaseNotationFunc FSN calls.
+	NotationTransferFork
+)
+
+// Block returns the block the fork is scheduled at, or nil if it is not
+// scheduled.
+func (c *ForkConfig) Block(fork Fork) *big.Int {
+	switch fork {
+	case SmartTransferFork:
+		return c.SmartTransferBlock
+	case TicketStorageFork:
+		return c.TicketStorageBlock
+	case FsnContractV2Fork:
+		return c.FsnContractV2Block
+	case BatchSendAssetFork:
+		return c.BatchSendAssetBlock
+	case AssetUpdateFork:
+		return c.AssetUpdateBlock
+	case NotationTransferFork:
+		return c.NotationTransferBlock
+	}
+	return nil
+}
+
+// IsForkEnabled reports whether the fork is active at blockNumber in the
+// schedule of the active chain. A nil blockNumber checks the latest rules.
+func IsForkEnabled(fork Fork, blockNumber *big.Int) bool {
+	cfg := GetForkConfig()
+	block := cfg.Block(fork)
+	return blockNumber == nil || (block != nil && blockNumber.Cmp(block) >= 0)
+}
+
 func GetPoSHashVersion(blockNumber *big.Int) int {
 	if IsHardFork(2, blockNumber) {
 		return PosV3
@@ -72,7 +159,9 @@ func IsMultipleMiningCheckingEnabled(blockNumber *big.Int) bool {
 	return IsHardFork(1, blockNumber)
 }
 
-func IsSmartTransferEnabled(blockNumber *big.Int) bool {
+// IsFsnContractEnabled reports whether the FSN precompiled contract is
+// deployed, which happened with the PoS hash v3 fork.
+func IsFsnContractEnabled(blockNumber *big.Int) bool {
 	return IsHardFork(2, blockNumber)
 }
 
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIsForkEnabled(t *testing.T) {
+	prev := GetForkConfig()
+	t.Cleanup(func() { SetForkConfig(prev) })
+
+	SetForkConfig(ForkConfig{
+		SmartTransferBlock:  big.NewInt(10),
+		TicketStorageBlock:  big.NewInt(20),
+		FsnContractV2Block:  big.NewInt(30),
+		BatchSendAssetBlock: big.NewInt(40),
+		AssetUpdateBlock:    big.NewInt(50),
+	})
+	tests := []struct {
+		fork  Fork
+		block *big.Int
+	}{
+		{SmartTransferFork, big.NewInt(10)},
+		{TicketStorageFork, big.NewInt(20)},
+		{FsnContractV2Fork, big.NewInt(30)},
+		{BatchSendAssetFork, big.NewInt(40)},
+		{AssetUpdateFork, big.NewInt(50)},
+		{NotationTransferFork, nil},
+	}
+	for _, tt := range tests {
+		if !IsForkEnabled(tt.fork, nil) {
+			t.Errorf("fork %d: disabled by the latest rules", tt.fork)
+		}
+		if tt.block == nil {
+			if IsForkEnabled(tt.fork, big.NewInt(1<<40)) {
+				t.Errorf("fork %d: enabled without being scheduled", tt.fork)
+			}
+			continue
+		}
+		if IsForkEnabled(tt.fork, new(big.Int).Sub(tt.block, Big1)) {
+			t.Errorf("fork %d: enabled before block %v", tt.fork, tt.block)
+		}
+		if !IsForkEnabled(tt.fork, tt.block) {
+			t.Errorf("fork %d: disabled at block %v", tt.fork, tt.block)
+		}
+	}
+}
@@ -67,6 +67,35 @@ type AssetValueChangeExArgs struct {
 	TransacData string       `json:"transacData"`
 }
 
+// TransferAssetOwnerArgs wacom
+type TransferAssetOwnerArgs struct {
+	FusionBaseArgs
+	AssetID Hash    `json:"asset"`
+	To      Address `json:"to"`
+	ToUSAN  uint64  `json:"toUSAN"`
+}
+
+// UpdateAssetArgs wacom, the fields not given keep their current value.
+type UpdateAssetArgs struct {
+	FusionBaseArgs
+	AssetID     Hash    `json:"asset"`
+	Description *string `json:"description"`
+	CanChange   *bool   `json:"canChange"`
+}
+
+// TransferNotationArgs wacom, the notation defaults to the sender's one.
+type TransferNotationArgs struct {
+	FusionBaseArgs
+	Notation uint64  `json:"notation"`
+	To       Address `json:"to"`
+}
+
+// ReleaseNotationArgs wacom, the notation defaults to the sender's one.
+type ReleaseNotationArgs struct {
+	FusionBaseArgs
+	Notation uint64 `json:"notation"`
+}
+
 // MakeSwapArgs wacom
 type MakeSwapArgs struct {
 	FusionBaseArgs
@@ -127,6 +156,23 @@ type TakeMultiSwapArgs struct {
 	Size   *big.Int
 }
 
+// BatchSendAssetEntryArgs is one transfer of BatchSendAssetArgs, to the
+// receiver or its notation.
+type BatchSendAssetEntryArgs struct {
+	AssetID   Hash            `json:"asset"`
+	To        Address         `json:"to"`
+	ToUSAN    uint64          `json:"toUSAN"`
+	Value     *hexutil.Big    `json:"value"`
+	StartTime *hexutil.Uint64 `json:"start"`
+	EndTime   *hexutil.Uint64 `json:"end"`
+}
+
+// BatchSendAssetArgs wacom
+type BatchSendAssetArgs struct {
+	FusionBaseArgs
+	Entries []BatchSendAssetEntryArgs `json:"entries"`
+}
+
 //////////////////// args ToParam, ToData, Init ///////////////////////
 
 func (args *FusionBaseArgs) ToData() ([]byte, error) {
@@ -213,6 +259,61 @@ func (args *AssetValueChangeExArgs) ToData() ([]byte, error) {
 	return args.ToParam().ToBytes()
 }
 
+func (args *TransferAssetOwnerArgs) ToParam() *TransferAssetOwnerParam {
+	return &TransferAssetOwnerParam{
+		AssetID: args.AssetID,
+		To:      args.To,
+	}
+}
+
+func (args *TransferAssetOwnerArgs) ToData() ([]byte, error) {
+	return args.ToParam().ToBytes()
+}
+
+func (args *UpdateAssetArgs) Init(asset *Asset) {
+	if args.Description == nil {
+		args.Description = new(string)
+		*args.Description = asset.Description
+	}
+	if args.CanChange == nil {
+		args.CanChange = new(bool)
+		*args.CanChange = asset.CanChange
+	}
+}
+
+func (args *UpdateAssetArgs) ToParam() *UpdateAssetParam {
+	return &UpdateAssetParam{
+		AssetID:     args.AssetID,
+		Description: *args.Description,
+		CanChange:   *args.CanChange,
+	}
+}
+
+func (args *UpdateAssetArgs) ToData() ([]byte, error) {
+	return args.ToParam().ToBytes()
+}
+
+func (args *TransferNotationArgs) ToParam() *TransferNotationParam {
+	return &TransferNotationParam{
+		Notation: args.Notation,
+		To:       args.To,
+	}
+}
+
+func (args *TransferNotationArgs) ToData() ([]byte, error) {
+	return args.ToParam().ToBytes()
+}
+
+func (args *ReleaseNotationArgs) ToParam() *ReleaseNotationParam {
+	return &ReleaseNotationParam{
+		Notation: args.Notation,
+	}
+}
+
+func (args *ReleaseNotationArgs) ToData() ([]byte, error) {
+	return args.ToParam().ToBytes()
+}
+
 func (args *MakeSwapArgs) Init(time *big.Int) {
 	args.Time = time
 
@@ -410,3 +511,35 @@ func (args *TimeLockArgs) Init(timeLockType TimeLockType) {
 		*(*uint64)(args.EndTime) = TimeLockForever
 	}
 }
+
+func (args *BatchSendAssetArgs) Init() {
+	for i := range args.Entries {
+		entry := &args.Entries[i]
+		if entry.StartTime == nil {
+			entry.StartTime = new(hexutil.Uint64)
+			*(*uint64)(entry.StartTime) = TimeLockNow
+		}
+		if entry.EndTime == nil {
+			entry.EndTime = new(hexutil.Uint64)
+			*(*uint64)(entry.EndTime) = TimeLockForever
+		}
+	}
+}
+
+func (args *BatchSendAssetArgs) ToParam() *BatchSendAssetParam {
+	entries := make([]BatchSendAssetEntry, len(args.Entries))
+	for i, entry := range args.Entries {
+		entries[i] = BatchSendAssetEntry{
+			To:        entry.To,
+			AssetID:   entry.AssetID,
+			Value:     entry.Value.ToInt(),
+			StartTime: uint64(*entry.StartTime),
+			EndTime:   uint64(*entry.EndTime),
+		}
+	}
+	return &BatchSendAssetParam{Entries: entries}
+}
+
+func (args *BatchSendAssetArgs) ToData() ([]byte, error) {
+	return args.ToParam().ToBytes()
+}
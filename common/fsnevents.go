@@ -0,0 +1,98 @@
+package common
+
+// The events of the Fusion subscriptions, available as
+// fsn_subscribe("<name>", filter) over WebSocket and IPC.
+
+// FsnEventFilter selects the events of a subscription by the accounts and the
+// assets involved. An empty list matches all of them, the tickets involve the
+// FSN asset.
+type FsnEventFilter struct {
+	Addresses []Address `json:"addresses"`
+	AssetIDs  []Hash    `json:"assetIDs"`
+}
+
+// MatchAddress reports whether the filter selects any of the addresses.
+func (f *FsnEventFilter) MatchAddress(addrs ...Address) bool {
+	if f == nil || len(f.Addresses) == 0 {
+		return true
+	}
+	for _, addr := range addrs {
+		for _, a := range f.Addresses {
+			if a == addr {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchAsset reports whether the filter selects any of the assets.
+func (f *FsnEventFilter) MatchAsset(ids ...Hash) bool {
+	if f == nil || len(f.AssetIDs) == 0 {
+		return true
+	}
+	for _, id := range ids {
+		for _, a := range f.AssetIDs {
+			if a == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FsnEventBlock is the block of an event.
+type FsnEventBlock struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	BlockHash   Hash   `json:"blockHash"`
+}
+
+// FsnTicketEvent is a ticket selected, retreated, purchased or expired.
+type FsnTicketEvent struct {
+	FsnEventBlock
+	TxHash     *Hash   `json:"txHash,omitempty"` // purchase transaction
+	TicketID   Hash    `json:"ticketID"`
+	Owner      Address `json:"owner"`
+	StartTime  uint64  `json:"startTime"`
+	ExpireTime uint64  `json:"expireTime"`
+	Value      string  `json:"value"`
+}
+
+// FsnAssetTransferEvent is an asset sent by an FSN call.
+type FsnAssetTransferEvent struct {
+	FsnEventBlock
+	TxHash  Hash    `json:"txHash"`
+	From    Address `json:"from"`
+	To      Address `json:"to"`
+	AssetID Hash    `json:"assetID"`
+	Value   string  `json:"value"`
+}
+
+// FsnTimeLockEvent is a time lock created by an FSN call, or a time lock item
+// which matured as its end time passed.
+type FsnTimeLockEvent struct {
+	FsnEventBlock
+	TxHash    *Hash    `json:"txHash,omitempty"`
+	LockType  string   `json:"lockType,omitempty"`
+	From      *Address `json:"from,omitempty"`
+	Owner     Address  `json:"owner"`
+	AssetID   Hash     `json:"assetID"`
+	StartTime uint64   `json:"startTime"`
+	EndTime   uint64   `json:"endTime"`
+	Value     string   `json:"value"`
+}
+
+// FsnSwapEvent is a swap or multi swap made, taken or recalled. The owner and
+// the assets of a taken or recalled swap are the ones of the swap.
+type FsnSwapEvent struct {
+	FsnEventBlock
+	TxHash       Hash      `json:"txHash"`
+	SwapID       Hash      `json:"swapID"`
+	Owner        Address   `json:"owner"`
+	Taker        *Address  `json:"taker,omitempty"`
+	FromAssetIDs []Hash    `json:"fromAssetIDs"`
+	ToAssetIDs   []Hash    `json:"toAssetIDs"`
+	Targets      []Address `json:"targets,omitempty"`
+	Size         string    `json:"size,omitempty"` // size made or taken
+	Deleted      bool      `json:"deleted,omitempty"`
+}
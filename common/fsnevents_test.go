@@ -0,0 +1,22 @@
+package common
+
+import "testing"
+
+func TestFsnEventFilter(t *testing.T) {
+	var (
+		a, b  = BytesToAddress([]byte{1}), BytesToAddress([]byte{2})
+		asset = HexToHash("0x01")
+	)
+	// a missing or empty filter matches everything
+	var none *FsnEventFilter
+	if !none.MatchAddress(a) || !none.MatchAsset(asset) || !new(FsnEventFilter).MatchAddress(a) {
+		t.Errorf("empty filter mismatch")
+	}
+	filter := &FsnEventFilter{Addresses: []Address{a}, AssetIDs: []Hash{SystemAssetID}}
+	if !filter.MatchAddress(b, a) || filter.MatchAddress(b) {
+		t.Errorf("address filter mismatch")
+	}
+	if !filter.MatchAsset(asset, SystemAssetID) || filter.MatchAsset(asset) {
+		t.Errorf("asset filter mismatch")
+	}
+}
@@ -56,6 +56,57 @@ type TimeLockParam struct {
 	Value     *big.Int `json:",string"`
 }
 
+// MaxBatchSendAssetEntries is the maximum number of entries of a batch send.
+const MaxBatchSendAssetEntries = 1000
+
+// BatchSendAssetEntryGas is the gas charged for each entry of a batch send,
+// the same as the value transfer of a call.
+const BatchSendAssetEntryGas uint64 = 9000
+
+// BatchSendAssetEntry is one transfer of a batch send. The receiver gets the
+// asset if the range covers now to forever and a time lock otherwise.
+type BatchSendAssetEntry struct {
+	To        Address
+	AssetID   Hash
+	Value     *big.Int `json:",string"`
+	StartTime uint64
+	EndTime   uint64
+}
+
+// BatchSendAssetParam sends assets and time locks to many receivers, all the
+// entries are applied or none.
+type BatchSendAssetParam struct {
+	Entries []BatchSendAssetEntry
+}
+
+// TransferAssetOwnerParam hands an asset to a new owner, who then is the only
+// one allowed to change or update it.
+type TransferAssetOwnerParam struct {
+	AssetID Hash
+	To      Address
+}
+
+// UpdateAssetParam replaces the description of an asset. CanChange can only
+// be turned off, which renounces changing the asset supply for good.
+type UpdateAssetParam struct {
+	AssetID     Hash
+	Description string
+	CanChange   bool
+}
+
+// TransferNotationParam moves the sender's notation to an address which has
+// no notation.
+type TransferNotationParam struct {
+	Notation uint64
+	To       Address
+}
+
+// ReleaseNotationParam gives up the sender's notation, it is never assigned
+// again.
+type ReleaseNotationParam struct {
+	Notation uint64
+}
+
 // MakeSwapParam wacom
 type MakeSwapParam struct {
 	FromAssetID   Hash
@@ -171,6 +222,31 @@ func (p *TakeMultiSwapParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// ToBytes wacom
+func (p *BatchSendAssetParam) ToBytes() ([]byte, error) {
+	return rlp.EncodeToBytes(p)
+}
+
+// ToBytes wacom
+func (p *TransferAssetOwnerParam) ToBytes() ([]byte, error) {
+	return rlp.EncodeToBytes(p)
+}
+
+// ToBytes wacom
+func (p *UpdateAssetParam) ToBytes() ([]byte, error) {
+	return rlp.EncodeToBytes(p)
+}
+
+// ToBytes wacom
+func (p *TransferNotationParam) ToBytes() ([]byte, error) {
+	return rlp.EncodeToBytes(p)
+}
+
+// ToBytes wacom
+func (p *ReleaseNotationParam) ToBytes() ([]byte, error) {
+	return rlp.EncodeToBytes(p)
+}
+
 type EmptyParam struct{}
 
 func (p *EmptyParam) ToBytes() ([]byte, error) {
@@ -227,6 +303,16 @@ func DecodeTxInput(input []byte) (interface{}, error) {
 		return DecodeFsnCallParam(&fsnCall, &MakeMultiSwapParam{})
 	case TakeMultiSwapFunc:
 		return DecodeFsnCallParam(&fsnCall, &TakeMultiSwapParam{})
+	case BatchSendAssetFunc:
+		return DecodeFsnCallParam(&fsnCall, &BatchSendAssetParam{})
+	case TransferAssetOwnerFunc:
+		return DecodeFsnCallParam(&fsnCall, &TransferAssetOwnerParam{})
+	case UpdateAssetFunc:
+		return DecodeFsnCallParam(&fsnCall, &UpdateAssetParam{})
+	case TransferNotationFunc:
+		return DecodeFsnCallParam(&fsnCall, &TransferNotationParam{})
+	case ReleaseNotationFunc:
+		return DecodeFsnCallParam(&fsnCall, &ReleaseNotationParam{})
 	case ReportIllegalFunc:
 		return fsnCall, fmt.Errorf("ReportIllegal should processed by datong.DecodeTxInput")
 	}
@@ -290,6 +376,89 @@ func (p *TimeLockParam) Check(blockNumber *big.Int, timestamp uint64) error {
 	return nil
 }
 
+// Check wacom
+func (p *BatchSendAssetParam) Check(blockNumber *big.Int, timestamp uint64) error {
+	if len(p.Entries) == 0 {
+		return fmt.Errorf("BatchSendAsset entries must be set")
+	}
+	if len(p.Entries) > MaxBatchSendAssetEntries {
+		return fmt.Errorf("BatchSendAsset has more than %d entries", MaxBatchSendAssetEntries)
+	}
+	for i, entry := range p.Entries {
+		if err := entry.Check(timestamp); err != nil {
+			return fmt.Errorf("BatchSendAsset entry %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Check wacom
+func (p *BatchSendAssetEntry) Check(timestamp uint64) error {
+	if p.Value == nil || p.Value.Cmp(Big0) <= 0 {
+		return fmt.Errorf("Value must be set and greater than 0")
+	}
+	if p.To == (Address{}) {
+		return fmt.Errorf("receiver address must be set and not zero address")
+	}
+	if p.AssetID == (Hash{}) {
+		return fmt.Errorf("empty asset ID, 'asset' must be specified instead of AssetID.")
+	}
+	if p.StartTime > p.EndTime {
+		return fmt.Errorf("StartTime must be less than or equal to EndTime")
+	}
+	if p.EndTime < timestamp {
+		return fmt.Errorf("EndTime must be greater than latest block time")
+	}
+	return nil
+}
+
+// Check wacom
+func (p *TransferAssetOwnerParam) Check(blockNumber *big.Int) error {
+	if p.AssetID == (Hash{}) {
+		return fmt.Errorf("empty asset ID, 'asset' must be specified instead of AssetID.")
+	}
+	if p.AssetID == SystemAssetID {
+		return fmt.Errorf("the system asset can't be transferred")
+	}
+	if p.To == (Address{}) {
+		return fmt.Errorf("new owner address must be set and not zero address")
+	}
+	return nil
+}
+
+// Check wacom
+func (p *UpdateAssetParam) Check(blockNumber *big.Int) error {
+	if p.AssetID == (Hash{}) {
+		return fmt.Errorf("empty asset ID, 'asset' must be specified instead of AssetID.")
+	}
+	if p.AssetID == SystemAssetID {
+		return fmt.Errorf("the system asset can't be updated")
+	}
+	if len(p.Description) > 1024 {
+		return fmt.Errorf("UpdateAsset description length is greater than 1024 chars")
+	}
+	return nil
+}
+
+// Check wacom
+func (p *TransferNotationParam) Check(blockNumber *big.Int) error {
+	if p.Notation == 0 {
+		return fmt.Errorf("notation must be set")
+	}
+	if p.To == (Address{}) {
+		return fmt.Errorf("receiver address must be set and not zero address")
+	}
+	return nil
+}
+
+// Check wacom
+func (p *ReleaseNotationParam) Check(blockNumber *big.Int) error {
+	if p.Notation == 0 {
+		return fmt.Errorf("notation must be set")
+	}
+	return nil
+}
+
 // Check wacom
 func (p *BuyTicketParam) Check(blockNumber *big.Int, timestamp uint64) error {
 	start, end := p.Start, p.End
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+
+	"github.com/FusionFoundation/efsn/rlp"
 )
 
 // SystemAssetID wacom
@@ -36,6 +38,10 @@ var (
 
 	// ReportIllegalAddress wacom
 	ReportKeyAddress = HexToAddress("0xfffffffffffffffffffffffffffffffffffffff8")
+
+	// TicketIndexKeyAddress holds the lists enumerating the tickets of the per
+	// ticket storage layout, next to the ticket records of TicketKeyAddress
+	TicketIndexKeyAddress = HexToAddress("0xfffffffffffffffffffffffffffffffffffffff7")
 )
 
 func (addr Address) IsSpecialKeyAddress() bool {
@@ -44,15 +50,11 @@ func (addr Address) IsSpecialKeyAddress() bool {
 		addr == AssetKeyAddress ||
 		addr == SwapKeyAddress ||
 		addr == MultiSwapKeyAddress ||
-		addr == ReportKeyAddress
+		addr == ReportKeyAddress ||
+		addr == TicketIndexKeyAddress
 }
 
 var (
-	// AutoBuyTicket wacom
-	AutoBuyTicket = false
-	// AutoBuyTicketChan wacom
-	AutoBuyTicketChan = make(chan int, 10)
-
 	// ReportIllegal wacom
 	ReportIllegalChan = make(chan []byte)
 )
@@ -95,6 +97,17 @@ const (
 	TakeMultiSwapFunc
 	// ReportIllegalFunc wacom
 	ReportIllegalFunc
+	// BatchSendAssetFunc sends assets and time locks to many receivers
+	BatchSendAssetFunc
+	// TransferAssetOwnerFunc hands an asset to a new owner
+	TransferAssetOwnerFunc
+	// UpdateAssetFunc updates the description of an asset and can renounce
+	// changing its supply
+	UpdateAssetFunc
+	// TransferNotationFunc moves the sender's notation to another address
+	TransferNotationFunc
+	// ReleaseNotationFunc gives up the sender's notation
+	ReleaseNotationFunc
 	// UnknownFunc
 	UnknownFunc = 0xff
 )
@@ -135,6 +148,16 @@ func (f FSNCallFunc) Name() string {
 		return "TakeMultiSwapFunc"
 	case ReportIllegalFunc:
 		return "ReportIllegalFunc"
+	case BatchSendAssetFunc:
+		return "BatchSendAssetFunc"
+	case TransferAssetOwnerFunc:
+		return "TransferAssetOwnerFunc"
+	case UpdateAssetFunc:
+		return "UpdateAssetFunc"
+	case TransferNotationFunc:
+		return "TransferNotationFunc"
+	case ReleaseNotationFunc:
+		return "ReleaseNotationFunc"
 	}
 	return "Unknown"
 }
@@ -143,12 +166,14 @@ func IsFsnCall(to *Address) bool {
 	return to != nil && *to == FSNCallAddress
 }
 
-func GetFsnCallFee(to *Address, funcType FSNCallFunc) *big.Int {
+// GetFsnCallFee returns the fee of an FSN call in the block, which goes to the
+// miner along with the gas.
+func GetFsnCallFee(to *Address, param *FSNCallParam, blockNumber *big.Int) *big.Int {
 	fee := big.NewInt(0)
 	if !IsFsnCall(to) {
 		return fee
 	}
-	switch funcType {
+	switch param.Func {
 	case GenNotationFunc:
 		fee = big.NewInt(100000000000000000) // 0.1 FSN
 	case GenAssetFunc:
@@ -157,10 +182,45 @@ func GetFsnCallFee(to *Address, funcType FSNCallFunc) *big.Int {
 		fee = big.NewInt(1000000000000000) // 0.001 FSN
 	case TimeLockFunc:
 		fee = big.NewInt(1000000000000000) // 0.001 FSN
+	case BatchSendAssetFunc:
+		if !IsForkEnabled(BatchSendAssetFork, blockNumber) {
+			break
+		}
+		// 0.001 FSN and 0.0001 FSN per entry
+		batchParam := BatchSendAssetParam{}
+		rlp.DecodeBytes(param.Data, &batchParam)
+		fee = new(big.Int).Mul(big.NewInt(100000000000000), big.NewInt(int64(len(batchParam.Entries))))
+		fee.Add(fee, big.NewInt(1000000000000000))
+	case TransferNotationFunc:
+		if IsForkEnabled(NotationTransferFork, blockNumber) {
+			fee = big.NewInt(10000000000000000) // 0.01 FSN
+		}
+	case ReleaseNotationFunc:
+		if IsForkEnabled(NotationTransferFork, blockNumber) {
+			fee = big.NewInt(1000000000000000) // 0.001 FSN
+		}
 	}
 	return fee
 }
 
+// GetFsnCallGas returns the gas an FSN call needs in the block on top of the
+// intrinsic gas of its transaction.
+func GetFsnCallGas(to *Address, param *FSNCallParam, blockNumber *big.Int) uint64 {
+	if !IsFsnCall(to) {
+		return 0
+	}
+	switch param.Func {
+	case BatchSendAssetFunc:
+		if !IsForkEnabled(BatchSendAssetFork, blockNumber) {
+			break
+		}
+		batchParam := BatchSendAssetParam{}
+		rlp.DecodeBytes(param.Data, &batchParam)
+		return uint64(len(batchParam.Entries)) * BatchSendAssetEntryGas
+	}
+	return 0
+}
+
 // ToAsset wacom
 func (p *GenAssetParam) ToAsset() Asset {
 	return Asset{
@@ -207,6 +267,37 @@ func (u *Asset) MarshalJSON() ([]byte, error) {
 	})
 }
 
+func (u *Asset) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		ID          Hash
+		Owner       Address
+		Name        string
+		Symbol      string
+		Decimals    uint8
+		Total       string
+		CanChange   bool
+		Description string
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	total, ok := new(big.Int).SetString(dec.Total, 10)
+	if !ok {
+		return fmt.Errorf("invalid asset total %q", dec.Total)
+	}
+	*u = Asset{
+		ID:          dec.ID,
+		Owner:       dec.Owner,
+		Name:        dec.Name,
+		Symbol:      dec.Symbol,
+		Decimals:    dec.Decimals,
+		Total:       total,
+		CanChange:   dec.CanChange,
+		Description: dec.Description,
+	}
+	return nil
+}
+
 // SystemAsset wacom
 var SystemAsset = Asset{
 	Name:        "Fusion",
@@ -267,6 +358,16 @@ func CheckSwapTargets(targets []Address, addr Address) error {
 	return fmt.Errorf("swap taker does not match the specified targets")
 }
 
+// CalcNotationDisplay returns the displayed notation of the notation count,
+// which is the count followed by two check digits.
+func CalcNotationDisplay(notation uint64) uint64 {
+	if notation == 0 {
+		return notation
+	}
+	check := (notation ^ 8192 ^ 13 + 73/76798669*708583737978) % 100
+	return (notation*100 + check)
+}
+
 // KeyValue wacom
 type KeyValue struct {
 	Key   string
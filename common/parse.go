@@ -16,6 +16,13 @@ var (
 	LogFusionAssetReceivedTopic = Keccak256Hash([]byte("LogFusionAssetReceived(bytes32,address,uint256,uint64,uint64,uint8)")) // = 0x8a9c8666f57c1ade38343d03dc1d891f209af2efb28c551a4a2c96160e7a2a6b
 
 	LogFusionAssetSentTopic = Keccak256Hash([]byte("LogFusionAssetSent(bytes32,address,uint256,uint64,uint64,uint8)")) // = 0xf9c07f165baf6a7868a16aa9de8b6f41fe0849ba33af6ece038847047c6606e7
+
+	// Swap events generated by the FSN contract for the swaps made, recalled and taken by contracts.
+	LogFusionSwapMadeTopic = Keccak256Hash([]byte("LogFusionSwapMade(bytes32,address,bytes32,uint64,uint64,uint256,bytes32,uint64,uint64,uint256,uint256,address)")) // = 0xc296ef6cf6699b4778594427c2229206f21b204c1a347be33c6c1a4da8cd92a7
+
+	LogFusionSwapRecalledTopic = Keccak256Hash([]byte("LogFusionSwapRecalled(bytes32,address)")) // = 0x955be226a253f7625c66eef9b1ad789a62cbca1af80a02f11e32623a4ce609bc
+
+	LogFusionSwapTakenTopic = Keccak256Hash([]byte("LogFusionSwapTaken(bytes32,address,uint256,bool)")) // = 0x3346b4f0e703308758adb763abecfb63821fe2b6a293c1c74a89e990fc08e2b1
 )
 
 func Keccak256Hash(data ...[]byte) (h Hash) {
@@ -520,6 +520,23 @@ func (u *TimeLockItem) MarshalJSON() ([]byte, error) {
 	})
 }
 
+func (u *TimeLockItem) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		StartTime uint64
+		EndTime   uint64
+		Value     string
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	value, ok := new(big.Int).SetString(dec.Value, 10)
+	if !ok {
+		return fmt.Errorf("invalid time lock value %q", dec.Value)
+	}
+	*u = TimeLockItem{StartTime: dec.StartTime, EndTime: dec.EndTime, Value: value}
+	return nil
+}
+
 func (z *TimeLock) ToDisplay() *TimeLock {
 	t := z.Clone()
 	items := t.Items
@@ -0,0 +1,276 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// VestingInterval is the period of the buckets of a vesting report.
+type VestingInterval string
+
+const (
+	VestingDaily   VestingInterval = "day"
+	VestingMonthly VestingInterval = "month"
+)
+
+const (
+	// MaxVestingTime is the latest time a vesting report can be bucketed to,
+	// the end of year 9999 UTC.
+	MaxVestingTime uint64 = 253402300799
+	// MaxVestingBuckets bounds the number of buckets of a vesting report.
+	MaxVestingBuckets = 10000
+)
+
+// VestingStep is a step of the vesting schedule of a time lock. From its time
+// until the time of the next step, Available is the value usable at each
+// second and Unlocked the value usable from that second on forever.
+type VestingStep struct {
+	Time      uint64
+	Available *big.Int
+	Unlocked  *big.Int
+}
+
+// VestingSchedule is the step function of the value unlocked by a time lock
+// over time, with a step at each change.
+type VestingSchedule []*VestingStep
+
+// VestingBucket sums up the vesting schedule over a day or month. The values
+// are the ones at the end of the bucket, Change is the change of the unlocked
+// value since the end of the previous bucket.
+type VestingBucket struct {
+	Start     uint64
+	End       uint64
+	Available *big.Int
+	Unlocked  *big.Int
+	Change    *big.Int
+}
+
+// UnlockedValue returns the value of the time lock usable from the timestamp
+// on forever, which can be converted to an asset at that time.
+func (z *TimeLock) UnlockedValue(timestamp uint64) *big.Int {
+	return new(big.Int).Set(z.GetSpendableValue(timestamp, TimeLockForever))
+}
+
+// VestingSchedule returns the vesting schedule of the time lock from the given
+// time on. The first step is at that time, the last step lasts forever.
+func (z *TimeLock) VestingSchedule(from uint64) VestingSchedule {
+	times := []uint64{from}
+	if !z.IsEmpty() {
+		for _, item := range z.Items {
+			if item.StartTime > from {
+				times = append(times, item.StartTime)
+			}
+			if item.EndTime >= from && item.EndTime < TimeLockForever {
+				times = append(times, item.EndTime+1)
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	schedule := VestingSchedule{}
+	for _, t := range times {
+		step := &VestingStep{
+			Time:      t,
+			Available: new(big.Int).Set(z.GetSpendableValue(t, t)),
+			Unlocked:  z.UnlockedValue(t),
+		}
+		if n := len(schedule); n > 0 {
+			last := schedule[n-1]
+			if last.Time == t || (last.Available.Cmp(step.Available) == 0 && last.Unlocked.Cmp(step.Unlocked) == 0) {
+				continue
+			}
+		}
+		schedule = append(schedule, step)
+	}
+	return schedule
+}
+
+// At returns the step of the schedule in effect at the given time, a zero
+// step if the time is before the schedule.
+func (s VestingSchedule) At(timestamp uint64) *VestingStep {
+	i := sort.Search(len(s), func(i int) bool { return s[i].Time > timestamp })
+	if i == 0 {
+		return &VestingStep{Time: timestamp, Available: new(big.Int), Unlocked: new(big.Int)}
+	}
+	return s[i-1]
+}
+
+// Buckets sums up the schedule from the from time to the to time included by
+// calendar day or month in UTC. The first and last buckets are cut at the from
+// and to times. The to time is clamped to the last step of the schedule, as
+// nothing changes after it.
+func (s VestingSchedule) Buckets(interval VestingInterval, from, to uint64) ([]*VestingBucket, error) {
+	if from > to {
+		return nil, fmt.Errorf("vesting report starts at %v after its end %v", from, to)
+	}
+	if n := len(s); n > 0 && to > s[n-1].Time && s[n-1].Time >= from {
+		to = s[n-1].Time
+	}
+	if to > MaxVestingTime {
+		return nil, fmt.Errorf("vesting report end %v exceeds %v", to, MaxVestingTime)
+	}
+	var buckets []*VestingBucket
+	prev := s.At(from).Unlocked
+	for start := from; ; {
+		end, err := interval.end(start)
+		if err != nil {
+			return nil, err
+		}
+		if end > to {
+			end = to
+		}
+		if len(buckets) == MaxVestingBuckets {
+			return nil, fmt.Errorf("too many vesting buckets, max %v", MaxVestingBuckets)
+		}
+		step := s.At(end)
+		buckets = append(buckets, &VestingBucket{
+			Start:     start,
+			End:       end,
+			Available: new(big.Int).Set(step.Available),
+			Unlocked:  new(big.Int).Set(step.Unlocked),
+			Change:    new(big.Int).Sub(step.Unlocked, prev),
+		})
+		prev = step.Unlocked
+		if end == to {
+			return buckets, nil
+		}
+		start = end + 1
+	}
+}
+
+// VestingScheduleArgs selects the period of a vesting report. From defaults
+// to the time of the block and To to the last change of the schedule. With an
+// interval the schedule is also summed up by day or month.
+type VestingScheduleArgs struct {
+	From     *uint64         `json:"from"`
+	To       *uint64         `json:"to"`
+	Interval VestingInterval `json:"interval"`
+}
+
+// VestingReport is the vesting report of a time lock balance.
+type VestingReport struct {
+	Steps   VestingSchedule  `json:"steps"`
+	Buckets []*VestingBucket `json:"buckets,omitempty"`
+}
+
+// VestingReport returns the vesting report of the time lock for the period
+// selected by args, in a block of the given time.
+func (z *TimeLock) VestingReport(args *VestingScheduleArgs, now uint64) (*VestingReport, error) {
+	if args == nil {
+		args = new(VestingScheduleArgs)
+	}
+	from := now
+	if args.From != nil && *args.From > from {
+		from = *args.From
+	}
+	steps := z.VestingSchedule(from)
+	report := &VestingReport{Steps: steps}
+	if args.Interval == "" {
+		return report, nil
+	}
+	to := steps[len(steps)-1].Time
+	if args.To != nil {
+		to = *args.To
+	}
+	buckets, err := steps.Buckets(args.Interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report.Buckets = buckets
+	return report, nil
+}
+
+// end returns the last second of the interval containing the timestamp.
+func (i VestingInterval) end(timestamp uint64) (uint64, error) {
+	switch i {
+	case VestingDaily:
+		return timestamp - timestamp%86400 + 86399, nil
+	case VestingMonthly:
+		t := time.Unix(int64(timestamp), 0).UTC()
+		next := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+		return uint64(next.Unix()) - 1, nil
+	default:
+		return 0, fmt.Errorf("unknown vesting interval %q, want %q or %q", string(i), VestingDaily, VestingMonthly)
+	}
+}
+
+type vestingStepJSON struct {
+	Time      uint64
+	Available string
+	Unlocked  string
+}
+
+func (u *VestingStep) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&vestingStepJSON{
+		Time:      u.Time,
+		Available: u.Available.String(),
+		Unlocked:  u.Unlocked.String(),
+	})
+}
+
+func (u *VestingStep) UnmarshalJSON(input []byte) error {
+	var dec vestingStepJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	available, err := parseVestingValue(dec.Available)
+	if err != nil {
+		return err
+	}
+	unlocked, err := parseVestingValue(dec.Unlocked)
+	if err != nil {
+		return err
+	}
+	*u = VestingStep{Time: dec.Time, Available: available, Unlocked: unlocked}
+	return nil
+}
+
+type vestingBucketJSON struct {
+	Start     uint64
+	End       uint64
+	Available string
+	Unlocked  string
+	Change    string
+}
+
+func (u *VestingBucket) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&vestingBucketJSON{
+		Start:     u.Start,
+		End:       u.End,
+		Available: u.Available.String(),
+		Unlocked:  u.Unlocked.String(),
+		Change:    u.Change.String(),
+	})
+}
+
+func (u *VestingBucket) UnmarshalJSON(input []byte) error {
+	var dec vestingBucketJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	available, err := parseVestingValue(dec.Available)
+	if err != nil {
+		return err
+	}
+	unlocked, err := parseVestingValue(dec.Unlocked)
+	if err != nil {
+		return err
+	}
+	change, err := parseVestingValue(dec.Change)
+	if err != nil {
+		return err
+	}
+	*u = VestingBucket{Start: dec.Start, End: dec.End, Available: available, Unlocked: unlocked, Change: change}
+	return nil
+}
+
+func parseVestingValue(s string) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid vesting value %q", s)
+	}
+	return value, nil
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestVestingBuckets(t *testing.T) {
+	const (
+		day  = 86400
+		from = 1600000000 - 1600000000%day // midnight UTC
+	)
+	timeLock := NewTimeLock(
+		&TimeLockItem{StartTime: from, EndTime: from + 2*day - 1, Value: big.NewInt(10)},
+		&TimeLockItem{StartTime: from + 2*day, EndTime: TimeLockForever, Value: big.NewInt(5)},
+	)
+	schedule := timeLock.VestingSchedule(from)
+	if last := schedule[len(schedule)-1].Time; last != from+2*day {
+		t.Fatalf("last step mismatch: have %v, want %v", last, from+2*day)
+	}
+
+	// a report up to the far future is cut at the last change of the schedule
+	buckets, err := schedule.Buckets(VestingDaily, from, MaxVestingTime)
+	if err != nil {
+		t.Fatalf("failed to bucket the schedule: %v", err)
+	}
+	want := []struct {
+		start, end        uint64
+		available, change int64
+	}{
+		{from, from + day - 1, 10, 0},
+		{from + day, from + 2*day - 1, 10, 0},
+		{from + 2*day, from + 2*day, 5, 0},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("bucket count mismatch: have %d, want %d", len(buckets), len(want))
+	}
+	for i, w := range want {
+		b := buckets[i]
+		if b.Start != w.start || b.End != w.end {
+			t.Errorf("bucket %d: range mismatch: have [%v, %v], want [%v, %v]", i, b.Start, b.End, w.start, w.end)
+		}
+		if b.Available.Int64() != w.available || b.Change.Int64() != w.change {
+			t.Errorf("bucket %d: value mismatch: have %v/%v, want %v/%v", i, b.Available, b.Change, w.available, w.change)
+		}
+	}
+
+	// an earlier end is kept
+	if buckets, err = schedule.Buckets(VestingDaily, from, from+day/2); err != nil {
+		t.Fatalf("failed to bucket the schedule: %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].End != from+day/2 {
+		t.Errorf("report end not kept: have %d buckets", len(buckets))
+	}
+}
+
+func TestVestingBucketsLimit(t *testing.T) {
+	const from = 1600000000
+
+	// a schedule changing for longer than the bucket limit is still refused
+	timeLock := NewTimeLock(&TimeLockItem{StartTime: from, EndTime: from + (MaxVestingBuckets+1)*86400, Value: big.NewInt(1)})
+	schedule := timeLock.VestingSchedule(from)
+	if _, err := schedule.Buckets(VestingDaily, from, MaxVestingTime); err == nil {
+		t.Errorf("expected too many buckets error")
+	}
+	if _, err := schedule.Buckets(VestingMonthly, from, MaxVestingTime); err != nil {
+		t.Errorf("failed to bucket the schedule by month: %v", err)
+	}
+	if _, err := schedule.Buckets("week", from, MaxVestingTime); err == nil {
+		t.Errorf("expected unknown interval error")
+	}
+}
+
+func TestVestingReportJSON(t *testing.T) {
+	const (
+		day  = 86400
+		from = 1600000000 - 1600000000%day
+	)
+	value, _ := new(big.Int).SetString("1000000000000000000000000000000", 10)
+	timeLock := NewTimeLock(&TimeLockItem{StartTime: from + day, EndTime: TimeLockForever, Value: value})
+	report, err := timeLock.VestingReport(&VestingScheduleArgs{Interval: VestingDaily}, from)
+	if err != nil {
+		t.Fatalf("failed to build the report: %v", err)
+	}
+	if len(report.Steps) != 2 || len(report.Buckets) != 2 {
+		t.Fatalf("report size mismatch: have %d steps and %d buckets, want 2 and 2", len(report.Steps), len(report.Buckets))
+	}
+
+	// the values are encoded as decimal strings and decoded back unchanged
+	enc, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("failed to encode the report: %v", err)
+	}
+	var dec VestingReport
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to decode the report: %v", err)
+	}
+	if !reflect.DeepEqual(&dec, report) {
+		t.Errorf("report mismatch after round trip:\nhave %s\nwant %s", mustJSON(t, &dec), enc)
+	}
+	if err := json.Unmarshal([]byte(`{"Time":1,"Available":"0x1","Unlocked":"1"}`), new(VestingStep)); err == nil {
+		t.Errorf("expected invalid vesting value error")
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	enc, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
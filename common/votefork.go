@@ -10,18 +10,9 @@ var (
 	ErrAccountFrozen      = errors.New("account frozen")
 )
 
-var (
-	VOTE1_FREEZE_TX_START uint64 = 739500
-	VOTE1_FREEZE_TX_END   uint64 = 786000
-
-	Vote1RefundAddress = HexToAddress("0xff948d492c31814dEde4CAA0af8824eF02Eb48D2")
-	Vote1DrainList     = []Address{
-		HexToAddress("0xb66cce16736feb5a50a9883675708027d3427c3c"),
-		HexToAddress("0x782da6fb0562074ec21942a7829064a8c2bb05c4"),
-		HexToAddress("0x6deed6878d062cd8754b3284306be814b215e332"),
-	}
-)
-
+// IsVote1ForkBlock reports whether blockNumber is the end of the vote1 freeze
+// range of the active chain, at which the vote1 accounts are drained.
 func IsVote1ForkBlock(blockNumber *big.Int) bool {
-	return blockNumber.Uint64() == VOTE1_FREEZE_TX_END
+	end := GetForkConfig().Vote1FreezeEnd
+	return end != nil && blockNumber.Cmp(end) == 0
 }
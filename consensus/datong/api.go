@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"os"
 
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/consensus"
@@ -16,6 +17,7 @@ import (
 // API wacom
 type API struct {
 	chain consensus.ChainReader
+	dt    *DaTong
 }
 
 func getSnapshotByHeader(header *types.Header) (*Snapshot, error) {
@@ -51,6 +53,28 @@ func (api *API) GetSnapshotAtHash(hash common.Hash) (*Snapshot, error) {
 	return getSnapshotByHeader(header)
 }
 
+// ExportSealingHistory writes the sealing history of the local signers to a
+// file, to be imported by the node taking over the signers.
+func (api *API) ExportSealingHistory(file string) (int, error) {
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+	return api.dt.ExportSealingHistory(out)
+}
+
+// ImportSealingHistory merges the sealing history exported by another node
+// from a file, so the local signers refuse the headers conflicting with it.
+func (api *API) ImportSealingHistory(file string) (*SealingImport, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return api.dt.ImportSealingHistory(in)
+}
+
 // DecodeLogData decode log data
 func DecodeLogData(data []byte) (interface{}, error) {
 	maps := make(map[string]interface{})
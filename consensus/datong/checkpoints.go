@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"sort"
+	"sync"
 
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/core/types"
@@ -42,71 +44,194 @@ var (
 	devnetLastCheckPoint uint64 = 0
 )
 
+// CheckPointsFile is the format of a custom check points file. A file without
+// chain id, as well as the legacy format, a plain map of numbers to hashes,
+// adds to the check points of the default network. A file with the chain id
+// of another network replaces them, for a private network.
+type CheckPointsFile struct {
+	ChainID     uint64                 `json:"chainId,omitempty"`
+	CheckPoints map[uint64]common.Hash `json:"checkpoints"`
+}
+
+// CheckPointsInfo describes the check point set in use.
+type CheckPointsInfo struct {
+	ChainID  uint64      `json:"chainId"`
+	Count    int         `json:"count"`
+	Last     uint64      `json:"last"`
+	LastHash common.Hash `json:"lastHash"`
+}
+
+// CheckPointMismatch is a check point which the chain doesn't match.
+type CheckPointMismatch struct {
+	Number uint64      `json:"number"`
+	Have   common.Hash `json:"have"`
+	Want   common.Hash `json:"want"`
+}
+
 var (
-	CheckPoints    map[uint64]common.Hash
-	LastCheckPoint uint64
+	checkPointsLock    sync.RWMutex
+	checkPoints        map[uint64]common.Hash
+	lastCheckPoint     uint64
+	checkPointsChainID *big.Int // chain the check points apply to
 )
 
-func InitCheckPoints(file string) {
+// defaultChainID returns the chain id the built-in check points of the
+// network selected by the rules apply to.
+//
+// The testnet and devnet rules have always returned each other's chain id, so
+// their built-in check points are never applied. Matching them up would make
+// existing testnet and devnet nodes rewind their chain below a mismatching
+// check point at startup, so it is kept as is. Those networks can still use
+// a check points file with their own chain id.
+func defaultChainID() *big.Int {
+	switch {
+	case common.UseTestnetRule:
+		return params.DevnetChainConfig.ChainID
+	case common.UseDevnetRule:
+		return params.TestnetChainConfig.ChainID
+	default: // maininet
+		return params.MainnetChainConfig.ChainID
+	}
+}
+
+func builtinCheckPoints() (map[uint64]common.Hash, uint64) {
+	var (
+		builtin map[uint64]common.Hash
+		last    uint64
+	)
 	if common.UseTestnetRule {
-		CheckPoints = testnetCheckPoints
-		LastCheckPoint = testnetLastCheckPoint
+		builtin, last = testnetCheckPoints, testnetLastCheckPoint
 	} else if common.UseDevnetRule {
-		CheckPoints = devnetCheckPoints
-		LastCheckPoint = devnetLastCheckPoint
+		builtin, last = devnetCheckPoints, devnetLastCheckPoint
 	} else {
-		CheckPoints = mainnetCheckPoints
-		LastCheckPoint = mainnetLastCheckPoint
+		builtin, last = mainnetCheckPoints, mainnetLastCheckPoint
+	}
+	cps := make(map[uint64]common.Hash, len(builtin))
+	for k, v := range builtin {
+		cps[k] = v
 	}
+	return cps, last
+}
 
+func InitCheckPoints(file string) {
 	defer func() {
-		log.Info("InitCheckPoints finished", "count", len(CheckPoints), "last", LastCheckPoint)
+		info := GetCheckPointsInfo()
+		log.Info("InitCheckPoints finished", "count", info.Count, "last", info.Last)
 	}()
-
-	// custom check points
-	if file == "" {
-		return
+	if err := LoadCheckPoints(file); err != nil {
+		log.Error("Could not load check points file, using the default check points", "err", err)
+		LoadCheckPoints("")
 	}
+}
+
+// ReadCheckPointsFile reads a custom check points file, in either format.
+func ReadCheckPointsFile(file string) (*CheckPointsFile, error) {
 	data, err := ioutil.ReadFile(file)
 	if err != nil {
-		log.Error("Could not read check ponits file", "err", err)
-		return
-	}
-	var cpoints map[uint64]string
-	if err := json.Unmarshal(data, &cpoints); err != nil {
-		log.Error("InitCheckPoints unmarshal json failed", "err", err)
-		return
-	}
-	for k, v := range cpoints {
-		CheckPoints[k] = common.HexToHash(v)
-		if k > LastCheckPoint {
-			LastCheckPoint = k
+		return nil, err
+	}
+	var cpf CheckPointsFile
+	if err := json.Unmarshal(data, &cpf); err != nil || cpf.CheckPoints == nil {
+		var cpoints map[uint64]string
+		if err := json.Unmarshal(data, &cpoints); err != nil {
+			return nil, fmt.Errorf("invalid check points file: %v", err)
+		}
+		cpf = CheckPointsFile{CheckPoints: make(map[uint64]common.Hash, len(cpoints))}
+		for k, v := range cpoints {
+			cpf.CheckPoints[k] = common.HexToHash(v)
 		}
 	}
+	return &cpf, nil
+}
+
+// LoadCheckPoints replaces the check points in use by the built in ones of
+// the network and the ones of the custom file if given. The check points in
+// use are kept if the file can't be read.
+func LoadCheckPoints(file string) error {
+	var cpf *CheckPointsFile
+	if file != "" {
+		var err error
+		if cpf, err = ReadCheckPointsFile(file); err != nil {
+			return err
+		}
+	}
+	cps, last := builtinCheckPoints()
+	chainID := defaultChainID()
+	if cpf != nil {
+		if cpf.ChainID != 0 && new(big.Int).SetUint64(cpf.ChainID).Cmp(chainID) != 0 {
+			// the check points of a private network
+			cps, last = make(map[uint64]common.Hash), 0
+			chainID = new(big.Int).SetUint64(cpf.ChainID)
+		}
+		for k, v := range cpf.CheckPoints {
+			cps[k] = v
+			if k > last {
+				last = k
+			}
+		}
+	}
+	checkPointsLock.Lock()
+	checkPoints, lastCheckPoint, checkPointsChainID = cps, last, chainID
+	checkPointsLock.Unlock()
+	return nil
+}
+
+// GetCheckPointsInfo describes the check point set in use.
+func GetCheckPointsInfo() *CheckPointsInfo {
+	checkPointsLock.RLock()
+	defer checkPointsLock.RUnlock()
+
+	info := &CheckPointsInfo{Count: len(checkPoints), Last: lastCheckPoint, LastHash: checkPoints[lastCheckPoint]}
+	if checkPointsChainID != nil {
+		info.ChainID = checkPointsChainID.Uint64()
+	}
+	return info
+}
+
+// GetCheckPoints returns a copy of the check points in use and the highest of
+// them if they apply to the chain, none otherwise.
+func GetCheckPoints(chainID *big.Int) (map[uint64]common.Hash, uint64) {
+	checkPointsLock.RLock()
+	defer checkPointsLock.RUnlock()
+
+	cps := make(map[uint64]common.Hash)
+	if !checkPointsApply(chainID) {
+		return cps, 0
+	}
+	for k, v := range checkPoints {
+		cps[k] = v
+	}
+	return cps, lastCheckPoint
+}
+
+// checkPointsApply reports whether the check points apply to the chain, the
+// caller must hold the lock.
+func checkPointsApply(chainID *big.Int) bool {
+	if checkPointsChainID == nil {
+		return false
+	}
+	// chains without check points, like private chains without a custom file
+	return chainID != nil && chainID.Cmp(checkPointsChainID) == 0
 }
 
 func IsInCheckPointsRange(blockHeight uint64) bool {
-	return blockHeight <= LastCheckPoint
+	checkPointsLock.RLock()
+	defer checkPointsLock.RUnlock()
+
+	return blockHeight <= lastCheckPoint
 }
 
 func CheckPoint(chainID *big.Int, blockHeight uint64, blockHash common.Hash) (isInRange bool, err error) {
-	var defaultChainID *big.Int
-	switch {
-	case common.UseTestnetRule:
-		defaultChainID = params.DevnetChainConfig.ChainID
-	case common.UseDevnetRule:
-		defaultChainID = params.TestnetChainConfig.ChainID
-	default: // maininet
-		defaultChainID = params.MainnetChainConfig.ChainID
-	}
-	// private chain
-	if chainID == nil || chainID.Cmp(defaultChainID) != 0 {
+	checkPointsLock.RLock()
+	defer checkPointsLock.RUnlock()
+
+	if !checkPointsApply(chainID) {
 		return false, nil
 	}
-	if blockHeight > LastCheckPoint {
+	if blockHeight > lastCheckPoint {
 		return false, nil
 	}
-	hash, exist := CheckPoints[blockHeight]
+	hash, exist := checkPoints[blockHeight]
 	if exist {
 		if blockHash != hash {
 			log.Info("check point failed, block hash mismatch", "number", blockHeight, "have", blockHash, "want", hash)
@@ -127,25 +252,18 @@ func CheckPointsInBlockChain(chainID *big.Int, chain types.Blocks) (int, error)
 }
 
 func CheckPointsInHeaderChain(chainID *big.Int, chain []*types.Header) (int, error) {
-	var defaultChainID *big.Int
-	switch {
-	case common.UseTestnetRule:
-		defaultChainID = params.DevnetChainConfig.ChainID
-	case common.UseDevnetRule:
-		defaultChainID = params.TestnetChainConfig.ChainID
-	default: // maininet
-		defaultChainID = params.MainnetChainConfig.ChainID
-	}
-	// private chain
-	if chainID == nil || chainID.Cmp(defaultChainID) != 0 {
+	checkPointsLock.RLock()
+	defer checkPointsLock.RUnlock()
+
+	if !checkPointsApply(chainID) {
 		return 0, nil
 	}
 	for i, header := range chain {
 		blockHeight := header.Number.Uint64()
-		if blockHeight > LastCheckPoint {
+		if blockHeight > lastCheckPoint {
 			break
 		}
-		hash, exist := CheckPoints[blockHeight]
+		hash, exist := checkPoints[blockHeight]
 		if !exist {
 			continue
 		}
@@ -159,3 +277,50 @@ func CheckPointsInHeaderChain(chainID *big.Int, chain []*types.Header) (int, err
 	}
 	return 0, nil
 }
+
+// VerifyCheckPoints checks the canonical blocks at the check point heights,
+// as returned by getBlock, against the check points in use. It returns the
+// number of check points verified, the mismatches found and the check points
+// up to the head whose block is missing, like a block without body. Check
+// points above the head of the chain are skipped.
+func VerifyCheckPoints(chainID *big.Int, head uint64, getBlock func(number uint64) *types.Block) (int, []CheckPointMismatch, []uint64, error) {
+	cps, _ := GetCheckPoints(chainID)
+	if len(cps) == 0 {
+		return 0, nil, nil, fmt.Errorf("no check points for chain id %v", chainID)
+	}
+	numbers := make([]uint64, 0, len(cps))
+	for number := range cps {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	var (
+		blocks  types.Blocks
+		missing = []uint64{}
+	)
+	for _, number := range numbers {
+		if number > head {
+			break
+		}
+		if block := getBlock(number); block != nil {
+			blocks = append(blocks, block)
+		} else {
+			missing = append(missing, number)
+		}
+	}
+	verified := len(blocks)
+	mismatches := []CheckPointMismatch{}
+	for len(blocks) > 0 {
+		i, err := CheckPointsInBlockChain(chainID, blocks)
+		if err == nil {
+			break
+		}
+		mismatches = append(mismatches, CheckPointMismatch{
+			Number: blocks[i].NumberU64(),
+			Have:   blocks[i].Hash(),
+			Want:   cps[blocks[i].NumberU64()],
+		})
+		blocks = blocks[i+1:]
+	}
+	return verified, mismatches, missing, nil
+}
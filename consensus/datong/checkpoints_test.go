@@ -0,0 +1,97 @@
+package datong
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/params"
+)
+
+func TestTestnetCheckPoints(t *testing.T) {
+	common.UseTestnetRule = true
+	defer func() {
+		common.UseTestnetRule = false
+		LoadCheckPoints("")
+	}()
+	if err := LoadCheckPoints(""); err != nil {
+		t.Fatal(err)
+	}
+	// the built-in testnet check points are still checked against the
+	// devnet chain id, so they don't apply to the testnet
+	if _, err := CheckPoint(params.TestnetChainConfig.ChainID, 10000, common.Hash{}); err != nil {
+		t.Errorf("testnet check point applied to the testnet: %v", err)
+	}
+	if _, err := CheckPoint(params.DevnetChainConfig.ChainID, 10000, common.Hash{}); err == nil {
+		t.Errorf("testnet check point mismatch not detected")
+	}
+
+	// a check points file with the testnet chain id applies to the testnet
+	cpf := &CheckPointsFile{
+		ChainID:     params.TestnetChainConfig.ChainID.Uint64(),
+		CheckPoints: map[uint64]common.Hash{10000: testnetCheckPoints[10000]},
+	}
+	file := filepath.Join(t.TempDir(), "checkpoints.json")
+	data, _ := json.Marshal(cpf)
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadCheckPoints(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CheckPoint(params.TestnetChainConfig.ChainID, 10000, common.Hash{}); err == nil {
+		t.Errorf("testnet check point mismatch not detected")
+	}
+	if _, err := CheckPoint(params.TestnetChainConfig.ChainID, 10000, testnetCheckPoints[10000]); err != nil {
+		t.Errorf("testnet check point refused: %v", err)
+	}
+}
+
+func TestVerifyCheckPoints(t *testing.T) {
+	block := func(number uint64) *types.Block {
+		return types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(number)})
+	}
+	// check points of a private chain: block 10 matches, block 20 doesn't,
+	// block 30 has no body and block 40 is above the head
+	cpf := &CheckPointsFile{
+		ChainID: 999,
+		CheckPoints: map[uint64]common.Hash{
+			10: block(10).Hash(),
+			20: common.HexToHash("0x20"),
+			30: common.HexToHash("0x30"),
+			40: common.HexToHash("0x40"),
+		},
+	}
+	file := filepath.Join(t.TempDir(), "checkpoints.json")
+	data, _ := json.Marshal(cpf)
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer LoadCheckPoints("")
+	if err := LoadCheckPoints(file); err != nil {
+		t.Fatal(err)
+	}
+	getBlock := func(number uint64) *types.Block {
+		if number == 30 || number > 35 {
+			return nil
+		}
+		return block(number)
+	}
+	verified, mismatches, missing, err := VerifyCheckPoints(big.NewInt(999), 35, getBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified != 2 {
+		t.Errorf("verified count mismatch: have %d, want 2", verified)
+	}
+	if len(mismatches) != 1 || mismatches[0].Number != 20 {
+		t.Errorf("mismatches mismatch: have %v", mismatches)
+	}
+	if len(missing) != 1 || missing[0] != 30 {
+		t.Errorf("missing blocks mismatch: have %v, want [30]", missing)
+	}
+}
@@ -2,6 +2,7 @@ package datong
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -17,6 +18,8 @@ import (
 	"github.com/FusionFoundation/efsn/common/hexutil"
 	cmath "github.com/FusionFoundation/efsn/common/math"
 	"github.com/FusionFoundation/efsn/consensus"
+	"github.com/FusionFoundation/efsn/consensus/datong/sighash"
+	"github.com/FusionFoundation/efsn/core/fsnlog"
 	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/core/state"
 	"github.com/FusionFoundation/efsn/core/types"
@@ -44,10 +47,12 @@ var (
 
 	errMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
 
-	errMissingSignature = errors.New("extra-data 65 byte suffix signature missing")
+	errMissingSignature = sighash.ErrMissingSignature
 
 	errUnauthorized = errors.New("unauthorized")
 
+	errUnanchoredHeader = errors.New("ticket set of parent unavailable past the last check point")
+
 	ErrNoTicket = errors.New("Miner doesn't have ticket")
 )
 
@@ -55,22 +60,40 @@ var (
 // backing account.
 type SignerFn func(accounts.Account, []byte) ([]byte, error)
 
+// HeaderSignerFn is a signer callback function to request a header to be
+// signed by a backing account, for signers checking what they sign. The
+// context is cancelled when the sealing is aborted.
+type HeaderSignerFn func(context.Context, accounts.Account, *types.Header) ([]byte, error)
+
 var (
-	extraVanity         = 32
-	extraSeal           = 65
+	extraVanity         = sighash.ExtraVanity
+	extraSeal           = sighash.ExtraSeal
 	MinBlockTime uint64 = 7   // 7 seconds
 	maxBlockTime uint64 = 600 // 10 minutes
 )
 
+// MinBlockInterval returns the minimum time between a block and its parent,
+// none for the instant sealing of developer chains.
+func MinBlockInterval(config *params.DaTongConfig) uint64 {
+	if config != nil && config.InstantSeal {
+		return 0
+	}
+	return MinBlockTime
+}
+
 // DaTong wacom
 type DaTong struct {
 	config     *params.DaTongConfig
 	db         ethdb.Database
 	stateCache state.Database
 
+	ticketsFetcher TicketsFetcher // retrieves the ticket sets missing locally, if set
+
 	signer common.Address
-	signFn SignerFn
+	signFn HeaderSignerFn
 	lock   sync.RWMutex
+
+	sealLock sync.Mutex // protects the sealing history
 }
 
 // New wacom
@@ -84,6 +107,14 @@ func New(config *params.DaTongConfig, db ethdb.Database) *DaTong {
 
 // Authorize wacom
 func (dt *DaTong) Authorize(signer common.Address, signFn SignerFn) {
+	dt.AuthorizeHeader(signer, func(ctx context.Context, account accounts.Account, header *types.Header) ([]byte, error) {
+		return signFn(account, sigHash(header).Bytes())
+	})
+}
+
+// AuthorizeHeader injects a signer signing the whole header instead of its
+// hash, like an external signer applying its own rules to the headers.
+func (dt *DaTong) AuthorizeHeader(signer common.Address, signFn HeaderSignerFn) {
 	dt.lock.Lock()
 	defer dt.lock.Unlock()
 	dt.signer = signer
@@ -98,13 +129,10 @@ func (dt *DaTong) Author(header *types.Header) (common.Address, error) {
 }
 
 func VerifySignature(header *types.Header) error {
-	signature := header.Extra[len(header.Extra)-extraSeal:]
-	pubkey, err := crypto.Ecrecover(sigHash(header).Bytes(), signature)
+	signer, err := sighash.Signer(header)
 	if err != nil {
 		return err
 	}
-	var signer common.Address
-	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
 	if header.Coinbase != signer {
 		return errors.New("Coinbase is not the signer")
 	}
@@ -172,7 +200,11 @@ func (dt *DaTong) verifyHeader(chain consensus.ChainReader, header *types.Header
 		return fmt.Errorf("PoS hash mismatch: have %x, want %x", header.UncleHash, posHash(parent))
 	}
 	// verify header time
-	if header.Time-parent.Time < MinBlockTime {
+	if dt.config.InstantSeal {
+		if header.Time < parent.Time {
+			return fmt.Errorf("block %v header.Time:%v < parent.Time:%v", header.Number, header.Time, parent.Time)
+		}
+	} else if header.Time-parent.Time < MinBlockTime {
 		return fmt.Errorf("block %v header.Time:%v < parent.Time:%v + %v Second",
 			header.Number, header.Time, parent.Time, MinBlockTime)
 
@@ -185,6 +217,22 @@ func (dt *DaTong) verifyHeader(chain consensus.ChainReader, header *types.Header
 	if err = dt.checkBlockTime(chain, header, parent); err != nil {
 		return err
 	}
+	// the seal of the headers of a header only chain can't be verified without
+	// the ticket set of their parent, such headers are accepted up to the last
+	// check point, which anchors them through the hash chain, past it the
+	// ticket set of the parent is retrieved if possible
+	if !dt.hasTickets(parent) {
+		isInRange, err := CheckPoint(chain.Config().ChainID, header.Number.Uint64(), header.Hash())
+		if err != nil {
+			return err
+		}
+		if isInRange {
+			return verifySnapshot(header)
+		}
+		if err := dt.fetchTickets(parent); err != nil {
+			return err
+		}
+	}
 	if isInRange, err := CheckPoint(chain.Config().ChainID, header.Number.Uint64(), header.Hash()); isInRange {
 		if err == nil {
 			selected, retreat, err := dt.getSelectedAndRetreatedTickets(chain, header, parent)
@@ -439,8 +487,8 @@ func (dt *DaTong) Finalize(chain consensus.ChainReader, header *types.Header, st
 		deleteTicket(t, ticketRetreat, !(t.IsInGenesis() || i == 0))
 	}
 
-	if common.IsVote1ForkBlock(header.Number) {
-		ApplyVote1HardFork(headerState, header.Number, parent.Time)
+	if config := chain.Config(); config.IsVote1ForkBlock(header.Number) {
+		ApplyVote1HardFork(headerState, config.Vote1FreezeRange, header.Number, parent.Time)
 	}
 
 	hash, err := headerState.UpdateTickets(header.Number, parent.Time)
@@ -477,6 +525,8 @@ func (dt *DaTong) Finalize(chain consensus.ChainReader, header *types.Header, st
 //
 // Note, the method returns immediately and will send the result async. More
 // than one result may also be returned depending on the consensus algorothm.
+// The header is signed asynchronously too, and dropped if stop is closed
+// before it is released.
 func (dt *DaTong) Seal(chain consensus.ChainReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
 	header := block.Header()
 	number := header.Number.Uint64()
@@ -497,23 +547,62 @@ func (dt *DaTong) Seal(chain consensus.ChainReader, block *types.Block, results
 		return errc
 	}
 
-	sighash, err := signFn(accounts.Account{Address: header.Coinbase}, sigHash(header).Bytes())
-	if err != nil {
+	// refuse to sign a header which would get the signer reported
+	sealHash := dt.SealHash(header)
+	if err := dt.checkSealed(header); err != nil {
 		return err
 	}
-	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
 
 	go func() {
+		release := time.NewTimer(delay)
+		defer release.Stop()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// sign while the block time passes, an external signer may take a
+		// while to approve the header
+		type signResult struct {
+			sig []byte
+			err error
+		}
+		signed := make(chan signResult, 1)
+		go func() {
+			sig, err := signFn(ctx, accounts.Account{Address: header.Coinbase}, header)
+			signed <- signResult{sig, err}
+		}()
+		var res signResult
+		select {
+		case <-stop:
+			return
+		case res = <-signed:
+		}
+		if res.err != nil {
+			log.Warn("Failed to sign block", "number", number, "sealhash", sealHash, "err", res.err)
+			return
+		}
+		if len(res.sig) != extraSeal {
+			log.Warn("Invalid block signature length", "number", number, "sealhash", sealHash, "length", len(res.sig))
+			return
+		}
+		copy(header.Extra[len(header.Extra)-extraSeal:], res.sig)
+
 		select {
 		case <-stop:
 			return
-		case <-time.After(delay):
+		case <-release.C:
 		}
 
+		// the header is recorded when released, as the headers recommitted
+		// at the same height before the delay passed are never released
+		if err := dt.recordSealed(header); err != nil {
+			log.Error("Refused to release conflicting block", "number", number, "sealhash", sealHash, "err", err)
+			return
+		}
 		select {
 		case results <- block.WithSeal(header):
 		default:
-			log.Warn("Sealing result is not read by miner", "sealhash", dt.SealHash(header))
+			log.Warn("Sealing result is not read by miner", "sealhash", sealHash)
+			dt.forgetSealed(header)
 		}
 	}()
 
@@ -559,7 +648,7 @@ func (dt *DaTong) APIs(chain consensus.ChainReader) []rpc.API {
 	return []rpc.API{{
 		Namespace: "fsn",
 		Version:   "1.0",
-		Service:   &API{chain: chain},
+		Service:   &API{chain: chain, dt: dt},
 		Public:    false,
 	}}
 }
@@ -687,7 +776,51 @@ func (dt *DaTong) getAllTickets(chain consensus.ChainReader, header *types.Heade
 
 		return nil
 	}
+	processStructuredLog := func(l *types.Log) error {
+		fl, err := fsnlog.Decode(l)
+		if err != nil {
+			return err
+		}
+		if _, hasError := fl.Fields["Error"]; hasError {
+			return nil
+		}
+		switch fl.Func {
+		case common.BuyTicketFunc:
+			id, idok := fl.Fields["TicketID"].(common.Hash)
+			owner, ownerok := fl.Fields["TicketOwner"].(common.Address)
+			start, startok := fl.Fields["StartTime"].(uint64)
+			end, endok := fl.Fields["ExpireTime"].(uint64)
+			if !idok || !ownerok || !startok || !endok {
+				return errors.New("buy ticket log has wrong data")
+			}
+			ticket := &common.Ticket{
+				Owner: owner,
+				TicketBody: common.TicketBody{
+					ID:         id,
+					Height:     l.BlockNumber,
+					StartTime:  start,
+					ExpireTime: end,
+				},
+			}
+			tickets, err = tickets.AddTicket(ticket)
+			return err
+		case common.ReportIllegalFunc:
+			delTickets, ok := fl.Fields["DeleteTickets"].([]common.Hash)
+			if !ok {
+				return fmt.Errorf("report log has wrong data")
+			}
+			for _, id := range delTickets {
+				if tickets, err = tickets.RemoveTicket(id); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
 	processLog := func(l *types.Log) error {
+		if fsnlog.IsStructured(l) {
+			return processStructuredLog(l)
+		}
 		funcType := getFuncType(l)
 		switch funcType {
 		case common.BuyTicketFunc:
@@ -740,32 +873,16 @@ func (dt *DaTong) getAllTickets(chain consensus.ChainReader, header *types.Heade
 	if err != nil {
 		return nil, err
 	}
-	if err := state.AddCachedTickets(header.MixDigest, tickets); err != nil {
+	if err := state.AddCachedTickets(header.Number, header.MixDigest, tickets); err != nil {
 		return nil, err
 	}
 	return tickets, nil
 }
 
-func sigHash(header *types.Header) (hash common.Hash) {
-	hasher := sha3.NewLegacyKeccak256()
-	rlp.Encode(hasher, []interface{}{
-		header.ParentHash,
-		header.UncleHash,
-		header.Coinbase,
-		header.Root,
-		header.TxHash,
-		header.ReceiptHash,
-		header.Bloom,
-		header.Difficulty,
-		header.Number,
-		header.GasLimit,
-		header.GasUsed,
-		header.Time,
-		header.Extra[:len(header.Extra)-extraSeal],
-		header.MixDigest,
-		header.Nonce,
-	})
-	hasher.Sum(hash[:0])
+// sigHash returns the hash signed by the sealer of the header, whose extra
+// data must hold the signature.
+func sigHash(header *types.Header) common.Hash {
+	hash, _ := sighash.Hash(header)
 	return hash
 }
 
@@ -900,18 +1017,7 @@ func (dt *DaTong) calcBlockDifficulty(chain consensus.ChainReader, header *types
 		retreat  common.TicketPtrSlice
 	)
 
-	// make consensus by tickets sequence(selectedTime) with: parentHash, weigth, ticketID, coinbase
-	ch := make(chan *DisInfoWithIndex, numberOfticketOwners)
-	list := make(DistanceSlice, numberOfticketOwners)
-	for k, v := range parentTickets {
-		go calcDisInfo(k, v, parent, ch)
-	}
-	for i := 0; i < int(numberOfticketOwners); i++ {
-		v := <-ch
-		list[v.index] = v.info
-	}
-	close(ch)
-	sort.Sort(list)
+	list := calcDistanceList(parentTickets, parent)
 	selectedTime := uint64(0)
 	for i, t := range list {
 		owner := t.tk.Owner
@@ -956,6 +1062,39 @@ func (dt *DaTong) calcBlockDifficulty(chain consensus.ChainReader, header *types
 	return difficulty, selected, selectedTime, retreat, nil
 }
 
+// make consensus by tickets sequence(selectedTime) with: parentHash, weigth, ticketID, coinbase
+func calcDistanceList(parentTickets common.TicketsDataSlice, parent *types.Header) DistanceSlice {
+	numberOfticketOwners := len(parentTickets)
+	ch := make(chan *DisInfoWithIndex, numberOfticketOwners)
+	list := make(DistanceSlice, numberOfticketOwners)
+	for k, v := range parentTickets {
+		go calcDisInfo(k, v, parent, ch)
+	}
+	for i := 0; i < numberOfticketOwners; i++ {
+		v := <-ch
+		list[v.index] = v.info
+	}
+	close(ch)
+	sort.Sort(list)
+	return list
+}
+
+// SelectionOrder returns the best ticket of every ticket owner in the order
+// in which the owners may seal the block following parent. The owner at
+// index i seals with order i and retreats the tickets before it.
+func (dt *DaTong) SelectionOrder(chain consensus.ChainReader, parent *types.Header) (common.TicketPtrSlice, error) {
+	parentTickets, err := dt.getAllTickets(chain, parent)
+	if err != nil {
+		return nil, err
+	}
+	list := calcDistanceList(parentTickets, parent)
+	order := make(common.TicketPtrSlice, len(list))
+	for i, t := range list {
+		order[i] = t.tk
+	}
+	return order, nil
+}
+
 // PreProcess update state if needed from various block info
 // used with some PoS Systems
 func (c *DaTong) PreProcess(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) error {
@@ -963,21 +1102,34 @@ func (c *DaTong) PreProcess(chain consensus.ChainReader, header *types.Header, s
 }
 
 func (dt *DaTong) calcDelayTime(chain consensus.ChainReader, header *types.Header) (time.Duration, error) {
+	sealTime, err := dt.SealTime(chain, header)
+	if err != nil {
+		return 0, err
+	}
+	return sealTime.Sub(time.Now()), nil
+}
+
+// SealTime returns the time at which a prepared header is released by Seal.
+func (dt *DaTong) SealTime(chain consensus.ChainReader, header *types.Header) (time.Time, error) {
+	// instant seal, no wait for the other miners
+	if dt.config.InstantSeal {
+		return time.Unix(int64(header.Time), 0), nil
+	}
 	list := header.Nonce.Uint64()
 	if list > 0 {
-		return time.Unix(int64(header.Time), 0).Sub(time.Now()), nil
+		return time.Unix(int64(header.Time), 0), nil
 	}
 
-	// delayTime = ParentTime + (15 - 2) - time.Now
+	// sealTime = ParentTime + (15 - 2)
 	parent := chain.GetHeaderByNumber(header.Number.Uint64() - 1)
 	endTime := header.Time + list*delayTimeModifier + dt.config.Period - 2
 
-	delayTime := time.Unix(int64(endTime), 0).Sub(time.Now())
+	sealTime := time.Unix(int64(endTime), 0)
 
 	// delay maximum
 	if endTime-header.Time > maxBlockTime {
 		endTime = header.Time + maxBlockTime + dt.config.Period - 2 + list
-		delayTime = time.Unix(int64(endTime), 0).Sub(time.Now())
+		sealTime = time.Unix(int64(endTime), 0)
 	}
 	if header.Number.Uint64() > (adjustIntervalBlocks + 1) {
 		// adjust = ( ( parent - gparent ) / 2 - (dt.config.Period) ) / dt.config.Period
@@ -992,9 +1144,9 @@ func (dt *DaTong) calcDelayTime(chain consensus.ChainReader, header *types.Heade
 		} else if adjust < -stampSecond {
 			adjust = -stampSecond
 		}
-		delayTime -= adjust
+		sealTime = sealTime.Add(-adjust)
 	}
-	return delayTime, nil
+	return sealTime, nil
 }
 
 // check ticket info
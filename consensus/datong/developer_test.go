@@ -0,0 +1,98 @@
+package datong_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/FusionFoundation/efsn/accounts"
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/consensus/misc"
+	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/core/vm"
+	"github.com/FusionFoundation/efsn/crypto"
+)
+
+// TestDeveloperChainSealing seals a few blocks on the developer genesis, as
+// the --dev miner does.
+func TestDeveloperChainSealing(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	developer := crypto.PubkeyToAddress(key.PublicKey)
+
+	genesis := core.DeveloperGenesisBlock(0, developer)
+	if now := uint64(time.Now().Unix()); genesis.Timestamp+60 < now || genesis.TicketCreateInfo.Time != genesis.Timestamp {
+		t.Fatalf("developer genesis not timestamped: time %d, tickets time %d", genesis.Timestamp, genesis.TicketCreateInfo.Time)
+	}
+	db := rawdb.NewMemoryDatabase()
+	if _, err := genesis.Commit(db); err != nil {
+		t.Fatalf("failed to commit the genesis: %v", err)
+	}
+	engine := datong.New(genesis.Config.DaTong, db)
+	engine.Authorize(developer, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create the chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// the developer tickets never expire
+	statedb, err := chain.StateAt(chain.Genesis().Root(), chain.Genesis().MixDigest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickets, err := statedb.AllTickets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ticket := range tickets.ToTicketSlice() {
+		if ticket.ExpireTime != common.TimeLockForever || ticket.StartTime != genesis.Timestamp {
+			t.Fatalf("developer ticket %x expires: start %d, expire %d", ticket.ID, ticket.StartTime, ticket.ExpireTime)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		parent := chain.CurrentBlock()
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			GasLimit:   parent.GasLimit(),
+			Time:       parent.Time() + 1,
+			Coinbase:   developer,
+			Difficulty: common.Big0,
+		}
+		if genesis.Config.IsLondon(header.Number) {
+			header.BaseFee = misc.CalcBaseFee(genesis.Config, parent.Header())
+		}
+		if err := engine.Prepare(chain, header); err != nil {
+			t.Fatalf("block %d: failed to prepare: %v", header.Number, err)
+		}
+		statedb, err := chain.StateAt(parent.Root(), parent.MixDigest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, err := engine.Finalize(chain, header, statedb, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("block %d: failed to finalize: %v", header.Number, err)
+		}
+		results := make(chan *types.Block, 1)
+		if err := engine.Seal(chain, block, results, make(chan struct{})); err != nil {
+			t.Fatalf("block %d: failed to seal: %v", header.Number, err)
+		}
+		select {
+		case block = <-results:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block %d: not sealed", header.Number)
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("block %d: failed to import: %v", header.Number, err)
+		}
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 3 {
+		t.Errorf("head mismatch: have %d, want 3", head)
+	}
+}
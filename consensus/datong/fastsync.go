@@ -0,0 +1,148 @@
+package datong
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/consensus"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/log"
+)
+
+// hasTickets reports whether the ticket set at the header can be retrieved,
+// which is the case when it is cached, when the state of the header is
+// available, or when the block is stored so the ticket set can be rebuilt
+// from the receipts of its ancestors. It is missing for the headers of a
+// header only chain, as inserted by fast and light sync. The light client has
+// no state cache and only ever uses cached ticket sets, which it retrieves with
+// its tickets fetcher.
+func (dt *DaTong) hasTickets(header *types.Header) bool {
+	if state.GetCachedTickets(header.MixDigest) != nil {
+		return true
+	}
+	if dt.stateCache == nil {
+		return false
+	}
+	if _, err := dt.stateCache.OpenTrie(header.Root); err == nil {
+		return true
+	}
+	return rawdb.HasBody(dt.db, header.Hash(), header.Number.Uint64())
+}
+
+// TicketsFetcher retrieves the ticket set at a header whose state isn't
+// available locally, like the light client retrieving it on demand.
+type TicketsFetcher func(header *types.Header) (common.TicketsDataSlice, error)
+
+// SetTicketsFetcher sets the retrieval of the ticket sets needed to verify the
+// seal of the headers of a header only chain past the last check point.
+func (dt *DaTong) SetTicketsFetcher(fetcher TicketsFetcher) {
+	dt.ticketsFetcher = fetcher
+}
+
+// fetchTickets retrieves the ticket set at a header of a header only chain
+// and caches it once it matches the commitment of the header, so the seal of
+// its child is verified against it like on a full node.
+func (dt *DaTong) fetchTickets(header *types.Header) error {
+	if dt.ticketsFetcher == nil {
+		return errUnanchoredHeader
+	}
+	tickets, err := dt.ticketsFetcher(header)
+	if err != nil {
+		return fmt.Errorf("retrieve tickets of block %v: %v", header.Number, err)
+	}
+	return state.AddCachedTickets(header.Number, header.MixDigest, tickets)
+}
+
+// verifySnapshot checks the ticket snapshot of a header of a header only
+// chain against the header itself, as far as possible without the ticket set
+// of its parent: a ticket must be selected, and the tickets selected before
+// it in the order of the header are retreated, up to the maximum number. It
+// is only sufficient below the last check point, the seal of the headers past
+// it is verified against the ticket set of their parent, see fetchTickets.
+func verifySnapshot(header *types.Header) error {
+	snap, err := NewSnapshotFromHeader(header)
+	if err != nil {
+		return err
+	}
+	if snap.Selected == (common.Hash{}) {
+		return errors.New("no selected ticket in snapshot")
+	}
+	if snap.TicketNumber < 0 {
+		return fmt.Errorf("invalid ticket number %v in snapshot", snap.TicketNumber)
+	}
+	retreated := make(map[common.Hash]struct{}, len(snap.Retreat))
+	for _, id := range snap.Retreat {
+		if id == snap.Selected {
+			return fmt.Errorf("selected ticket %v retreated", id.String())
+		}
+		if _, ok := retreated[id]; ok {
+			return fmt.Errorf("ticket %v retreated twice", id.String())
+		}
+		retreated[id] = struct{}{}
+	}
+	order := header.Nonce.Uint64()
+	want := order
+	if want > maxNumberOfDeletedTickets {
+		want = maxNumberOfDeletedTickets
+	}
+	if uint64(len(snap.Retreat)) > want {
+		return fmt.Errorf("too many retreated tickets, have %v, max %v", len(snap.Retreat), want)
+	}
+	if common.IsHeaderSnapCheckingEnabled(header.Number) {
+		if uint64(len(snap.Retreat)) != want {
+			return fmt.Errorf("retreated tickets count mismatch, have %v, want %v", len(snap.Retreat), want)
+		}
+		if snap.TicketNumber == 0 {
+			return errors.New("no tickets left in snapshot")
+		}
+	}
+	if header.Difficulty == nil || header.Difficulty.Sign() <= 0 {
+		return errors.New("non-positive difficulty")
+	}
+	return nil
+}
+
+// VerifyState checks the ticket set of a block state which was downloaded
+// instead of being computed, as done for the pivot block of fast sync. The
+// seals of the headers below the pivot can't be verified without the ticket
+// sets of their parents, which is why fast sync stops at the last check point,
+// so the ticket set of the pivot must match the one committed to by its header
+// before the following blocks are verified against it.
+func (dt *DaTong) VerifyState(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) error {
+	if hash := statedb.TicketsCommitment(); hash != header.MixDigest {
+		return fmt.Errorf("tickets hash mismatch, have %v, want %v", hash.String(), header.MixDigest.String())
+	}
+	tickets, err := statedb.AllTickets()
+	if err != nil {
+		return err
+	}
+	if err := statedb.Error(); err != nil {
+		return err
+	}
+	snap, err := NewSnapshotFromHeader(header)
+	if err != nil {
+		return err
+	}
+	// the block removes the selected and retreated tickets from the ticket set
+	if _, err := tickets.Get(snap.Selected); err == nil {
+		return fmt.Errorf("selected ticket %v still exists", snap.Selected.String())
+	}
+	if common.IsHeaderSnapCheckingEnabled(header.Number) {
+		for _, id := range snap.Retreat {
+			if _, err := tickets.Get(id); err == nil {
+				return fmt.Errorf("retreated ticket %v still exists", id.String())
+			}
+		}
+		if number := tickets.NumberOfTickets(); uint64(snap.TicketNumber) != number {
+			return fmt.Errorf("ticket number mismatch, have %v, want %v", number, snap.TicketNumber)
+		}
+	}
+	if err := state.AddCachedTickets(header.Number, header.MixDigest, tickets); err != nil {
+		return err
+	}
+	log.Info("Verified fast sync pivot tickets", "number", header.Number, "tickets", tickets.NumberOfTickets(), "hash", header.MixDigest.TerminalString())
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/common/math"
 	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/core/vm"
 	"github.com/FusionFoundation/efsn/rlp"
@@ -120,16 +121,31 @@ func CheckAddingReport(state vm.StateDB, report []byte, blockNumber *big.Int) (*
 	return header1, header2, nil
 }
 
-// punish miner and reward reporter
-func ProcessReport(heade1, header2 *types.Header, reporter common.Address, state vm.StateDB, height *big.Int, timestamp uint64) []common.Hash {
+// punish miner and reward reporter, returns the deleted tickets and the FSN
+// time lock actually taken from the miner
+func ProcessReport(heade1, header2 *types.Header, reporter common.Address, state vm.StateDB, height *big.Int, timestamp uint64) ([]common.Hash, *common.TimeLock) {
 	miner := heade1.Coinbase
 	deleteTickets := punishTicket(state, miner)
-	if len(deleteTickets) < maxPunishTicketCount {
-		diffCount := int64(maxPunishTicketCount - len(deleteTickets))
-		value := new(big.Int).Mul(common.TicketPrice(height), big.NewInt(diffCount))
-		punishTimeLock(state, miner, value, height, timestamp)
+	var taken *common.TimeLock
+	if penalty := ReportPenalty(len(deleteTickets), height, timestamp); penalty != nil {
+		taken = punishTimeLock(state, miner, penalty, height, timestamp)
+	}
+	return deleteTickets, taken
+}
+
+// ReportPenalty returns the FSN time lock taken from a reported miner of which
+// the given number of tickets were deleted, nil if no time lock is taken. The
+// penalty is taken as far as the time lock balance of the miner covers it.
+func ReportPenalty(deletedTickets int, height *big.Int, timestamp uint64) *common.TimeLockItem {
+	if deletedTickets >= maxPunishTicketCount {
+		return nil
+	}
+	diffCount := int64(maxPunishTicketCount - deletedTickets)
+	return &common.TimeLockItem{
+		StartTime: timestamp,
+		EndTime:   timestamp + 30*24*3600,
+		Value:     new(big.Int).Mul(common.TicketPrice(height), big.NewInt(diffCount)),
 	}
-	return deleteTickets
 }
 
 func punishTicket(state vm.StateDB, miner common.Address) []common.Hash {
@@ -166,47 +182,52 @@ func punishTicket(state vm.StateDB, miner common.Address) []common.Hash {
 	return ids
 }
 
-func punishTimeLock(state vm.StateDB, miner common.Address, value *big.Int, height *big.Int, timestamp uint64) {
-	needStart := timestamp
-	needEnd := needStart + 30*24*3600
-	needValue := value
-	needItem := &common.TimeLockItem{
-		StartTime: needStart,
-		EndTime:   needEnd,
-		Value:     needValue,
-	}
+// punishTimeLock takes the penalty from the FSN time lock balance of the
+// miner, as far as it covers it, and returns the time lock taken.
+func punishTimeLock(state vm.StateDB, miner common.Address, needItem *common.TimeLockItem, height *big.Int, timestamp uint64) *common.TimeLock {
+	needStart := needItem.StartTime
+	needEnd := needItem.EndTime
+	needValue := needItem.Value
 	needTimelock := common.NewTimeLock(needItem)
 	timelockBalance := state.GetTimeLockBalance(common.SystemAssetID, miner)
 	if timelockBalance.Cmp(needTimelock) >= 0 {
 		state.SubTimeLockBalance(miner, common.SystemAssetID, needTimelock, height, timestamp)
-	} else {
-		var leftItems []*common.TimeLockItem
-		for i, item := range timelockBalance.Items {
-			if item.EndTime < needStart { // expired
-				continue
-			}
-			if item.StartTime > needEnd { // kept
-				leftItems = append(leftItems, timelockBalance.Items[i:]...)
-				break
-			}
-			if item.Value.Cmp(needValue) > 0 { // punished
-				leftItems = append(leftItems, &common.TimeLockItem{
-					StartTime: common.MaxUint64(needStart, item.StartTime),
-					EndTime:   common.MinUint64(needEnd, item.EndTime),
-					Value:     new(big.Int).Sub(item.Value, needValue),
-				})
-			}
-			if item.EndTime > needEnd { // left (has intersection)
-				leftItems = append(leftItems, &common.TimeLockItem{
-					StartTime: needEnd + 1,
-					EndTime:   item.EndTime,
-					Value:     item.Value,
-				})
-			}
+		return needTimelock
+	}
+	taken := new(common.TimeLock)
+	var leftItems []*common.TimeLockItem
+	for i, item := range timelockBalance.Items {
+		if item.EndTime < needStart { // expired
+			continue
+		}
+		if item.StartTime > needEnd { // kept
+			leftItems = append(leftItems, timelockBalance.Items[i:]...)
+			break
+		}
+		punished := &common.TimeLockItem{
+			StartTime: common.MaxUint64(needStart, item.StartTime),
+			EndTime:   common.MinUint64(needEnd, item.EndTime),
+			Value:     new(big.Int).Set(math.BigMin(item.Value, needValue)),
+		}
+		taken.Add(taken, common.NewTimeLock(punished))
+		if item.Value.Cmp(needValue) > 0 { // punished
+			leftItems = append(leftItems, &common.TimeLockItem{
+				StartTime: punished.StartTime,
+				EndTime:   punished.EndTime,
+				Value:     new(big.Int).Sub(item.Value, needValue),
+			})
+		}
+		if item.EndTime > needEnd { // left (has intersection)
+			leftItems = append(leftItems, &common.TimeLockItem{
+				StartTime: needEnd + 1,
+				EndTime:   item.EndTime,
+				Value:     item.Value,
+			})
 		}
-		timelockBalance.SetItems(leftItems)
-		state.SetTimeLockBalance(miner, common.SystemAssetID, timelockBalance)
 	}
+	timelockBalance.SetItems(leftItems)
+	state.SetTimeLockBalance(miner, common.SystemAssetID, timelockBalance)
+	return taken
 }
 
 func DecodeTxInput(input []byte) (interface{}, error) {
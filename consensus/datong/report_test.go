@@ -0,0 +1,61 @@
+package datong
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+)
+
+func TestReportPenaltyTaken(t *testing.T) {
+	var (
+		miner     = common.BytesToAddress([]byte{0x61})
+		height    = big.NewInt(100)
+		timestamp = uint64(1000000)
+		header    = &types.Header{Coinbase: miner, Number: big.NewInt(90)}
+		penalty   = ReportPenalty(0, height, timestamp)
+	)
+	tests := []struct {
+		balance *common.TimeLockItem // FSN time lock of the miner
+		taken   *common.TimeLockItem // time lock taken, nil if none
+	}{
+		// balance covering the penalty
+		{
+			balance: &common.TimeLockItem{StartTime: timestamp, EndTime: common.TimeLockForever, Value: new(big.Int).Mul(penalty.Value, big.NewInt(2))},
+			taken:   penalty,
+		},
+		// balance covering part of the penalty period and value
+		{
+			balance: &common.TimeLockItem{StartTime: timestamp, EndTime: timestamp + 100, Value: big.NewInt(7)},
+			taken:   &common.TimeLockItem{StartTime: timestamp, EndTime: timestamp + 100, Value: big.NewInt(7)},
+		},
+		// no balance
+		{},
+	}
+	for i, tt := range tests {
+		statedb, _ := state.New(common.Hash{}, common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+		if tt.balance != nil {
+			statedb.AddTimeLockBalance(miner, common.SystemAssetID, common.NewTimeLock(tt.balance), height, timestamp)
+		}
+		deleted, taken := ProcessReport(header, header, common.Address{}, statedb, height, timestamp)
+		if len(deleted) != 0 {
+			t.Errorf("test %d: deleted tickets of a miner without tickets: %v", i, deleted)
+		}
+		want := new(common.TimeLock)
+		if tt.taken != nil {
+			want = common.NewTimeLock(tt.taken)
+		}
+		if !taken.EqualTo(want) {
+			t.Errorf("test %d: taken time lock mismatch: have %v, want %v", i, taken, want)
+		}
+		if tt.balance != nil {
+			left := new(common.TimeLock).Sub(common.NewTimeLock(tt.balance), want)
+			if balance := statedb.GetTimeLockBalance(common.SystemAssetID, miner); !balance.EqualTo(left) {
+				t.Errorf("test %d: balance left mismatch: have %v, want %v", i, balance, left)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Package sighash implements the hash of the DaTong block headers signed by
+// their sealer, shared by the consensus engine and the external signers, which
+// don't depend on the engine.
+package sighash
+
+import (
+	"errors"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/crypto"
+	"github.com/FusionFoundation/efsn/rlp"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	ExtraVanity = 32 // Fixed number of extra-data prefix bytes reserved for signer vanity
+	ExtraSeal   = 65 // Fixed number of extra-data suffix bytes reserved for signer seal
+)
+
+// ErrMissingSignature is returned if a header's extra-data can't hold the
+// signature.
+var ErrMissingSignature = errors.New("extra-data 65 byte suffix signature missing")
+
+// Hash returns the hash signed by the sealer of the header, which covers all
+// the fields of the header but the signature at the end of its extra-data.
+func Hash(header *types.Header) (hash common.Hash, err error) {
+	if len(header.Extra) < ExtraVanity+ExtraSeal {
+		return common.Hash{}, ErrMissingSignature
+	}
+	hasher := sha3.NewLegacyKeccak256()
+	rlp.Encode(hasher, []interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-ExtraSeal],
+		header.MixDigest,
+		header.Nonce,
+	})
+	hasher.Sum(hash[:0])
+	return hash, nil
+}
+
+// Signer returns the address which signed the header.
+func Signer(header *types.Header) (common.Address, error) {
+	hash, err := Hash(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signature := header.Extra[len(header.Extra)-ExtraSeal:]
+	pubkey, err := crypto.Ecrecover(hash.Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
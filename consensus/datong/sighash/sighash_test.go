@@ -0,0 +1,55 @@
+package sighash
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/crypto"
+)
+
+func TestSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	header := &types.Header{
+		Coinbase:   addr,
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(1),
+		Time:       100,
+		Extra:      make([]byte, ExtraVanity+ExtraSeal),
+	}
+	hash, err := Hash(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(header.Extra[ExtraVanity:], sig)
+
+	// the signature isn't part of the hash
+	if have, _ := Hash(header); have != hash {
+		t.Fatalf("hash changed by the signature: have %x, want %x", have, hash)
+	}
+	if signer, err := Signer(header); err != nil || signer != addr {
+		t.Fatalf("signer mismatch: have %v, err %v, want %v", signer.String(), err, addr.String())
+	}
+
+	// every other field is
+	header.Time++
+	if have, _ := Hash(header); have == hash {
+		t.Fatal("hash doesn't cover the time")
+	}
+	if signer, _ := Signer(header); signer == addr {
+		t.Fatal("signature valid for a modified header")
+	}
+
+	header.Extra = header.Extra[:ExtraVanity]
+	if _, err := Hash(header); err != ErrMissingSignature {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrMissingSignature)
+	}
+	if _, err := Signer(header); err != ErrMissingSignature {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrMissingSignature)
+	}
+}
@@ -0,0 +1,218 @@
+package datong
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/ethdb"
+	"github.com/FusionFoundation/efsn/log"
+)
+
+// sealedPrefix + signer + number (uint64 big endian) + parent hash -> seal hash
+var sealedPrefix = []byte("datong-sealed-")
+
+const sealingHistoryVersion = 1
+
+// sealingHistoryDepth is the number of blocks below a newly sealed header the
+// sealing history of its signer is kept for. Reports older than maxReportDepth
+// are rejected, the margin covers the reorganisations which would make the
+// signer seal at an older height again.
+const sealingHistoryDepth = 10 * maxReportDepth
+
+// ErrConflictingSeal is returned by Seal for a header conflicting with one
+// released before.
+var ErrConflictingSeal = errors.New("conflicting header already sealed")
+
+// SealRecord is a header released by a local signer. Two headers of the same
+// signer with the same parent and different hashes are reported as illegal,
+// and the tickets and time locks of the signer are punished, so a header
+// conflicting with a record is never sealed again.
+type SealRecord struct {
+	Signer     common.Address `json:"signer"`
+	Number     uint64         `json:"number"`
+	ParentHash common.Hash    `json:"parentHash"`
+	SealHash   common.Hash    `json:"sealHash"` // hash signed
+}
+
+// SealingHistory is the interchange format of the sealing history, used to
+// move a signer between nodes without losing its slashing protection.
+type SealingHistory struct {
+	Version uint64       `json:"version"`
+	Records []SealRecord `json:"records"`
+}
+
+// SealingImport is the outcome of a sealing history import.
+type SealingImport struct {
+	Imported  int          `json:"imported"`
+	Existing  int          `json:"existing"`
+	Conflicts []SealRecord `json:"conflicts"` // records conflicting with a local one, which is kept
+}
+
+func sealedKey(signer common.Address, number uint64, parent common.Hash) []byte {
+	key := make([]byte, len(sealedPrefix)+common.AddressLength+8+common.HashLength)
+	n := copy(key, sealedPrefix)
+	n += copy(key[n:], signer[:])
+	binary.BigEndian.PutUint64(key[n:], number)
+	copy(key[n+8:], parent[:])
+	return key
+}
+
+func readSealed(db ethdb.KeyValueReader, signer common.Address, number uint64, parent common.Hash) (common.Hash, bool) {
+	data, err := db.Get(sealedKey(signer, number, parent))
+	if err != nil || len(data) != common.HashLength {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(data), true
+}
+
+// checkSealed returns an error if a header conflicting with the header was
+// sealed before. Sealing the same header again is allowed. The headers are
+// compared by the hash signed, which covers every field of the header but
+// the signature, unlike the seal hash of the miner.
+func (dt *DaTong) checkSealed(header *types.Header) error {
+	if sealed, ok := readSealed(dt.db, header.Coinbase, header.Number.Uint64(), header.ParentHash); ok && sealed != sigHash(header) {
+		return fmt.Errorf("%w: number %v, parent %v, sealed %v", ErrConflictingSeal, header.Number, header.ParentHash.String(), sealed.String())
+	}
+	return nil
+}
+
+// recordSealed adds the header to the sealing history unless it conflicts
+// with a header sealed before.
+func (dt *DaTong) recordSealed(header *types.Header) error {
+	dt.sealLock.Lock()
+	defer dt.sealLock.Unlock()
+
+	if err := dt.checkSealed(header); err != nil {
+		return err
+	}
+	hash := sigHash(header)
+	if err := dt.db.Put(sealedKey(header.Coinbase, header.Number.Uint64(), header.ParentHash), hash[:]); err != nil {
+		return err
+	}
+	if err := dt.pruneSealed(header.Coinbase, header.Number.Uint64()); err != nil {
+		log.Warn("Failed to prune sealing history", "number", header.Number, "err", err)
+	}
+	return nil
+}
+
+// pruneSealed removes the records of the signer more than sealingHistoryDepth
+// blocks below the number, the caller must hold the seal lock.
+func (dt *DaTong) pruneSealed(signer common.Address, number uint64) error {
+	if number <= sealingHistoryDepth {
+		return nil
+	}
+	prefix := append(append([]byte{}, sealedPrefix...), signer[:]...)
+	it := dt.db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	batch := dt.db.NewBatch()
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8+common.HashLength {
+			continue
+		}
+		// the records are ordered by number
+		if binary.BigEndian.Uint64(key[len(prefix):]) >= number-sealingHistoryDepth {
+			break
+		}
+		if err := batch.Delete(common.CopyBytes(key)); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// forgetSealed removes the header from the sealing history, for a header
+// which was recorded but never released.
+func (dt *DaTong) forgetSealed(header *types.Header) {
+	dt.sealLock.Lock()
+	defer dt.sealLock.Unlock()
+
+	if err := dt.db.Delete(sealedKey(header.Coinbase, header.Number.Uint64(), header.ParentHash)); err != nil {
+		log.Error("Failed to remove sealing record", "number", header.Number, "err", err)
+	}
+}
+
+// ExportSealingHistory writes the sealing history of all the local signers
+// as JSON and returns the number of records written.
+func (dt *DaTong) ExportSealingHistory(w io.Writer) (int, error) {
+	history := SealingHistory{Version: sealingHistoryVersion, Records: []SealRecord{}}
+
+	it := dt.db.NewIterator(sealedPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()[len(sealedPrefix):]
+		if len(key) != common.AddressLength+8+common.HashLength || len(it.Value()) != common.HashLength {
+			continue
+		}
+		history.Records = append(history.Records, SealRecord{
+			Signer:     common.BytesToAddress(key[:common.AddressLength]),
+			Number:     binary.BigEndian.Uint64(key[common.AddressLength:]),
+			ParentHash: common.BytesToHash(key[common.AddressLength+8:]),
+			SealHash:   common.BytesToHash(it.Value()),
+		})
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return len(history.Records), enc.Encode(&history)
+}
+
+// ImportSealingHistory merges a sealing history exported by another node into
+// the local one. A record conflicting with a local record means both headers
+// were released, it is reported and the local record is kept.
+func (dt *DaTong) ImportSealingHistory(r io.Reader) (*SealingImport, error) {
+	var history SealingHistory
+	if err := json.NewDecoder(r).Decode(&history); err != nil {
+		return nil, fmt.Errorf("invalid sealing history: %v", err)
+	}
+	if history.Version != sealingHistoryVersion {
+		return nil, fmt.Errorf("unsupported sealing history version %d", history.Version)
+	}
+
+	dt.sealLock.Lock()
+	defer dt.sealLock.Unlock()
+
+	result := &SealingImport{Conflicts: []SealRecord{}}
+	batch := dt.db.NewBatch()
+	pending := make(map[string]common.Hash)
+	for _, rec := range history.Records {
+		if rec.Number == 0 || rec.SealHash == (common.Hash{}) {
+			return nil, fmt.Errorf("invalid sealing record for %v at number %d", rec.Signer.String(), rec.Number)
+		}
+		key := sealedKey(rec.Signer, rec.Number, rec.ParentHash)
+		sealed, ok := pending[string(key)]
+		if !ok {
+			sealed, ok = readSealed(dt.db, rec.Signer, rec.Number, rec.ParentHash)
+		}
+		if ok {
+			if sealed == rec.SealHash {
+				result.Existing++
+			} else {
+				log.Warn("Conflicting sealing record", "signer", rec.Signer, "number", rec.Number, "local", sealed, "imported", rec.SealHash)
+				result.Conflicts = append(result.Conflicts, rec)
+			}
+			continue
+		}
+		if err := batch.Put(key, rec.SealHash[:]); err != nil {
+			return nil, err
+		}
+		pending[string(key)] = rec.SealHash
+		result.Imported++
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	log.Info("Imported sealing history", "imported", result.Imported, "existing", result.Existing, "conflicts", len(result.Conflicts))
+	return result, nil
+}
@@ -0,0 +1,203 @@
+package datong
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/FusionFoundation/efsn/accounts"
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/params"
+)
+
+var (
+	sealSigner = common.BytesToAddress([]byte{0x51})
+	sealOther  = common.BytesToAddress([]byte{0x52})
+)
+
+func newSealingEngine() *DaTong {
+	return New(&params.DaTongConfig{}, rawdb.NewMemoryDatabase())
+}
+
+// sealHeader returns a header of the signer on top of the parent, headers
+// differing in time conflict with each other.
+func sealHeader(signer common.Address, number uint64, parent common.Hash, time uint64) *types.Header {
+	return &types.Header{
+		ParentHash: parent,
+		Coinbase:   signer,
+		Number:     new(big.Int).SetUint64(number),
+		Time:       time,
+		Difficulty: big.NewInt(1),
+		Extra:      make([]byte, extraVanity+extraSeal),
+	}
+}
+
+func recordHeader(t *testing.T, dt *DaTong, header *types.Header) error {
+	t.Helper()
+	return dt.recordSealed(header)
+}
+
+func TestSealingHistoryConflicts(t *testing.T) {
+	dt := newSealingEngine()
+	parent := common.HexToHash("0x01")
+
+	header := sealHeader(sealSigner, 10, parent, 100)
+	if err := recordHeader(t, dt, header); err != nil {
+		t.Fatalf("first header refused: %v", err)
+	}
+	// sealing the same header again is allowed
+	if err := dt.checkSealed(header); err != nil {
+		t.Fatalf("same header refused: %v", err)
+	}
+	if err := recordHeader(t, dt, header); err != nil {
+		t.Fatalf("same header refused: %v", err)
+	}
+
+	// a different header of the signer on the same parent would be reported
+	conflict := sealHeader(sealSigner, 10, parent, 101)
+	if err := dt.checkSealed(conflict); !errors.Is(err, ErrConflictingSeal) {
+		t.Fatalf("conflicting header check: have %v, want %v", err, ErrConflictingSeal)
+	}
+	if err := recordHeader(t, dt, conflict); !errors.Is(err, ErrConflictingSeal) {
+		t.Fatalf("conflicting header record: have %v, want %v", err, ErrConflictingSeal)
+	}
+
+	// on another parent, or by another signer, it can't be reported
+	for _, other := range []*types.Header{
+		sealHeader(sealSigner, 10, common.HexToHash("0x02"), 101),
+		sealHeader(sealOther, 10, parent, 101),
+		sealHeader(sealSigner, 11, parent, 101),
+	} {
+		if err := recordHeader(t, dt, other); err != nil {
+			t.Errorf("header %v of %v on %v refused: %v", other.Number, other.Coinbase.String(), other.ParentHash.String(), err)
+		}
+	}
+
+	// a header recorded but never released doesn't block another one
+	dt.forgetSealed(header)
+	if err := recordHeader(t, dt, conflict); err != nil {
+		t.Fatalf("header refused after the unreleased one was forgotten: %v", err)
+	}
+}
+
+func TestSealingHistoryPruning(t *testing.T) {
+	dt := newSealingEngine()
+	parent := common.HexToHash("0x01")
+
+	// the records of another signer are kept whatever the height
+	if err := recordHeader(t, dt, sealHeader(sealOther, 1, parent, 100)); err != nil {
+		t.Fatal(err)
+	}
+	for _, number := range []uint64{1, 2, 3, sealingHistoryDepth} {
+		if err := recordHeader(t, dt, sealHeader(sealSigner, number, parent, 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// nothing is pruned up to the history depth
+	for _, number := range []uint64{1, 2, 3} {
+		if _, ok := readSealed(dt.db, sealSigner, number, parent); !ok {
+			t.Fatalf("record %d pruned too early", number)
+		}
+	}
+
+	head := uint64(sealingHistoryDepth + 2)
+	if err := recordHeader(t, dt, sealHeader(sealSigner, head, parent, 100)); err != nil {
+		t.Fatal(err)
+	}
+	for number, kept := range map[uint64]bool{1: false, 2: true, 3: true, sealingHistoryDepth: true, head: true} {
+		if _, ok := readSealed(dt.db, sealSigner, number, parent); ok != kept {
+			t.Errorf("record %d: have kept %v, want %v", number, ok, kept)
+		}
+	}
+	if _, ok := readSealed(dt.db, sealOther, 1, parent); !ok {
+		t.Error("record of another signer pruned")
+	}
+
+	// a pruned height can't be reported anymore, so it may be sealed again
+	if err := recordHeader(t, dt, sealHeader(sealSigner, 1, parent, 101)); err != nil {
+		t.Errorf("pruned height refused: %v", err)
+	}
+}
+
+func TestSealingHistoryExportImport(t *testing.T) {
+	src, dst := newSealingEngine(), newSealingEngine()
+	parent := common.HexToHash("0x01")
+
+	for number := uint64(1); number <= 3; number++ {
+		if err := recordHeader(t, src, sealHeader(sealSigner, number, parent, 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the destination already released the same header 1 and a conflicting header 2
+	if err := recordHeader(t, dst, sealHeader(sealSigner, 1, parent, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordHeader(t, dst, sealHeader(sealSigner, 2, parent, 101)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if n, err := src.ExportSealingHistory(&buf); err != nil || n != 3 {
+		t.Fatalf("export: have %d records, err %v, want 3", n, err)
+	}
+	result, err := dst.ImportSealingHistory(&buf)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.Imported != 1 || result.Existing != 1 || len(result.Conflicts) != 1 || result.Conflicts[0].Number != 2 {
+		t.Fatalf("import mismatch: %+v", result)
+	}
+	// the imported record protects the destination, the local one is kept
+	if err := recordHeader(t, dst, sealHeader(sealSigner, 3, parent, 101)); !errors.Is(err, ErrConflictingSeal) {
+		t.Errorf("header conflicting with an imported record: have %v, want %v", err, ErrConflictingSeal)
+	}
+	if err := recordHeader(t, dst, sealHeader(sealSigner, 2, parent, 101)); err != nil {
+		t.Errorf("local record replaced by a conflicting import: %v", err)
+	}
+
+	if _, err := dst.ImportSealingHistory(bytes.NewBufferString(`{"version":2,"records":[]}`)); err == nil {
+		t.Error("unsupported version imported")
+	}
+}
+
+func TestSealAbort(t *testing.T) {
+	dt := New(&params.DaTongConfig{InstantSeal: true}, rawdb.NewMemoryDatabase())
+
+	signing, aborted := make(chan struct{}), make(chan struct{})
+	dt.AuthorizeHeader(sealSigner, func(ctx context.Context, account accounts.Account, header *types.Header) ([]byte, error) {
+		close(signing)
+		<-ctx.Done()
+		close(aborted)
+		return nil, ctx.Err()
+	})
+	header := sealHeader(sealSigner, 1, common.HexToHash("0x01"), uint64(time.Now().Unix()))
+	results, stop := make(chan *types.Block, 1), make(chan struct{})
+	if err := dt.Seal(nil, types.NewBlockWithHeader(header), results, stop); err != nil {
+		t.Fatalf("failed to seal: %v", err)
+	}
+	select {
+	case <-signing:
+	case <-time.After(time.Second):
+		t.Fatal("header not signed asynchronously")
+	}
+	close(stop)
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("signer not cancelled with the sealing")
+	}
+	select {
+	case block := <-results:
+		t.Fatalf("aborted block %d released", block.NumberU64())
+	case <-time.After(100 * time.Millisecond):
+	}
+	// the aborted header is not recorded, a replacement may be sealed
+	if err := dt.checkSealed(sealHeader(sealSigner, 1, common.HexToHash("0x01"), header.Time+1)); err != nil {
+		t.Errorf("replacement of aborted header refused: %v", err)
+	}
+}
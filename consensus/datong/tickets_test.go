@@ -0,0 +1,58 @@
+package datong
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/params"
+)
+
+func TestFetchTickets(t *testing.T) {
+	prev := common.GetForkConfig()
+	common.SetForkConfig(common.ForkConfig{TicketStorageBlock: big.NewInt(1)})
+	t.Cleanup(func() { common.SetForkConfig(prev) })
+
+	statedb, err := state.New(common.Hash{}, common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := byte(1); id <= 2; id++ {
+		ticket := common.Ticket{
+			Owner:      common.BytesToAddress([]byte{id}),
+			TicketBody: common.TicketBody{ID: common.BytesToHash([]byte{id}), Height: uint64(id), StartTime: 1000, ExpireTime: 100000},
+		}
+		if err := statedb.AddTicket(ticket); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := statedb.UpdateTickets(big.NewInt(2), 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &types.Header{Number: big.NewInt(2), MixDigest: hash}
+	tickets, err := statedb.AllTickets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without a fetcher the headers past the last check point are refused
+	dt := New(&params.DaTongConfig{}, nil)
+	if err := dt.fetchTickets(header); err != errUnanchoredHeader {
+		t.Fatalf("error mismatch: have %v, want %v", err, errUnanchoredHeader)
+	}
+
+	// a retrieved ticket set must match the commitment of the header
+	forged, _ := tickets.DeepCopy().RemoveTicket(common.BytesToHash([]byte{2}))
+	dt.SetTicketsFetcher(func(*types.Header) (common.TicketsDataSlice, error) { return forged, nil })
+	if err := dt.fetchTickets(header); err == nil {
+		t.Fatal("forged ticket set accepted")
+	}
+	dt.SetTicketsFetcher(func(*types.Header) (common.TicketsDataSlice, error) { return tickets, nil })
+	if err := dt.fetchTickets(header); err != nil {
+		t.Fatalf("ticket set rejected: %v", err)
+	}
+}
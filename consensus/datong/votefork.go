@@ -3,13 +3,13 @@ package datong
 import (
 	"math/big"
 
-	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/params"
 )
 
 //-------------------------- vote1 fork -------------------------
-func ApplyVote1HardFork(statedb *state.StateDB, blockNumber *big.Int, timestamp uint64) {
-	for _, addr := range common.Vote1DrainList {
-		statedb.TransferAll(addr, common.Vote1RefundAddress, blockNumber, timestamp)
+func ApplyVote1HardFork(statedb *state.StateDB, vote1 *params.Vote1FreezeRange, blockNumber *big.Int, timestamp uint64) {
+	for _, addr := range vote1.DrainList {
+		statedb.TransferAll(addr, vote1.RefundAddress, blockNumber, timestamp)
 	}
 }
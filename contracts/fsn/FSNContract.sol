@@ -7,6 +7,12 @@ contract FSNContract {
     event LogFusionAssetReceived(bytes32 indexed _asset, address indexed _from, uint256 _value, uint64 _start, uint64 _end, SendAssetFlag _flag);
     event LogFusionAssetSent(bytes32 indexed _asset, address indexed _to, uint256 _value, uint64 _start, uint64 _end, SendAssetFlag _flag);
 
+    // these events will be generated by the low level impl,
+    // they are logged by the precompile address instead of the calling contract.
+    event LogFusionSwapMade(bytes32 indexed _swapID, address indexed _owner, bytes32 _fromAsset, uint64 _fromStart, uint64 _fromEnd, uint256 _minFromAmount, bytes32 _toAsset, uint64 _toStart, uint64 _toEnd, uint256 _minToAmount, uint256 _swapSize, address _target);
+    event LogFusionSwapRecalled(bytes32 indexed _swapID, address indexed _owner);
+    event LogFusionSwapTaken(bytes32 indexed _swapID, address indexed _taker, uint256 _size, bool _deleted);
+
     enum SendAssetFlag {
         UseAny,                 // 0
         UseAnyToTimeLock,       // 1
@@ -16,8 +22,70 @@ contract FSNContract {
         UseAssetToTimeLock      // 5
     }
 
+    // func types of the precompile
+    uint256 constant SendAssetFunc = 1;
+    uint256 constant GetBalanceFunc = 2;
+    uint256 constant GetTimeLockBalanceFunc = 3;
+    uint256 constant GetAssetFunc = 4;
+    uint256 constant GetNotationFunc = 5;
+    uint256 constant GetAddressByNotationFunc = 6;
+    uint256 constant MakeSwapFunc = 7;
+    uint256 constant RecallSwapFunc = 8;
+    uint256 constant TakeSwapFunc = 9;
+
     function _sendAsset(bytes32 asset, address to, uint256 value, uint64 start, uint64 end, SendAssetFlag flag) internal returns (bool, bytes memory) {
-        bytes memory input = abi.encode(1, asset, to, value, start, end, flag);
+        bytes memory input = abi.encode(SendAssetFunc, asset, to, value, start, end, flag);
+        return precompile.call(input);
+    }
+
+    // the following functions are available after the FSN contract v2 hard fork.
+
+    function _query(bytes memory input) internal view returns (bytes memory) {
+        (bool success, bytes memory ret) = precompile.staticcall(input);
+        require(success, string(ret));
+        return ret;
+    }
+
+    function _getBalance(bytes32 asset, address account) internal view returns (uint256) {
+        return abi.decode(_query(abi.encode(GetBalanceFunc, asset, account)), (uint256));
+    }
+
+    // returns the time locked value the account can spend during the whole period,
+    // a start in the past means now and an end of 0 means forever.
+    function _getTimeLockBalance(bytes32 asset, address account, uint64 start, uint64 end) internal view returns (uint256) {
+        return abi.decode(_query(abi.encode(GetTimeLockBalanceFunc, asset, account, start, end)), (uint256));
+    }
+
+    function _getAsset(bytes32 asset) internal view returns (address owner, uint256 total, uint8 decimals, bool canChange, string memory name, string memory symbol) {
+        return abi.decode(_query(abi.encode(GetAssetFunc, asset)), (address, uint256, uint8, bool, string, string));
+    }
+
+    function _getNotation(address account) internal view returns (uint64) {
+        return abi.decode(_query(abi.encode(GetNotationFunc, account)), (uint64));
+    }
+
+    function _getAddressByNotation(uint64 notation) internal view returns (address) {
+        return abi.decode(_query(abi.encode(GetAddressByNotationFunc, notation)), (address));
+    }
+
+    // makes a swap owned by this contract and returns its id, a zero target makes a public swap.
+    // a from start of 0 and a from end of 0xffffffffffffffff offer asset, other ranges offer time lock.
+    function _makeSwap(bytes32 fromAsset, uint64 fromStart, uint64 fromEnd, uint256 minFromAmount, bytes32 toAsset, uint64 toStart, uint64 toEnd, uint256 minToAmount, uint256 swapSize, address target) internal returns (bytes32) {
+        bytes memory input = abi.encode(MakeSwapFunc, fromAsset, fromStart, fromEnd, minFromAmount);
+        input = abi.encodePacked(input, abi.encode(toAsset, toStart, toEnd, minToAmount));
+        input = abi.encodePacked(input, abi.encode(swapSize, target));
+        (bool success, bytes memory ret) = precompile.call(input);
+        require(success, string(ret));
+        return abi.decode(ret, (bytes32));
+    }
+
+    function _recallSwap(bytes32 swapID) internal returns (bool, bytes memory) {
+        bytes memory input = abi.encode(RecallSwapFunc, swapID);
+        return precompile.call(input);
+    }
+
+    function _takeSwap(bytes32 swapID, uint256 size) internal returns (bool, bytes memory) {
+        bytes memory input = abi.encode(TakeSwapFunc, swapID, size);
         return precompile.call(input);
     }
 }
@@ -27,4 +27,27 @@ contract FSNExample is FSNContract {
         require(success, "call sendAsset failed");
         return true;
     }
+
+    // the following functions use the precompile functions of the FSN contract v2 hard fork.
+
+    function balanceOf(bytes32 asset) public view returns (uint256 balance, uint256 lockedForever) {
+        balance = _getBalance(asset, address(this));
+        lockedForever = _getTimeLockBalance(asset, address(this), 0, 0);
+    }
+
+    function makeSwap(bytes32 fromAsset, uint256 minFromAmount, bytes32 toAsset, uint256 minToAmount, uint256 swapSize) onlyOwner public returns (bytes32 swapID) {
+        return _makeSwap(fromAsset, 0, 0xffffffffffffffff, minFromAmount, toAsset, 0, 0xffffffffffffffff, minToAmount, swapSize, address(0));
+    }
+
+    function recallSwap(bytes32 swapID) onlyOwner public returns (bool success) {
+        (success,) = _recallSwap(swapID);
+        require(success, "call recallSwap failed");
+        return true;
+    }
+
+    function takeSwap(bytes32 swapID, uint256 size) onlyOwner public returns (bool success) {
+        (success,) = _takeSwap(swapID, size);
+        require(success, "call takeSwap failed");
+        return true;
+    }
 }
@@ -167,6 +167,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	if cacheConfig == nil {
 		cacheConfig = defaultCacheConfig
 	}
+	// Fusion fork checks follow the schedule of the chain being processed
+	common.SetForkConfig(chainConfig.ForkConfig())
+
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	receiptsCache, _ := lru.New(receiptsCacheLimit)
@@ -229,7 +232,8 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		}
 	}
 	// check points, make sure that we do not have any mismatch block in our chain
-	for height, hash := range datong.CheckPoints {
+	checkPoints, _ := datong.GetCheckPoints(bc.chainConfig.ChainID)
+	for height, hash := range checkPoints {
 		headerByNumber := bc.GetHeaderByNumber(height)
 		if headerByNumber != nil && headerByNumber.Hash() != hash {
 			log.Error("check point mismatch", "number", height, "hash", headerByNumber.Hash(), "want", hash)
@@ -389,6 +393,17 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	if _, err := trie.NewSecure(block.Root(), bc.stateCache.TrieDB()); err != nil {
 		return err
 	}
+	// The ticket set in the state must match the header, as the blocks on top
+	// of it are verified against it
+	if dt, ok := bc.engine.(*datong.DaTong); ok {
+		statedb, err := state.New(block.Root(), block.MixDigest(), bc.stateCache)
+		if err != nil {
+			return err
+		}
+		if err := dt.VerifyState(bc, block.Header(), statedb); err != nil {
+			return fmt.Errorf("invalid pivot state: %v", err)
+		}
+	}
 	// If all checks out, manually set the head block
 	bc.chainmu.Lock()
 	bc.currentBlock.Store(block)
@@ -1355,12 +1370,6 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	// Set new head.
 	if status == CanonStatTy {
 		bc.writeHeadBlock(block)
-		// (auto) buy ticket when block height changed
-		if common.AutoBuyTicket == true { // if enable
-			go func() { // do not block process
-				common.AutoBuyTicketChan <- 1
-			}()
-		}
 	}
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
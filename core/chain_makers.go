@@ -96,6 +96,10 @@ func (b *BlockGen) AddTxWithChain(bc *BlockChain, tx *types.Transaction) {
 	if b.gasPool == nil {
 		b.SetCoinbase(common.Address{})
 	}
+	if bc == nil {
+		// the EVM context reads the parent header from the chain
+		bc = b.chainReader.(*BlockChain)
+	}
 	b.statedb.Prepare(tx.Hash(), len(b.txs))
 	receipt, err := ApplyTransaction(b.config, bc, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, &b.header.GasUsed, vm.Config{})
 	if err != nil {
@@ -166,6 +170,13 @@ func (b *BlockGen) OffsetTime(seconds int64) {
 	b.header.Difficulty = b.engine.CalcDifficulty(b.chainReader, b.header.Time, b.parent.Header())
 }
 
+// Prepare runs the consensus engine's header preparation on the generated
+// block. It's needed by engines like DaTong, whose difficulty, nonce and
+// time depend on the coinbase, so the coinbase and time must be set first.
+func (b *BlockGen) Prepare() error {
+	return b.engine.Prepare(b.chainReader, b.header)
+}
+
 // GenerateChain creates a chain of n blocks. The first block's
 // parent will be the provided parent. db is used to store
 // intermediate states and should contain the parent's state trie.
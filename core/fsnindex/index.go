@@ -0,0 +1,414 @@
+// Package fsnindex implements an optional chain indexer which keeps a local,
+// queryable index of the Fusion assets, the open swaps and the double-sign
+// reports of the canonical chain.
+package fsnindex
+
+import (
+	"encoding/binary"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/ethdb"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+// The index lives in its own table (rawdb.FsnIndexPrefix) next to the chain
+// indexer metadata, so all data keys use upper case prefixes which can't collide
+// with the lower case "count" and "shead" keys of core.ChainIndexer.
+var (
+	assetPrefix       = []byte("A") // assetPrefix + asset id -> rlp(asset)
+	assetOwnerPrefix  = []byte("O") // assetOwnerPrefix + owner + asset id -> marker
+	swapPrefix        = []byte("S") // swapPrefix + kind + swap id -> rlp(swap or multi swap)
+	swapOwnerPrefix   = []byte("W") // swapOwnerPrefix + kind + owner + swap id -> marker
+	swapPairPrefix    = []byte("P") // swapPairPrefix + kind + from asset + to asset + swap id -> marker
+	swapTargetPrefix  = []byte("T") // swapTargetPrefix + kind + target + swap id -> marker
+	reportPrefix      = []byte("R") // reportPrefix + number (uint64 big endian) + report hash -> rlp(report)
+	reportHashPrefix  = []byte("H") // reportHashPrefix + report hash -> number (uint64 big endian)
+	reportMinerPrefix = []byte("M") // reportMinerPrefix + miner + number (uint64 big endian) + report hash -> marker
+	journalPrefix     = []byte("J") // journalPrefix + section (uint64 big endian) -> rlp(undo entries)
+	versionKey        = []byte("V") // versionKey -> index version (uint64 big endian)
+
+	marker = []byte{1}
+)
+
+// swap kinds, used to keep single and multi swaps apart inside the index.
+const (
+	kindSwap      byte = 0
+	kindMultiSwap byte = 1
+)
+
+const (
+	// DefaultPageSize is the number of entries returned when no page size is given.
+	DefaultPageSize = 100
+
+	// MaxPageSize is the maximum number of entries returned by a single query.
+	MaxPageSize = 1000
+)
+
+func assetKey(id common.Hash) []byte {
+	return append(append([]byte{}, assetPrefix...), id.Bytes()...)
+}
+
+func assetOwnerKey(owner common.Address, id common.Hash) []byte {
+	key := append(append([]byte{}, assetOwnerPrefix...), owner.Bytes()...)
+	return append(key, id.Bytes()...)
+}
+
+func swapKey(kind byte, id common.Hash) []byte {
+	return append(append(append([]byte{}, swapPrefix...), kind), id.Bytes()...)
+}
+
+func swapOwnerKey(kind byte, owner common.Address, id common.Hash) []byte {
+	key := append(append(append([]byte{}, swapOwnerPrefix...), kind), owner.Bytes()...)
+	return append(key, id.Bytes()...)
+}
+
+func swapPairKey(kind byte, from, to common.Hash, id common.Hash) []byte {
+	key := append(append(append([]byte{}, swapPairPrefix...), kind), from.Bytes()...)
+	key = append(key, to.Bytes()...)
+	return append(key, id.Bytes()...)
+}
+
+func swapTargetKey(kind byte, target common.Address, id common.Hash) []byte {
+	key := append(append(append([]byte{}, swapTargetPrefix...), kind), target.Bytes()...)
+	return append(key, id.Bytes()...)
+}
+
+func journalKey(section uint64) []byte {
+	key := make([]byte, len(journalPrefix)+8)
+	copy(key, journalPrefix)
+	binary.BigEndian.PutUint64(key[len(journalPrefix):], section)
+	return key
+}
+
+// AssetFilter selects a page of indexed assets.
+type AssetFilter struct {
+	Owner    *common.Address `json:"owner"`
+	Page     uint64          `json:"page"`
+	PageSize uint64          `json:"pageSize"`
+}
+
+// SwapFilter selects a page of indexed swaps. For multi swaps an asset pair
+// matches if any of the from assets and any of the to assets match.
+type SwapFilter struct {
+	Owner       *common.Address `json:"owner"`
+	FromAssetID *common.Hash    `json:"fromAssetID"`
+	ToAssetID   *common.Hash    `json:"toAssetID"`
+	Target      *common.Address `json:"target"`
+	Page        uint64          `json:"page"`
+	PageSize    uint64          `json:"pageSize"`
+}
+
+// pager skips the entries of the previous pages and collects the requested one.
+type pager struct {
+	skip  uint64
+	limit uint64
+}
+
+func newPager(page, pageSize uint64) *pager {
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return &pager{skip: page * pageSize, limit: pageSize}
+}
+
+// take reports whether a matching entry belongs to the page, and whether
+// the page is full afterwards.
+func (p *pager) take() (bool, bool) {
+	if p.skip > 0 {
+		p.skip--
+		return false, false
+	}
+	p.limit--
+	return true, p.limit == 0
+}
+
+// Index provides read access to the data built by the Fusion indexer.
+type Index struct {
+	db          ethdb.Database
+	sectionSize uint64
+}
+
+// NewIndex returns a reader over the Fusion index stored in the chain database,
+// built by an indexer with the given section size.
+func NewIndex(chainDb ethdb.Database, size uint64) *Index {
+	return &Index{db: rawdb.NewTable(chainDb, string(rawdb.FsnIndexPrefix)), sectionSize: size}
+}
+
+// Head returns the number and hash of the last block included in the index,
+// the index only holds the assets and swaps as of that block. It reports false
+// as long as no section has been indexed.
+func (idx *Index) Head() (uint64, common.Hash, bool) {
+	// the section count and heads are the metadata of core.ChainIndexer
+	data, _ := idx.db.Get([]byte("count"))
+	if len(data) != 8 || binary.BigEndian.Uint64(data) == 0 {
+		return 0, common.Hash{}, false
+	}
+	sections := binary.BigEndian.Uint64(data)
+
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], sections-1)
+	hash, _ := idx.db.Get(append([]byte("shead"), enc[:]...))
+	return sections*idx.sectionSize - 1, common.BytesToHash(hash), true
+}
+
+// GetAsset retrieves an indexed asset.
+func (idx *Index) GetAsset(id common.Hash) (*common.Asset, error) {
+	return readAsset(idx.db, id)
+}
+
+// Assets returns a page of indexed assets matching the filter.
+func (idx *Index) Assets(filter AssetFilter) ([]common.Asset, error) {
+	prefix := assetPrefix
+	if filter.Owner != nil {
+		prefix = append(append([]byte{}, assetOwnerPrefix...), filter.Owner.Bytes()...)
+	}
+	it := idx.db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var (
+		p      = newPager(filter.Page, filter.PageSize)
+		assets = make([]common.Asset, 0)
+	)
+	for it.Next() {
+		var asset *common.Asset
+		if filter.Owner != nil {
+			id := common.BytesToHash(it.Key()[len(prefix):])
+			a, err := readAsset(idx.db, id)
+			if err != nil {
+				return nil, err
+			}
+			asset = a
+		} else {
+			asset = new(common.Asset)
+			if err := rlp.DecodeBytes(it.Value(), asset); err != nil {
+				return nil, err
+			}
+		}
+		take, full := p.take()
+		if take {
+			assets = append(assets, *asset)
+		}
+		if full {
+			break
+		}
+	}
+	return assets, it.Error()
+}
+
+// AllAssets returns all the indexed assets, or the ones of the owner if it is
+// given. The assets are read in a single pass of one iterator, which sees the
+// index as of one commit of the indexer.
+func (idx *Index) AllAssets(owner *common.Address) (map[common.Hash]common.Asset, error) {
+	it := idx.db.NewIterator(assetPrefix, nil)
+	defer it.Release()
+
+	assets := make(map[common.Hash]common.Asset)
+	for it.Next() {
+		var asset common.Asset
+		if err := rlp.DecodeBytes(it.Value(), &asset); err != nil {
+			return nil, err
+		}
+		if owner == nil || asset.Owner == *owner {
+			assets[asset.ID] = asset
+		}
+	}
+	return assets, it.Error()
+}
+
+// GetSwap retrieves an indexed swap.
+func (idx *Index) GetSwap(id common.Hash) (*common.Swap, error) {
+	return readSwap(idx.db, id)
+}
+
+// GetMultiSwap retrieves an indexed multi swap.
+func (idx *Index) GetMultiSwap(id common.Hash) (*common.MultiSwap, error) {
+	return readMultiSwap(idx.db, id)
+}
+
+// Swaps returns a page of open swaps matching the filter.
+func (idx *Index) Swaps(filter SwapFilter) ([]common.Swap, error) {
+	swaps := make([]common.Swap, 0)
+	err := idx.iterateSwaps(kindSwap, filter, func(id common.Hash, collect bool) (bool, error) {
+		swap, err := readSwap(idx.db, id)
+		if err != nil || !filter.matchSwap(swap) {
+			return false, err
+		}
+		if collect {
+			swaps = append(swaps, *swap)
+		}
+		return true, nil
+	})
+	return swaps, err
+}
+
+// AllSwaps returns all the open swaps matching the filter, its page is
+// ignored. Like AllAssets, the swaps are read in a single pass of one iterator.
+func (idx *Index) AllSwaps(filter SwapFilter) (map[common.Hash]common.Swap, error) {
+	it := idx.db.NewIterator(append(append([]byte{}, swapPrefix...), kindSwap), nil)
+	defer it.Release()
+
+	swaps := make(map[common.Hash]common.Swap)
+	for it.Next() {
+		var swap common.Swap
+		if err := rlp.DecodeBytes(it.Value(), &swap); err != nil {
+			return nil, err
+		}
+		if filter.matchSwap(&swap) {
+			swaps[swap.ID] = swap
+		}
+	}
+	return swaps, it.Error()
+}
+
+// MultiSwaps returns a page of open multi swaps matching the filter.
+func (idx *Index) MultiSwaps(filter SwapFilter) ([]common.MultiSwap, error) {
+	swaps := make([]common.MultiSwap, 0)
+	err := idx.iterateSwaps(kindMultiSwap, filter, func(id common.Hash, collect bool) (bool, error) {
+		swap, err := readMultiSwap(idx.db, id)
+		if err != nil || !filter.matchMultiSwap(swap) {
+			return false, err
+		}
+		if collect {
+			swaps = append(swaps, *swap)
+		}
+		return true, nil
+	})
+	return swaps, err
+}
+
+// iterateSwaps walks the most selective index for the filter and hands every
+// candidate swap id to visit, which reports whether the swap matched and only
+// collects it if it belongs to the requested page.
+func (idx *Index) iterateSwaps(kind byte, filter SwapFilter, visit func(id common.Hash, collect bool) (bool, error)) error {
+	var prefix []byte
+	switch {
+	case filter.Owner != nil:
+		prefix = append(append(append([]byte{}, swapOwnerPrefix...), kind), filter.Owner.Bytes()...)
+	case filter.FromAssetID != nil:
+		prefix = append(append(append([]byte{}, swapPairPrefix...), kind), filter.FromAssetID.Bytes()...)
+		if filter.ToAssetID != nil {
+			prefix = append(prefix, filter.ToAssetID.Bytes()...)
+		}
+	case filter.Target != nil:
+		prefix = append(append(append([]byte{}, swapTargetPrefix...), kind), filter.Target.Bytes()...)
+	default:
+		prefix = append(append([]byte{}, swapPrefix...), kind)
+	}
+	it := idx.db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var (
+		p    = newPager(filter.Page, filter.PageSize)
+		seen = make(map[common.Hash]struct{})
+	)
+	for it.Next() {
+		key := it.Key()
+		id := common.BytesToHash(key[len(key)-common.HashLength:])
+		// multi swaps may appear several times under the same pair prefix
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		matched, err := visit(id, p.skip == 0)
+		if err != nil {
+			return err
+		}
+		if matched {
+			if _, full := p.take(); full {
+				break
+			}
+		}
+	}
+	return it.Error()
+}
+
+func (f *SwapFilter) matchSwap(swap *common.Swap) bool {
+	if f.Owner != nil && swap.Owner != *f.Owner {
+		return false
+	}
+	if f.FromAssetID != nil && swap.FromAssetID != *f.FromAssetID {
+		return false
+	}
+	if f.ToAssetID != nil && swap.ToAssetID != *f.ToAssetID {
+		return false
+	}
+	if f.Target != nil && !containsAddress(swap.Targes, *f.Target) {
+		return false
+	}
+	return true
+}
+
+func (f *SwapFilter) matchMultiSwap(swap *common.MultiSwap) bool {
+	if f.Owner != nil && swap.Owner != *f.Owner {
+		return false
+	}
+	if f.FromAssetID != nil && !containsHash(swap.FromAssetID, *f.FromAssetID) {
+		return false
+	}
+	if f.ToAssetID != nil && !containsHash(swap.ToAssetID, *f.ToAssetID) {
+		return false
+	}
+	if f.Target != nil && !containsAddress(swap.Targes, *f.Target) {
+		return false
+	}
+	return true
+}
+
+func containsAddress(list []common.Address, addr common.Address) bool {
+	for _, a := range list {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(list []common.Hash, hash common.Hash) bool {
+	for _, h := range list {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func readAsset(db ethdb.KeyValueReader, id common.Hash) (*common.Asset, error) {
+	data, err := db.Get(assetKey(id))
+	if err != nil {
+		return nil, err
+	}
+	asset := new(common.Asset)
+	if err := rlp.DecodeBytes(data, asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+func readSwap(db ethdb.KeyValueReader, id common.Hash) (*common.Swap, error) {
+	data, err := db.Get(swapKey(kindSwap, id))
+	if err != nil {
+		return nil, err
+	}
+	swap := new(common.Swap)
+	if err := rlp.DecodeBytes(data, swap); err != nil {
+		return nil, err
+	}
+	return swap, nil
+}
+
+func readMultiSwap(db ethdb.KeyValueReader, id common.Hash) (*common.MultiSwap, error) {
+	data, err := db.Get(swapKey(kindMultiSwap, id))
+	if err != nil {
+		return nil, err
+	}
+	swap := new(common.MultiSwap)
+	if err := rlp.DecodeBytes(data, swap); err != nil {
+		return nil, err
+	}
+	return swap, nil
+}
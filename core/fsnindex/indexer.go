@@ -0,0 +1,690 @@
+package fsnindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/fsnlog"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/core/vm"
+	"github.com/FusionFoundation/efsn/ethdb"
+	"github.com/FusionFoundation/efsn/log"
+	"github.com/FusionFoundation/efsn/params"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+const (
+	// indexThrottling is the time to wait between processing two consecutive
+	// index sections, to avoid hogging the disk while catching up.
+	indexThrottling = 10 * time.Millisecond
+
+	// indexVersion is the version of the index content, an index of another
+	// version is rebuilt from scratch.
+	//  1: assets and swaps
+	//  2: double-sign reports
+	//  3: genesis assets, every log of multi log calls
+	//  4: time lock taken by the reports
+	indexVersion = 4
+
+	// journalSections is the number of most recent sections whose undo journal
+	// is kept. A reorg deeper than that can't be rolled back and makes the
+	// index rebuild from the genesis.
+	journalSections = 32
+)
+
+// journalEntry records the value a key had before the first modification
+// within a section, so that the section can be rolled back on a reorg.
+type journalEntry struct {
+	Key    []byte
+	Value  []byte
+	Exists bool
+}
+
+// Indexer implements a core.ChainIndexer backend, following the FSN call
+// transactions of the canonical chain and maintaining the asset, swap and
+// report index.
+type Indexer struct {
+	chainDb ethdb.Database      // database to read blocks and receipts from
+	db      ethdb.Database      // index table to write index data and journals into
+	config  *params.ChainConfig // chain config used to derive receipts and senders
+
+	section uint64
+	dirty   map[string][]byte // pending writes of the current section, nil means deleted
+	journal []journalEntry    // undo entries of the current section
+}
+
+// NewIndexer returns a chain indexer that builds the Fusion asset, swap and
+// report index for the canonical chain.
+func NewIndexer(chainDb ethdb.Database, config *params.ChainConfig, size, confirms uint64) *core.ChainIndexer {
+	table := rawdb.NewTable(chainDb, string(rawdb.FsnIndexPrefix))
+	if err := checkVersion(table); err != nil {
+		log.Error("Failed to reset Fusion index", "err", err)
+	}
+	backend := &Indexer{
+		chainDb: chainDb,
+		db:      table,
+		config:  config,
+	}
+	return core.NewChainIndexer(chainDb, table, backend, size, confirms, indexThrottling, "fsnindex")
+}
+
+// checkVersion wipes an index of another version, including the section
+// metadata of the chain indexer, so the index is rebuilt from the genesis.
+func checkVersion(db ethdb.Database) error {
+	if data, _ := db.Get(versionKey); len(data) == 8 && binary.BigEndian.Uint64(data) == indexVersion {
+		return nil
+	}
+	count, err := wipe(db)
+	if err != nil {
+		return err
+	}
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], indexVersion)
+	if err := db.Put(versionKey, enc[:]); err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Info("Rebuilding Fusion index", "version", indexVersion)
+	}
+	return nil
+}
+
+// wipe deletes all index data and journals, keeping only the version, and
+// returns the number of deleted keys.
+func wipe(db ethdb.Database) (int, error) {
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	count := 0
+	for it.Next() {
+		if bytes.Equal(it.Key(), versionKey) {
+			continue
+		}
+		batch.Delete(common.CopyBytes(it.Key()))
+		if count++; batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return count, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return count, err
+	}
+	return count, batch.Write()
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new index section. Any
+// journaled section at or above the new one belongs to a reorged chain segment
+// and is rolled back first.
+func (idx *Indexer) Reset(ctx context.Context, section uint64, prevHead common.Hash) error {
+	idx.section = section
+	idx.dirty = make(map[string][]byte)
+	idx.journal = nil
+
+	return idx.rollback(section)
+}
+
+// rollback reverts all journaled sections from the given one upwards.
+func (idx *Indexer) rollback(section uint64) error {
+	var sections []uint64
+
+	it := idx.db.NewIterator(journalPrefix, journalKey(section)[len(journalPrefix):])
+	for it.Next() {
+		sections = append(sections, binary.BigEndian.Uint64(it.Key()[len(journalPrefix):]))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+	// every committed section has a journal, if the one of the first reorged
+	// section was pruned already the index can't be rolled back. Failing the
+	// reset makes the chain indexer reprocess the chain from the genesis.
+	sort.Slice(sections, func(i, j int) bool { return sections[i] > sections[j] })
+	if sections[len(sections)-1] != section {
+		if _, err := wipe(idx.db); err != nil {
+			return err
+		}
+		return fmt.Errorf("reorg of section %d beyond the journal history, rebuilding index", section)
+	}
+	batch := idx.db.NewBatch()
+	for _, s := range sections {
+		data, err := idx.db.Get(journalKey(s))
+		if err != nil {
+			return err
+		}
+		var entries []journalEntry
+		if err := rlp.DecodeBytes(data, &entries); err != nil {
+			return err
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Exists {
+				batch.Put(entries[i].Key, entries[i].Value)
+			} else {
+				batch.Delete(entries[i].Key)
+			}
+		}
+		batch.Delete(journalKey(s))
+	}
+	log.Debug("Rolled back Fusion index sections", "from", section, "count", len(sections))
+	return batch.Write()
+}
+
+// Process implements core.ChainIndexerBackend, applying the FSN calls of a
+// block to the index.
+func (idx *Indexer) Process(ctx context.Context, header *types.Header) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return idx.applyGenesis(header)
+	}
+	hash := header.Hash()
+	body := rawdb.ReadBody(idx.chainDb, hash, number)
+	if body == nil {
+		return fmt.Errorf("block body #%d [%x..] not found", number, hash[:4])
+	}
+	if len(body.Transactions) == 0 {
+		return nil
+	}
+	receipts := rawdb.ReadReceipts(idx.chainDb, hash, number, idx.config)
+	if len(receipts) != len(body.Transactions) {
+		return fmt.Errorf("block receipts #%d [%x..] not found", number, hash[:4])
+	}
+	signer := types.MakeSigner(idx.config, header.Number)
+	for i, tx := range body.Transactions {
+		if !common.IsFsnCall(tx.To()) {
+			// swaps made and taken by contracts through the FSN contract
+			for _, l := range receipts[i].Logs {
+				if l.Address != vm.FSNContractAddress {
+					continue
+				}
+				if err := idx.applyContractLog(l, header.Time); err != nil {
+					return fmt.Errorf("block #%d tx %x: %v", number, tx.Hash(), err)
+				}
+			}
+			continue
+		}
+		var param common.FSNCallParam
+		if err := rlp.DecodeBytes(tx.Data(), &param); err != nil {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		if err := idx.applyCallLogs(header, tx, from, &param, receipts[i].Logs); err != nil {
+			return fmt.Errorf("block #%d tx %x: %v", number, tx.Hash(), err)
+		}
+	}
+	return nil
+}
+
+// applyGenesis indexes the system asset and the assets allocated by the
+// genesis specification.
+func (idx *Indexer) applyGenesis(header *types.Header) error {
+	if err := idx.putAsset(common.SystemAsset); err != nil {
+		return err
+	}
+	for _, asset := range rawdb.ReadGenesisAssets(idx.chainDb, header.Hash()) {
+		if err := idx.putAsset(asset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyCallLogs applies the FSN call logs of a transaction in order. Most
+// calls emit a single log, but a batch call emits one per entry.
+func (idx *Indexer) applyCallLogs(header *types.Header, tx *types.Transaction, from common.Address, param *common.FSNCallParam, logs []*types.Log) error {
+	for _, l := range logs {
+		if l.Address != common.FSNCallAddress || len(l.Topics) == 0 {
+			continue
+		}
+		funcType, data := common.FSNCallFunc(l.Topics[0][common.HashLength-1]), l.Data
+		if fsnlog.IsStructured(l) {
+			// structured logs decode to the fields of the legacy logs
+			decoded, err := fsnlog.Decode(l)
+			if err != nil {
+				continue
+			}
+			if data, err = json.Marshal(decoded.Fields); err != nil {
+				return err
+			}
+			funcType = decoded.Func
+		}
+		var err error
+		if funcType == common.ReportIllegalFunc {
+			err = idx.applyReport(header, tx, from, param.Data, data)
+		} else {
+			err = idx.applyLog(from, funcType, data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the section changes and
+// their undo journal into the database. The journal is written even if empty,
+// so that a rollback can tell a pruned journal from an unchanged section.
+func (idx *Indexer) Commit() error {
+	batch := idx.db.NewBatch()
+	for key, value := range idx.dirty {
+		if value == nil {
+			batch.Delete([]byte(key))
+		} else {
+			batch.Put([]byte(key), value)
+		}
+	}
+	data, err := rlp.EncodeToBytes(idx.journal)
+	if err != nil {
+		return err
+	}
+	batch.Put(journalKey(idx.section), data)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	if idx.section+1 > journalSections {
+		return idx.Prune(idx.section + 1 - journalSections)
+	}
+	return nil
+}
+
+// Prune deletes the undo journals of the sections below the threshold, which
+// can't be rolled back afterwards.
+func (idx *Indexer) Prune(threshold uint64) error {
+	it := idx.db.NewIterator(journalPrefix, nil)
+	defer it.Release()
+
+	batch := idx.db.NewBatch()
+	for it.Next() {
+		if binary.BigEndian.Uint64(it.Key()[len(journalPrefix):]) >= threshold {
+			break
+		}
+		batch.Delete(common.CopyBytes(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// fsn call log contents, the params are logged with their fields flattened
+// next to the extra key values added by the state transition.
+type (
+	genAssetLog struct {
+		common.GenAssetParam
+		AssetID common.Hash
+		Error   string
+	}
+	assetValueChangeLog struct {
+		common.AssetValueChangeExParam
+		Error string
+	}
+	transferAssetOwnerLog struct {
+		common.TransferAssetOwnerParam
+		Error string
+	}
+	updateAssetLog struct {
+		common.UpdateAssetParam
+		Error string
+	}
+	makeSwapLog struct {
+		common.MakeSwapParam
+		SwapID common.Hash
+		Error  string
+	}
+	makeMultiSwapLog struct {
+		common.MakeMultiSwapParam
+		SwapID common.Hash
+		Error  string
+	}
+	swapIDLog struct {
+		SwapID  common.Hash
+		Size    *big.Int
+		Deleted logBool
+		Error   string
+	}
+)
+
+// logBool is a boolean logged as a string by the legacy logs.
+type logBool bool
+
+func (b *logBool) UnmarshalJSON(input []byte) error {
+	*b = logBool(string(input) == "true" || string(input) == `"true"`)
+	return nil
+}
+
+// applyLog updates the index with the outcome of one FSN call.
+func (idx *Indexer) applyLog(from common.Address, funcType common.FSNCallFunc, data []byte) error {
+	switch funcType {
+	case common.GenAssetFunc:
+		var l genAssetLog
+		if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+			return nil
+		}
+		asset := l.ToAsset()
+		asset.ID = l.AssetID
+		asset.Owner = from
+		return idx.putAsset(asset)
+
+	case common.AssetValueChangeFunc:
+		var l assetValueChangeLog
+		if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+			return nil
+		}
+		asset, err := idx.getAsset(l.AssetID)
+		if asset == nil || err != nil {
+			return err
+		}
+		if l.IsInc {
+			asset.Total = new(big.Int).Add(asset.Total, l.Value)
+		} else {
+			asset.Total = new(big.Int).Sub(asset.Total, l.Value)
+		}
+		return idx.putAsset(*asset)
+
+	case common.TransferAssetOwnerFunc:
+		var l transferAssetOwnerLog
+		if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+			return nil
+		}
+		asset, err := idx.getAsset(l.AssetID)
+		if asset == nil || err != nil {
+			return err
+		}
+		if err := idx.set(assetOwnerKey(asset.Owner, asset.ID), nil); err != nil {
+			return err
+		}
+		asset.Owner = l.To
+		return idx.putAsset(*asset)
+
+	case common.UpdateAssetFunc:
+		var l updateAssetLog
+		if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+			return nil
+		}
+		asset, err := idx.getAsset(l.AssetID)
+		if asset == nil || err != nil {
+			return err
+		}
+		asset.Description = l.Description
+		asset.CanChange = l.CanChange
+		return idx.putAsset(*asset)
+
+	case common.MakeSwapFunc, common.MakeSwapFuncExt:
+		var l makeSwapLog
+		if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+			return nil
+		}
+		return idx.putSwap(&common.Swap{
+			ID:            l.SwapID,
+			Owner:         from,
+			FromAssetID:   l.FromAssetID,
+			FromStartTime: l.FromStartTime,
+			FromEndTime:   l.FromEndTime,
+			MinFromAmount: l.MinFromAmount,
+			ToAssetID:     l.ToAssetID,
+			ToStartTime:   l.ToStartTime,
+			ToEndTime:     l.ToEndTime,
+			MinToAmount:   l.MinToAmount,
+			SwapSize:      l.SwapSize,
+			Targes:        l.Targes,
+			Time:          l.Time,
+			Description:   l.Description,
+		}, true)
+
+	case common.MakeMultiSwapFunc:
+		var l makeMultiSwapLog
+		if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+			return nil
+		}
+		return idx.putMultiSwap(&common.MultiSwap{
+			ID:            l.SwapID,
+			Owner:         from,
+			FromAssetID:   l.FromAssetID,
+			FromStartTime: l.FromStartTime,
+			FromEndTime:   l.FromEndTime,
+			MinFromAmount: l.MinFromAmount,
+			ToAssetID:     l.ToAssetID,
+			ToStartTime:   l.ToStartTime,
+			ToEndTime:     l.ToEndTime,
+			MinToAmount:   l.MinToAmount,
+			SwapSize:      l.SwapSize,
+			Targes:        l.Targes,
+			Time:          l.Time,
+			Description:   l.Description,
+		}, true)
+
+	case common.RecallSwapFunc, common.TakeSwapFunc, common.TakeSwapFuncExt:
+		var l swapIDLog
+		if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+			return nil
+		}
+		swap, err := idx.getSwap(l.SwapID)
+		if swap == nil || err != nil {
+			return err
+		}
+		if funcType == common.RecallSwapFunc || bool(l.Deleted) {
+			return idx.deleteSwap(swap)
+		}
+		swap.SwapSize = new(big.Int).Sub(swap.SwapSize, l.Size)
+		return idx.putSwap(swap, false)
+
+	case common.RecallMultiSwapFunc, common.TakeMultiSwapFunc:
+		var l swapIDLog
+		if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+			return nil
+		}
+		swap, err := idx.getMultiSwap(l.SwapID)
+		if swap == nil || err != nil {
+			return err
+		}
+		if funcType == common.RecallMultiSwapFunc || bool(l.Deleted) {
+			return idx.deleteMultiSwap(swap)
+		}
+		swap.SwapSize = new(big.Int).Sub(swap.SwapSize, l.Size)
+		return idx.putMultiSwap(swap, false)
+	}
+	return nil
+}
+
+// applyContractLog updates the index with a swap event of the FSN contract.
+// The events carry the swap id and the calling contract as topics, the data
+// of a made swap is the abi encoding of the swap fields.
+func (idx *Indexer) applyContractLog(l *types.Log, time uint64) error {
+	if len(l.Topics) != 3 {
+		return nil
+	}
+	var (
+		id   = l.Topics[1]
+		from = common.BytesToAddress(l.Topics[2].Bytes())
+		word = func(i int) []byte { return common.GetData(l.Data, uint64(i*32), 32) }
+	)
+	switch l.Topics[0] {
+	case common.LogFusionSwapMadeTopic:
+		swap := &common.Swap{
+			ID:            id,
+			Owner:         from,
+			FromAssetID:   common.BytesToHash(word(0)),
+			FromStartTime: new(big.Int).SetBytes(word(1)).Uint64(),
+			FromEndTime:   new(big.Int).SetBytes(word(2)).Uint64(),
+			MinFromAmount: new(big.Int).SetBytes(word(3)),
+			ToAssetID:     common.BytesToHash(word(4)),
+			ToStartTime:   new(big.Int).SetBytes(word(5)).Uint64(),
+			ToEndTime:     new(big.Int).SetBytes(word(6)).Uint64(),
+			MinToAmount:   new(big.Int).SetBytes(word(7)),
+			SwapSize:      new(big.Int).SetBytes(word(8)),
+			Time:          new(big.Int).SetUint64(time),
+		}
+		if target := common.BytesToAddress(word(9)); target != (common.Address{}) {
+			swap.Targes = []common.Address{target}
+		}
+		return idx.putSwap(swap, true)
+
+	case common.LogFusionSwapRecalledTopic, common.LogFusionSwapTakenTopic:
+		swap, err := idx.getSwap(id)
+		if swap == nil || err != nil {
+			return err
+		}
+		if l.Topics[0] == common.LogFusionSwapRecalledTopic || new(big.Int).SetBytes(word(1)).Sign() != 0 {
+			return idx.deleteSwap(swap)
+		}
+		swap.SwapSize = new(big.Int).Sub(swap.SwapSize, new(big.Int).SetBytes(word(0)))
+		return idx.putSwap(swap, false)
+	}
+	return nil
+}
+
+// get reads a key through the pending changes of the current section.
+func (idx *Indexer) get(key []byte) ([]byte, error) {
+	if value, ok := idx.dirty[string(key)]; ok {
+		return value, nil
+	}
+	if ok, err := idx.db.Has(key); !ok || err != nil {
+		return nil, err
+	}
+	return idx.db.Get(key)
+}
+
+// set stages a write (or a deletion if value is nil), journaling the original
+// value on the first modification of the key within the section.
+func (idx *Indexer) set(key []byte, value []byte) error {
+	if _, ok := idx.dirty[string(key)]; !ok {
+		prev, err := idx.get(key)
+		if err != nil {
+			return err
+		}
+		idx.journal = append(idx.journal, journalEntry{
+			Key:    common.CopyBytes(key),
+			Value:  prev,
+			Exists: prev != nil,
+		})
+	}
+	idx.dirty[string(key)] = value
+	return nil
+}
+
+func (idx *Indexer) getAsset(id common.Hash) (*common.Asset, error) {
+	data, err := idx.get(assetKey(id))
+	if data == nil || err != nil {
+		return nil, err
+	}
+	asset := new(common.Asset)
+	if err := rlp.DecodeBytes(data, asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+func (idx *Indexer) putAsset(asset common.Asset) error {
+	data, err := rlp.EncodeToBytes(&asset)
+	if err != nil {
+		return err
+	}
+	if err := idx.set(assetKey(asset.ID), data); err != nil {
+		return err
+	}
+	return idx.set(assetOwnerKey(asset.Owner, asset.ID), marker)
+}
+
+func (idx *Indexer) getSwap(id common.Hash) (*common.Swap, error) {
+	data, err := idx.get(swapKey(kindSwap, id))
+	if data == nil || err != nil {
+		return nil, err
+	}
+	swap := new(common.Swap)
+	if err := rlp.DecodeBytes(data, swap); err != nil {
+		return nil, err
+	}
+	return swap, nil
+}
+
+// putSwap stores a swap, adding the secondary index entries for new swaps.
+func (idx *Indexer) putSwap(swap *common.Swap, isNew bool) error {
+	data, err := rlp.EncodeToBytes(swap)
+	if err != nil {
+		return err
+	}
+	if err := idx.set(swapKey(kindSwap, swap.ID), data); err != nil {
+		return err
+	}
+	if !isNew {
+		return nil
+	}
+	return idx.setSwapLookups(kindSwap, swap.ID, swap.Owner, []common.Hash{swap.FromAssetID}, []common.Hash{swap.ToAssetID}, swap.Targes, marker)
+}
+
+func (idx *Indexer) deleteSwap(swap *common.Swap) error {
+	if err := idx.set(swapKey(kindSwap, swap.ID), nil); err != nil {
+		return err
+	}
+	return idx.setSwapLookups(kindSwap, swap.ID, swap.Owner, []common.Hash{swap.FromAssetID}, []common.Hash{swap.ToAssetID}, swap.Targes, nil)
+}
+
+func (idx *Indexer) getMultiSwap(id common.Hash) (*common.MultiSwap, error) {
+	data, err := idx.get(swapKey(kindMultiSwap, id))
+	if data == nil || err != nil {
+		return nil, err
+	}
+	swap := new(common.MultiSwap)
+	if err := rlp.DecodeBytes(data, swap); err != nil {
+		return nil, err
+	}
+	return swap, nil
+}
+
+// putMultiSwap stores a multi swap, adding the secondary index entries for new swaps.
+func (idx *Indexer) putMultiSwap(swap *common.MultiSwap, isNew bool) error {
+	data, err := rlp.EncodeToBytes(swap)
+	if err != nil {
+		return err
+	}
+	if err := idx.set(swapKey(kindMultiSwap, swap.ID), data); err != nil {
+		return err
+	}
+	if !isNew {
+		return nil
+	}
+	return idx.setSwapLookups(kindMultiSwap, swap.ID, swap.Owner, swap.FromAssetID, swap.ToAssetID, swap.Targes, marker)
+}
+
+func (idx *Indexer) deleteMultiSwap(swap *common.MultiSwap) error {
+	if err := idx.set(swapKey(kindMultiSwap, swap.ID), nil); err != nil {
+		return err
+	}
+	return idx.setSwapLookups(kindMultiSwap, swap.ID, swap.Owner, swap.FromAssetID, swap.ToAssetID, swap.Targes, nil)
+}
+
+// setSwapLookups writes (or deletes if value is nil) the owner, asset pair and
+// target lookups of a swap.
+func (idx *Indexer) setSwapLookups(kind byte, id common.Hash, owner common.Address, from, to []common.Hash, targets []common.Address, value []byte) error {
+	keys := [][]byte{swapOwnerKey(kind, owner, id)}
+	for _, f := range from {
+		for _, t := range to {
+			keys = append(keys, swapPairKey(kind, f, t, id))
+		}
+	}
+	for _, target := range targets {
+		keys = append(keys, swapTargetKey(kind, target, id))
+	}
+	for _, key := range keys {
+		if err := idx.set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
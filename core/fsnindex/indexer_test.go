@@ -0,0 +1,530 @@
+package fsnindex
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/core/fsnlog"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/crypto"
+	"github.com/FusionFoundation/efsn/params"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+func mustLog(t *testing.T, value map[string]interface{}) []byte {
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+const testSectionSize = 8
+
+func newTestIndexer() (*Indexer, *Index) {
+	db := rawdb.NewMemoryDatabase()
+	indexer := &Indexer{
+		chainDb: db,
+		db:      rawdb.NewTable(db, string(rawdb.FsnIndexPrefix)),
+		config:  params.TestChainConfig,
+	}
+	return indexer, NewIndex(db, testSectionSize)
+}
+
+func TestIndexerAssetsAndSwaps(t *testing.T) {
+	indexer, index := newTestIndexer()
+
+	var (
+		owner   = common.HexToAddress("0x01")
+		taker   = common.HexToAddress("0x02")
+		assetID = common.HexToHash("0xaa")
+		swapID  = common.HexToHash("0xbb")
+	)
+	// section 0: create an asset and a swap selling it for FSN
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	err := indexer.applyLog(owner, common.GenAssetFunc, mustLog(t, map[string]interface{}{
+		"Name": "Test", "Symbol": "TST", "Decimals": 18, "Total": 1000, "AssetID": assetID,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = indexer.applyLog(owner, common.MakeSwapFunc, mustLog(t, map[string]interface{}{
+		"FromAssetID": assetID, "ToAssetID": common.SystemAssetID, "MinFromAmount": 1,
+		"MinToAmount": 2, "SwapSize": 10, "Targes": []common.Address{taker}, "SwapID": swapID,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	assets, err := index.Assets(AssetFilter{Owner: &owner})
+	if err != nil || len(assets) != 1 || assets[0].ID != assetID {
+		t.Fatalf("owner assets mismatch: have %v, err %v", assets, err)
+	}
+	from := assetID
+	swaps, err := index.Swaps(SwapFilter{FromAssetID: &from, Target: &taker})
+	if err != nil || len(swaps) != 1 || swaps[0].ID != swapID {
+		t.Fatalf("pair swaps mismatch: have %v, err %v", swaps, err)
+	}
+
+	// section 1: take part of the swap, then reorg it away
+	if err := indexer.Reset(nil, 1, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	err = indexer.applyLog(taker, common.TakeSwapFunc, mustLog(t, map[string]interface{}{
+		"SwapID": swapID, "Size": 4, "Deleted": "false",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if swap, err := index.GetSwap(swapID); err != nil || swap.SwapSize.Cmp(big.NewInt(6)) != 0 {
+		t.Fatalf("swap size mismatch after take: have %v, err %v", swap, err)
+	}
+	if err := indexer.Reset(nil, 1, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if swap, err := index.GetSwap(swapID); err != nil || swap.SwapSize.Cmp(big.NewInt(10)) != 0 {
+		t.Fatalf("swap size mismatch after rollback: have %v, err %v", swap, err)
+	}
+
+	// rolling back section 0 removes everything
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if assets, _ := index.Assets(AssetFilter{}); len(assets) != 0 {
+		t.Fatalf("assets left after rollback: %v", assets)
+	}
+	if swaps, _ := index.Swaps(SwapFilter{Owner: &owner}); len(swaps) != 0 {
+		t.Fatalf("swaps left after rollback: %v", swaps)
+	}
+}
+
+func TestIndexerAssetOwnerTransfer(t *testing.T) {
+	indexer, index := newTestIndexer()
+
+	var (
+		owner    = common.HexToAddress("0x01")
+		newOwner = common.HexToAddress("0x02")
+		assetID  = common.HexToHash("0xaa")
+	)
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	err := indexer.applyLog(owner, common.GenAssetFunc, mustLog(t, map[string]interface{}{
+		"Name": "Test", "Symbol": "TST", "Decimals": 18, "Total": 1000, "CanChange": true, "AssetID": assetID,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = indexer.applyLog(owner, common.TransferAssetOwnerFunc, mustLog(t, map[string]interface{}{
+		"AssetID": assetID, "To": newOwner,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = indexer.applyLog(newOwner, common.UpdateAssetFunc, mustLog(t, map[string]interface{}{
+		"AssetID": assetID, "Description": "https://example.org", "CanChange": false,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if assets, _ := index.Assets(AssetFilter{Owner: &owner}); len(assets) != 0 {
+		t.Fatalf("assets left to the previous owner: %v", assets)
+	}
+	assets, err := index.Assets(AssetFilter{Owner: &newOwner})
+	if err != nil || len(assets) != 1 || assets[0].ID != assetID {
+		t.Fatalf("new owner assets mismatch: have %v, err %v", assets, err)
+	}
+	if asset := assets[0]; asset.Owner != newOwner || asset.CanChange || asset.Description != "https://example.org" {
+		t.Fatalf("updated asset mismatch: have %+v", asset)
+	}
+}
+
+func TestIndexPaging(t *testing.T) {
+	indexer, index := newTestIndexer()
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		asset := common.Asset{ID: common.BigToHash(big.NewInt(int64(i))), Total: big.NewInt(1)}
+		if err := indexer.putAsset(asset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for page, want := range []int{2, 2, 1, 0} {
+		assets, err := index.Assets(AssetFilter{Page: uint64(page), PageSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(assets) != want {
+			t.Errorf("page %d: have %d assets, want %d", page, len(assets), want)
+		}
+	}
+}
+
+func TestIndexAll(t *testing.T) {
+	indexer, index := newTestIndexer()
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		owner = common.HexToAddress("0x01")
+		other = common.HexToAddress("0x02")
+	)
+	// more entries than a page, all of them are read in one pass
+	const count = MaxPageSize + 5
+	for i := 1; i <= count; i++ {
+		id := common.BigToHash(big.NewInt(int64(i)))
+		holder := owner
+		if i%2 == 0 {
+			holder = other
+		}
+		if err := indexer.putAsset(common.Asset{ID: id, Owner: holder, Total: big.NewInt(1)}); err != nil {
+			t.Fatal(err)
+		}
+		swap := &common.Swap{ID: id, Owner: holder, FromAssetID: id, ToAssetID: common.SystemAssetID, MinFromAmount: big.NewInt(1), MinToAmount: big.NewInt(1), SwapSize: big.NewInt(1)}
+		if err := indexer.putSwap(swap, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if assets, err := index.AllAssets(nil); err != nil || len(assets) != count {
+		t.Fatalf("asset count mismatch: have %d, want %d, err %v", len(assets), count, err)
+	}
+	assets, err := index.AllAssets(&owner)
+	if err != nil || len(assets) != count/2+1 {
+		t.Fatalf("owner asset count mismatch: have %d, want %d, err %v", len(assets), count/2+1, err)
+	}
+	for id, asset := range assets {
+		if asset.ID != id || asset.Owner != owner {
+			t.Fatalf("asset %x mismatch: have %+v", id, asset)
+		}
+	}
+	if swaps, err := index.AllSwaps(SwapFilter{}); err != nil || len(swaps) != count {
+		t.Fatalf("swap count mismatch: have %d, want %d, err %v", len(swaps), count, err)
+	}
+	swaps, err := index.AllSwaps(SwapFilter{Owner: &other})
+	if err != nil || len(swaps) != count/2 {
+		t.Fatalf("owner swap count mismatch: have %d, want %d, err %v", len(swaps), count/2, err)
+	}
+	for id, swap := range swaps {
+		if swap.ID != id || swap.Owner != other {
+			t.Fatalf("swap %x mismatch: have %+v", id, swap)
+		}
+	}
+}
+
+func TestIndexerContractSwaps(t *testing.T) {
+	indexer, index := newTestIndexer()
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		contract = common.HexToAddress("0x03")
+		assetID  = common.HexToHash("0xaa")
+		swapID   = common.HexToHash("0xcc")
+		word     = func(v uint64) []byte { return common.BigToHash(new(big.Int).SetUint64(v)).Bytes() }
+		data     []byte
+	)
+	data = append(data, assetID.Bytes()...)
+	data = append(data, word(0)...)
+	data = append(data, word(common.TimeLockForever)...)
+	data = append(data, word(1)...)
+	data = append(data, common.SystemAssetID.Bytes()...)
+	data = append(data, word(0)...)
+	data = append(data, word(common.TimeLockForever)...)
+	data = append(data, word(2)...)
+	data = append(data, word(10)...)
+	data = append(data, word(0)...)
+
+	topics := func(topic common.Hash) []common.Hash {
+		return []common.Hash{topic, swapID, common.BytesToHash(contract.Bytes())}
+	}
+	if err := indexer.applyContractLog(&types.Log{Topics: topics(common.LogFusionSwapMadeTopic), Data: data}, 1000); err != nil {
+		t.Fatal(err)
+	}
+	taken := append(word(3), word(0)...)
+	if err := indexer.applyContractLog(&types.Log{Topics: topics(common.LogFusionSwapTakenTopic), Data: taken}, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	swaps, err := index.Swaps(SwapFilter{Owner: &contract})
+	if err != nil || len(swaps) != 1 {
+		t.Fatalf("contract swaps mismatch: have %v, err %v", swaps, err)
+	}
+	if swap := swaps[0]; swap.ID != swapID || swap.FromAssetID != assetID || swap.SwapSize.Cmp(big.NewInt(7)) != 0 || len(swap.Targes) != 0 {
+		t.Fatalf("contract swap mismatch: have %+v", swap)
+	}
+
+	if err := indexer.Reset(nil, 1, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.applyContractLog(&types.Log{Topics: topics(common.LogFusionSwapRecalledTopic)}, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if swap, _ := index.GetSwap(swapID); swap != nil {
+		t.Fatalf("recalled swap still indexed: %v", swap)
+	}
+}
+
+func testReport(miner common.Address, number int64) []byte {
+	header1 := &types.Header{Coinbase: miner, Number: big.NewInt(number), Difficulty: big.NewInt(1), Extra: []byte{1}}
+	header2 := &types.Header{Coinbase: miner, Number: big.NewInt(number), Difficulty: big.NewInt(1), Extra: []byte{2}}
+	data1, _ := rlp.EncodeToBytes(header1)
+	data2, _ := rlp.EncodeToBytes(header2)
+	if bytes.Compare(data1, data2) > 0 {
+		data1, data2 = data2, data1
+	}
+	report := append(common.IntToBytes(len(data1)), data1...)
+	return append(report, data2...)
+}
+
+func TestIndexerReports(t *testing.T) {
+	indexer, index := newTestIndexer()
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		reporter = common.HexToAddress("0x01")
+		miner1   = common.HexToAddress("0x02")
+		miner2   = common.HexToAddress("0x03")
+		miner3   = common.HexToAddress("0x04")
+		ticket   = common.HexToHash("0xdd")
+		parent   = &types.Header{Number: big.NewInt(9), Time: 1000, Difficulty: big.NewInt(1)}
+		header   = &types.Header{Number: big.NewInt(10), Time: 1015, ParentHash: parent.Hash(), Difficulty: big.NewInt(1)}
+		tx1      = types.NewTransaction(0, common.FSNCallAddress, nil, 0, nil, nil)
+		tx2      = types.NewTransaction(1, common.FSNCallAddress, nil, 0, nil, nil)
+		tx3      = types.NewTransaction(2, common.FSNCallAddress, nil, 0, nil, nil)
+	)
+	rawdb.WriteHeader(indexer.chainDb, parent)
+
+	// legacy log with a deleted ticket, structured log without tickets
+	enc, _ := rlp.EncodeToBytes([]common.Hash{ticket})
+	report1 := testReport(miner1, 5)
+	if err := indexer.applyReport(header, tx1, reporter, report1, mustLog(t, map[string]interface{}{
+		"Base": "", "DeleteTickets": hexutil.Encode(enc),
+	})); err != nil {
+		t.Fatal(err)
+	}
+	report2 := testReport(miner2, 6)
+	if err := indexer.applyReport(header, tx2, reporter, report2, mustLog(t, map[string]interface{}{
+		"DeleteTickets": []common.Hash{},
+	})); err != nil {
+		t.Fatal(err)
+	}
+	// structured log of the time lock taken from a miner short of balance
+	topics, data, err := fsnlog.Encode(common.ReportIllegalFunc, reporter, map[string]interface{}{
+		"DeleteTickets":    []common.Hash{},
+		"PenaltyStartTime": []uint64{parent.Time},
+		"PenaltyEndTime":   []uint64{parent.Time + 100},
+		"PenaltyValue":     []*big.Int{big.NewInt(7)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := fsnlog.Decode(&types.Log{Address: common.FSNCallAddress, Topics: topics, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report3 := testReport(miner3, 7)
+	if err := indexer.applyReport(header, tx3, reporter, report3, mustLog(t, decoded.Fields)); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := index.GetReport(crypto.Keccak256Hash(report1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Miner != miner1 || r.Reporter != reporter || r.TxHash != tx1.Hash() || r.Header1.Number.Uint64() != 5 ||
+		len(r.DeletedTickets) != 1 || r.DeletedTickets[0] != ticket || r.TimeLockPenalty != nil {
+		t.Fatalf("report mismatch: have %+v", r)
+	}
+	reports, err := index.Reports(ReportFilter{Miner: &miner2})
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("miner reports mismatch: have %v, err %v", reports, err)
+	}
+	// the legacy logs are indexed with the nominal penalty
+	penalty := reports[0].TimeLockPenalty
+	if len(reports[0].DeletedTickets) != 0 || penalty == nil || len(penalty.Items) != 1 || penalty.Items[0].StartTime != parent.Time ||
+		penalty.Items[0].Value.Cmp(common.TicketPrice(header.Number)) != 0 {
+		t.Fatalf("penalty mismatch: have %+v", reports[0])
+	}
+	r, err = index.GetReport(crypto.Keccak256Hash(report3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.NewTimeLock(&common.TimeLockItem{StartTime: parent.Time, EndTime: parent.Time + 100, Value: big.NewInt(7)})
+	if r.Miner != miner3 || r.TimeLockPenalty == nil || !r.TimeLockPenalty.EqualTo(want) {
+		t.Fatalf("taken penalty mismatch: have %v, want %v", r.TimeLockPenalty, want)
+	}
+	if reports, _ := index.Reports(ReportFilter{PageSize: 1, Page: 1}); len(reports) != 1 {
+		t.Fatalf("report page mismatch: have %v", reports)
+	}
+
+	// an index of another version is wiped
+	indexer.db.Put(versionKey, []byte{0, 0, 0, 0, 0, 0, 0, 1})
+	if err := checkVersion(indexer.db); err != nil {
+		t.Fatal(err)
+	}
+	if reports, _ := index.Reports(ReportFilter{}); len(reports) != 0 {
+		t.Fatalf("reports left after version change: %v", reports)
+	}
+}
+
+func legacyLog(t *testing.T, funcType common.FSNCallFunc, value map[string]interface{}) *types.Log {
+	topic := common.Hash{}
+	topic[common.HashLength-1] = uint8(funcType)
+	return &types.Log{Address: common.FSNCallAddress, Topics: []common.Hash{topic}, Data: mustLog(t, value)}
+}
+
+func TestIndexerMultiLogCall(t *testing.T) {
+	indexer, index := newTestIndexer()
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		owner  = common.HexToAddress("0x01")
+		asset1 = common.HexToHash("0xaa")
+		asset2 = common.HexToHash("0xbb")
+		header = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+		tx     = types.NewTransaction(0, common.FSNCallAddress, nil, 0, nil, nil)
+		param  = &common.FSNCallParam{Func: common.GenAssetFunc}
+	)
+	logs := []*types.Log{
+		legacyLog(t, common.GenAssetFunc, map[string]interface{}{"Name": "One", "Total": 1, "AssetID": asset1}),
+		{Address: common.HexToAddress("0x02"), Topics: []common.Hash{{}}},
+		legacyLog(t, common.GenAssetFunc, map[string]interface{}{"Name": "Two", "Total": 2, "AssetID": asset2}),
+		legacyLog(t, common.UpdateAssetFunc, map[string]interface{}{"AssetID": asset1, "Description": "updated"}),
+	}
+	if err := indexer.applyCallLogs(header, tx, owner, param, logs); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	assets, err := index.Assets(AssetFilter{Owner: &owner})
+	if err != nil || len(assets) != 2 {
+		t.Fatalf("assets of a multi log call mismatch: have %v, err %v", assets, err)
+	}
+	if asset, _ := index.GetAsset(asset1); asset == nil || asset.Description != "updated" {
+		t.Fatalf("later log of the call not applied: have %+v", asset)
+	}
+}
+
+func TestIndexerGenesisAssets(t *testing.T) {
+	indexer, index := newTestIndexer()
+
+	var (
+		owner   = common.HexToAddress("0x01")
+		genesis = &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+		asset   = common.Asset{ID: common.HexToHash("0xaa"), Owner: owner, Name: "Genesis", Total: big.NewInt(100)}
+	)
+	rawdb.WriteGenesisAssets(indexer.chainDb, genesis.Hash(), []common.Asset{asset})
+
+	if err := indexer.Reset(nil, 0, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Process(nil, genesis); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if have, err := index.GetAsset(common.SystemAssetID); err != nil || have.Name != common.SystemAsset.Name {
+		t.Fatalf("system asset mismatch: have %v, err %v", have, err)
+	}
+	assets, err := index.Assets(AssetFilter{Owner: &owner})
+	if err != nil || len(assets) != 1 || assets[0].Name != "Genesis" {
+		t.Fatalf("genesis assets mismatch: have %v, err %v", assets, err)
+	}
+}
+
+func TestIndexerJournalPruning(t *testing.T) {
+	indexer, index := newTestIndexer()
+	if err := checkVersion(indexer.db); err != nil {
+		t.Fatal(err)
+	}
+
+	sections := uint64(journalSections + 2)
+	for section := uint64(0); section < sections; section++ {
+		if err := indexer.Reset(nil, section, common.Hash{}); err != nil {
+			t.Fatal(err)
+		}
+		asset := common.Asset{ID: common.BigToHash(new(big.Int).SetUint64(section + 1)), Total: big.NewInt(1)}
+		if err := indexer.putAsset(asset); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for section := uint64(0); section < sections; section++ {
+		has, _ := indexer.db.Has(journalKey(section))
+		if want := section >= sections-journalSections; has != want {
+			t.Errorf("section %d: journal present %v, want %v", section, has, want)
+		}
+	}
+
+	// a reorg within the journal history is rolled back
+	if err := indexer.Reset(nil, sections-1, common.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if assets, _ := index.Assets(AssetFilter{PageSize: MaxPageSize}); uint64(len(assets)) != sections-1 {
+		t.Fatalf("assets after a shallow rollback: have %d, want %d", len(assets), sections-1)
+	}
+	// a deeper one can't be undone and wipes the index for a rebuild
+	if err := indexer.Reset(nil, 1, common.Hash{}); err == nil {
+		t.Fatal("reorg beyond the journal history accepted")
+	}
+	if assets, _ := index.Assets(AssetFilter{}); len(assets) != 0 {
+		t.Fatalf("assets left after a deep reorg: %d", len(assets))
+	}
+	if _, err := indexer.db.Get(versionKey); err != nil {
+		t.Fatalf("index version lost on rebuild: %v", err)
+	}
+}
+
+func TestIndexHead(t *testing.T) {
+	_, index := newTestIndexer()
+	if _, _, ok := index.Head(); ok {
+		t.Fatal("empty index has a head")
+	}
+	head := common.HexToHash("0xaa")
+	index.db.Put([]byte("count"), []byte{0, 0, 0, 0, 0, 0, 0, 2})
+	index.db.Put(append([]byte("shead"), 0, 0, 0, 0, 0, 0, 0, 1), head.Bytes())
+
+	number, hash, ok := index.Head()
+	if !ok || number != 2*testSectionSize-1 || hash != head {
+		t.Fatalf("index head mismatch: have #%d %x %v", number, hash, ok)
+	}
+}
@@ -0,0 +1,232 @@
+package fsnindex
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/crypto"
+	"github.com/FusionFoundation/efsn/ethdb"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+// Report is a double-sign report accepted by the chain. The miner of the two
+// headers lost the deleted tickets, and the time lock penalty if it had not
+// enough tickets, as far as its FSN time lock balance covered the penalty.
+// The penalty is the time lock taken as logged, the nominal penalty for the
+// legacy logs which don't log it.
+type Report struct {
+	Hash            common.Hash      `json:"hash"` // keccak256 of the report, as stored by the state
+	TxHash          common.Hash      `json:"txHash"`
+	BlockNumber     uint64           `json:"blockNumber"`
+	BlockHash       common.Hash      `json:"blockHash"`
+	Reporter        common.Address   `json:"reporter"`
+	Miner           common.Address   `json:"miner"`
+	Header1         *types.Header    `json:"header1"`
+	Header2         *types.Header    `json:"header2"`
+	DeletedTickets  []common.Hash    `json:"deletedTickets"`
+	TimeLockPenalty *common.TimeLock `json:"timeLockPenalty" rlp:"nil"`
+}
+
+// ReportFilter selects a page of indexed reports, in block order.
+type ReportFilter struct {
+	Miner    *common.Address `json:"miner"`
+	Page     uint64          `json:"page"`
+	PageSize uint64          `json:"pageSize"`
+}
+
+func reportKey(number uint64, hash common.Hash) []byte {
+	key := make([]byte, len(reportPrefix)+8+common.HashLength)
+	n := copy(key, reportPrefix)
+	binary.BigEndian.PutUint64(key[n:], number)
+	copy(key[n+8:], hash[:])
+	return key
+}
+
+func reportHashKey(hash common.Hash) []byte {
+	return append(append([]byte{}, reportHashPrefix...), hash.Bytes()...)
+}
+
+func reportMinerKey(miner common.Address, number uint64, hash common.Hash) []byte {
+	key := make([]byte, len(reportMinerPrefix)+common.AddressLength+8+common.HashLength)
+	n := copy(key, reportMinerPrefix)
+	n += copy(key[n:], miner[:])
+	binary.BigEndian.PutUint64(key[n:], number)
+	copy(key[n+8:], hash[:])
+	return key
+}
+
+// GetReport retrieves an indexed report by its hash.
+func (idx *Index) GetReport(hash common.Hash) (*Report, error) {
+	data, err := idx.db.Get(reportHashKey(hash))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 8 {
+		return nil, fmt.Errorf("invalid report lookup entry")
+	}
+	return readReport(idx.db, binary.BigEndian.Uint64(data), hash)
+}
+
+// Reports returns a page of indexed reports matching the filter.
+func (idx *Index) Reports(filter ReportFilter) ([]Report, error) {
+	prefix := reportPrefix
+	if filter.Miner != nil {
+		prefix = append(append([]byte{}, reportMinerPrefix...), filter.Miner.Bytes()...)
+	}
+	it := idx.db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var (
+		p       = newPager(filter.Page, filter.PageSize)
+		reports = make([]Report, 0)
+	)
+	for it.Next() {
+		take, full := p.take()
+		if take {
+			var report *Report
+			if filter.Miner != nil {
+				key := it.Key()[len(prefix):]
+				r, err := readReport(idx.db, binary.BigEndian.Uint64(key), common.BytesToHash(key[8:]))
+				if err != nil {
+					return nil, err
+				}
+				report = r
+			} else {
+				report = new(Report)
+				if err := rlp.DecodeBytes(it.Value(), report); err != nil {
+					return nil, err
+				}
+			}
+			reports = append(reports, *report)
+		}
+		if full {
+			break
+		}
+	}
+	return reports, it.Error()
+}
+
+func readReport(db ethdb.KeyValueReader, number uint64, hash common.Hash) (*Report, error) {
+	data, err := db.Get(reportKey(number, hash))
+	if err != nil {
+		return nil, err
+	}
+	report := new(Report)
+	if err := rlp.DecodeBytes(data, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// reportLog is the log of a report. The deleted tickets are logged as the hex
+// of their rlp encoding by the legacy logs, and as a list by the structured
+// logs, which log the time lock taken from the miner too.
+type reportLog struct {
+	DeleteTickets    json.RawMessage
+	PenaltyStartTime []uint64
+	PenaltyEndTime   []uint64
+	PenaltyValue     []*big.Int
+	Error            string
+}
+
+// penalty returns the logged time lock taken from the miner, nil if none was
+// taken, and whether the log holds it.
+func (l *reportLog) penalty() (*common.TimeLock, bool, error) {
+	if l.PenaltyValue == nil {
+		return nil, false, nil
+	}
+	if len(l.PenaltyStartTime) != len(l.PenaltyValue) || len(l.PenaltyEndTime) != len(l.PenaltyValue) {
+		return nil, true, fmt.Errorf("invalid penalty items")
+	}
+	if len(l.PenaltyValue) == 0 {
+		return nil, true, nil
+	}
+	penalty := new(common.TimeLock)
+	for i, value := range l.PenaltyValue {
+		item := &common.TimeLockItem{StartTime: l.PenaltyStartTime[i], EndTime: l.PenaltyEndTime[i], Value: value}
+		penalty.Add(penalty, common.NewTimeLock(item))
+	}
+	return penalty, true, nil
+}
+
+func (l *reportLog) deletedTickets() ([]common.Hash, error) {
+	tickets := []common.Hash{}
+	if err := json.Unmarshal(l.DeleteTickets, &tickets); err == nil {
+		return tickets, nil
+	}
+	var str string
+	if err := json.Unmarshal(l.DeleteTickets, &str); err != nil {
+		return nil, fmt.Errorf("invalid deleted tickets: %v", err)
+	}
+	enc, err := hexutil.Decode(str)
+	if err != nil {
+		return nil, err
+	}
+	if err := rlp.DecodeBytes(enc, &tickets); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// applyReport indexes a report accepted by the block.
+func (idx *Indexer) applyReport(header *types.Header, tx *types.Transaction, reporter common.Address, report []byte, data []byte) error {
+	var l reportLog
+	if err := json.Unmarshal(data, &l); err != nil || l.Error != "" {
+		return nil
+	}
+	deleted, err := l.deletedTickets()
+	if err != nil {
+		return err
+	}
+	header1, header2, err := datong.DecodeReport(report)
+	if err != nil {
+		return err
+	}
+	number := header.Number.Uint64()
+	penalty, logged, err := l.penalty()
+	if err != nil {
+		return err
+	}
+	if !logged {
+		// the penalty starts at the time of the parent, like the state transition
+		parent := rawdb.ReadHeader(idx.chainDb, header.ParentHash, number-1)
+		if parent == nil {
+			return fmt.Errorf("parent header #%d [%x..] not found", number-1, header.ParentHash[:4])
+		}
+		if item := datong.ReportPenalty(len(deleted), header.Number, parent.Time); item != nil {
+			penalty = common.NewTimeLock(item)
+		}
+	}
+	r := &Report{
+		Hash:            crypto.Keccak256Hash(report),
+		TxHash:          tx.Hash(),
+		BlockNumber:     number,
+		BlockHash:       header.Hash(),
+		Reporter:        reporter,
+		Miner:           header1.Coinbase,
+		Header1:         header1,
+		Header2:         header2,
+		DeletedTickets:  deleted,
+		TimeLockPenalty: penalty,
+	}
+	enc, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		return err
+	}
+	var num [8]byte
+	binary.BigEndian.PutUint64(num[:], number)
+	if err := idx.set(reportKey(number, r.Hash), enc); err != nil {
+		return err
+	}
+	if err := idx.set(reportHashKey(r.Hash), num[:]); err != nil {
+		return err
+	}
+	return idx.set(reportMinerKey(r.Miner, number, r.Hash), marker)
+}
@@ -0,0 +1,297 @@
+// Package fsnlog implements the structured logs of the FSN calls.
+//
+// Before the FSN log v2 fork the state transition logs every FSN call with
+// the func type as the only topic and the JSON encoding of the call param
+// and its outcome as data. After the fork the logs are indexed by func type,
+// sender, receiver and asset, and carry the ABI encoding of the fields of
+// the func, so they can be selected with plain log filters.
+package fsnlog
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/FusionFoundation/efsn/accounts/abi"
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+// Version is the version of the log schema, the legacy JSON logs are version 0.
+const Version = 2
+
+// topic positions of the structured logs
+const (
+	funcTopic = iota
+	fromTopic
+	toTopic
+	assetTopic
+	numTopics
+)
+
+var errNotStructured = errors.New("not a structured FSN call log")
+
+// schema describes the log of a func type. The to and asset fields are
+// logged as topics, the other fields are ABI encoded in order as data.
+type schema struct {
+	to    string
+	asset string
+	args  abi.Arguments
+}
+
+var schemas = map[common.FSNCallFunc]*schema{
+	common.GenNotationFunc: newSchema("", "",
+		"Error", "string", "notation", "uint64"),
+	common.GenAssetFunc: newSchema("", "AssetID",
+		"Error", "string", "Name", "string", "Symbol", "string", "Decimals", "uint8",
+		"Total", "uint256", "CanChange", "bool", "Description", "string"),
+	common.SendAssetFunc: newSchema("To", "AssetID",
+		"Error", "string", "Value", "uint256"),
+	common.TimeLockFunc: newSchema("To", "AssetID",
+		"Error", "string", "LockType", "string", "Type", "uint8",
+		"StartTime", "uint64", "EndTime", "uint64", "Value", "uint256"),
+	common.BuyTicketFunc: newSchema("", "",
+		"Error", "string", "TicketID", "bytes32", "TicketOwner", "address",
+		"StartTime", "uint64", "ExpireTime", "uint64"),
+	common.AssetValueChangeFunc: newSchema("To", "AssetID",
+		"Error", "string", "Value", "uint256", "IsInc", "bool", "TransacData", "string"),
+	common.MakeSwapFunc:    makeSwapSchema,
+	common.MakeSwapFuncExt: makeSwapSchema,
+	common.RecallSwapFunc:  recallSwapSchema,
+	common.TakeSwapFunc:    takeSwapSchema,
+	common.TakeSwapFuncExt: takeSwapSchema,
+	common.MakeMultiSwapFunc: newSchema("", "",
+		"Error", "string", "SwapID", "bytes32",
+		"FromAssetID", "bytes32[]", "FromStartTime", "uint64[]", "FromEndTime", "uint64[]", "MinFromAmount", "uint256[]",
+		"ToAssetID", "bytes32[]", "ToStartTime", "uint64[]", "ToEndTime", "uint64[]", "MinToAmount", "uint256[]",
+		"SwapSize", "uint256", "Targes", "address[]", "Time", "uint256", "Description", "string"),
+	common.RecallMultiSwapFunc: recallSwapSchema,
+	common.TakeMultiSwapFunc:   takeSwapSchema,
+	// the penalty items are the time lock actually taken from the miner
+	common.ReportIllegalFunc: newSchema("", "",
+		"Error", "string", "DeleteTickets", "bytes32[]",
+		"PenaltyStartTime", "uint64[]", "PenaltyEndTime", "uint64[]", "PenaltyValue", "uint256[]"),
+	// one log per entry, or one for the entry failing the batch
+	common.BatchSendAssetFunc: newSchema("To", "AssetID",
+		"Error", "string", "Index", "uint64", "StartTime", "uint64", "EndTime", "uint64", "Value", "uint256"),
+	common.TransferAssetOwnerFunc: newSchema("To", "AssetID",
+		"Error", "string"),
+	common.UpdateAssetFunc: newSchema("", "AssetID",
+		"Error", "string", "Description", "string", "CanChange", "bool"),
+	common.TransferNotationFunc: newSchema("To", "",
+		"Error", "string", "Notation", "uint64"),
+	common.ReleaseNotationFunc: newSchema("", "",
+		"Error", "string", "Notation", "uint64"),
+}
+
+var (
+	makeSwapSchema = newSchema("", "FromAssetID",
+		"Error", "string", "SwapID", "bytes32",
+		"FromStartTime", "uint64", "FromEndTime", "uint64", "MinFromAmount", "uint256",
+		"ToAssetID", "bytes32", "ToStartTime", "uint64", "ToEndTime", "uint64", "MinToAmount", "uint256",
+		"SwapSize", "uint256", "Targes", "address[]", "Time", "uint256", "Description", "string")
+	recallSwapSchema = newSchema("", "",
+		"Error", "string", "SwapID", "bytes32")
+	takeSwapSchema = newSchema("", "",
+		"Error", "string", "SwapID", "bytes32", "Size", "uint256", "Deleted", "bool")
+	// errorSchema logs the funcs without fields of their own
+	errorSchema = newSchema("", "", "Error", "string")
+)
+
+// newSchema builds a schema from name and type pairs.
+func newSchema(to, asset string, fields ...string) *schema {
+	s := &schema{to: to, asset: asset}
+	for i := 0; i < len(fields); i += 2 {
+		typ, err := abi.NewType(fields[i+1], "", nil)
+		if err != nil {
+			panic(fmt.Sprintf("fsnlog: invalid type of %s: %v", fields[i], err))
+		}
+		s.args = append(s.args, abi.Argument{Name: fields[i], Type: typ})
+	}
+	return s
+}
+
+func schemaOf(funcType common.FSNCallFunc) *schema {
+	if s, ok := schemas[funcType]; ok {
+		return s
+	}
+	return errorSchema
+}
+
+// Topic returns the first topic of the logs of a func type, the func type
+// in the last byte and the schema version in the byte before it.
+func Topic(funcType common.FSNCallFunc) common.Hash {
+	topic := common.Hash{}
+	topic[common.HashLength-2] = Version
+	topic[common.HashLength-1] = uint8(funcType)
+	return topic
+}
+
+// ParseTopic returns the schema version and the func type of a first topic.
+func ParseTopic(topic common.Hash) (uint8, common.FSNCallFunc) {
+	return topic[common.HashLength-2], common.FSNCallFunc(topic[common.HashLength-1])
+}
+
+// IsStructured returns whether the log is a structured FSN call log.
+func IsStructured(l *types.Log) bool {
+	if l.Address != common.FSNCallAddress || len(l.Topics) != numTopics {
+		return false
+	}
+	version, _ := ParseTopic(l.Topics[funcTopic])
+	return version == Version
+}
+
+// Encode returns the topics and the data of the log of an FSN call. The
+// fields are the ones of the legacy logs, the call param fields along with
+// the outcome key values, missing fields are logged as zero values.
+func Encode(funcType common.FSNCallFunc, from common.Address, fields map[string]interface{}) ([]common.Hash, []byte, error) {
+	s := schemaOf(funcType)
+	normalize(funcType, fields)
+
+	topics := make([]common.Hash, numTopics)
+	topics[funcTopic] = Topic(funcType)
+	topics[fromTopic] = common.BytesToHash(from.Bytes())
+	if to, ok := fields[s.to].(common.Address); ok {
+		topics[toTopic] = common.BytesToHash(to.Bytes())
+	}
+	if asset, ok := fields[s.asset].(common.Hash); ok {
+		topics[assetTopic] = asset
+	}
+
+	values := make([]interface{}, len(s.args))
+	for i, arg := range s.args {
+		value, err := convert(fields[arg.Name], arg.Type)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", arg.Name, err)
+		}
+		values[i] = value
+	}
+	data, err := s.args.Pack(values...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return topics, data, nil
+}
+
+// normalize converts the legacy fields which aren't logged as is.
+func normalize(funcType common.FSNCallFunc, fields map[string]interface{}) {
+	switch funcType {
+	case common.BuyTicketFunc:
+		// the buy ticket param is logged in its rlp encoding
+		if base, ok := fields["Base"].([]byte); ok {
+			var param common.BuyTicketParam
+			if err := rlp.DecodeBytes(base, &param); err == nil {
+				fields["StartTime"] = param.Start
+				fields["ExpireTime"] = param.End
+			}
+		}
+	case common.TakeSwapFunc, common.TakeSwapFuncExt, common.TakeMultiSwapFunc:
+		if deleted, ok := fields["Deleted"].(string); ok {
+			fields["Deleted"] = deleted == "true"
+		}
+	case common.ReportIllegalFunc:
+		// the deleted tickets are logged as the hex of their rlp encoding
+		if str, ok := fields["DeleteTickets"].(string); ok {
+			var tickets []common.Hash
+			if enc, err := hexutil.Decode(str); err == nil && rlp.DecodeBytes(enc, &tickets) == nil {
+				fields["DeleteTickets"] = tickets
+			}
+		}
+	}
+}
+
+var bigType = reflect.TypeOf(new(big.Int))
+
+// convert returns the value as the go type packed by the abi type.
+func convert(value interface{}, typ abi.Type) (interface{}, error) {
+	rt := typ.GetType()
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		switch {
+		case rt == bigType:
+			return new(big.Int), nil
+		case rt.Kind() == reflect.Slice:
+			return reflect.MakeSlice(rt, 0, 0).Interface(), nil
+		}
+		return reflect.Zero(rt).Interface(), nil
+	}
+	switch {
+	case typ.T == abi.SliceTy && v.Kind() == reflect.Slice:
+		out := reflect.MakeSlice(rt, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := convert(v.Index(i).Interface(), *typ.Elem)
+			if err != nil {
+				return nil, err
+			}
+			out.Index(i).Set(reflect.ValueOf(elem))
+		}
+		return out.Interface(), nil
+	case v.Kind() == rt.Kind() && v.Type().ConvertibleTo(rt):
+		return v.Convert(rt).Interface(), nil
+	case isUint(v.Kind()) && isUint(rt.Kind()):
+		return v.Convert(rt).Interface(), nil
+	}
+	return nil, fmt.Errorf("can't log %T as %v", value, typ)
+}
+
+func isUint(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// Log is a decoded structured FSN call log.
+type Log struct {
+	Func   common.FSNCallFunc
+	From   common.Address
+	Fields map[string]interface{}
+}
+
+// Decode decodes a structured FSN call log. The fields are named like the
+// ones of the legacy logs, the error is left out for successful calls.
+func Decode(l *types.Log) (*Log, error) {
+	if !IsStructured(l) {
+		return nil, errNotStructured
+	}
+	_, funcType := ParseTopic(l.Topics[funcTopic])
+	s := schemaOf(funcType)
+
+	values, err := s.args.UnpackValues(l.Data)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{}, len(values)+2)
+	for i, arg := range s.args {
+		switch value := values[i].(type) {
+		case [common.HashLength]byte:
+			fields[arg.Name] = common.Hash(value)
+		case [][common.HashLength]byte:
+			hashes := make([]common.Hash, len(value))
+			for j := range value {
+				hashes[j] = value[j]
+			}
+			fields[arg.Name] = hashes
+		default:
+			fields[arg.Name] = value
+		}
+	}
+	if s.to != "" {
+		fields[s.to] = common.BytesToAddress(l.Topics[toTopic].Bytes())
+	}
+	if s.asset != "" {
+		fields[s.asset] = l.Topics[assetTopic]
+	}
+	if fields["Error"] == "" {
+		delete(fields, "Error")
+	}
+	return &Log{
+		Func:   funcType,
+		From:   common.BytesToAddress(l.Topics[fromTopic].Bytes()),
+		Fields: fields,
+	}, nil
+}
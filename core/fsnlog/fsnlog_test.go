@@ -0,0 +1,155 @@
+package fsnlog
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+var (
+	from    = common.HexToAddress("0x01")
+	to      = common.HexToAddress("0x02")
+	assetID = common.HexToHash("0xaa")
+	swapID  = common.HexToHash("0xbb")
+)
+
+func roundTrip(t *testing.T, funcType common.FSNCallFunc, fields map[string]interface{}) (*types.Log, map[string]interface{}) {
+	topics, data, err := Encode(funcType, from, fields)
+	if err != nil {
+		t.Fatalf("encode %s: %v", funcType.Name(), err)
+	}
+	l := &types.Log{Address: common.FSNCallAddress, Topics: topics, Data: data}
+	decoded, err := Decode(l)
+	if err != nil {
+		t.Fatalf("decode %s: %v", funcType.Name(), err)
+	}
+	if decoded.Func != funcType || decoded.From != from {
+		t.Fatalf("decoded func %v from %x, want %v from %x", decoded.Func, decoded.From, funcType, from)
+	}
+	return l, decoded.Fields
+}
+
+func checkField(t *testing.T, fields map[string]interface{}, name string, want interface{}) {
+	t.Helper()
+	if want, ok := want.(*big.Int); ok {
+		if got, ok := fields[name].(*big.Int); ok && got.Cmp(want) == 0 {
+			return
+		}
+	}
+	if got := fields[name]; !reflect.DeepEqual(got, want) {
+		t.Errorf("field %s: got %v (%T), want %v (%T)", name, got, got, want, want)
+	}
+}
+
+func TestSendAssetTopics(t *testing.T) {
+	l, fields := roundTrip(t, common.SendAssetFunc, map[string]interface{}{
+		"AssetID": assetID,
+		"To":      to,
+		"Value":   big.NewInt(1000),
+	})
+	want := []common.Hash{Topic(common.SendAssetFunc), from.Hash(), to.Hash(), assetID}
+	if !reflect.DeepEqual(l.Topics, want) {
+		t.Fatalf("topics mismatch: got %x, want %x", l.Topics, want)
+	}
+	if version, funcType := ParseTopic(l.Topics[0]); version != Version || funcType != common.SendAssetFunc {
+		t.Fatalf("parsed topic version %d func %v", version, funcType)
+	}
+	checkField(t, fields, "AssetID", assetID)
+	checkField(t, fields, "To", to)
+	checkField(t, fields, "Value", big.NewInt(1000))
+	if _, ok := fields["Error"]; ok {
+		t.Errorf("successful call decoded with error")
+	}
+}
+
+func TestLegacyFields(t *testing.T) {
+	// time lock types are logged as uint8
+	_, fields := roundTrip(t, common.TimeLockFunc, map[string]interface{}{
+		"Type":      common.AssetToTimeLock,
+		"AssetID":   assetID,
+		"To":        to,
+		"StartTime": uint64(100),
+		"EndTime":   uint64(200),
+		"Value":     big.NewInt(5),
+		"LockType":  "AssetToTimeLock",
+	})
+	checkField(t, fields, "Type", uint8(common.AssetToTimeLock))
+	checkField(t, fields, "LockType", "AssetToTimeLock")
+	checkField(t, fields, "EndTime", uint64(200))
+
+	// the buy ticket param is decoded from its rlp encoding
+	base, _ := rlp.EncodeToBytes(&common.BuyTicketParam{Start: 10, End: 20})
+	_, fields = roundTrip(t, common.BuyTicketFunc, map[string]interface{}{
+		"Base":        base,
+		"TicketID":    swapID,
+		"TicketOwner": from,
+	})
+	checkField(t, fields, "TicketID", swapID)
+	checkField(t, fields, "TicketOwner", from)
+	checkField(t, fields, "StartTime", uint64(10))
+	checkField(t, fields, "ExpireTime", uint64(20))
+
+	// swap deletion is logged as a string
+	_, fields = roundTrip(t, common.TakeSwapFunc, map[string]interface{}{
+		"SwapID":  swapID,
+		"Size":    big.NewInt(2),
+		"Deleted": "true",
+	})
+	checkField(t, fields, "Deleted", true)
+
+	// reported tickets are logged as the hex of their rlp encoding
+	tickets := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}
+	enc, _ := rlp.EncodeToBytes(tickets)
+	_, fields = roundTrip(t, common.ReportIllegalFunc, map[string]interface{}{
+		"Base":          "",
+		"DeleteTickets": hexutil.Encode(enc),
+	})
+	checkField(t, fields, "DeleteTickets", tickets)
+}
+
+func TestMissingFields(t *testing.T) {
+	// failed calls miss the outcome fields, make swap params may miss the time
+	_, fields := roundTrip(t, common.MakeSwapFunc, map[string]interface{}{
+		"FromAssetID":   assetID,
+		"MinFromAmount": big.NewInt(1),
+		"ToAssetID":     common.SystemAssetID,
+		"MinToAmount":   big.NewInt(2),
+		"SwapSize":      big.NewInt(3),
+		"Targes":        []common.Address{to},
+		"Time":          (*big.Int)(nil),
+		"Error":         "not enough from asset",
+	})
+	checkField(t, fields, "Error", "not enough from asset")
+	checkField(t, fields, "FromAssetID", assetID)
+	checkField(t, fields, "SwapID", common.Hash{})
+	checkField(t, fields, "Time", new(big.Int))
+	checkField(t, fields, "Targes", []common.Address{to})
+
+	_, fields = roundTrip(t, common.MakeMultiSwapFunc, map[string]interface{}{
+		"FromAssetID":   []common.Hash{assetID},
+		"FromStartTime": []uint64{1},
+		"MinFromAmount": []*big.Int{big.NewInt(4)},
+		"SwapSize":      big.NewInt(3),
+	})
+	checkField(t, fields, "FromAssetID", []common.Hash{assetID})
+	checkField(t, fields, "FromStartTime", []uint64{1})
+	checkField(t, fields, "MinFromAmount", []*big.Int{big.NewInt(4)})
+	checkField(t, fields, "ToAssetID", []common.Hash{})
+}
+
+func TestLegacyLogNotStructured(t *testing.T) {
+	topic := common.Hash{}
+	topic[common.HashLength-1] = common.SendAssetFunc
+	l := &types.Log{Address: common.FSNCallAddress, Topics: []common.Hash{topic}, Data: []byte("{}")}
+	if IsStructured(l) {
+		t.Fatal("legacy log reported as structured")
+	}
+	if _, err := Decode(l); err == nil {
+		t.Fatal("decoded a legacy log")
+	}
+}
@@ -0,0 +1,285 @@
+// Package fsnrecord records the Fusion state changes made through a state
+// database, as reported by the FSN tracer and the simulation of FSN calls.
+//
+// FSN calls never reach the EVM interpreter, so instead of following opcodes
+// the recorder wraps the state database of the EVM and records the calls
+// changing Fusion state.
+package fsnrecord
+
+import (
+	"math/big"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/core/vm"
+)
+
+// Types of the recorded operations.
+const (
+	SubBalance       = "subBalance"
+	AddBalance       = "addBalance"
+	SubTimeLock      = "subTimeLock"
+	AddTimeLock      = "addTimeLock"
+	SetTimeLock      = "setTimeLock"
+	GenNotation      = "genNotation"
+	TransferNotation = "transferNotation"
+	BurnNotation     = "burnNotation"
+	GenAsset         = "genAsset"
+	UpdateAsset      = "updateAsset"
+	AddTicket        = "addTicket"
+	RemoveTicket     = "removeTicket"
+	AddSwap          = "addSwap"
+	UpdateSwap       = "updateSwap"
+	RemoveSwap       = "removeSwap"
+	AddMultiSwap     = "addMultiSwap"
+	UpdateMultiSwap  = "updateMultiSwap"
+	RemoveMultiSwap  = "removeMultiSwap"
+)
+
+// Operation is a Fusion state change.
+type Operation struct {
+	Type     string           `json:"type"`
+	Account  *common.Address  `json:"account,omitempty"`
+	To       *common.Address  `json:"to,omitempty"`
+	Asset    *common.Hash     `json:"asset,omitempty"`
+	Value    *hexutil.Big     `json:"value,omitempty"`
+	TimeLock *common.TimeLock `json:"timeLock,omitempty"`
+	ID       *common.Hash     `json:"id,omitempty"`
+	Notation uint64           `json:"notation,omitempty"`
+	Data     interface{}      `json:"data,omitempty"`
+}
+
+// AccountAsset is the balance or time lock balance of an account in an asset.
+type AccountAsset struct {
+	Account common.Address
+	Asset   common.Hash
+}
+
+// Recorder is a vm.StateDB recording the Fusion state changes made through
+// it: asset and time lock balance changes, tickets, notations, assets and
+// swaps. Changes reverted through it are dropped.
+type Recorder struct {
+	vm.StateDB
+	ops       []*Operation
+	snapshots map[int]int // state snapshot id => number of recorded operations
+}
+
+// New returns a recorder of the Fusion state changes made to db through it.
+func New(db vm.StateDB) *Recorder {
+	return &Recorder{StateDB: db, snapshots: make(map[int]int)}
+}
+
+// Operations returns the recorded operations in the order they were made.
+func (r *Recorder) Operations() []*Operation {
+	return r.ops
+}
+
+// Balances returns the balances changed, in the order of their first change.
+func (r *Recorder) Balances() []AccountAsset {
+	return r.changed(SubBalance, AddBalance)
+}
+
+// TimeLocks returns the time lock balances changed, in the order of their
+// first change.
+func (r *Recorder) TimeLocks() []AccountAsset {
+	return r.changed(SubTimeLock, AddTimeLock, SetTimeLock)
+}
+
+func (r *Recorder) changed(types ...string) []AccountAsset {
+	var (
+		keys []AccountAsset
+		seen = make(map[AccountAsset]bool)
+	)
+	for _, op := range r.ops {
+		for _, typ := range types {
+			if op.Type != typ {
+				continue
+			}
+			key := AccountAsset{*op.Account, *op.Asset}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func (r *Recorder) record(op *Operation) {
+	r.ops = append(r.ops, op)
+}
+
+func (r *Recorder) Snapshot() int {
+	id := r.StateDB.Snapshot()
+	r.snapshots[id] = len(r.ops)
+	return id
+}
+
+func (r *Recorder) RevertToSnapshot(id int) {
+	r.StateDB.RevertToSnapshot(id)
+	if n, ok := r.snapshots[id]; ok && n <= len(r.ops) {
+		r.ops = r.ops[:n]
+	}
+}
+
+func (r *Recorder) recordBalance(typ string, addr common.Address, assetID common.Hash, value *big.Int) {
+	if value == nil || value.Sign() == 0 {
+		return
+	}
+	r.record(&Operation{Type: typ, Account: &addr, Asset: &assetID, Value: (*hexutil.Big)(new(big.Int).Set(value))})
+}
+
+func (r *Recorder) recordTimeLock(typ string, addr common.Address, assetID common.Hash, value *common.TimeLock) {
+	if value == nil || value.IsEmpty() {
+		return
+	}
+	r.record(&Operation{Type: typ, Account: &addr, Asset: &assetID, TimeLock: value.Clone()})
+}
+
+func (r *Recorder) SubBalance(addr common.Address, assetID common.Hash, value *big.Int) {
+	r.StateDB.SubBalance(addr, assetID, value)
+	r.recordBalance(SubBalance, addr, assetID, value)
+}
+
+func (r *Recorder) AddBalance(addr common.Address, assetID common.Hash, value *big.Int) {
+	r.StateDB.AddBalance(addr, assetID, value)
+	r.recordBalance(AddBalance, addr, assetID, value)
+}
+
+func (r *Recorder) SubTimeLockBalance(addr common.Address, assetID common.Hash, value *common.TimeLock, blockNumber *big.Int, timestamp uint64) {
+	r.StateDB.SubTimeLockBalance(addr, assetID, value, blockNumber, timestamp)
+	r.recordTimeLock(SubTimeLock, addr, assetID, value)
+}
+
+func (r *Recorder) AddTimeLockBalance(addr common.Address, assetID common.Hash, value *common.TimeLock, blockNumber *big.Int, timestamp uint64) {
+	r.StateDB.AddTimeLockBalance(addr, assetID, value, blockNumber, timestamp)
+	r.recordTimeLock(AddTimeLock, addr, assetID, value)
+}
+
+func (r *Recorder) SetTimeLockBalance(addr common.Address, assetID common.Hash, value *common.TimeLock) {
+	r.StateDB.SetTimeLockBalance(addr, assetID, value)
+	op := &Operation{Type: SetTimeLock, Account: &addr, Asset: &assetID}
+	if value != nil {
+		op.TimeLock = value.Clone()
+	}
+	r.record(op)
+}
+
+func (r *Recorder) GenNotation(addr common.Address) error {
+	if err := r.StateDB.GenNotation(addr); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: GenNotation, Account: &addr, Notation: r.StateDB.GetNotation(addr)})
+	return nil
+}
+
+func (r *Recorder) TransferNotation(notation uint64, from common.Address, to common.Address) error {
+	if err := r.StateDB.TransferNotation(notation, from, to); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: TransferNotation, Account: &from, To: &to, Notation: notation})
+	return nil
+}
+
+func (r *Recorder) BurnNotation(addr common.Address) {
+	notation := r.StateDB.GetNotation(addr)
+	r.StateDB.BurnNotation(addr)
+	if notation != 0 {
+		r.record(&Operation{Type: BurnNotation, Account: &addr, Notation: notation})
+	}
+}
+
+func (r *Recorder) GenAsset(asset common.Asset) error {
+	if err := r.StateDB.GenAsset(asset); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: GenAsset, Account: &asset.Owner, Asset: &asset.ID, Data: asset})
+	return nil
+}
+
+func (r *Recorder) UpdateAsset(asset common.Asset) error {
+	if err := r.StateDB.UpdateAsset(asset); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: UpdateAsset, Account: &asset.Owner, Asset: &asset.ID, Data: asset})
+	return nil
+}
+
+func (r *Recorder) AddTicket(ticket common.Ticket) error {
+	if err := r.StateDB.AddTicket(ticket); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: AddTicket, Account: &ticket.Owner, ID: &ticket.ID, Data: &ticket})
+	return nil
+}
+
+func (r *Recorder) RemoveTicket(id common.Hash) error {
+	ticket, _ := r.StateDB.GetTicket(id)
+	if err := r.StateDB.RemoveTicket(id); err != nil {
+		return err
+	}
+	op := &Operation{Type: RemoveTicket, ID: &id}
+	if ticket != nil {
+		op.Account, op.Data = &ticket.Owner, ticket
+	}
+	r.record(op)
+	return nil
+}
+
+func (r *Recorder) AddSwap(swap common.Swap) error {
+	if err := r.StateDB.AddSwap(swap); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: AddSwap, Account: &swap.Owner, ID: &swap.ID, Data: swap})
+	return nil
+}
+
+func (r *Recorder) UpdateSwap(swap common.Swap) error {
+	if err := r.StateDB.UpdateSwap(swap); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: UpdateSwap, Account: &swap.Owner, ID: &swap.ID, Data: swap})
+	return nil
+}
+
+func (r *Recorder) RemoveSwap(id common.Hash) error {
+	swap, err := r.StateDB.GetSwap(id)
+	if err := r.StateDB.RemoveSwap(id); err != nil {
+		return err
+	}
+	op := &Operation{Type: RemoveSwap, ID: &id}
+	if err == nil {
+		op.Account = &swap.Owner
+	}
+	r.record(op)
+	return nil
+}
+
+func (r *Recorder) AddMultiSwap(swap common.MultiSwap) error {
+	if err := r.StateDB.AddMultiSwap(swap); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: AddMultiSwap, Account: &swap.Owner, ID: &swap.ID, Data: swap})
+	return nil
+}
+
+func (r *Recorder) UpdateMultiSwap(swap common.MultiSwap) error {
+	if err := r.StateDB.UpdateMultiSwap(swap); err != nil {
+		return err
+	}
+	r.record(&Operation{Type: UpdateMultiSwap, Account: &swap.Owner, ID: &swap.ID, Data: swap})
+	return nil
+}
+
+func (r *Recorder) RemoveMultiSwap(id common.Hash) error {
+	swap, err := r.StateDB.GetMultiSwap(id)
+	if err := r.StateDB.RemoveMultiSwap(id); err != nil {
+		return err
+	}
+	op := &Operation{Type: RemoveMultiSwap, ID: &id}
+	if err == nil {
+		op.Account = &swap.Owner
+	}
+	r.record(op)
+	return nil
+}
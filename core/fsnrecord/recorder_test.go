@@ -0,0 +1,94 @@
+package fsnrecord
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/state"
+)
+
+var (
+	alice   = common.HexToAddress("0x01")
+	bob     = common.HexToAddress("0x02")
+	assetID = common.HexToHash("0xaa")
+)
+
+func newRecorder(t *testing.T) *Recorder {
+	statedb, err := state.New(common.Hash{}, common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(statedb)
+}
+
+func opTypes(r *Recorder) []string {
+	types := []string{}
+	for _, op := range r.Operations() {
+		types = append(types, op.Type)
+	}
+	return types
+}
+
+func TestRecordChanges(t *testing.T) {
+	r := newRecorder(t)
+	timeLock := common.NewTimeLock(&common.TimeLockItem{StartTime: 100, EndTime: 200, Value: big.NewInt(5)})
+
+	r.AddBalance(alice, common.SystemAssetID, big.NewInt(10))
+	r.AddBalance(bob, assetID, new(big.Int)) // zero changes aren't recorded
+	r.AddTimeLockBalance(bob, assetID, timeLock, big.NewInt(1), 50)
+	r.SubBalance(alice, common.SystemAssetID, big.NewInt(3))
+	r.AddBalance(bob, common.SystemAssetID, big.NewInt(3))
+	if err := r.GenNotation(alice); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{AddBalance, AddTimeLock, SubBalance, AddBalance, GenNotation}
+	if have := opTypes(r); !reflect.DeepEqual(have, want) {
+		t.Fatalf("operations mismatch: have %v, want %v", have, want)
+	}
+	wantBalances := []AccountAsset{{alice, common.SystemAssetID}, {bob, common.SystemAssetID}}
+	if have := r.Balances(); !reflect.DeepEqual(have, wantBalances) {
+		t.Errorf("balances mismatch: have %v, want %v", have, wantBalances)
+	}
+	wantTimeLocks := []AccountAsset{{bob, assetID}}
+	if have := r.TimeLocks(); !reflect.DeepEqual(have, wantTimeLocks) {
+		t.Errorf("time locks mismatch: have %v, want %v", have, wantTimeLocks)
+	}
+	if op := r.Operations()[4]; op.Notation == 0 || op.Notation != r.GetNotation(alice) {
+		t.Errorf("notation mismatch: have %d, want %d", op.Notation, r.GetNotation(alice))
+	}
+
+	// the recorded values are copies
+	timeLock.Items[0].Value.SetInt64(7)
+	if v := r.Operations()[1].TimeLock.Items[0].Value.Int64(); v != 5 {
+		t.Errorf("recorded time lock shared with the caller: value %d", v)
+	}
+}
+
+func TestRecordReverts(t *testing.T) {
+	r := newRecorder(t)
+
+	r.AddBalance(alice, common.SystemAssetID, big.NewInt(10))
+	outer := r.Snapshot()
+	r.SubBalance(alice, common.SystemAssetID, big.NewInt(1))
+	inner := r.Snapshot()
+	r.AddBalance(bob, common.SystemAssetID, big.NewInt(1))
+	r.RevertToSnapshot(inner)
+
+	if have, want := opTypes(r), []string{AddBalance, SubBalance}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("operations after the inner revert mismatch: have %v, want %v", have, want)
+	}
+	r.RevertToSnapshot(outer)
+	if have, want := opTypes(r), []string{AddBalance}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("operations after the outer revert mismatch: have %v, want %v", have, want)
+	}
+	if have := r.Balances(); len(have) != 1 || have[0] != (AccountAsset{alice, common.SystemAssetID}) {
+		t.Errorf("balances mismatch: have %v", have)
+	}
+	if balance := r.GetBalance(common.SystemAssetID, alice); balance.Int64() != 10 {
+		t.Errorf("balance mismatch: have %v, want 10", balance)
+	}
+}
@@ -27,6 +27,8 @@ func (g Genesis) MarshalJSON() ([]byte, error) {
 		Coinbase         common.Address                              `json:"coinbase"`
 		Alloc            map[common.UnprefixedAddress]GenesisAccount `json:"alloc"      gencodec:"required"`
 		TicketCreateInfo *TicketsCreate                              `json:"ticketsCreate"`
+		Assets           []common.Asset                              `json:"assets,omitempty"`
+		Tickets          []TicketsCreate                             `json:"tickets,omitempty"`
 		Number           math.HexOrDecimal64                         `json:"number"`
 		GasUsed          math.HexOrDecimal64                         `json:"gasUsed"`
 		ParentHash       common.Hash                                 `json:"parentHash"`
@@ -47,6 +49,8 @@ func (g Genesis) MarshalJSON() ([]byte, error) {
 		}
 	}
 	enc.TicketCreateInfo = g.TicketCreateInfo
+	enc.Assets = g.Assets
+	enc.Tickets = g.Tickets
 	enc.Number = math.HexOrDecimal64(g.Number)
 	enc.GasUsed = math.HexOrDecimal64(g.GasUsed)
 	enc.ParentHash = g.ParentHash
@@ -65,6 +69,8 @@ func (g *Genesis) UnmarshalJSON(input []byte) error {
 		Coinbase         *common.Address                             `json:"coinbase"`
 		Alloc            map[common.UnprefixedAddress]GenesisAccount `json:"alloc"      gencodec:"required"`
 		TicketCreateInfo *TicketsCreate                              `json:"ticketsCreate"`
+		Assets           []common.Asset                              `json:"assets,omitempty"`
+		Tickets          []TicketsCreate                             `json:"tickets,omitempty"`
 		Number           *math.HexOrDecimal64                        `json:"number"`
 		GasUsed          *math.HexOrDecimal64                        `json:"gasUsed"`
 		ParentHash       *common.Hash                                `json:"parentHash"`
@@ -109,6 +115,12 @@ func (g *Genesis) UnmarshalJSON(input []byte) error {
 	if dec.TicketCreateInfo != nil {
 		g.TicketCreateInfo = dec.TicketCreateInfo
 	}
+	if dec.Assets != nil {
+		g.Assets = dec.Assets
+	}
+	if dec.Tickets != nil {
+		g.Tickets = dec.Tickets
+	}
 	if dec.Number != nil {
 		g.Number = uint64(*dec.Number)
 	}
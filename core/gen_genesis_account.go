@@ -16,11 +16,14 @@ var _ = (*genesisAccountMarshaling)(nil)
 
 func (g GenesisAccount) MarshalJSON() ([]byte, error) {
 	type GenesisAccount struct {
-		Code       hexutil.Bytes               `json:"code,omitempty"`
-		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
-		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
-		Nonce      math.HexOrDecimal64         `json:"nonce,omitempty"`
-		PrivateKey hexutil.Bytes               `json:"secretKey,omitempty"`
+		Code       hexutil.Bytes                         `json:"code,omitempty"`
+		Storage    map[storageJSON]storageJSON           `json:"storage,omitempty"`
+		Balance    *math.HexOrDecimal256                 `json:"balance" gencodec:"required"`
+		Nonce      math.HexOrDecimal64                   `json:"nonce,omitempty"`
+		PrivateKey hexutil.Bytes                         `json:"secretKey,omitempty"`
+		Assets     map[common.Hash]*math.HexOrDecimal256 `json:"assets,omitempty"`
+		TimeLocks  map[common.Hash]*common.TimeLock      `json:"timelocks,omitempty"`
+		Notation   math.HexOrDecimal64                   `json:"notation,omitempty"`
 	}
 	var enc GenesisAccount
 	enc.Code = g.Code
@@ -33,16 +36,27 @@ func (g GenesisAccount) MarshalJSON() ([]byte, error) {
 	enc.Balance = (*math.HexOrDecimal256)(g.Balance)
 	enc.Nonce = math.HexOrDecimal64(g.Nonce)
 	enc.PrivateKey = g.PrivateKey
+	if g.Assets != nil {
+		enc.Assets = make(map[common.Hash]*math.HexOrDecimal256, len(g.Assets))
+		for k, v := range g.Assets {
+			enc.Assets[k] = (*math.HexOrDecimal256)(v)
+		}
+	}
+	enc.TimeLocks = g.TimeLocks
+	enc.Notation = math.HexOrDecimal64(g.Notation)
 	return json.Marshal(&enc)
 }
 
 func (g *GenesisAccount) UnmarshalJSON(input []byte) error {
 	type GenesisAccount struct {
-		Code       *hexutil.Bytes              `json:"code,omitempty"`
-		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
-		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
-		Nonce      *math.HexOrDecimal64        `json:"nonce,omitempty"`
-		PrivateKey *hexutil.Bytes              `json:"secretKey,omitempty"`
+		Code       *hexutil.Bytes                        `json:"code,omitempty"`
+		Storage    map[storageJSON]storageJSON           `json:"storage,omitempty"`
+		Balance    *math.HexOrDecimal256                 `json:"balance" gencodec:"required"`
+		Nonce      *math.HexOrDecimal64                  `json:"nonce,omitempty"`
+		PrivateKey *hexutil.Bytes                        `json:"secretKey,omitempty"`
+		Assets     map[common.Hash]*math.HexOrDecimal256 `json:"assets,omitempty"`
+		TimeLocks  map[common.Hash]*common.TimeLock      `json:"timelocks,omitempty"`
+		Notation   *math.HexOrDecimal64                  `json:"notation,omitempty"`
 	}
 	var dec GenesisAccount
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -67,5 +81,17 @@ func (g *GenesisAccount) UnmarshalJSON(input []byte) error {
 	if dec.PrivateKey != nil {
 		g.PrivateKey = *dec.PrivateKey
 	}
+	if dec.Assets != nil {
+		g.Assets = make(map[common.Hash]*big.Int, len(dec.Assets))
+		for k, v := range dec.Assets {
+			g.Assets[k] = (*big.Int)(v)
+		}
+	}
+	if dec.TimeLocks != nil {
+		g.TimeLocks = dec.TimeLocks
+	}
+	if dec.Notation != nil {
+		g.Notation = uint64(*dec.Notation)
+	}
 	return nil
 }
@@ -23,7 +23,9 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
@@ -58,6 +60,8 @@ type Genesis struct {
 	Coinbase         common.Address      `json:"coinbase"`
 	Alloc            GenesisAlloc        `json:"alloc"      gencodec:"required"`
 	TicketCreateInfo *TicketsCreate      `json:"ticketsCreate"`
+	Assets           []common.Asset      `json:"assets,omitempty"`
+	Tickets          []TicketsCreate     `json:"tickets,omitempty"`
 
 	// These fields are used for consensus tests. Please don't use them
 	// in actual genesis blocks.
@@ -69,14 +73,26 @@ type Genesis struct {
 
 // TicketsCreate wacom
 type TicketsCreate struct {
-	Owner common.Address `json:"owner"`
-	Count uint64         `json:"count"`
-	Time  uint64         `json:"time"`
+	Owner      common.Address `json:"owner"`
+	Count      uint64         `json:"count"`
+	Time       uint64         `json:"time"`
+	ExpireTime uint64         `json:"expireTime,omitempty"` // 30 days after time if zero
 }
 
 // GenesisAlloc specifies the initial state that is part of the genesis block.
 type GenesisAlloc map[common.Address]GenesisAccount
 
+func (ga GenesisAlloc) sortedAddresses() []common.Address {
+	addrs := make([]common.Address, 0, len(ga))
+	for addr := range ga {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
+
 func (ga *GenesisAlloc) UnmarshalJSON(data []byte) error {
 	m := make(map[common.UnprefixedAddress]GenesisAccount)
 	if err := json.Unmarshal(data, &m); err != nil {
@@ -90,12 +106,17 @@ func (ga *GenesisAlloc) UnmarshalJSON(data []byte) error {
 }
 
 // GenesisAccount is an account in the state of the genesis block.
+// Balance is the balance of the system asset, Assets holds the balances
+// of the custom assets created in the genesis block.
 type GenesisAccount struct {
-	Code       []byte                      `json:"code,omitempty"`
-	Storage    map[common.Hash]common.Hash `json:"storage,omitempty"`
-	Balance    *big.Int                    `json:"balance" gencodec:"required"`
-	Nonce      uint64                      `json:"nonce,omitempty"`
-	PrivateKey []byte                      `json:"secretKey,omitempty"` // for tests
+	Code       []byte                           `json:"code,omitempty"`
+	Storage    map[common.Hash]common.Hash      `json:"storage,omitempty"`
+	Balance    *big.Int                         `json:"balance" gencodec:"required"`
+	Nonce      uint64                           `json:"nonce,omitempty"`
+	PrivateKey []byte                           `json:"secretKey,omitempty"` // for tests
+	Assets     map[common.Hash]*big.Int         `json:"assets,omitempty"`
+	TimeLocks  map[common.Hash]*common.TimeLock `json:"timelocks,omitempty"`
+	Notation   uint64                           `json:"notation,omitempty"`
 }
 
 // field type overrides for gencodec
@@ -116,6 +137,8 @@ type genesisAccountMarshaling struct {
 	Nonce      math.HexOrDecimal64
 	Storage    map[storageJSON]storageJSON
 	PrivateKey hexutil.Bytes
+	Assets     map[common.Hash]*math.HexOrDecimal256
+	Notation   math.HexOrDecimal64
 }
 
 // storageJSON represents a 256 bit byte array, but allows less than 256 bits when
@@ -163,6 +186,11 @@ func (e *GenesisMismatchError) Error() string {
 //
 // The returned chain configuration is never nil.
 func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, error) {
+	config, hash, err := setupGenesisBlock(db, genesis)
+	return params.WithBuiltinForks(hash, config), hash, err
+}
+
+func setupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, error) {
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
@@ -177,7 +205,10 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 			log.Info("Writing custom genesis block")
 		}
 		block, err := genesis.Commit(db)
-		return genesis.Config, block.Hash(), err
+		if err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+		return genesis.Config, block.Hash(), nil
 	}
 
 	// Check whether the genesis block is already written.
@@ -186,6 +217,8 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 		if hash != stored {
 			return genesis.Config, hash, &GenesisMismatchError{stored, hash}
 		}
+		// databases initialized before the genesis assets were stored
+		rawdb.WriteGenesisAssets(db, stored, genesis.Assets)
 	}
 
 	// Get the existing chain configuration.
@@ -231,7 +264,8 @@ func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 }
 
 // ToBlock creates the genesis block and writes state of a genesis specification
-// to the given database (or discards it if nil).
+// to the given database (or discards it if nil). It neither modifies the
+// specification nor the process wide fork schedule.
 func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 
 	if db == nil {
@@ -245,30 +279,73 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 		for key, value := range account.Storage {
 			statedb.SetState(addr, key, value)
 		}
+		for assetID, value := range account.Assets {
+			statedb.AddBalance(addr, assetID, value)
+		}
+		for assetID, timelock := range account.TimeLocks {
+			statedb.AddTimeLockBalance(addr, assetID, timelock, common.Big0, g.Timestamp)
+		}
+	}
+	// notations are allocated in address order, so that the notation count
+	// doesn't depend on the iteration order of the alloc map.
+	for _, addr := range g.Alloc.sortedAddresses() {
+		if notation := g.Alloc[addr].Notation; notation != 0 {
+			if err := statedb.AllocNotation(addr, notation); err != nil {
+				log.Error("Failed to allocate genesis notation", "address", addr, "notation", notation, "err", err)
+			}
+		}
+	}
+	for _, asset := range g.Assets {
+		if err := statedb.GenAsset(asset); err != nil {
+			log.Error("Failed to create genesis asset", "asset", asset.ID, "err", err)
+		}
 	}
 
+	// the ticket set determines the mix digest and the snapshot in the extra
+	// data, the genesis specification itself is left untouched
+	mixDigest, extra := g.Mixhash, g.ExtraData
+	batches := g.Tickets
 	if g.TicketCreateInfo != nil {
-		expireTime := g.TicketCreateInfo.Time + 30*24*3600
-		if g.Config.ChainID.Cmp(params.DevnetChainConfig.ChainID) == 0 {
-			expireTime = common.TimeLockForever
-		}
-		for x := uint64(0); x < g.TicketCreateInfo.Count; x++ {
-			from := g.TicketCreateInfo.Owner
-			hash := crypto.Keccak256Hash(new(big.Int).SetUint64(x).Bytes())
-			id := crypto.Keccak256Hash(from[:], hash[:])
-			ticket := common.Ticket{
-				Owner: from,
-				TicketBody: common.TicketBody{
-					ID:         id,
-					Height:     0,
-					StartTime:  g.TicketCreateInfo.Time,
-					ExpireTime: expireTime,
-				},
+		batches = append([]TicketsCreate{*g.TicketCreateInfo}, batches...)
+	}
+	if len(batches) > 0 {
+		var (
+			total   uint64
+			indexes = make(map[common.Address]uint64)
+		)
+		for _, batch := range batches {
+			expireTime := batch.ExpireTime
+			if expireTime == 0 {
+				expireTime = batch.Time + 30*24*3600
+			}
+			if g.Config != nil && g.Config.ChainID.Cmp(params.DevnetChainConfig.ChainID) == 0 {
+				expireTime = common.TimeLockForever
+			}
+			// ticket ids are numbered per owner, so that several batches
+			// of the same owner don't collide.
+			from := batch.Owner
+			for i := uint64(0); i < batch.Count; i++ {
+				x := indexes[from] + i
+				hash := crypto.Keccak256Hash(new(big.Int).SetUint64(x).Bytes())
+				id := crypto.Keccak256Hash(from[:], hash[:])
+				ticket := common.Ticket{
+					Owner: from,
+					TicketBody: common.TicketBody{
+						ID:         id,
+						Height:     0,
+						StartTime:  batch.Time,
+						ExpireTime: expireTime,
+					},
+				}
+				statedb.AddTicket(ticket)
 			}
-			statedb.AddTicket(ticket)
+			indexes[from] += batch.Count
+			total += batch.Count
 		}
-		g.Mixhash, _ = statedb.UpdateTickets(common.Big0, g.Timestamp)
-		g.ExtraData = datong.GenerateGenesisExtraData(g.ExtraData, g.TicketCreateInfo.Count)
+		// the genesis tickets are laid out according to the genesis config
+		ticketStorage := g.Config != nil && g.Config.IsTicketStorage(common.Big0)
+		mixDigest, _ = statedb.UpdateGenesisTickets(ticketStorage, g.Timestamp)
+		extra = datong.GenerateGenesisExtraData(g.ExtraData, total)
 	}
 
 	statedb.GenAsset(common.SystemAsset)
@@ -280,11 +357,11 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 		Time:       g.Timestamp,
 		ParentHash: g.ParentHash,
 		UncleHash:  types.EmptyUncleHash,
-		Extra:      g.ExtraData,
+		Extra:      extra,
 		GasLimit:   g.GasLimit,
 		GasUsed:    g.GasUsed,
 		Difficulty: g.Difficulty,
-		MixDigest:  g.Mixhash,
+		MixDigest:  mixDigest,
 		Coinbase:   g.Coinbase,
 		Root:       root,
 	}
@@ -306,6 +383,9 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
+	if err := g.verifyFusionAlloc(); err != nil {
+		return nil, err
+	}
 	block := g.ToBlock(db)
 	if block.Number().Sign() != 0 {
 		return nil, fmt.Errorf("can't commit genesis block with number > 0")
@@ -322,9 +402,103 @@ func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
 		config = params.AllEthashProtocolChanges
 	}
 	rawdb.WriteChainConfig(db, block.Hash(), config)
+	rawdb.WriteGenesisAssets(db, block.Hash(), g.Assets)
 	return block, nil
 }
 
+// verifyFusionAlloc checks the genesis assets, time locks and notations,
+// which ToBlock can only skip if they are wrong.
+func (g *Genesis) verifyFusionAlloc() error {
+	assets := map[common.Hash]*common.Asset{common.SystemAssetID: &common.SystemAsset}
+	for i, asset := range g.Assets {
+		if asset.ID == (common.Hash{}) || asset.ID == common.SystemAssetID || asset.ID == common.OwnerUSANAssetID {
+			return fmt.Errorf("genesis asset has an invalid id %x", asset.ID)
+		}
+		if assets[asset.ID] != nil {
+			return fmt.Errorf("genesis asset %x is declared twice", asset.ID)
+		}
+		if asset.Owner == (common.Address{}) {
+			return fmt.Errorf("genesis asset %x has no owner", asset.ID)
+		}
+		if asset.Total == nil || asset.Total.Sign() < 0 {
+			return fmt.Errorf("genesis asset %x has an invalid total", asset.ID)
+		}
+		assets[asset.ID] = &g.Assets[i]
+	}
+	var (
+		notations = make(map[uint64]common.Address)
+		balances  = make(map[common.Hash]*big.Int)
+		timelocks = make(map[common.Hash]*common.TimeLock)
+	)
+	for addr, account := range g.Alloc {
+		for assetID, value := range account.Assets {
+			if assets[assetID] == nil {
+				return fmt.Errorf("genesis account %x holds unknown asset %x", addr, assetID)
+			}
+			if value == nil || value.Sign() < 0 {
+				return fmt.Errorf("genesis account %x has an invalid balance of asset %x", addr, assetID)
+			}
+			balances[assetID] = addBig(balances[assetID], value)
+		}
+		for assetID, timelock := range account.TimeLocks {
+			if assets[assetID] == nil {
+				return fmt.Errorf("genesis account %x holds unknown asset %x", addr, assetID)
+			}
+			if timelock == nil {
+				return fmt.Errorf("genesis account %x has an empty time lock of asset %x", addr, assetID)
+			}
+			for _, item := range timelock.Items {
+				if item == nil || item.Value == nil {
+					return fmt.Errorf("genesis account %x has an invalid time lock of asset %x", addr, assetID)
+				}
+			}
+			if err := timelock.IsValid(); err != nil {
+				return fmt.Errorf("genesis account %x has an invalid time lock of asset %x: %v", addr, assetID, err)
+			}
+			timelocks[assetID] = new(common.TimeLock).Add(timelocks[assetID], timelock)
+		}
+		if account.Notation != 0 {
+			if common.CalcNotationDisplay(account.Notation/100) != account.Notation {
+				return fmt.Errorf("genesis account %x has an invalid notation %d", addr, account.Notation)
+			}
+			if other, ok := notations[account.Notation]; ok {
+				return fmt.Errorf("genesis notation %d is allocated to %x and %x", account.Notation, other, addr)
+			}
+			notations[account.Notation] = addr
+		}
+	}
+	// the balances plus the largest amount time locked at any one time must
+	// be covered by the asset total. The FSN supply is set by the alloc, the
+	// developer chains hand out more than the nominal system asset total.
+	for assetID, asset := range assets {
+		if assetID == common.SystemAssetID {
+			continue
+		}
+		allocated := balances[assetID]
+		if timelock := timelocks[assetID]; timelock != nil {
+			var peak *big.Int
+			for _, item := range timelock.Items {
+				if peak == nil || item.Value.Cmp(peak) > 0 {
+					peak = item.Value
+				}
+			}
+			allocated = addBig(allocated, peak)
+		}
+		if allocated != nil && allocated.Cmp(asset.Total) > 0 {
+			return fmt.Errorf("genesis allocates %v of asset %x, more than its total %v", allocated, assetID, asset.Total)
+		}
+	}
+	return nil
+}
+
+// addBig returns x+y, treating a nil x as zero.
+func addBig(x, y *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int).Set(y)
+	}
+	return new(big.Int).Add(x, y)
+}
+
 // MustCommit writes the genesis block and state to db, panicking on error.
 // The block is committed as the canonical head block.
 func (g *Genesis) MustCommit(db ethdb.Database) *types.Block {
@@ -407,19 +581,68 @@ func DefaultDevnetGenesisBlock() *Genesis {
 	}
 }
 
-// DeveloperGenesisBlock returns the 'efsn --dev' genesis block. Note, this must
-// be seeded with the
+// Test assets of the developer chain, held by the faucet account.
+var (
+	DeveloperUSDAssetID   = common.HexToHash("0x01")
+	DeveloperTokenAssetID = common.HexToHash("0x02")
+)
+
+// DeveloperTickets is the number of tickets the faucet account of the developer
+// chain holds in the genesis. Each block consumes a ticket, so an instant
+// sealing developer chain, which doesn't buy tickets, stops after that many
+// blocks.
+const DeveloperTickets = 10000
+
+// DeveloperGenesisBlock returns the 'efsn --dev' genesis block. The faucet
+// account holds the tickets mining the chain, FSN and the test assets. Blocks
+// are sealed as soon as transactions arrive with a zero period.
 func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 	// Override the default period to the user requested one
-	config := *params.AllCliqueProtocolChanges
-	config.Clique.Period = period
+	config := *params.DeveloperChainConfig
+	config.DaTong = &params.DaTongConfig{
+		Period:      period,
+		InstantSeal: period == 0,
+	}
+	usdTotal := new(big.Int).Mul(big.NewInt(1e12), big.NewInt(1e6))
+	tokenTotal := new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18))
+	now := uint64(time.Now().Unix())
 
-	// Assemble and return the genesis with the precompiles and faucet pre-funded
+	// Assemble and return the genesis with the precompiles and faucet pre-funded,
+	// the developer tickets never expire so that the chain can be resumed later
 	return &Genesis{
 		Config:     &config,
-		ExtraData:  append(append(make([]byte, 32), faucet[:]...), make([]byte, 65)...),
+		Timestamp:  now,
+		ExtraData:  make([]byte, 32),
 		GasLimit:   11500000,
 		Difficulty: big.NewInt(1),
+		TicketCreateInfo: &TicketsCreate{
+			Owner:      faucet,
+			Count:      DeveloperTickets,
+			Time:       now,
+			ExpireTime: common.TimeLockForever,
+		},
+		Assets: []common.Asset{
+			{
+				ID:          DeveloperUSDAssetID,
+				Owner:       faucet,
+				Name:        "Developer USD",
+				Symbol:      "DUSD",
+				Decimals:    6,
+				Total:       usdTotal,
+				CanChange:   true,
+				Description: "test asset of the developer chain",
+			},
+			{
+				ID:          DeveloperTokenAssetID,
+				Owner:       faucet,
+				Name:        "Developer Token",
+				Symbol:      "DTK",
+				Decimals:    18,
+				Total:       tokenTotal,
+				CanChange:   true,
+				Description: "test asset of the developer chain",
+			},
+		},
 		Alloc: map[common.Address]GenesisAccount{
 			common.BytesToAddress([]byte{1}): {Balance: big.NewInt(1)}, // ECRecover
 			common.BytesToAddress([]byte{2}): {Balance: big.NewInt(1)}, // SHA256
@@ -430,7 +653,13 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 			common.BytesToAddress([]byte{7}): {Balance: big.NewInt(1)}, // ECScalarMul
 			common.BytesToAddress([]byte{8}): {Balance: big.NewInt(1)}, // ECPairing
 			common.BytesToAddress([]byte{9}): {Balance: big.NewInt(1)}, // BLAKE2b
-			faucet:                           {Balance: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(9))},
+			faucet: {
+				Balance: new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18)),
+				Assets: map[common.Hash]*big.Int{
+					DeveloperUSDAssetID:   usdTotal,
+					DeveloperTokenAssetID: tokenTotal,
+				},
+			},
 		},
 	}
 }
@@ -18,10 +18,23 @@ package core
 
 import (
 	"container/list"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
 
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/consensus"
+	"github.com/FusionFoundation/efsn/consensus/misc"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/state"
 	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/ethdb"
 	"github.com/FusionFoundation/efsn/event"
+	"github.com/FusionFoundation/efsn/params"
+	"github.com/FusionFoundation/efsn/rpc"
+	"github.com/FusionFoundation/efsn/trie"
 )
 
 // Implement our EthTest Manager
@@ -78,9 +91,203 @@ func (tm *TestManager) Db() ethdb.Database {
 func NewTestManager() *TestManager {
 	testManager := &TestManager{}
 	testManager.eventMux = new(event.TypeMux)
-	testManager.db = ethdb.NewMemDatabase()
+	testManager.db = rawdb.NewMemoryDatabase()
 	// testManager.txPool = NewTxPool(testManager)
 	// testManager.blockChain = NewBlockChain(testManager)
 	// testManager.stateManager = NewStateManager(testManager)
 	return testManager
 }
+
+var (
+	errFakeSeal = errors.New("invalid fake seal")
+
+	// fakeBlockReward is credited to the coinbase of every block, so that
+	// blocks without transactions change the state too.
+	fakeBlockReward = big.NewInt(params.Ether)
+)
+
+// fakeEngine is a consensus engine accepting any seal, it stands in for the
+// proof of work fakers of the upstream tests. The coinbase earns a fixed
+// reward and the difficulty follows the Homestead rules.
+type fakeEngine struct {
+	fakeFail  uint64        // Block number which fails the seal check
+	fakeDelay time.Duration // Time delay to sleep for before returning from verify
+	fullFake  bool          // Accept everything as valid
+
+	// header chains are validated one header at a time, the headers verified
+	// so far stand in for the parents not yet written to the chain
+	lock     sync.Mutex
+	verified map[common.Hash]*types.Header
+}
+
+// newFakeEngine returns an engine accepting any seal but checking the headers.
+func newFakeEngine() *fakeEngine {
+	return &fakeEngine{}
+}
+
+// newFakeFailer returns an engine rejecting the seal of the given block.
+func newFakeFailer(fail uint64) *fakeEngine {
+	return &fakeEngine{fakeFail: fail}
+}
+
+// newFakeDelayer returns an engine taking the delay to verify each seal.
+func newFakeDelayer(delay time.Duration) *fakeEngine {
+	return &fakeEngine{fakeDelay: delay}
+}
+
+// newFullFakeEngine returns an engine accepting any header.
+func newFullFakeEngine() *fakeEngine {
+	return &fakeEngine{fullFake: true}
+}
+
+func (e *fakeEngine) Author(header *types.Header) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+func (e *fakeEngine) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	if e.fullFake {
+		return nil
+	}
+	number := header.Number.Uint64()
+	if chain.GetHeader(header.Hash(), number) != nil {
+		return nil
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		e.lock.Lock()
+		parent = e.verified[header.ParentHash]
+		e.lock.Unlock()
+	}
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if err := e.verifyHeader(chain, header, parent, seal); err != nil {
+		return err
+	}
+	e.lock.Lock()
+	if e.verified == nil {
+		e.verified = make(map[common.Hash]*types.Header)
+	}
+	e.verified[header.Hash()] = header
+	e.lock.Unlock()
+	return nil
+}
+
+func (e *fakeEngine) verifyHeader(chain consensus.ChainReader, header, parent *types.Header, seal bool) error {
+	if header.Time <= parent.Time {
+		return errors.New("timestamp older than parent")
+	}
+	if header.Number.Uint64() != parent.Number.Uint64()+1 {
+		return consensus.ErrInvalidNumber
+	}
+	if header.GasUsed > header.GasLimit {
+		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
+	}
+	if want := e.CalcDifficulty(chain, header.Time, parent); header.Difficulty.Cmp(want) != 0 {
+		return fmt.Errorf("invalid difficulty: have %v, want %v", header.Difficulty, want)
+	}
+	if err := misc.VerifyDAOHeaderExtraData(chain.Config(), header); err != nil {
+		return err
+	}
+	if seal {
+		return e.VerifySeal(chain, header)
+	}
+	return nil
+}
+
+func (e *fakeEngine) PreProcess(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) error {
+	return nil
+}
+
+func (e *fakeEngine) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort, results := make(chan struct{}), make(chan error, len(headers))
+	go func() {
+		for i, header := range headers {
+			var err error
+			switch {
+			case e.fullFake:
+			case i == 0:
+				err = e.VerifyHeader(chain, header, seals[i])
+			case headers[i-1].Hash() != header.ParentHash:
+				err = consensus.ErrUnknownAncestor
+			default:
+				err = e.verifyHeader(chain, header, headers[i-1], seals[i])
+			}
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+func (e *fakeEngine) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if !e.fullFake && len(block.Uncles()) > 2 {
+		return errors.New("too many uncles")
+	}
+	return nil
+}
+
+func (e *fakeEngine) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	if e.fakeDelay > 0 {
+		time.Sleep(e.fakeDelay)
+	}
+	if e.fakeFail != 0 && e.fakeFail == header.Number.Uint64() {
+		return errFakeSeal
+	}
+	return nil
+}
+
+func (e *fakeEngine) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Difficulty = e.CalcDifficulty(chain, header.Time, parent)
+	return nil
+}
+
+func (e *fakeEngine) Finalize(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	statedb.AddBalance(header.Coinbase, common.SystemAssetID, fakeBlockReward)
+	header.Root = statedb.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	return types.NewBlock(header, txs, uncles, receipts, trie.NewStackTrie(nil)), nil
+}
+
+func (e *fakeEngine) Seal(chain consensus.ChainReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	select {
+	case results <- block:
+	case <-stop:
+	}
+	return nil
+}
+
+func (e *fakeEngine) SealHash(header *types.Header) common.Hash {
+	return header.Hash()
+}
+
+// CalcDifficulty returns the Homestead difficulty of a block, a block time
+// under 10 seconds raises it.
+func (e *fakeEngine) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
+	x := new(big.Int).SetUint64((time - parent.Time) / 10)
+	x.Sub(big.NewInt(1), x)
+	if x.Cmp(big.NewInt(-99)) < 0 {
+		x.SetInt64(-99)
+	}
+	y := new(big.Int).Div(parent.Difficulty, params.DifficultyBoundDivisor)
+	x.Mul(y, x)
+	x.Add(parent.Difficulty, x)
+	if x.Cmp(params.MinimumDifficulty) < 0 {
+		x.Set(params.MinimumDifficulty)
+	}
+	return x
+}
+
+func (e *fakeEngine) APIs(chain consensus.ChainReader) []rpc.API {
+	return nil
+}
+
+func (e *fakeEngine) Close() error {
+	return nil
+}
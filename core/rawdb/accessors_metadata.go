@@ -79,3 +79,32 @@ func WriteChainConfig(db ethdb.KeyValueWriter, hash common.Hash, cfg *params.Cha
 		log.Crit("Failed to store chain config", "err", err)
 	}
 }
+
+// ReadGenesisAssets retrieves the assets allocated by the genesis block with
+// the given hash, the genesis state only holds them by id.
+func ReadGenesisAssets(db ethdb.KeyValueReader, hash common.Hash) []common.Asset {
+	data, _ := db.Get(genesisAssetsKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var assets []common.Asset
+	if err := rlp.DecodeBytes(data, &assets); err != nil {
+		log.Error("Invalid genesis assets RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return assets
+}
+
+// WriteGenesisAssets stores the assets allocated by the genesis block.
+func WriteGenesisAssets(db ethdb.KeyValueWriter, hash common.Hash, assets []common.Asset) {
+	if len(assets) == 0 {
+		return
+	}
+	data, err := rlp.EncodeToBytes(assets)
+	if err != nil {
+		log.Crit("Failed to RLP encode genesis assets", "err", err)
+	}
+	if err := db.Put(genesisAssetsKey(hash), data); err != nil {
+		log.Crit("Failed to store genesis assets", "err", err)
+	}
+}
@@ -93,8 +93,11 @@ var (
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
+	genesisAssetsPrefix = []byte("fusion-genesis-assets-") // genesisAssetsPrefix + hash -> rlp(genesis assets)
+
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	FsnIndexPrefix       = []byte("iF") // FsnIndexPrefix is the data table of the Fusion asset and swap indexer
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -230,3 +233,8 @@ func IsCodeKey(key []byte) (bool, []byte) {
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
 }
+
+// genesisAssetsKey = genesisAssetsPrefix + hash
+func genesisAssetsKey(hash common.Hash) []byte {
+	return append(genesisAssetsPrefix, hash.Bytes()...)
+}
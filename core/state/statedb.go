@@ -89,8 +89,9 @@ type StateDB struct {
 	validRevisions []revision
 	nextRevisionId int
 
-	ticketsHash common.Hash
-	tickets     common.TicketsDataSlice
+	ticketsHash  common.Hash
+	tickets      common.TicketsDataSlice
+	ticketsDirty bool // tickets changed since ticketsHash, only tracked for the per ticket storage layout
 
 	rwlock sync.RWMutex
 }
@@ -174,13 +175,23 @@ func calcTicketsStorageData(tickets common.TicketsDataSlice) ([]byte, error) {
 	return data, nil
 }
 
-func AddCachedTickets(hash common.Hash, tickets common.TicketsDataSlice) error {
-	data, err := calcTicketsStorageData(tickets)
-	if err != nil {
-		return fmt.Errorf("AddCachedTickets: %v", err)
-	}
-	if hash != crypto.Keccak256Hash(data) {
-		return fmt.Errorf("AddCachedTickets: hash mismatch")
+func AddCachedTickets(blockNumber *big.Int, hash common.Hash, tickets common.TicketsDataSlice) error {
+	if common.IsForkEnabled(common.TicketStorageFork, blockNumber) {
+		root, err := ticketsStorageRoot(tickets)
+		if err != nil {
+			return fmt.Errorf("AddCachedTickets: %v", err)
+		}
+		if hash != root {
+			return fmt.Errorf("AddCachedTickets: hash mismatch")
+		}
+	} else {
+		data, err := calcTicketsStorageData(tickets)
+		if err != nil {
+			return fmt.Errorf("AddCachedTickets: %v", err)
+		}
+		if hash != crypto.Keccak256Hash(data) {
+			return fmt.Errorf("AddCachedTickets: hash mismatch")
+		}
 	}
 	cachedTicketSlice.Add(hash, tickets)
 	return nil
@@ -238,6 +249,7 @@ func (s *StateDB) Reset(root common.Hash) error {
 	s.clearJournalAndRefund()
 	s.accessList = newAccessList()
 	s.tickets = nil
+	s.ticketsDirty = false
 	return nil
 }
 
@@ -749,7 +761,10 @@ func (s *StateDB) Copy() *StateDB {
 		preimages:           make(map[common.Hash][]byte, len(s.preimages)),
 		journal:             newJournal(),
 		ticketsHash:         s.ticketsHash,
-		tickets:             s.tickets.DeepCopy(),
+		ticketsDirty:        s.ticketsDirty,
+	}
+	if s.tickets != nil {
+		state.tickets = s.tickets.DeepCopy()
 	}
 	// Copy the dirty states, logs, and preimages
 	for addr := range s.journal.dirties {
@@ -825,6 +840,11 @@ func (s *StateDB) RevertToSnapshot(revid int) {
 	// Replay the journal to undo changes and remove invalidated snapshots
 	s.journal.revert(s, snapshot)
 	s.validRevisions = s.validRevisions[:idx]
+
+	// the ticket storage itself is journaled, but its in-memory copy isn't
+	if s.tickets != nil && s.isTicketStorage() {
+		s.tickets = nil
+	}
 }
 
 // GetRefund returns the current value of the refund counter.
@@ -1061,6 +1081,32 @@ func (s *StateDB) GenNotation(addr common.Address) error {
 	return nil
 }
 
+// AllocNotation assigns the given notation to the account, notations
+// generated later on are counted from the highest allocated one.
+func (s *StateDB) AllocNotation(addr common.Address, notation uint64) error {
+	stateObject := s.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		if n := s.GetNotation(addr); n != 0 {
+			return fmt.Errorf("Account %s has a notation:%d", addr.String(), n)
+		}
+		count := notation / 100
+		if notation == 0 || s.CalcNotationDisplay(count) != notation {
+			return fmt.Errorf("invalid notation %d", notation)
+		}
+		if _, err := s.GetAddressByNotation(notation); err == nil {
+			return fmt.Errorf("notation %d is in use", notation)
+		}
+		if current, err := s.GetNotationCount(); err != nil {
+			return err
+		} else if count > current {
+			s.setNotationCount(count)
+		}
+		s.setNotationToAddressLookup(notation, addr)
+		stateObject.SetNotation(notation)
+	}
+	return nil
+}
+
 func (s *StateDB) BurnNotation(addr common.Address) {
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
@@ -1166,11 +1212,7 @@ func (s *StateDB) TransferNotation(notation uint64, from common.Address, to comm
 
 // CalcNotationDisplay wacom
 func (s *StateDB) CalcNotationDisplay(notation uint64) uint64 {
-	if notation == 0 {
-		return notation
-	}
-	check := (notation ^ 8192 ^ 13 + 73/76798669*708583737978) % 100
-	return (notation*100 + check)
+	return common.CalcNotationDisplay(notation)
 }
 
 // AllAssets wacom
@@ -1233,6 +1275,10 @@ func (s *StateDB) UpdateAsset(asset common.Asset) error {
 
 // IsTicketExist wacom
 func (s *StateDB) IsTicketExist(id common.Hash) bool {
+	if s.isTicketStorage() {
+		_, exist := s.readStoredTicket(id)
+		return exist
+	}
 	tickets, err := s.AllTickets()
 	if err != nil {
 		log.Error("IsTicketExist unable to retrieve all tickets")
@@ -1245,6 +1291,12 @@ func (s *StateDB) IsTicketExist(id common.Hash) bool {
 
 // GetTicket wacom
 func (s *StateDB) GetTicket(id common.Hash) (*common.Ticket, error) {
+	if s.isTicketStorage() {
+		if t, exist := s.readStoredTicket(id); exist {
+			return &t.Ticket, nil
+		}
+		return nil, fmt.Errorf("%v ticket not fount", id.String())
+	}
 	tickets, err := s.AllTickets()
 	if err != nil {
 		log.Error("GetTicket unable to retrieve all tickets")
@@ -1255,6 +1307,9 @@ func (s *StateDB) GetTicket(id common.Hash) (*common.Ticket, error) {
 
 // AllTickets wacom
 func (s *StateDB) AllTickets() (common.TicketsDataSlice, error) {
+	if s.isTicketStorage() {
+		return s.allTicketsFromStorage()
+	}
 	if len(s.tickets) != 0 {
 		return s.tickets, nil
 	}
@@ -1269,6 +1324,36 @@ func (s *StateDB) AllTickets() (common.TicketsDataSlice, error) {
 	s.rwlock.RLock()
 	defer s.rwlock.RUnlock()
 
+	tickets, err := s.readTicketsBlob()
+	if err != nil || len(tickets) == 0 {
+		return tickets, err
+	}
+	s.tickets = tickets
+	cachedTicketSlice.Add(key, s.tickets)
+	return s.tickets, nil
+}
+
+// allTicketsFromStorage returns the ticket set of the per ticket storage
+// layout, served from memory or the ticket cache whenever possible.
+func (s *StateDB) allTicketsFromStorage() (common.TicketsDataSlice, error) {
+	if s.tickets != nil {
+		return s.tickets, nil
+	}
+	if !s.ticketsDirty {
+		if ts := cachedTicketSlice.Get(s.ticketsHash); ts != nil {
+			s.tickets = ts.DeepCopy()
+			return s.tickets, nil
+		}
+	}
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+
+	s.tickets = s.allStoredTickets()
+	return s.tickets, s.Error()
+}
+
+// readTicketsBlob decodes the compressed ticket blob of the ticket key account.
+func (s *StateDB) readTicketsBlob() (common.TicketsDataSlice, error) {
 	blob := s.GetData(common.TicketKeyAddress)
 	if len(blob) == 0 {
 		return common.TicketsDataSlice{}, s.Error()
@@ -1292,13 +1377,37 @@ func (s *StateDB) AllTickets() (common.TicketsDataSlice, error) {
 		log.Error("Unable to decode tickets")
 		return nil, fmt.Errorf("Unable to decode tickets, err: %v", err)
 	}
-	s.tickets = tickets
-	cachedTicketSlice.Add(key, s.tickets)
-	return s.tickets, nil
+	return tickets, nil
+}
+
+// AllTicketsByAddress returns the tickets owned by the given address.
+func (s *StateDB) AllTicketsByAddress(owner common.Address) (common.TicketsData, error) {
+	if s.isTicketStorage() {
+		return s.storedTicketsByAddress(owner), s.Error()
+	}
+	tickets, err := s.AllTickets()
+	if err != nil {
+		return common.TicketsData{}, err
+	}
+	for _, v := range tickets {
+		if v.Owner == owner {
+			return v, nil
+		}
+	}
+	return common.TicketsData{Owner: owner}, nil
 }
 
 // AddTicket wacom
 func (s *StateDB) AddTicket(ticket common.Ticket) error {
+	if s.isTicketStorage() {
+		if err := s.addStoredTicket(&ticket); err != nil {
+			return err
+		}
+		s.updateTicketsMirror(func(tickets common.TicketsDataSlice) (common.TicketsDataSlice, error) {
+			return tickets.AddTicket(&ticket)
+		})
+		return nil
+	}
 	tickets, err := s.AllTickets()
 	if err != nil {
 		return fmt.Errorf("AddTicket error: %v", err)
@@ -1313,6 +1422,15 @@ func (s *StateDB) AddTicket(ticket common.Ticket) error {
 
 // RemoveTicket wacom
 func (s *StateDB) RemoveTicket(id common.Hash) error {
+	if s.isTicketStorage() {
+		if _, err := s.removeStoredTicket(id); err != nil {
+			return err
+		}
+		s.updateTicketsMirror(func(tickets common.TicketsDataSlice) (common.TicketsDataSlice, error) {
+			return tickets.RemoveTicket(id)
+		})
+		return nil
+	}
 	tickets, err := s.AllTickets()
 	if err != nil {
 		return fmt.Errorf("RemoveTicket error: %v", err)
@@ -1326,16 +1444,71 @@ func (s *StateDB) RemoveTicket(id common.Hash) error {
 }
 
 func (s *StateDB) TotalNumberOfTickets() uint64 {
+	if s.isTicketStorage() {
+		return s.getTicketUint(ticketCountKey)
+	}
 	s.rwlock.RLock()
 	defer s.rwlock.RUnlock()
 
 	return s.tickets.NumberOfTickets()
 }
 
+// UpdateTickets clears the expired tickets and returns the commitment of the
+// resulting ticket set. At the ticket storage fork the tickets are migrated
+// from the compressed blob into the per ticket storage layout, which is
+// committed to by the storage root of the ticket key account from then on.
 func (s *StateDB) UpdateTickets(blockNumber *big.Int, timestamp uint64) (common.Hash, error) {
+	return s.updateTickets(common.IsForkEnabled(common.TicketStorageFork, blockNumber), blockNumber, timestamp)
+}
+
+// UpdateGenesisTickets is UpdateTickets for a genesis block, with the ticket
+// storage fork given by the genesis chain config instead of the active fork
+// schedule, so building a genesis block doesn't depend on the running chain.
+func (s *StateDB) UpdateGenesisTickets(ticketStorage bool, timestamp uint64) (common.Hash, error) {
+	return s.updateTickets(ticketStorage, common.Big0, timestamp)
+}
+
+func (s *StateDB) updateTickets(ticketStorage bool, blockNumber *big.Int, timestamp uint64) (common.Hash, error) {
 	s.rwlock.Lock()
 	defer s.rwlock.Unlock()
 
+	if !s.isTicketStorage() {
+		if !ticketStorage {
+			return s.updateTicketsBlob(timestamp)
+		}
+		tickets := s.tickets
+		if len(tickets) == 0 {
+			var err error
+			if tickets, err = s.readTicketsBlob(); err != nil {
+				return common.Hash{}, fmt.Errorf("UpdateTickets: %v", err)
+			}
+		}
+		if err := s.migrateTickets(tickets, timestamp); err != nil {
+			return common.Hash{}, fmt.Errorf("UpdateTickets: %v", err)
+		}
+		s.tickets = tickets
+		log.Info("Migrated tickets into per ticket storage", "number", blockNumber, "tickets", tickets.NumberOfTickets())
+	}
+	if err := s.clearExpiredStoredTickets(timestamp); err != nil {
+		return common.Hash{}, fmt.Errorf("UpdateTickets: %v", err)
+	}
+	hash := s.ticketsRoot()
+	if s.tickets != nil {
+		cachedTicketSlice.Add(hash, s.tickets)
+	}
+	return hash, nil
+}
+
+// TicketsCommitment returns the hash of the ticket set of the state, which is
+// the MixDigest of the block header.
+func (s *StateDB) TicketsCommitment() common.Hash {
+	if s.isTicketStorage() {
+		return s.ticketsRoot()
+	}
+	return s.GetDataHash(common.TicketKeyAddress)
+}
+
+func (s *StateDB) updateTicketsBlob(timestamp uint64) (common.Hash, error) {
 	tickets := s.tickets
 	tickets, err := tickets.ClearExpiredTickets(timestamp)
 	if err != nil {
@@ -1354,6 +1527,20 @@ func (s *StateDB) UpdateTickets(blockNumber *big.Int, timestamp uint64) (common.
 }
 
 func (s *StateDB) ClearTickets(from, to common.Address, blockNumber *big.Int, timestamp uint64) {
+	if s.isTicketStorage() {
+		for _, ticket := range s.storedTicketsByAddress(from).Tickets {
+			if ticket.ExpireTime > timestamp {
+				value := common.NewTimeLock(&common.TimeLockItem{
+					StartTime: ticket.StartTime,
+					EndTime:   ticket.ExpireTime,
+					Value:     ticket.Value(),
+				})
+				s.AddTimeLockBalance(to, common.SystemAssetID, value, blockNumber, timestamp)
+			}
+			s.RemoveTicket(ticket.ID)
+		}
+		return
+	}
 	tickets, err := s.AllTickets()
 	if err != nil {
 		return
@@ -22,8 +22,10 @@ import (
 	"testing"
 
 	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/crypto"
 	"github.com/FusionFoundation/efsn/ethdb"
+	"github.com/FusionFoundation/efsn/ethdb/memorydb"
 	"github.com/FusionFoundation/efsn/trie"
 )
 
@@ -38,8 +40,8 @@ type testAccount struct {
 // makeTestState create a sample test state to test node-wise reconstruction.
 func makeTestState() (Database, common.Hash, []*testAccount) {
 	// Create an empty state
-	db := NewDatabase(ethdb.NewMemDatabase())
-	state, _ := New(common.Hash{}, db)
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	state, _ := New(common.Hash{}, common.Hash{}, db)
 
 	// Fill it with some arbitrary data
 	accounts := []*testAccount{}
@@ -47,7 +49,7 @@ func makeTestState() (Database, common.Hash, []*testAccount) {
 		obj := state.GetOrNewStateObject(common.BytesToAddress([]byte{i}))
 		acc := &testAccount{address: common.BytesToAddress([]byte{i})}
 
-		obj.AddBalance(big.NewInt(int64(11 * i)))
+		obj.AddBalance(common.SystemAssetID, big.NewInt(int64(11*i)))
 		acc.balance = big.NewInt(int64(11 * i))
 
 		obj.SetNonce(uint64(42 * i))
@@ -70,7 +72,7 @@ func makeTestState() (Database, common.Hash, []*testAccount) {
 // account array.
 func checkStateAccounts(t *testing.T, db ethdb.Database, root common.Hash, accounts []*testAccount) {
 	// Check root availability and state contents
-	state, err := New(root, NewDatabase(db))
+	state, err := New(root, common.Hash{}, NewDatabase(db))
 	if err != nil {
 		t.Fatalf("failed to create state trie at %x: %v", root, err)
 	}
@@ -78,7 +80,7 @@ func checkStateAccounts(t *testing.T, db ethdb.Database, root common.Hash, accou
 		t.Fatalf("inconsistent state trie at %x: %v", root, err)
 	}
 	for i, acc := range accounts {
-		if balance := state.GetBalance(acc.address); balance.Cmp(acc.balance) != 0 {
+		if balance := state.GetBalance(common.SystemAssetID, acc.address); balance.Cmp(acc.balance) != 0 {
 			t.Errorf("account %d: balance mismatch: have %v, want %v", i, balance, acc.balance)
 		}
 		if nonce := state.GetNonce(acc.address); nonce != acc.nonce {
@@ -111,7 +113,7 @@ func checkStateConsistency(db ethdb.Database, root common.Hash) error {
 	if _, err := db.Get(root.Bytes()); err != nil {
 		return nil // Consider a non existent state consistent.
 	}
-	state, err := New(root, NewDatabase(db))
+	state, err := New(root, common.Hash{}, NewDatabase(db))
 	if err != nil {
 		return err
 	}
@@ -121,10 +123,51 @@ func checkStateConsistency(db ethdb.Database, root common.Hash) error {
 	return it.Error
 }
 
+// newTestStateSync creates a state sync scheduler writing into the database.
+func newTestStateSync(root common.Hash, db ethdb.Database) *trie.Sync {
+	return NewStateSync(root, db, trie.NewSyncBloom(1, db), nil)
+}
+
+// missingStateData returns the hashes of the trie nodes and codes the
+// scheduler is missing.
+func missingStateData(sched *trie.Sync, max int) []common.Hash {
+	nodes, _, codes := sched.Missing(max)
+	return append(nodes, codes...)
+}
+
+// fetchStateData retrieves a trie node or a contract code from the source state.
+func fetchStateData(t *testing.T, db Database, hash common.Hash) trie.SyncResult {
+	data, err := db.TrieDB().Node(hash)
+	if err != nil {
+		data, err = db.ContractCode(common.Hash{}, hash)
+	}
+	if err != nil {
+		t.Fatalf("failed to retrieve node data for %x", hash)
+	}
+	return trie.SyncResult{Hash: hash, Data: data}
+}
+
+// processStateData feeds the results to the scheduler and commits them.
+func processStateData(t *testing.T, sched *trie.Sync, db ethdb.Database, results []trie.SyncResult) {
+	for i, result := range results {
+		if err := sched.Process(result); err != nil {
+			t.Fatalf("failed to process result #%d: %v", i, err)
+		}
+	}
+	batch := db.NewBatch()
+	if err := sched.Commit(batch); err != nil {
+		t.Fatalf("failed to commit data: %v", err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write data: %v", err)
+	}
+}
+
 // Tests that an empty state is not scheduled for syncing.
 func TestEmptyStateSync(t *testing.T) {
 	empty := common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
-	if req := NewStateSync(empty, ethdb.NewMemDatabase()).Missing(1); len(req) != 0 {
+	sched := NewStateSync(empty, rawdb.NewMemoryDatabase(), trie.NewSyncBloom(1, memorydb.New()), nil)
+	if req := missingStateData(sched, 1); len(req) != 0 {
 		t.Errorf("content requested for empty state: %v", req)
 	}
 }
@@ -139,26 +182,17 @@ func testIterativeStateSync(t *testing.T, batch int) {
 	srcDb, srcRoot, srcAccounts := makeTestState()
 
 	// Create a destination state and sync with the scheduler
-	dstDb := ethdb.NewMemDatabase()
-	sched := NewStateSync(srcRoot, dstDb)
+	dstDb := rawdb.NewMemoryDatabase()
+	sched := newTestStateSync(srcRoot, dstDb)
 
-	queue := append([]common.Hash{}, sched.Missing(batch)...)
+	queue := missingStateData(sched, batch)
 	for len(queue) > 0 {
 		results := make([]trie.SyncResult, len(queue))
 		for i, hash := range queue {
-			data, err := srcDb.TrieDB().Node(hash)
-			if err != nil {
-				t.Fatalf("failed to retrieve node data for %x", hash)
-			}
-			results[i] = trie.SyncResult{Hash: hash, Data: data}
-		}
-		if _, index, err := sched.Process(results); err != nil {
-			t.Fatalf("failed to process result #%d: %v", index, err)
-		}
-		if index, err := sched.Commit(dstDb); err != nil {
-			t.Fatalf("failed to commit data #%d: %v", index, err)
+			results[i] = fetchStateData(t, srcDb, hash)
 		}
-		queue = append(queue[:0], sched.Missing(batch)...)
+		processStateData(t, sched, dstDb, results)
+		queue = missingStateData(sched, batch)
 	}
 	// Cross check that the two states are in sync
 	checkStateAccounts(t, dstDb, srcRoot, srcAccounts)
@@ -171,27 +205,18 @@ func TestIterativeDelayedStateSync(t *testing.T) {
 	srcDb, srcRoot, srcAccounts := makeTestState()
 
 	// Create a destination state and sync with the scheduler
-	dstDb := ethdb.NewMemDatabase()
-	sched := NewStateSync(srcRoot, dstDb)
+	dstDb := rawdb.NewMemoryDatabase()
+	sched := newTestStateSync(srcRoot, dstDb)
 
-	queue := append([]common.Hash{}, sched.Missing(0)...)
+	queue := missingStateData(sched, 0)
 	for len(queue) > 0 {
 		// Sync only half of the scheduled nodes
 		results := make([]trie.SyncResult, len(queue)/2+1)
 		for i, hash := range queue[:len(results)] {
-			data, err := srcDb.TrieDB().Node(hash)
-			if err != nil {
-				t.Fatalf("failed to retrieve node data for %x", hash)
-			}
-			results[i] = trie.SyncResult{Hash: hash, Data: data}
-		}
-		if _, index, err := sched.Process(results); err != nil {
-			t.Fatalf("failed to process result #%d: %v", index, err)
-		}
-		if index, err := sched.Commit(dstDb); err != nil {
-			t.Fatalf("failed to commit data #%d: %v", index, err)
+			results[i] = fetchStateData(t, srcDb, hash)
 		}
-		queue = append(queue[len(results):], sched.Missing(0)...)
+		processStateData(t, sched, dstDb, results)
+		queue = append(queue[len(results):], missingStateData(sched, 0)...)
 	}
 	// Cross check that the two states are in sync
 	checkStateAccounts(t, dstDb, srcRoot, srcAccounts)
@@ -208,32 +233,23 @@ func testIterativeRandomStateSync(t *testing.T, batch int) {
 	srcDb, srcRoot, srcAccounts := makeTestState()
 
 	// Create a destination state and sync with the scheduler
-	dstDb := ethdb.NewMemDatabase()
-	sched := NewStateSync(srcRoot, dstDb)
+	dstDb := rawdb.NewMemoryDatabase()
+	sched := newTestStateSync(srcRoot, dstDb)
 
 	queue := make(map[common.Hash]struct{})
-	for _, hash := range sched.Missing(batch) {
+	for _, hash := range missingStateData(sched, batch) {
 		queue[hash] = struct{}{}
 	}
 	for len(queue) > 0 {
 		// Fetch all the queued nodes in a random order
 		results := make([]trie.SyncResult, 0, len(queue))
 		for hash := range queue {
-			data, err := srcDb.TrieDB().Node(hash)
-			if err != nil {
-				t.Fatalf("failed to retrieve node data for %x", hash)
-			}
-			results = append(results, trie.SyncResult{Hash: hash, Data: data})
+			results = append(results, fetchStateData(t, srcDb, hash))
 		}
 		// Feed the retrieved results back and queue new tasks
-		if _, index, err := sched.Process(results); err != nil {
-			t.Fatalf("failed to process result #%d: %v", index, err)
-		}
-		if index, err := sched.Commit(dstDb); err != nil {
-			t.Fatalf("failed to commit data #%d: %v", index, err)
-		}
+		processStateData(t, sched, dstDb, results)
 		queue = make(map[common.Hash]struct{})
-		for _, hash := range sched.Missing(batch) {
+		for _, hash := range missingStateData(sched, batch) {
 			queue[hash] = struct{}{}
 		}
 	}
@@ -248,11 +264,11 @@ func TestIterativeRandomDelayedStateSync(t *testing.T) {
 	srcDb, srcRoot, srcAccounts := makeTestState()
 
 	// Create a destination state and sync with the scheduler
-	dstDb := ethdb.NewMemDatabase()
-	sched := NewStateSync(srcRoot, dstDb)
+	dstDb := rawdb.NewMemoryDatabase()
+	sched := newTestStateSync(srcRoot, dstDb)
 
 	queue := make(map[common.Hash]struct{})
-	for _, hash := range sched.Missing(0) {
+	for _, hash := range missingStateData(sched, 0) {
 		queue[hash] = struct{}{}
 	}
 	for len(queue) > 0 {
@@ -260,25 +276,15 @@ func TestIterativeRandomDelayedStateSync(t *testing.T) {
 		results := make([]trie.SyncResult, 0, len(queue)/2+1)
 		for hash := range queue {
 			delete(queue, hash)
-
-			data, err := srcDb.TrieDB().Node(hash)
-			if err != nil {
-				t.Fatalf("failed to retrieve node data for %x", hash)
-			}
-			results = append(results, trie.SyncResult{Hash: hash, Data: data})
+			results = append(results, fetchStateData(t, srcDb, hash))
 
 			if len(results) >= cap(results) {
 				break
 			}
 		}
 		// Feed the retrieved results back and queue new tasks
-		if _, index, err := sched.Process(results); err != nil {
-			t.Fatalf("failed to process result #%d: %v", index, err)
-		}
-		if index, err := sched.Commit(dstDb); err != nil {
-			t.Fatalf("failed to commit data #%d: %v", index, err)
-		}
-		for _, hash := range sched.Missing(0) {
+		processStateData(t, sched, dstDb, results)
+		for _, hash := range missingStateData(sched, 0) {
 			queue[hash] = struct{}{}
 		}
 	}
@@ -292,41 +298,35 @@ func TestIncompleteStateSync(t *testing.T) {
 	// Create a random state to copy
 	srcDb, srcRoot, srcAccounts := makeTestState()
 
+	isCode := make(map[common.Hash]bool)
+	for _, acc := range srcAccounts {
+		if len(acc.code) > 0 {
+			isCode[crypto.Keccak256Hash(acc.code)] = true
+		}
+	}
 	checkTrieConsistency(srcDb.TrieDB().DiskDB().(ethdb.Database), srcRoot)
 
 	// Create a destination state and sync with the scheduler
-	dstDb := ethdb.NewMemDatabase()
-	sched := NewStateSync(srcRoot, dstDb)
+	dstDb := rawdb.NewMemoryDatabase()
+	sched := newTestStateSync(srcRoot, dstDb)
 
 	added := []common.Hash{}
-	queue := append([]common.Hash{}, sched.Missing(1)...)
+	queue := missingStateData(sched, 1)
 	for len(queue) > 0 {
 		// Fetch a batch of state nodes
 		results := make([]trie.SyncResult, len(queue))
 		for i, hash := range queue {
-			data, err := srcDb.TrieDB().Node(hash)
-			if err != nil {
-				t.Fatalf("failed to retrieve node data for %x", hash)
-			}
-			results[i] = trie.SyncResult{Hash: hash, Data: data}
+			results[i] = fetchStateData(t, srcDb, hash)
 		}
 		// Process each of the state nodes
-		if _, index, err := sched.Process(results); err != nil {
-			t.Fatalf("failed to process result #%d: %v", index, err)
-		}
-		if index, err := sched.Commit(dstDb); err != nil {
-			t.Fatalf("failed to commit data #%d: %v", index, err)
-		}
+		processStateData(t, sched, dstDb, results)
 		for _, result := range results {
 			added = append(added, result.Hash)
 		}
 		// Check that all known sub-tries added so far are complete or missing entirely.
-	checkSubtries:
 		for _, hash := range added {
-			for _, acc := range srcAccounts {
-				if hash == crypto.Keccak256Hash(acc.code) {
-					continue checkSubtries // skip trie check of code nodes.
-				}
+			if isCode[hash] {
+				continue // skip trie check of code nodes.
 			}
 			// Can't use checkStateConsistency here because subtrie keys may have odd
 			// length and crash in LeafKey.
@@ -335,17 +335,24 @@ func TestIncompleteStateSync(t *testing.T) {
 			}
 		}
 		// Fetch the next batch to retrieve
-		queue = append(queue[:0], sched.Missing(1)...)
+		queue = missingStateData(sched, 1)
 	}
 	// Sanity check that removing any node from the database is detected
 	for _, node := range added[1:] {
-		key := node.Bytes()
-		value, _ := dstDb.Get(key)
-
-		dstDb.Delete(key)
+		if isCode[node] {
+			code := rawdb.ReadCode(dstDb, node)
+			rawdb.DeleteCode(dstDb, node)
+			if err := checkStateConsistency(dstDb, added[0]); err == nil {
+				t.Fatalf("trie inconsistency not caught, missing code: %x", node)
+			}
+			rawdb.WriteCode(dstDb, node, code)
+			continue
+		}
+		value := rawdb.ReadTrieNode(dstDb, node)
+		rawdb.DeleteTrieNode(dstDb, node)
 		if err := checkStateConsistency(dstDb, added[0]); err == nil {
-			t.Fatalf("trie inconsistency not caught, missing: %x", key)
+			t.Fatalf("trie inconsistency not caught, missing: %x", node)
 		}
-		dstDb.Put(key, value)
+		rawdb.WriteTrieNode(dstDb, node, value)
 	}
 }
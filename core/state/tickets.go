@@ -0,0 +1,405 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/crypto"
+	"github.com/FusionFoundation/efsn/ethdb/memorydb"
+	"github.com/FusionFoundation/efsn/rlp"
+	"github.com/FusionFoundation/efsn/trie"
+)
+
+// Ticket storage layout used once common.TicketStorageFork is reached.
+//
+// Instead of a single gzipped RLP blob kept in the code area of the ticket key
+// account, every ticket lives in its own storage slots of that account, keyed
+// by the ticket id. The ticket key account holds nothing else, so its storage
+// root only depends on the ticket set and not on the order of the changes
+// which led to it. That root is the tickets commitment of the block header and
+// can be recomputed from a ticket set alone, see ticketsStorageRoot.
+//
+//	ticket, id, 0                      -> exists marker | owner address
+//	ticket, id, 1                      -> height | start time | expire time
+//
+// The lists needed to enumerate the tickets live in the ticket index account.
+// They are arrays with swap-remove semantics, so adding or removing a ticket
+// touches a constant number of slots, and they aren't part of the commitment.
+//
+//	layout                             -> layout version
+//	count                              -> total number of tickets
+//	owners                             -> number of owners
+//	owners, i                          -> owner address
+//	owner, addr                        -> owner index + 1 | number of owner tickets
+//	owner, addr, i                     -> ticket id
+//	position, id                       -> owner list index | expiry bucket | expiry bucket index
+//	expiry, bucket                     -> number of tickets in bucket
+//	expiry, bucket, i                  -> ticket id
+//	cursor                             -> oldest expiry bucket which may hold tickets
+const (
+	ticketStorageVersion = 1
+
+	// ticketExpiryBucket is the time span (in seconds) grouped into one expiry bucket.
+	ticketExpiryBucket = 3600
+)
+
+var (
+	ticketLayoutKey   = ticketStorageKey([]byte("layout"))
+	ticketCountKey    = ticketStorageKey([]byte("count"))
+	ticketOwnersKey   = ticketStorageKey([]byte("owners"))
+	ticketCursorKey   = ticketStorageKey([]byte("cursor"))
+	ticketOwnerTag    = []byte("owner")
+	ticketTag         = []byte("ticket")
+	ticketPositionTag = []byte("position")
+	ticketExpiryTag   = []byte("expiry")
+	ticketOwnersTag   = []byte("owners")
+	ticketExistsFlag  = byte(1)
+)
+
+func ticketStorageKey(parts ...[]byte) common.Hash {
+	return crypto.Keccak256Hash(append([][]byte{[]byte("tickets.")}, parts...)...)
+}
+
+func uint64Bytes(v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return buf[:]
+}
+
+func ownerSlotKey(i uint64) common.Hash {
+	return ticketStorageKey(ticketOwnersTag, uint64Bytes(i))
+}
+
+func ownerInfoKey(owner common.Address) common.Hash {
+	return ticketStorageKey(ticketOwnerTag, owner[:])
+}
+
+func ownerTicketKey(owner common.Address, i uint64) common.Hash {
+	return ticketStorageKey(ticketOwnerTag, owner[:], uint64Bytes(i))
+}
+
+func ticketSlotKey(id common.Hash, n byte) common.Hash {
+	return ticketStorageKey(ticketTag, id[:], []byte{n})
+}
+
+func ticketPositionKey(id common.Hash) common.Hash {
+	return ticketStorageKey(ticketPositionTag, id[:])
+}
+
+func expiryBucketKey(bucket uint64) common.Hash {
+	return ticketStorageKey(ticketExpiryTag, uint64Bytes(bucket))
+}
+
+func expirySlotKey(bucket, i uint64) common.Hash {
+	return ticketStorageKey(ticketExpiryTag, uint64Bytes(bucket), uint64Bytes(i))
+}
+
+// storedTicket is a ticket together with its positions in the storage lists.
+type storedTicket struct {
+	common.Ticket
+	ownerIndex  uint64
+	bucket      uint64
+	bucketIndex uint64
+}
+
+// packUint64s packs up to four values into a storage slot, right aligned.
+func packUint64s(values ...uint64) common.Hash {
+	var h common.Hash
+	offset := common.HashLength - 8*len(values)
+	for i, v := range values {
+		binary.BigEndian.PutUint64(h[offset+8*i:], v)
+	}
+	return h
+}
+
+// unpackUint64 returns the n-th of count values packed by packUint64s.
+func unpackUint64(h common.Hash, n, count int) uint64 {
+	offset := common.HashLength - 8*count + 8*n
+	return binary.BigEndian.Uint64(h[offset : offset+8])
+}
+
+// ticketRecord returns the committed storage slots of a ticket.
+func ticketRecord(t *common.Ticket) (head common.Hash, body common.Hash) {
+	head[0] = ticketExistsFlag
+	copy(head[common.HashLength-common.AddressLength:], t.Owner[:])
+	return head, packUint64s(t.Height, t.StartTime, t.ExpireTime)
+}
+
+// ticketsStorageRoot computes the storage root the ticket key account has
+// when it holds the given ticket set, the same way the state object encodes
+// its storage.
+func ticketsStorageRoot(tickets common.TicketsDataSlice) (common.Hash, error) {
+	tr, err := trie.NewSecure(common.Hash{}, trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	update := func(key, value common.Hash) error {
+		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+		return tr.TryUpdate(key[:], v)
+	}
+	for _, v := range tickets {
+		for _, body := range v.Tickets {
+			head, record := ticketRecord(&common.Ticket{Owner: v.Owner, TicketBody: body})
+			if err := update(ticketSlotKey(body.ID, 0), head); err != nil {
+				return common.Hash{}, err
+			}
+			if err := update(ticketSlotKey(body.ID, 1), record); err != nil {
+				return common.Hash{}, err
+			}
+		}
+	}
+	return tr.Hash(), nil
+}
+
+func (s *StateDB) getTicketUint(key common.Hash) uint64 {
+	return unpackUint64(s.GetState(common.TicketIndexKeyAddress, key), 0, 1)
+}
+
+func (s *StateDB) setTicketUint(key common.Hash, v uint64) {
+	s.SetState(common.TicketIndexKeyAddress, key, packUint64s(v))
+}
+
+// isTicketStorage reports whether the tickets of this state are kept in the
+// per ticket storage layout.
+func (s *StateDB) isTicketStorage() bool {
+	return s.getTicketUint(ticketLayoutKey) == ticketStorageVersion
+}
+
+func (s *StateDB) readStoredTicket(id common.Hash) (*storedTicket, bool) {
+	head := s.GetState(common.TicketKeyAddress, ticketSlotKey(id, 0))
+	if head[0] != ticketExistsFlag {
+		return nil, false
+	}
+	body := s.GetState(common.TicketKeyAddress, ticketSlotKey(id, 1))
+	pos := s.GetState(common.TicketIndexKeyAddress, ticketPositionKey(id))
+	return &storedTicket{
+		Ticket: common.Ticket{
+			Owner: common.BytesToAddress(head[common.HashLength-common.AddressLength:]),
+			TicketBody: common.TicketBody{
+				ID:         id,
+				Height:     unpackUint64(body, 0, 3),
+				StartTime:  unpackUint64(body, 1, 3),
+				ExpireTime: unpackUint64(body, 2, 3),
+			},
+		},
+		ownerIndex:  unpackUint64(pos, 0, 3),
+		bucket:      unpackUint64(pos, 1, 3),
+		bucketIndex: unpackUint64(pos, 2, 3),
+	}, true
+}
+
+func (s *StateDB) writeStoredTicket(t *storedTicket) {
+	head, body := ticketRecord(&t.Ticket)
+	s.SetState(common.TicketKeyAddress, ticketSlotKey(t.ID, 0), head)
+	s.SetState(common.TicketKeyAddress, ticketSlotKey(t.ID, 1), body)
+	s.writeTicketPosition(t)
+}
+
+func (s *StateDB) writeTicketPosition(t *storedTicket) {
+	s.SetState(common.TicketIndexKeyAddress, ticketPositionKey(t.ID), packUint64s(t.ownerIndex, t.bucket, t.bucketIndex))
+}
+
+func (s *StateDB) deleteStoredTicket(id common.Hash) {
+	s.SetState(common.TicketKeyAddress, ticketSlotKey(id, 0), common.Hash{})
+	s.SetState(common.TicketKeyAddress, ticketSlotKey(id, 1), common.Hash{})
+	s.SetState(common.TicketIndexKeyAddress, ticketPositionKey(id), common.Hash{})
+}
+
+// ownerInfo returns the index + 1 of the owner in the owner list (0 if absent)
+// and the number of tickets of the owner.
+func (s *StateDB) ownerInfo(owner common.Address) (uint64, uint64) {
+	info := s.GetState(common.TicketIndexKeyAddress, ownerInfoKey(owner))
+	return unpackUint64(info, 0, 2), unpackUint64(info, 1, 2)
+}
+
+func (s *StateDB) setOwnerInfo(owner common.Address, index, count uint64) {
+	if count == 0 {
+		s.SetState(common.TicketIndexKeyAddress, ownerInfoKey(owner), common.Hash{})
+		return
+	}
+	s.SetState(common.TicketIndexKeyAddress, ownerInfoKey(owner), packUint64s(index, count))
+}
+
+// addStoredTicket inserts a ticket into the per ticket storage layout.
+func (s *StateDB) addStoredTicket(ticket *common.Ticket) error {
+	if _, exist := s.readStoredTicket(ticket.ID); exist {
+		return fmt.Errorf("AddTicket: %v ticket exist", ticket.ID.String())
+	}
+	// append to the owner list, registering the owner if needed
+	index, count := s.ownerInfo(ticket.Owner)
+	if index == 0 {
+		owners := s.getTicketUint(ticketOwnersKey)
+		s.SetState(common.TicketIndexKeyAddress, ownerSlotKey(owners), ticket.Owner.Hash())
+		s.setTicketUint(ticketOwnersKey, owners+1)
+		index = owners + 1
+	}
+	s.SetState(common.TicketIndexKeyAddress, ownerTicketKey(ticket.Owner, count), ticket.ID)
+	s.setOwnerInfo(ticket.Owner, index, count+1)
+
+	// append to the expiry bucket, tickets expiring before the cursor are kept
+	// in the cursor bucket so they are still cleared
+	bucket := ticket.ExpireTime / ticketExpiryBucket
+	if cursor := s.getTicketUint(ticketCursorKey); bucket < cursor {
+		bucket = cursor
+	}
+	size := s.getTicketUint(expiryBucketKey(bucket))
+	s.SetState(common.TicketIndexKeyAddress, expirySlotKey(bucket, size), ticket.ID)
+	s.setTicketUint(expiryBucketKey(bucket), size+1)
+
+	s.writeStoredTicket(&storedTicket{
+		Ticket:      *ticket,
+		ownerIndex:  count,
+		bucket:      bucket,
+		bucketIndex: size,
+	})
+	s.setTicketUint(ticketCountKey, s.getTicketUint(ticketCountKey)+1)
+	return nil
+}
+
+// removeStoredTicket deletes a ticket from the per ticket storage layout.
+func (s *StateDB) removeStoredTicket(id common.Hash) (*common.Ticket, error) {
+	t, exist := s.readStoredTicket(id)
+	if !exist {
+		return nil, fmt.Errorf("RemoveTicket: %v ticket not found", id.String())
+	}
+	// swap-remove from the owner list
+	index, count := s.ownerInfo(t.Owner)
+	if last := count - 1; t.ownerIndex != last {
+		moved := s.GetState(common.TicketIndexKeyAddress, ownerTicketKey(t.Owner, last))
+		s.SetState(common.TicketIndexKeyAddress, ownerTicketKey(t.Owner, t.ownerIndex), moved)
+		if mt, ok := s.readStoredTicket(moved); ok {
+			mt.ownerIndex = t.ownerIndex
+			s.writeTicketPosition(mt)
+		}
+	}
+	s.SetState(common.TicketIndexKeyAddress, ownerTicketKey(t.Owner, count-1), common.Hash{})
+	s.setOwnerInfo(t.Owner, index, count-1)
+
+	// drop the owner once its last ticket is gone
+	if count == 1 {
+		owners := s.getTicketUint(ticketOwnersKey)
+		if last := owners - 1; index-1 != last {
+			moved := common.BytesToAddress(s.GetState(common.TicketIndexKeyAddress, ownerSlotKey(last)).Bytes())
+			s.SetState(common.TicketIndexKeyAddress, ownerSlotKey(index-1), moved.Hash())
+			_, movedCount := s.ownerInfo(moved)
+			s.setOwnerInfo(moved, index, movedCount)
+		}
+		s.SetState(common.TicketIndexKeyAddress, ownerSlotKey(owners-1), common.Hash{})
+		s.setTicketUint(ticketOwnersKey, owners-1)
+	}
+
+	// swap-remove from the expiry bucket
+	size := s.getTicketUint(expiryBucketKey(t.bucket))
+	if last := size - 1; t.bucketIndex != last {
+		moved := s.GetState(common.TicketIndexKeyAddress, expirySlotKey(t.bucket, last))
+		s.SetState(common.TicketIndexKeyAddress, expirySlotKey(t.bucket, t.bucketIndex), moved)
+		if mt, ok := s.readStoredTicket(moved); ok {
+			mt.bucketIndex = t.bucketIndex
+			s.writeTicketPosition(mt)
+		}
+	}
+	s.SetState(common.TicketIndexKeyAddress, expirySlotKey(t.bucket, size-1), common.Hash{})
+	s.setTicketUint(expiryBucketKey(t.bucket), size-1)
+
+	s.deleteStoredTicket(id)
+	s.setTicketUint(ticketCountKey, s.getTicketUint(ticketCountKey)-1)
+	return &t.Ticket, nil
+}
+
+// storedTicketsByAddress reads all tickets of an owner.
+func (s *StateDB) storedTicketsByAddress(owner common.Address) common.TicketsData {
+	_, count := s.ownerInfo(owner)
+	data := common.TicketsData{
+		Owner:   owner,
+		Tickets: make(common.TicketBodySlice, 0, count),
+	}
+	for i := uint64(0); i < count; i++ {
+		id := s.GetState(common.TicketIndexKeyAddress, ownerTicketKey(owner, i))
+		if t, ok := s.readStoredTicket(id); ok {
+			data.Tickets = append(data.Tickets, t.TicketBody)
+		}
+	}
+	return data
+}
+
+// allStoredTickets reads the full ticket set out of the per ticket storage layout.
+func (s *StateDB) allStoredTickets() common.TicketsDataSlice {
+	owners := s.getTicketUint(ticketOwnersKey)
+	tickets := make(common.TicketsDataSlice, 0, owners)
+	for i := uint64(0); i < owners; i++ {
+		owner := common.BytesToAddress(s.GetState(common.TicketIndexKeyAddress, ownerSlotKey(i)).Bytes())
+		tickets = append(tickets, s.storedTicketsByAddress(owner))
+	}
+	return tickets
+}
+
+// clearExpiredStoredTickets removes all tickets expired at the given time,
+// visiting only the expiry buckets between the cursor and the current one.
+func (s *StateDB) clearExpiredStoredTickets(timestamp uint64) error {
+	cursor, current := s.getTicketUint(ticketCursorKey), timestamp/ticketExpiryBucket
+	for bucket := cursor; bucket <= current; bucket++ {
+		// walk backwards, swap-remove only moves already visited entries
+		for i := s.getTicketUint(expiryBucketKey(bucket)); i > 0; i-- {
+			id := s.GetState(common.TicketIndexKeyAddress, expirySlotKey(bucket, i-1))
+			t, ok := s.readStoredTicket(id)
+			if !ok || t.ExpireTime > timestamp {
+				continue
+			}
+			if _, err := s.removeStoredTicket(id); err != nil {
+				return err
+			}
+			s.updateTicketsMirror(func(tickets common.TicketsDataSlice) (common.TicketsDataSlice, error) {
+				return tickets.RemoveTicket(id)
+			})
+		}
+	}
+	if current > cursor {
+		s.setTicketUint(ticketCursorKey, current)
+	}
+	if s.getTicketUint(ticketCountKey) == 0 {
+		return fmt.Errorf("Next block have no ticket, wait buy ticket.")
+	}
+	return nil
+}
+
+// migrateTickets moves the compressed ticket blob into the per ticket storage layout.
+func (s *StateDB) migrateTickets(tickets common.TicketsDataSlice, timestamp uint64) error {
+	s.setTicketUint(ticketCursorKey, timestamp/ticketExpiryBucket)
+	for _, v := range tickets {
+		for _, t := range v.Tickets {
+			if err := s.addStoredTicket(&common.Ticket{Owner: v.Owner, TicketBody: t}); err != nil {
+				return err
+			}
+		}
+	}
+	s.setTicketUint(ticketLayoutKey, ticketStorageVersion)
+	s.SetData(common.TicketKeyAddress, nil)
+	return nil
+}
+
+// ticketsRoot returns the storage root of the ticket key account, which is
+// the commitment of the ticket set in the per ticket storage layout.
+func (s *StateDB) ticketsRoot() common.Hash {
+	obj := s.getStateObject(common.TicketKeyAddress)
+	if obj == nil {
+		return emptyRoot
+	}
+	obj.updateRoot(s.db)
+	return obj.data.Root
+}
+
+// updateTicketsMirror applies a change to the in-memory ticket set if loaded.
+func (s *StateDB) updateTicketsMirror(update func(common.TicketsDataSlice) (common.TicketsDataSlice, error)) {
+	s.ticketsDirty = true
+	if s.tickets == nil {
+		return
+	}
+	tickets, err := update(s.tickets)
+	if err != nil {
+		s.tickets = nil
+		return
+	}
+	s.tickets = tickets
+}
@@ -0,0 +1,149 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+)
+
+// The per ticket storage layout commits to the ticket set with the storage
+// root of the ticket key account, which must only depend on the ticket set so
+// that a ticket set served by a peer can be checked against a header.
+
+func withTicketStorage(t *testing.T) {
+	prev := common.GetForkConfig()
+	common.SetForkConfig(common.ForkConfig{TicketStorageBlock: big.NewInt(1)})
+	t.Cleanup(func() { common.SetForkConfig(prev) })
+}
+
+func newTicketState(t *testing.T) *StateDB {
+	statedb, err := New(common.Hash{}, common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statedb
+}
+
+func testTicket(owner, id byte, expire uint64) common.Ticket {
+	return common.Ticket{
+		Owner: common.BytesToAddress([]byte{owner}),
+		TicketBody: common.TicketBody{
+			ID:         common.BytesToHash([]byte{id}),
+			Height:     uint64(id),
+			StartTime:  1000,
+			ExpireTime: expire,
+		},
+	}
+}
+
+func addTickets(t *testing.T, statedb *StateDB, tickets ...common.Ticket) {
+	for _, ticket := range tickets {
+		if err := statedb.AddTicket(ticket); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func updateTickets(t *testing.T, statedb *StateDB, number int64, time uint64) common.Hash {
+	hash, err := statedb.UpdateTickets(big.NewInt(number), time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestTicketStorageCommitmentOrder(t *testing.T) {
+	withTicketStorage(t)
+
+	var (
+		t1 = testTicket(1, 1, 100000)
+		t2 = testTicket(1, 2, 200000)
+		t3 = testTicket(2, 3, 300000)
+		t4 = testTicket(3, 4, 400000)
+	)
+	// migrate a blob, remove a ticket which makes the lists swap, add another
+	a := newTicketState(t)
+	addTickets(t, a, t1, t2, t3)
+	updateTickets(t, a, 0, 2000)
+	updateTickets(t, a, 1, 2000)
+	if err := a.RemoveTicket(t1.ID); err != nil {
+		t.Fatal(err)
+	}
+	addTickets(t, a, t4)
+	hashA := updateTickets(t, a, 2, 2000)
+
+	// reach the same ticket set through the migration in another order
+	b := newTicketState(t)
+	addTickets(t, b, t4, t3)
+	updateTickets(t, b, 1, 2000)
+	addTickets(t, b, t2)
+	hashB := updateTickets(t, b, 2, 2000)
+
+	if hashA != hashB {
+		t.Fatalf("commitment depends on the change order: %x != %x", hashA, hashB)
+	}
+	if have := a.TicketsCommitment(); have != hashA {
+		t.Fatalf("commitment mismatch: have %x, want %x", have, hashA)
+	}
+	tickets, err := b.AllTickets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := tickets.NumberOfTickets(); n != 3 {
+		t.Fatalf("ticket count mismatch: have %d, want 3", n)
+	}
+	if err := AddCachedTickets(big.NewInt(2), hashA, tickets); err != nil {
+		t.Fatalf("ticket set rejected: %v", err)
+	}
+
+	// a ticket set differing in any field doesn't match the commitment
+	forged := tickets.DeepCopy()
+	forged[0].Tickets[0].ExpireTime++
+	if err := AddCachedTickets(big.NewInt(2), hashA, forged); err == nil {
+		t.Fatal("forged ticket set accepted")
+	}
+	forged, _ = tickets.DeepCopy().RemoveTicket(t2.ID)
+	if err := AddCachedTickets(big.NewInt(2), hashA, forged); err == nil {
+		t.Fatal("incomplete ticket set accepted")
+	}
+}
+
+func TestTicketStorageExpiry(t *testing.T) {
+	withTicketStorage(t)
+
+	var (
+		expiring = testTicket(1, 1, 5000)
+		staying  = testTicket(1, 2, 50000)
+		other    = testTicket(2, 3, 50000)
+	)
+	statedb := newTicketState(t)
+	addTickets(t, statedb, other)
+	updateTickets(t, statedb, 1, 1000)
+	addTickets(t, statedb, expiring, staying)
+	if n := statedb.TotalNumberOfTickets(); n != 3 {
+		t.Fatalf("ticket count mismatch: have %d, want 3", n)
+	}
+	hash := updateTickets(t, statedb, 2, 10000)
+
+	if statedb.IsTicketExist(expiring.ID) {
+		t.Fatal("expired ticket not cleared")
+	}
+	if ticket, err := statedb.GetTicket(staying.ID); err != nil || *ticket != staying {
+		t.Fatalf("ticket mismatch: have %v, err %v", ticket, err)
+	}
+	owned, err := statedb.AllTicketsByAddress(staying.Owner)
+	if err != nil || len(owned.Tickets) != 1 || owned.Tickets[0].ID != staying.ID {
+		t.Fatalf("owner tickets mismatch: have %v, err %v", owned, err)
+	}
+
+	// the commitment equals the one of a state only ever holding the rest
+	rest := newTicketState(t)
+	addTickets(t, rest, staying)
+	updateTickets(t, rest, 1, 1000)
+	addTickets(t, rest, other)
+	if want := updateTickets(t, rest, 2, 10000); hash != want {
+		t.Fatalf("commitment after expiry mismatch: have %x, want %x", hash, want)
+	}
+}
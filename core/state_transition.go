@@ -28,6 +28,7 @@ import (
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
 	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core/fsnlog"
 	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/core/vm"
 	"github.com/FusionFoundation/efsn/crypto"
@@ -68,6 +69,7 @@ type StateTransition struct {
 	data       []byte
 	state      vm.StateDB
 	evm        *vm.EVM
+	logErr     error // set if an FSN call log can't be encoded
 }
 
 // Message represents a message sent to a contract.
@@ -288,7 +290,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	if common.IsFsnCall(msg.To()) {
 		fsnCallParam = &common.FSNCallParam{}
 		rlp.DecodeBytes(msg.Data(), fsnCallParam)
-		st.fee = common.GetFsnCallFee(msg.To(), fsnCallParam.Func)
+		st.fee = common.GetFsnCallFee(msg.To(), fsnCallParam, st.evm.Context.BlockNumber)
 	}
 
 	// First check this message satisfies all consensus rules before
@@ -316,6 +318,9 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	if fsnCallParam != nil {
+		gas += common.GetFsnCallGas(msg.To(), fsnCallParam, st.evm.Context.BlockNumber)
+	}
 	if st.gas < gas {
 		return nil, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, st.gas, gas)
 	}
@@ -341,10 +346,15 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
 
 		if fsnCallParam != nil {
+			snapshot := st.state.Snapshot()
 			errc := st.handleFsnCall(fsnCallParam)
+			if st.logErr != nil {
+				st.state.RevertToSnapshot(snapshot)
+				errc = st.logErr
+			}
 			if errc != nil {
 				isInMining := st.evm.Context.MixDigest == (common.Hash{})
-				if isInMining {
+				if isInMining || st.evm.Config.RejectFailedFsnCalls {
 					// don't pack tx if handle FsnCall meet error
 					return nil, errc
 				}
@@ -538,36 +548,13 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 			st.addLog(common.TimeLockFunc, timeLockParam, common.NewKeyValue("LockType", "TimeLockToAsset"), common.NewKeyValue("AssetID", timeLockParam.AssetID))
 			return nil
 		case common.SmartTransfer:
-			if !common.IsSmartTransferEnabled(height) {
+			if !common.IsForkEnabled(common.SmartTransferFork, height) {
 				st.addLog(common.TimeLockFunc, timeLockParam, common.NewKeyValue("LockType", "SmartTransfer"), common.NewKeyValue("Error", "not enabled"))
 				return fmt.Errorf("SendTimeLock not enabled")
 			}
-			timeLockBalance := st.state.GetTimeLockBalance(timeLockParam.AssetID, st.msg.From())
-			if timeLockBalance.Cmp(needValue) < 0 {
-				timeLockValue := timeLockBalance.GetSpendableValue(start, end)
-				assetBalance := st.state.GetBalance(timeLockParam.AssetID, st.msg.From())
-				if new(big.Int).Add(timeLockValue, assetBalance).Cmp(timeLockParam.Value) < 0 {
-					st.addLog(common.TimeLockFunc, timeLockParam, common.NewKeyValue("LockType", "SmartTransfer"), common.NewKeyValue("Error", "not enough balance"))
-					return fmt.Errorf("not enough balance")
-				}
-				if timeLockValue.Sign() > 0 {
-					subTimeLock := common.GetTimeLock(timeLockValue, start, end)
-					st.state.SubTimeLockBalance(st.msg.From(), timeLockParam.AssetID, subTimeLock, height, timestamp)
-				}
-				useAssetAmount := new(big.Int).Sub(timeLockParam.Value, timeLockValue)
-				st.state.SubBalance(st.msg.From(), timeLockParam.AssetID, useAssetAmount)
-				surplus := common.GetSurplusTimeLock(useAssetAmount, start, end, timestamp)
-				if !surplus.IsEmpty() {
-					st.state.AddTimeLockBalance(st.msg.From(), timeLockParam.AssetID, surplus, height, timestamp)
-				}
-			} else {
-				st.state.SubTimeLockBalance(st.msg.From(), timeLockParam.AssetID, needValue, height, timestamp)
-			}
-
-			if !common.IsWholeAsset(start, end, timestamp) {
-				st.state.AddTimeLockBalance(timeLockParam.To, timeLockParam.AssetID, needValue, height, timestamp)
-			} else {
-				st.state.AddBalance(timeLockParam.To, timeLockParam.AssetID, timeLockParam.Value)
+			if err := st.smartTransfer(timeLockParam.To, timeLockParam.AssetID, timeLockParam.Value, start, end, needValue, height, timestamp); err != nil {
+				st.addLog(common.TimeLockFunc, timeLockParam, common.NewKeyValue("LockType", "SmartTransfer"), common.NewKeyValue("Error", err.Error()))
+				return err
 			}
 			st.addLog(common.TimeLockFunc, timeLockParam, common.NewKeyValue("LockType", "SmartTransfer"), common.NewKeyValue("AssetID", timeLockParam.AssetID))
 			return nil
@@ -1444,6 +1431,141 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 		}
 		st.addLog(common.TakeMultiSwapFunc, takeSwapParam, common.NewKeyValue("SwapID", swap.ID), common.NewKeyValue("Deleted", swapDeleted))
 		return nil
+	case common.BatchSendAssetFunc:
+		if !common.IsForkEnabled(common.BatchSendAssetFork, height) {
+			break
+		}
+		outputCommandInfo("BatchSendAssetFunc", "from", st.msg.From())
+		batchSendAssetParam := common.BatchSendAssetParam{}
+		rlp.DecodeBytes(param.Data, &batchSendAssetParam)
+		if err := batchSendAssetParam.Check(height, timestamp); err != nil {
+			st.addLog(common.BatchSendAssetFunc, common.BatchSendAssetEntry{}, common.NewKeyValue("Error", err.Error()))
+			return err
+		}
+		// the entries are applied all or none, a failing entry reverts the
+		// entries before it along with their logs.
+		snapshot := st.state.Snapshot()
+		for i, entry := range batchSendAssetParam.Entries {
+			start := entry.StartTime
+			if start < timestamp {
+				start = timestamp
+			}
+			needValue := common.NewTimeLock(&common.TimeLockItem{
+				StartTime: start,
+				EndTime:   entry.EndTime,
+				Value:     new(big.Int).Set(entry.Value),
+			})
+			err := needValue.IsValid()
+			if err == nil {
+				err = st.smartTransfer(entry.To, entry.AssetID, entry.Value, start, entry.EndTime, needValue, height, timestamp)
+			}
+			if err != nil {
+				st.state.RevertToSnapshot(snapshot)
+				st.addLog(common.BatchSendAssetFunc, entry, common.NewKeyValue("Index", uint64(i)), common.NewKeyValue("Error", err.Error()))
+				return fmt.Errorf("entry %d: %v", i, err)
+			}
+			st.addLog(common.BatchSendAssetFunc, entry, common.NewKeyValue("Index", uint64(i)))
+		}
+		return nil
+	case common.TransferAssetOwnerFunc:
+		if !common.IsForkEnabled(common.AssetUpdateFork, height) {
+			break
+		}
+		outputCommandInfo("TransferAssetOwnerFunc", "from", st.msg.From())
+		transferAssetOwnerParam := common.TransferAssetOwnerParam{}
+		rlp.DecodeBytes(param.Data, &transferAssetOwnerParam)
+		if err := transferAssetOwnerParam.Check(height); err != nil {
+			st.addLog(common.TransferAssetOwnerFunc, transferAssetOwnerParam, common.NewKeyValue("Error", err.Error()))
+			return err
+		}
+		asset, err := st.state.GetAsset(transferAssetOwnerParam.AssetID)
+		if err != nil {
+			st.addLog(common.TransferAssetOwnerFunc, transferAssetOwnerParam, common.NewKeyValue("Error", "asset not found"))
+			return fmt.Errorf("asset not found")
+		}
+		if asset.Owner != st.msg.From() {
+			st.addLog(common.TransferAssetOwnerFunc, transferAssetOwnerParam, common.NewKeyValue("Error", "can only be changed by owner"))
+			return fmt.Errorf("can only be changed by owner")
+		}
+		asset.Owner = transferAssetOwnerParam.To
+		if err := st.state.UpdateAsset(asset); err != nil {
+			st.addLog(common.TransferAssetOwnerFunc, transferAssetOwnerParam, common.NewKeyValue("Error", "error update asset"))
+			return err
+		}
+		st.addLog(common.TransferAssetOwnerFunc, transferAssetOwnerParam)
+		return nil
+	case common.UpdateAssetFunc:
+		if !common.IsForkEnabled(common.AssetUpdateFork, height) {
+			break
+		}
+		outputCommandInfo("UpdateAssetFunc", "from", st.msg.From())
+		updateAssetParam := common.UpdateAssetParam{}
+		rlp.DecodeBytes(param.Data, &updateAssetParam)
+		if err := updateAssetParam.Check(height); err != nil {
+			st.addLog(common.UpdateAssetFunc, updateAssetParam, common.NewKeyValue("Error", err.Error()))
+			return err
+		}
+		asset, err := st.state.GetAsset(updateAssetParam.AssetID)
+		if err != nil {
+			st.addLog(common.UpdateAssetFunc, updateAssetParam, common.NewKeyValue("Error", "asset not found"))
+			return fmt.Errorf("asset not found")
+		}
+		if asset.Owner != st.msg.From() {
+			st.addLog(common.UpdateAssetFunc, updateAssetParam, common.NewKeyValue("Error", "can only be changed by owner"))
+			return fmt.Errorf("can only be changed by owner")
+		}
+		if updateAssetParam.CanChange && !asset.CanChange {
+			st.addLog(common.UpdateAssetFunc, updateAssetParam, common.NewKeyValue("Error", "asset can't be made changeable again"))
+			return fmt.Errorf("asset can't be made changeable again")
+		}
+		asset.Description = updateAssetParam.Description
+		asset.CanChange = updateAssetParam.CanChange
+		if err := st.state.UpdateAsset(asset); err != nil {
+			st.addLog(common.UpdateAssetFunc, updateAssetParam, common.NewKeyValue("Error", "error update asset"))
+			return err
+		}
+		st.addLog(common.UpdateAssetFunc, updateAssetParam)
+		return nil
+	case common.TransferNotationFunc:
+		if !common.IsForkEnabled(common.NotationTransferFork, height) {
+			break
+		}
+		outputCommandInfo("TransferNotationFunc", "from", st.msg.From())
+		transferNotationParam := common.TransferNotationParam{}
+		rlp.DecodeBytes(param.Data, &transferNotationParam)
+		if err := transferNotationParam.Check(height); err != nil {
+			st.addLog(common.TransferNotationFunc, transferNotationParam, common.NewKeyValue("Error", err.Error()))
+			return err
+		}
+		if err := checkNotationTransfer(st.state, st.msg.From(), transferNotationParam.Notation, transferNotationParam.To); err != nil {
+			st.addLog(common.TransferNotationFunc, transferNotationParam, common.NewKeyValue("Error", err.Error()))
+			return err
+		}
+		if err := st.state.TransferNotation(transferNotationParam.Notation, st.msg.From(), transferNotationParam.To); err != nil {
+			st.addLog(common.TransferNotationFunc, transferNotationParam, common.NewKeyValue("Error", err.Error()))
+			return err
+		}
+		st.addLog(common.TransferNotationFunc, transferNotationParam)
+		return nil
+	case common.ReleaseNotationFunc:
+		if !common.IsForkEnabled(common.NotationTransferFork, height) {
+			break
+		}
+		outputCommandInfo("ReleaseNotationFunc", "from", st.msg.From())
+		releaseNotationParam := common.ReleaseNotationParam{}
+		rlp.DecodeBytes(param.Data, &releaseNotationParam)
+		if err := releaseNotationParam.Check(height); err != nil {
+			st.addLog(common.ReleaseNotationFunc, releaseNotationParam, common.NewKeyValue("Error", err.Error()))
+			return err
+		}
+		if notation := st.state.GetNotation(st.msg.From()); notation != releaseNotationParam.Notation {
+			err := fmt.Errorf("notation %d is not owned by the sender", releaseNotationParam.Notation)
+			st.addLog(common.ReleaseNotationFunc, releaseNotationParam, common.NewKeyValue("Error", err.Error()))
+			return err
+		}
+		st.state.BurnNotation(st.msg.From())
+		st.addLog(common.ReleaseNotationFunc, releaseNotationParam)
+		return nil
 	case common.ReportIllegalFunc:
 		if !common.IsMultipleMiningCheckingEnabled(height) {
 			return fmt.Errorf("report not enabled")
@@ -1456,16 +1578,81 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 		if err := st.state.AddReport(report); err != nil {
 			return err
 		}
-		delTickets := datong.ProcessReport(header1, header2, st.msg.From(), st.state, height, timestamp)
+		delTickets, penalty := datong.ProcessReport(header1, header2, st.msg.From(), st.state, height, timestamp)
 		enc, _ := rlp.EncodeToBytes(delTickets)
 		str := hexutil.Encode(enc)
-		st.addLog(common.ReportIllegalFunc, "", common.NewKeyValue("DeleteTickets", str))
+		keyValues := []*common.KeyValue{common.NewKeyValue("DeleteTickets", str)}
+		if st.evm.ChainConfig().IsFsnLogV2(height) {
+			// the time lock taken from the miner, as far as its balance covered the penalty
+			var starts, ends []uint64
+			var values []*big.Int
+			if penalty != nil {
+				for _, item := range penalty.Items {
+					starts, ends, values = append(starts, item.StartTime), append(ends, item.EndTime), append(values, item.Value)
+				}
+			}
+			keyValues = append(keyValues,
+				common.NewKeyValue("PenaltyStartTime", starts),
+				common.NewKeyValue("PenaltyEndTime", ends),
+				common.NewKeyValue("PenaltyValue", values))
+		}
+		st.addLog(common.ReportIllegalFunc, "", keyValues...)
 		common.DebugInfo("ReportIllegal", "reporter", st.msg.From(), "double-miner", header1.Coinbase, "current-block-height", height, "double-mining-height", header1.Number, "DeleteTickets", delTickets)
 		return nil
 	}
 	return fmt.Errorf("Unsupported")
 }
 
+// smartTransfer sends value of the asset from the sender to the receiver for
+// the time range, using the time lock balance of the sender covering it first
+// and its asset balance for the rest. The receiver gets asset balance if the
+// range covers now to forever and a time lock otherwise.
+func (st *StateTransition) smartTransfer(to common.Address, assetID common.Hash, value *big.Int, start, end uint64, needValue *common.TimeLock, height *big.Int, timestamp uint64) error {
+	from := st.msg.From()
+	timeLockBalance := st.state.GetTimeLockBalance(assetID, from)
+	if timeLockBalance.Cmp(needValue) < 0 {
+		timeLockValue := timeLockBalance.GetSpendableValue(start, end)
+		assetBalance := st.state.GetBalance(assetID, from)
+		if new(big.Int).Add(timeLockValue, assetBalance).Cmp(value) < 0 {
+			return fmt.Errorf("not enough balance")
+		}
+		if timeLockValue.Sign() > 0 {
+			subTimeLock := common.GetTimeLock(timeLockValue, start, end)
+			st.state.SubTimeLockBalance(from, assetID, subTimeLock, height, timestamp)
+		}
+		useAssetAmount := new(big.Int).Sub(value, timeLockValue)
+		st.state.SubBalance(from, assetID, useAssetAmount)
+		surplus := common.GetSurplusTimeLock(useAssetAmount, start, end, timestamp)
+		if !surplus.IsEmpty() {
+			st.state.AddTimeLockBalance(from, assetID, surplus, height, timestamp)
+		}
+	} else {
+		st.state.SubTimeLockBalance(from, assetID, needValue, height, timestamp)
+	}
+
+	if !common.IsWholeAsset(start, end, timestamp) {
+		st.state.AddTimeLockBalance(to, assetID, needValue, height, timestamp)
+	} else {
+		st.state.AddBalance(to, assetID, value)
+	}
+	return nil
+}
+
+// checkNotationTransfer checks the notation is the sender's one and the
+// receiver has none, the state would burn the receiver's notation otherwise.
+func checkNotationTransfer(state vm.StateDB, from common.Address, notation uint64, to common.Address) error {
+	if state.GetNotation(from) != notation {
+		return fmt.Errorf("notation %d is not owned by the sender", notation)
+	}
+	if to == from {
+		return fmt.Errorf("can not transfer the notation to the sender")
+	}
+	if n := state.GetNotation(to); n != 0 {
+		return fmt.Errorf("Account %s has a notation:%d", to.String(), n)
+	}
+	return nil
+}
+
 func (st *StateTransition) addLog(typ common.FSNCallFunc, value interface{}, keyValues ...*common.KeyValue) {
 
 	t := reflect.TypeOf(value)
@@ -1486,6 +1673,24 @@ func (st *StateTransition) addLog(typ common.FSNCallFunc, value interface{}, key
 		maps[keyValues[i].Key] = keyValues[i].Value
 	}
 
+	if st.evm.ChainConfig().IsFsnLogV2(st.evm.Context.BlockNumber) {
+		topics, data, err := fsnlog.Encode(typ, st.msg.From(), maps)
+		if err != nil {
+			// the call fails as a whole if its outcome can't be logged
+			if st.logErr == nil {
+				st.logErr = fmt.Errorf("failed to encode %s log: %v", typ.Name(), err)
+			}
+			return
+		}
+		st.evm.StateDB.AddLog(&types.Log{
+			Address:     common.FSNCallAddress,
+			Topics:      topics,
+			Data:        data,
+			BlockNumber: st.evm.Context.BlockNumber.Uint64(),
+		})
+		return
+	}
+
 	data, _ := json.Marshal(maps)
 
 	topic := common.Hash{}
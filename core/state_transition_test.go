@@ -0,0 +1,251 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/core/vm"
+	"github.com/FusionFoundation/efsn/params"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+var (
+	fsnCaller   = common.BytesToAddress([]byte{0x11})
+	fsnReceiver = common.BytesToAddress([]byte{0x12})
+	fsnCoinbase = common.BytesToAddress([]byte{0x13})
+)
+
+// newFsnCallState returns a state funding the FSN caller.
+func newFsnCallState(t *testing.T) *state.StateDB {
+	statedb, err := state.New(common.Hash{}, common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.AddBalance(fsnCaller, common.SystemAssetID, new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether)))
+	return statedb
+}
+
+// applyFsnCall applies an FSN call of the caller in a block sealed with the
+// mix digest, as done by a node importing the block.
+func applyFsnCall(t *testing.T, statedb *state.StateDB, vmConfig vm.Config, funcType common.FSNCallFunc, param interface{}) (*ExecutionResult, error) {
+	return applyFsnCallInput(t, statedb, vmConfig, 1000000, fsnCallInput(t, funcType, param))
+}
+
+// fsnCallInput encodes an FSN call as transaction data.
+func fsnCallInput(t *testing.T, funcType common.FSNCallFunc, param interface{}) []byte {
+	data, err := rlp.EncodeToBytes(param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := rlp.EncodeToBytes(&common.FSNCallParam{Func: funcType, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+// applyFsnCallInput applies the encoded FSN call with the gas limit.
+func applyFsnCallInput(t *testing.T, statedb *state.StateDB, vmConfig vm.Config, gas uint64, input []byte) (*ExecutionResult, error) {
+	number := big.NewInt(1)
+	msg := types.NewMessage(fsnCaller, &common.FSNCallAddress, statedb.GetNonce(fsnCaller), new(big.Int), gas, new(big.Int), new(big.Int), new(big.Int), input, nil, false)
+	blockCtx := vm.BlockContext{
+		CanTransfer:         CanTransfer,
+		Transfer:            Transfer,
+		CanTransferTimeLock: CanTransferTimeLock,
+		TransferTimeLock:    TransferTimeLock,
+		GetHash:             func(uint64) common.Hash { return common.Hash{} },
+		Coinbase:            fsnCoinbase,
+		GasLimit:            math.MaxUint64,
+		BlockNumber:         number,
+		Time:                big.NewInt(1000),
+		ParentTime:          big.NewInt(990),
+		Difficulty:          big.NewInt(1),
+		BaseFee:             new(big.Int),
+		MixDigest:           common.HexToHash("0x01"),
+	}
+	vmConfig.NoBaseFee = true
+	evm := vm.NewEVM(blockCtx, NewEVMTxContext(msg), statedb, params.DeveloperChainConfig, vmConfig)
+	statedb.Prepare(common.Hash{}, 0)
+	return ApplyMessage(evm, msg, new(GasPool).AddGas(math.MaxUint64))
+}
+
+func TestRejectFailedFsnCalls(t *testing.T) {
+	failing := &common.SendAssetParam{AssetID: common.SystemAssetID, To: fsnReceiver, Value: new(big.Int).Mul(big.NewInt(2000), big.NewInt(params.Ether))}
+
+	// a failed FSN call only logs its error when importing a block
+	statedb := newFsnCallState(t)
+	if result, err := applyFsnCall(t, statedb, vm.Config{}, common.SendAssetFunc, failing); err != nil || result.Failed() {
+		t.Fatalf("failed FSN call rejected on import: result %v, err %v", result, err)
+	}
+	if nonce := statedb.GetNonce(fsnCaller); nonce != 1 {
+		t.Fatalf("nonce mismatch: have %d, want 1", nonce)
+	}
+
+	// and rejects the transaction when asked to, like the miner does
+	statedb = newFsnCallState(t)
+	_, err := applyFsnCall(t, statedb, vm.Config{RejectFailedFsnCalls: true}, common.SendAssetFunc, failing)
+	if err == nil || !strings.Contains(err.Error(), "not enough asset") {
+		t.Fatalf("error mismatch: have %v, want not enough asset", err)
+	}
+
+	// successful calls are applied either way
+	statedb = newFsnCallState(t)
+	ok := &common.SendAssetParam{AssetID: common.SystemAssetID, To: fsnReceiver, Value: big.NewInt(params.Ether)}
+	if _, err := applyFsnCall(t, statedb, vm.Config{RejectFailedFsnCalls: true}, common.SendAssetFunc, ok); err != nil {
+		t.Fatalf("FSN call rejected: %v", err)
+	}
+	if balance := statedb.GetBalance(common.SystemAssetID, fsnReceiver); balance.Cmp(ok.Value) != 0 {
+		t.Fatalf("receiver balance mismatch: have %v, want %v", balance, ok.Value)
+	}
+}
+
+func TestBatchSendAssetGas(t *testing.T) {
+	prev := common.GetForkConfig()
+	t.Cleanup(func() { common.SetForkConfig(prev) })
+
+	second := common.BytesToAddress([]byte{0x14})
+	batch := &common.BatchSendAssetParam{Entries: []common.BatchSendAssetEntry{
+		{To: fsnReceiver, AssetID: common.SystemAssetID, Value: big.NewInt(params.Ether), EndTime: common.TimeLockForever},
+		{To: second, AssetID: common.SystemAssetID, Value: big.NewInt(params.Ether), EndTime: common.TimeLockForever},
+		{To: fsnReceiver, AssetID: common.SystemAssetID, Value: big.NewInt(params.Ether), StartTime: 2000, EndTime: 3000},
+	}}
+	input := fsnCallInput(t, common.BatchSendAssetFunc, batch)
+	intrinsic, err := IntrinsicGas(input, nil, false, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := intrinsic + 3*common.BatchSendAssetEntryGas
+
+	// each entry is charged on top of the intrinsic gas after the fork
+	common.SetForkConfig(common.ForkConfig{BatchSendAssetBlock: big.NewInt(1)})
+	statedb := newFsnCallState(t)
+	result, err := applyFsnCallInput(t, statedb, vm.Config{}, want, input)
+	if err != nil || result.Failed() {
+		t.Fatalf("batch send failed: result %v, err %v", result, err)
+	}
+	if result.UsedGas != want {
+		t.Fatalf("gas mismatch: have %d, want %d", result.UsedGas, want)
+	}
+	if balance := statedb.GetBalance(common.SystemAssetID, second); balance.Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Fatalf("receiver balance mismatch: have %v, want %v", balance, params.Ether)
+	}
+	// along with the fee of 0.001 FSN and 0.0001 FSN per entry
+	fee := new(big.Int).Add(big.NewInt(1000000000000000), big.NewInt(3*100000000000000))
+	if balance := statedb.GetBalance(common.SystemAssetID, fsnCoinbase); balance.Cmp(fee) != 0 {
+		t.Fatalf("fee mismatch: have %v, want %v", balance, fee)
+	}
+
+	// a batch short of the entry gas is rejected before being applied
+	statedb = newFsnCallState(t)
+	if _, err := applyFsnCallInput(t, statedb, vm.Config{}, want-1, input); !errors.Is(err, ErrIntrinsicGas) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrIntrinsicGas)
+	}
+	if balance := statedb.GetBalance(common.SystemAssetID, second); balance.Sign() != 0 {
+		t.Fatalf("rejected batch paid out %v", balance)
+	}
+
+	// and the entries aren't charged before the fork
+	common.SetForkConfig(common.ForkConfig{BatchSendAssetBlock: big.NewInt(2)})
+	statedb = newFsnCallState(t)
+	result, err = applyFsnCallInput(t, statedb, vm.Config{}, want, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.UsedGas != intrinsic {
+		t.Fatalf("gas mismatch before the fork: have %d, want %d", result.UsedGas, intrinsic)
+	}
+}
+
+func TestTransferNotation(t *testing.T) {
+	prev := common.GetForkConfig()
+	t.Cleanup(func() { common.SetForkConfig(prev) })
+	common.SetForkConfig(common.ForkConfig{NotationTransferBlock: big.NewInt(1)})
+
+	statedb := newFsnCallState(t)
+	if err := statedb.GenNotation(fsnCaller); err != nil {
+		t.Fatal(err)
+	}
+	notation := statedb.GetNotation(fsnCaller)
+	transfer := &common.TransferNotationParam{Notation: notation, To: fsnReceiver}
+	if _, err := applyFsnCall(t, statedb, vm.Config{RejectFailedFsnCalls: true}, common.TransferNotationFunc, transfer); err != nil {
+		t.Fatalf("notation transfer rejected: %v", err)
+	}
+	if n := statedb.GetNotation(fsnReceiver); n != notation {
+		t.Fatalf("receiver notation mismatch: have %d, want %d", n, notation)
+	}
+	if n := statedb.GetNotation(fsnCaller); n != 0 {
+		t.Fatalf("sender kept notation %d", n)
+	}
+	if addr, err := statedb.GetAddressByNotation(notation); err != nil || addr != fsnReceiver {
+		t.Fatalf("notation lookup mismatch: have %v (err %v), want %v", addr, err, fsnReceiver)
+	}
+	if fee := statedb.GetBalance(common.SystemAssetID, fsnCoinbase); fee.Cmp(big.NewInt(10000000000000000)) != 0 {
+		t.Fatalf("fee mismatch: have %v, want 0.01 FSN", fee)
+	}
+
+	// the notation is no longer the sender's to transfer
+	if _, err := applyFsnCall(t, statedb, vm.Config{RejectFailedFsnCalls: true}, common.TransferNotationFunc, transfer); err == nil || !strings.Contains(err.Error(), "not owned by the sender") {
+		t.Fatalf("error mismatch: have %v, want not owned by the sender", err)
+	}
+	// and a notation can't be sent to an account having one
+	if err := statedb.GenNotation(fsnCaller); err != nil {
+		t.Fatal(err)
+	}
+	transfer = &common.TransferNotationParam{Notation: statedb.GetNotation(fsnCaller), To: fsnReceiver}
+	if _, err := applyFsnCall(t, statedb, vm.Config{RejectFailedFsnCalls: true}, common.TransferNotationFunc, transfer); err == nil || !strings.Contains(err.Error(), "has a notation") {
+		t.Fatalf("error mismatch: have %v, want has a notation", err)
+	}
+
+	// nor transferred before the fork
+	common.SetForkConfig(common.ForkConfig{NotationTransferBlock: big.NewInt(2)})
+	if _, err := applyFsnCall(t, statedb, vm.Config{RejectFailedFsnCalls: true}, common.TransferNotationFunc, &common.TransferNotationParam{Notation: transfer.Notation, To: fsnCoinbase}); err == nil {
+		t.Fatal("notation transferred before the fork")
+	}
+	if n := statedb.GetNotation(fsnCoinbase); n != 0 {
+		t.Fatalf("notation %d transferred before the fork", n)
+	}
+}
+
+func TestReleaseNotation(t *testing.T) {
+	prev := common.GetForkConfig()
+	t.Cleanup(func() { common.SetForkConfig(prev) })
+	common.SetForkConfig(common.ForkConfig{NotationTransferBlock: big.NewInt(1)})
+
+	statedb := newFsnCallState(t)
+	if err := statedb.GenNotation(fsnCaller); err != nil {
+		t.Fatal(err)
+	}
+	notation := statedb.GetNotation(fsnCaller)
+
+	// only the owner releases a notation
+	if _, err := applyFsnCall(t, statedb, vm.Config{RejectFailedFsnCalls: true}, common.ReleaseNotationFunc, &common.ReleaseNotationParam{Notation: notation + 1}); err == nil || !strings.Contains(err.Error(), "not owned by the sender") {
+		t.Fatalf("error mismatch: have %v, want not owned by the sender", err)
+	}
+	if _, err := applyFsnCall(t, statedb, vm.Config{RejectFailedFsnCalls: true}, common.ReleaseNotationFunc, &common.ReleaseNotationParam{Notation: notation}); err != nil {
+		t.Fatalf("notation release rejected: %v", err)
+	}
+	if n := statedb.GetNotation(fsnCaller); n != 0 {
+		t.Fatalf("sender kept notation %d", n)
+	}
+	if _, err := statedb.GetAddressByNotation(notation); err == nil {
+		t.Fatal("released notation still assigned")
+	}
+	if fee := statedb.GetBalance(common.SystemAssetID, fsnCoinbase); fee.Cmp(big.NewInt(1000000000000000)) != 0 {
+		t.Fatalf("fee mismatch: have %v, want 0.001 FSN", fee)
+	}
+
+	// and the released notation is never assigned again
+	if err := statedb.GenNotation(fsnCaller); err != nil {
+		t.Fatal(err)
+	}
+	if n := statedb.GetNotation(fsnCaller); n == notation {
+		t.Fatalf("released notation %d assigned again", n)
+	}
+}
@@ -1940,7 +1940,16 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 		return fmt.Errorf("decode FSNCallParam error")
 	}
 
-	fee := common.GetFsnCallFee(to, param.Func)
+	fee := common.GetFsnCallFee(to, &param, nextBlockNumber)
+	if gas := common.GetFsnCallGas(to, &param, nextBlockNumber); gas > 0 {
+		intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), false, true, pool.istanbul)
+		if err != nil {
+			return err
+		}
+		if tx.Gas() < intrGas+gas {
+			return ErrIntrinsicGas
+		}
+	}
 	fsnValue := big.NewInt(0)
 
 	switch param.Func {
@@ -2017,7 +2026,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 				return fmt.Errorf("TimeLockToAsset: not enough time lock balance")
 			}
 		case common.SmartTransfer:
-			if !common.IsSmartTransferEnabled(nextBlockNumber) {
+			if !common.IsForkEnabled(common.SmartTransferFork, nextBlockNumber) {
 				return fmt.Errorf("SendTimeLock not enabled")
 			}
 			timeLockBalance := state.GetTimeLockBalance(timeLockParam.AssetID, from)
@@ -2414,6 +2423,120 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 			}
 		}
 
+	case common.BatchSendAssetFunc:
+		if !common.IsForkEnabled(common.BatchSendAssetFork, nextBlockNumber) {
+			return fmt.Errorf("Unsupported FsnCall func '%v'", param.Func.Name())
+		}
+		batchSendAssetParam := common.BatchSendAssetParam{}
+		rlp.DecodeBytes(param.Data, &batchSendAssetParam)
+		if err := batchSendAssetParam.Check(height, timestamp); err != nil {
+			return err
+		}
+		// spend the balances entry by entry like the state transition, the
+		// entries sending to the sender are not credited back.
+		balances := make(map[common.Hash]*big.Int)
+		timeLockBalances := make(map[common.Hash]*common.TimeLock)
+		for i, entry := range batchSendAssetParam.Entries {
+			if _, ok := balances[entry.AssetID]; !ok {
+				balances[entry.AssetID] = new(big.Int).Set(state.GetBalance(entry.AssetID, from))
+				timeLockBalances[entry.AssetID] = state.GetTimeLockBalance(entry.AssetID, from).Clone()
+			}
+			balance := balances[entry.AssetID]
+			timeLockBalance := timeLockBalances[entry.AssetID]
+
+			start := common.MaxUint64(entry.StartTime, timestamp)
+			needValue := common.NewTimeLock(&common.TimeLockItem{
+				StartTime: start,
+				EndTime:   entry.EndTime,
+				Value:     new(big.Int).Set(entry.Value),
+			})
+			if err := needValue.IsValid(); err != nil {
+				return fmt.Errorf("BatchSendAsset entry %d: %v", i, err)
+			}
+			if timeLockBalance.Cmp(needValue) >= 0 {
+				timeLockBalance.Sub(timeLockBalance, needValue)
+				continue
+			}
+			timeLockValue := timeLockBalance.GetSpendableValue(start, entry.EndTime)
+			useAssetAmount := new(big.Int).Sub(entry.Value, timeLockValue)
+			if balance.Cmp(useAssetAmount) < 0 {
+				return fmt.Errorf("BatchSendAsset entry %d: not enough balance", i)
+			}
+			if timeLockValue.Sign() > 0 {
+				timeLockBalance.Sub(timeLockBalance, common.GetTimeLock(timeLockValue, start, entry.EndTime))
+			}
+			balance.Sub(balance, useAssetAmount)
+			if surplus := common.GetSurplusTimeLock(useAssetAmount, start, entry.EndTime, timestamp); !surplus.IsEmpty() {
+				timeLockBalance.Add(timeLockBalance, surplus)
+			}
+			if entry.AssetID == common.SystemAssetID {
+				fsnValue.Add(fsnValue, useAssetAmount)
+			}
+		}
+
+	case common.TransferAssetOwnerFunc:
+		if !common.IsForkEnabled(common.AssetUpdateFork, nextBlockNumber) {
+			return fmt.Errorf("Unsupported FsnCall func '%v'", param.Func.Name())
+		}
+		transferAssetOwnerParam := common.TransferAssetOwnerParam{}
+		rlp.DecodeBytes(param.Data, &transferAssetOwnerParam)
+		if err := transferAssetOwnerParam.Check(height); err != nil {
+			return err
+		}
+		asset, err := state.GetAsset(transferAssetOwnerParam.AssetID)
+		if err != nil {
+			return fmt.Errorf("asset not found")
+		}
+		if asset.Owner != from {
+			return fmt.Errorf("can only be changed by owner")
+		}
+
+	case common.UpdateAssetFunc:
+		if !common.IsForkEnabled(common.AssetUpdateFork, nextBlockNumber) {
+			return fmt.Errorf("Unsupported FsnCall func '%v'", param.Func.Name())
+		}
+		updateAssetParam := common.UpdateAssetParam{}
+		rlp.DecodeBytes(param.Data, &updateAssetParam)
+		if err := updateAssetParam.Check(height); err != nil {
+			return err
+		}
+		asset, err := state.GetAsset(updateAssetParam.AssetID)
+		if err != nil {
+			return fmt.Errorf("asset not found")
+		}
+		if asset.Owner != from {
+			return fmt.Errorf("can only be changed by owner")
+		}
+		if updateAssetParam.CanChange && !asset.CanChange {
+			return fmt.Errorf("asset can't be made changeable again")
+		}
+
+	case common.TransferNotationFunc:
+		if !common.IsForkEnabled(common.NotationTransferFork, nextBlockNumber) {
+			return fmt.Errorf("Unsupported FsnCall func '%v'", param.Func.Name())
+		}
+		transferNotationParam := common.TransferNotationParam{}
+		rlp.DecodeBytes(param.Data, &transferNotationParam)
+		if err := transferNotationParam.Check(height); err != nil {
+			return err
+		}
+		if err := checkNotationTransfer(state, from, transferNotationParam.Notation, transferNotationParam.To); err != nil {
+			return err
+		}
+
+	case common.ReleaseNotationFunc:
+		if !common.IsForkEnabled(common.NotationTransferFork, nextBlockNumber) {
+			return fmt.Errorf("Unsupported FsnCall func '%v'", param.Func.Name())
+		}
+		releaseNotationParam := common.ReleaseNotationParam{}
+		rlp.DecodeBytes(param.Data, &releaseNotationParam)
+		if err := releaseNotationParam.Check(height); err != nil {
+			return err
+		}
+		if n := state.GetNotation(from); n != releaseNotationParam.Notation {
+			return fmt.Errorf("notation %d is not owned by the sender", releaseNotationParam.Notation)
+		}
+
 	case common.ReportIllegalFunc:
 		if _, _, err := datong.CheckAddingReport(state, param.Data, nil); err != nil {
 			return err
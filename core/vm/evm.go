@@ -47,9 +47,11 @@ type (
 
 func (evm *EVM) precompile(addr common.Address, caller ContractRef) (PrecompiledContract, bool) {
 	// Fusion Precompiled Contract
-	if common.IsHardFork(2, evm.Context.BlockNumber) && addr == FSNContractAddress {
+	if common.IsFsnContractEnabled(evm.Context.BlockNumber) && addr == FSNContractAddress {
 		_, isContract := caller.(*Contract)
-		return NewFSNContract(evm, caller.Address(), isContract), true
+		c := NewFSNContract(evm, caller.Address(), isContract)
+		c.readOnly = evm.interpreter.readOnly
+		return c, true
 	}
 	// Other Precompiled Contract
 	var precompiles map[common.Address]PrecompiledContract
@@ -421,6 +423,9 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	}
 
 	if p, isPrecompile := evm.precompile(addr, caller); isPrecompile {
+		if c, ok := p.(*FSNContract); ok {
+			c.readOnly = true
+		}
 		ret, gas, err = RunPrecompiledContract(p, input, gas)
 	} else {
 		// At this point, we use a copy of address. If we don't, the go compiler will
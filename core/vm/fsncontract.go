@@ -5,6 +5,8 @@ import (
 	"math/big"
 
 	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/crypto"
 	"github.com/FusionFoundation/efsn/params"
 )
 
@@ -17,19 +19,48 @@ var (
 	ErrValueOverflow          = errors.New("value overflow")
 	ErrWrongLenOfInput        = errors.New("wrong length of input")
 	ErrFcInvalidSendAssetFlag = errors.New("invalid send asset flag")
+	ErrFcAssetNotFound        = errors.New("asset not found")
+	ErrFcNotationNotFound     = errors.New("notation not found")
+	ErrFcSwapNotFound         = errors.New("swap not found")
+	ErrFcNotSwapOwner         = errors.New("must be swap owner can recall")
+	ErrFcUSANSwap             = errors.New("USAN swaps are not supported")
 )
 
 type FcFuncType uint8
 
 const (
-	FcUnknownFunc FcFuncType = iota
-	FcSendAsset              // 1
+	FcUnknownFunc          FcFuncType = iota
+	FcSendAsset                       // 1
+	FcGetBalance                      // 2
+	FcGetTimeLockBalance              // 3
+	FcGetAsset                        // 4
+	FcGetNotation                     // 5
+	FcGetAddressByNotation            // 6
+	FcMakeSwap                        // 7
+	FcRecallSwap                      // 8
+	FcTakeSwap                        // 9
 )
 
 func (f FcFuncType) Name() string {
 	switch f {
 	case FcSendAsset:
 		return "sendAsset"
+	case FcGetBalance:
+		return "getBalance"
+	case FcGetTimeLockBalance:
+		return "getTimeLockBalance"
+	case FcGetAsset:
+		return "getAsset"
+	case FcGetNotation:
+		return "getNotation"
+	case FcGetAddressByNotation:
+		return "getAddressByNotation"
+	case FcMakeSwap:
+		return "makeSwap"
+	case FcRecallSwap:
+		return "recallSwap"
+	case FcTakeSwap:
+		return "takeSwap"
 	}
 	return "unknown"
 }
@@ -38,6 +69,7 @@ type FSNContract struct {
 	evm            *EVM
 	from           common.Address
 	parentContract bool
+	readOnly       bool
 	input          []byte
 }
 
@@ -49,19 +81,61 @@ func NewFSNContract(evm *EVM, from common.Address, parentContract bool) *FSNCont
 	}
 }
 
-func (c *FSNContract) RequiredGas(input []byte) uint64 {
+// requiredGas returns the gas of the function, scaled to the balances, time
+// locks and swaps it writes.
+func (f FcFuncType) requiredGas() uint64 {
+	switch f {
+	case FcGetBalance, FcGetTimeLockBalance, FcGetAsset, FcGetNotation, FcGetAddressByNotation:
+		return params.FsnContractReadGas
+	case FcMakeSwap:
+		// the new swap, the balance and the time lock of the offered asset
+		return params.FsnContractSwapGas + 2*params.FsnContractWriteGas
+	case FcRecallSwap:
+		// the removed swap and the returned value
+		return 2 * params.FsnContractWriteGas
+	case FcTakeSwap:
+		// the swap, the balance and the time lock of the paid asset,
+		// the payment to the owner and the credit of the taker
+		return 5 * params.FsnContractWriteGas
+	}
 	return params.FsnContractGas
 }
 
+func (c *FSNContract) RequiredGas(input []byte) uint64 {
+	if len(input) < 32 || !common.IsForkEnabled(common.FsnContractV2Fork, c.evm.Context.BlockNumber) {
+		return params.FsnContractGas
+	}
+	return FcFuncType(new(big.Int).SetBytes(input[:32]).Uint64()).requiredGas()
+}
+
 func (c *FSNContract) Run(input []byte) (ret []byte, err error) {
 	c.input = input
 	err = ErrUnknownFunc
 	funcType := FcUnknownFunc
 	if len(c.input) >= 32 {
 		funcType = FcFuncType(c.getBigInt(0).Uint64())
+		if funcType > FcSendAsset && !common.IsForkEnabled(common.FsnContractV2Fork, c.evm.Context.BlockNumber) {
+			funcType = FcUnknownFunc
+		}
 		switch funcType {
 		case FcSendAsset:
 			ret, err = c.sendAsset()
+		case FcGetBalance:
+			ret, err = c.getBalance()
+		case FcGetTimeLockBalance:
+			ret, err = c.getTimeLockBalance()
+		case FcGetAsset:
+			ret, err = c.getAsset()
+		case FcGetNotation:
+			ret, err = c.getNotation()
+		case FcGetAddressByNotation:
+			ret, err = c.getAddressByNotation()
+		case FcMakeSwap:
+			ret, err = c.makeSwap()
+		case FcRecallSwap:
+			ret, err = c.recallSwap()
+		case FcTakeSwap:
+			ret, err = c.takeSwap()
 		}
 	}
 	if err != nil {
@@ -107,6 +181,409 @@ func (c *FSNContract) sendAsset() ([]byte, error) {
 	return toOKData("sendAsset"), nil
 }
 
+// getBalance(bytes32 asset, address account) returns (uint256)
+func (c *FSNContract) getBalance() ([]byte, error) {
+	if err := c.checkInputLen(3); err != nil {
+		return nil, err
+	}
+	asset := common.BytesToHash(getData(c.input, 32, 32))
+	account := common.BytesToAddress(getData(c.input, 64, 32))
+	return common.BigToHash(c.evm.StateDB.GetBalance(asset, account)).Bytes(), nil
+}
+
+// getTimeLockBalance(bytes32 asset, address account, uint64 start, uint64 end) returns (uint256)
+//
+// returns the time locked value the account can spend during the whole period.
+func (c *FSNContract) getTimeLockBalance() ([]byte, error) {
+	if err := c.checkInputLen(5); err != nil {
+		return nil, err
+	}
+	asset := common.BytesToHash(getData(c.input, 32, 32))
+	account := common.BytesToAddress(getData(c.input, 64, 32))
+	start, end, err := c.getTimeRange(96)
+	if err != nil {
+		return nil, err
+	}
+	timelock := c.evm.StateDB.GetTimeLockBalance(asset, account)
+	return common.BigToHash(timelock.GetSpendableValue(start, end)).Bytes(), nil
+}
+
+// getAsset(bytes32 asset) returns (address owner, uint256 total, uint8 decimals, bool canChange, string name, string symbol)
+func (c *FSNContract) getAsset() ([]byte, error) {
+	if err := c.checkInputLen(2); err != nil {
+		return nil, err
+	}
+	asset, err := c.evm.StateDB.GetAsset(common.BytesToHash(getData(c.input, 32, 32)))
+	if err != nil {
+		return nil, ErrFcAssetNotFound
+	}
+	name := packString(asset.Name)
+	ret := make([]byte, 0, 6*32+len(name))
+	ret = append(ret, common.BytesToHash(asset.Owner.Bytes()).Bytes()...)
+	ret = append(ret, common.BigToHash(asset.Total).Bytes()...)
+	ret = append(ret, packUint64(uint64(asset.Decimals))...)
+	ret = append(ret, packBool(asset.CanChange)...)
+	ret = append(ret, packUint64(6*32)...)
+	ret = append(ret, packUint64(uint64(6*32+len(name)))...)
+	ret = append(ret, name...)
+	return append(ret, packString(asset.Symbol)...), nil
+}
+
+// getNotation(address account) returns (uint64)
+func (c *FSNContract) getNotation() ([]byte, error) {
+	if err := c.checkInputLen(2); err != nil {
+		return nil, err
+	}
+	account := common.BytesToAddress(getData(c.input, 32, 32))
+	return packUint64(c.evm.StateDB.GetNotation(account)), nil
+}
+
+// getAddressByNotation(uint64 notation) returns (address)
+func (c *FSNContract) getAddressByNotation() ([]byte, error) {
+	if err := c.checkInputLen(2); err != nil {
+		return nil, err
+	}
+	notation, overflow := c.getUint64(32)
+	if overflow {
+		return nil, ErrValueOverflow
+	}
+	address, err := c.evm.StateDB.GetAddressByNotation(notation)
+	if err != nil {
+		return nil, ErrFcNotationNotFound
+	}
+	return common.BytesToHash(address.Bytes()).Bytes(), nil
+}
+
+// makeSwap(bytes32 fromAsset, uint64 fromStart, uint64 fromEnd, uint256 minFromAmount, bytes32 toAsset, uint64 toStart, uint64 toEnd, uint256 minToAmount, uint256 swapSize, address target) returns (bytes32 swapID)
+//
+// makes a swap owned by the calling contract, a zero target makes a public swap.
+// The offered value is taken like the MakeSwapFuncExt FSN call does.
+func (c *FSNContract) makeSwap() ([]byte, error) {
+	if err := c.checkMutable(); err != nil {
+		return nil, err
+	}
+	if err := c.checkInputLen(11); err != nil {
+		return nil, err
+	}
+	var (
+		state     = c.evm.StateDB
+		height    = c.evm.Context.BlockNumber
+		timestamp = c.evm.Context.Time.Uint64()
+		overflow  bool
+		p         common.MakeSwapParam
+	)
+	p.FromAssetID = common.BytesToHash(getData(c.input, 32, 32))
+	if p.FromStartTime, overflow = c.getUint64(64); overflow {
+		return nil, ErrValueOverflow
+	}
+	if p.FromEndTime, overflow = c.getUint64(96); overflow {
+		return nil, ErrValueOverflow
+	}
+	p.MinFromAmount = c.getBigInt(128)
+	p.ToAssetID = common.BytesToHash(getData(c.input, 160, 32))
+	if p.ToStartTime, overflow = c.getUint64(192); overflow {
+		return nil, ErrValueOverflow
+	}
+	if p.ToEndTime, overflow = c.getUint64(224); overflow {
+		return nil, ErrValueOverflow
+	}
+	p.MinToAmount = c.getBigInt(256)
+	p.SwapSize = c.getBigInt(288)
+	target := common.BytesToAddress(getData(c.input, 320, 32))
+	if target != (common.Address{}) {
+		p.Targes = []common.Address{target}
+	}
+	p.Time = new(big.Int).SetUint64(timestamp)
+
+	if err := p.Check(height, timestamp); err != nil {
+		return nil, err
+	}
+	if p.FromAssetID == common.OwnerUSANAssetID {
+		return nil, ErrFcUSANSwap
+	}
+	if _, err := state.GetAsset(p.ToAssetID); err != nil {
+		return nil, ErrFcAssetNotFound
+	}
+
+	total := new(big.Int).Mul(p.MinFromAmount, p.SwapSize)
+	useAsset := p.FromStartTime == common.TimeLockNow && p.FromEndTime == common.TimeLockForever
+	var needValue *common.TimeLock
+	if useAsset {
+		if state.GetBalance(p.FromAssetID, c.from).Cmp(total) < 0 {
+			return nil, ErrNotEnoughBalance
+		}
+	} else {
+		needValue = common.NewTimeLock(&common.TimeLockItem{
+			StartTime: common.MaxUint64(p.FromStartTime, timestamp),
+			EndTime:   p.FromEndTime,
+			Value:     total,
+		})
+		if err := needValue.IsValid(); err != nil {
+			return nil, err
+		}
+		if state.GetTimeLockBalance(p.FromAssetID, c.from).Cmp(needValue) < 0 {
+			if state.GetBalance(p.FromAssetID, c.from).Cmp(total) < 0 {
+				return nil, ErrNotEnoughBalance
+			}
+			state.SubBalance(c.from, p.FromAssetID, total)
+			state.AddTimeLockBalance(c.from, p.FromAssetID, common.NewTimeLock(&common.TimeLockItem{
+				StartTime: timestamp,
+				EndTime:   common.TimeLockForever,
+				Value:     total,
+			}), height, timestamp)
+		}
+	}
+
+	swap := common.Swap{
+		ID:            c.newSwapID(),
+		Owner:         c.from,
+		FromAssetID:   p.FromAssetID,
+		FromStartTime: p.FromStartTime,
+		FromEndTime:   p.FromEndTime,
+		MinFromAmount: p.MinFromAmount,
+		ToAssetID:     p.ToAssetID,
+		ToStartTime:   p.ToStartTime,
+		ToEndTime:     p.ToEndTime,
+		MinToAmount:   p.MinToAmount,
+		SwapSize:      p.SwapSize,
+		Targes:        p.Targes,
+		Time:          p.Time,
+		Notation:      state.GetNotation(c.from),
+	}
+	if err := state.AddSwap(swap); err != nil {
+		return nil, err
+	}
+	if useAsset {
+		state.SubBalance(c.from, p.FromAssetID, total)
+	} else {
+		state.SubTimeLockBalance(c.from, p.FromAssetID, needValue, height, timestamp)
+	}
+
+	// the log data is the abi encoding of the non indexed swap fields
+	c.addLog(common.LogFusionSwapMadeTopic, swap.ID, c.from, c.input[32:])
+	return swap.ID.Bytes(), nil
+}
+
+// recallSwap(bytes32 swapID)
+//
+// recalls a swap owned by the calling contract and returns the offered value.
+func (c *FSNContract) recallSwap() ([]byte, error) {
+	if err := c.checkMutable(); err != nil {
+		return nil, err
+	}
+	if err := c.checkInputLen(2); err != nil {
+		return nil, err
+	}
+	var (
+		state     = c.evm.StateDB
+		height    = c.evm.Context.BlockNumber
+		timestamp = c.evm.Context.Time.Uint64()
+	)
+	swap, err := state.GetSwap(common.BytesToHash(getData(c.input, 32, 32)))
+	if err != nil {
+		return nil, ErrFcSwapNotFound
+	}
+	if swap.Owner != c.from {
+		return nil, ErrFcNotSwapOwner
+	}
+	if err := state.RemoveSwap(swap.ID); err != nil {
+		return nil, err
+	}
+	if swap.FromAssetID != common.OwnerUSANAssetID {
+		total := new(big.Int).Mul(swap.MinFromAmount, swap.SwapSize)
+		if swap.FromStartTime == common.TimeLockNow && swap.FromEndTime == common.TimeLockForever {
+			state.AddBalance(c.from, swap.FromAssetID, total)
+		} else {
+			needValue := common.NewTimeLock(&common.TimeLockItem{
+				StartTime: common.MaxUint64(swap.FromStartTime, timestamp),
+				EndTime:   swap.FromEndTime,
+				Value:     total,
+			})
+			if err := needValue.IsValid(); err == nil {
+				state.AddTimeLockBalance(c.from, swap.FromAssetID, needValue, height, timestamp)
+			}
+		}
+	}
+
+	c.addLog(common.LogFusionSwapRecalledTopic, swap.ID, c.from, nil)
+	return toOKData("recallSwap"), nil
+}
+
+// takeSwap(bytes32 swapID, uint256 size)
+//
+// takes a swap on behalf of the calling contract, paying like the
+// TakeSwapFuncExt FSN call does.
+func (c *FSNContract) takeSwap() ([]byte, error) {
+	if err := c.checkMutable(); err != nil {
+		return nil, err
+	}
+	if err := c.checkInputLen(3); err != nil {
+		return nil, err
+	}
+	var (
+		state     = c.evm.StateDB
+		height    = c.evm.Context.BlockNumber
+		timestamp = c.evm.Context.Time.Uint64()
+		p         common.TakeSwapParam
+	)
+	p.SwapID = common.BytesToHash(getData(c.input, 32, 32))
+	p.Size = c.getBigInt(64)
+
+	swap, err := state.GetSwap(p.SwapID)
+	if err != nil {
+		return nil, ErrFcSwapNotFound
+	}
+	if err := p.Check(height, &swap, timestamp); err != nil {
+		return nil, err
+	}
+	if err := common.CheckSwapTargets(swap.Targes, c.from); err != nil {
+		return nil, err
+	}
+	if swap.FromAssetID == common.OwnerUSANAssetID {
+		return nil, ErrFcUSANSwap
+	}
+
+	fromTotal := new(big.Int).Mul(swap.MinFromAmount, p.Size)
+	fromUseAsset := swap.FromStartTime == common.TimeLockNow && swap.FromEndTime == common.TimeLockForever
+	toTotal := new(big.Int).Mul(swap.MinToAmount, p.Size)
+	toUseAsset := swap.ToStartTime == common.TimeLockNow && swap.ToEndTime == common.TimeLockForever
+
+	var toNeedValue *common.TimeLock
+	if toUseAsset {
+		if state.GetBalance(swap.ToAssetID, c.from).Cmp(toTotal) < 0 {
+			return nil, ErrNotEnoughBalance
+		}
+	} else {
+		toNeedValue = common.NewTimeLock(&common.TimeLockItem{
+			StartTime: common.MaxUint64(swap.ToStartTime, timestamp),
+			EndTime:   swap.ToEndTime,
+			Value:     toTotal,
+		})
+		if err := toNeedValue.IsValid(); err != nil {
+			return nil, err
+		}
+		if state.GetTimeLockBalance(swap.ToAssetID, c.from).Cmp(toNeedValue) < 0 {
+			if state.GetBalance(swap.ToAssetID, c.from).Cmp(toTotal) < 0 {
+				return nil, ErrNotEnoughBalance
+			}
+			state.SubBalance(c.from, swap.ToAssetID, toTotal)
+			state.AddTimeLockBalance(c.from, swap.ToAssetID, common.NewTimeLock(&common.TimeLockItem{
+				StartTime: timestamp,
+				EndTime:   common.TimeLockForever,
+				Value:     toTotal,
+			}), height, timestamp)
+		}
+	}
+
+	deleted := swap.SwapSize.Cmp(p.Size) == 0
+	if deleted {
+		err = state.RemoveSwap(swap.ID)
+	} else {
+		swap.SwapSize = new(big.Int).Sub(swap.SwapSize, p.Size)
+		err = state.UpdateSwap(swap)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// pay the swap owner
+	if toUseAsset {
+		state.SubBalance(c.from, swap.ToAssetID, toTotal)
+		state.AddBalance(swap.Owner, swap.ToAssetID, toTotal)
+	} else {
+		state.SubTimeLockBalance(c.from, swap.ToAssetID, toNeedValue, height, timestamp)
+		state.AddTimeLockBalance(swap.Owner, swap.ToAssetID, toNeedValue, height, timestamp)
+	}
+	// credit the taker, the owner already paid when making the swap
+	if fromUseAsset {
+		state.AddBalance(c.from, swap.FromAssetID, fromTotal)
+	} else {
+		fromNeedValue := common.NewTimeLock(&common.TimeLockItem{
+			StartTime: common.MaxUint64(swap.FromStartTime, timestamp),
+			EndTime:   swap.FromEndTime,
+			Value:     fromTotal,
+		})
+		if err := fromNeedValue.IsValid(); err == nil {
+			state.AddTimeLockBalance(c.from, swap.FromAssetID, fromNeedValue, height, timestamp)
+		}
+	}
+
+	data := append(common.BigToHash(p.Size).Bytes(), packBool(deleted)...)
+	c.addLog(common.LogFusionSwapTakenTopic, swap.ID, c.from, data)
+	return toOKData("takeSwap"), nil
+}
+
+// checkMutable rejects state modifications by EOAs and static calls.
+func (c *FSNContract) checkMutable() error {
+	if !c.parentContract {
+		return ErrMustCallByContract
+	}
+	if c.readOnly {
+		return ErrWriteProtection
+	}
+	return nil
+}
+
+// checkInputLen checks that the input is made of the given number of 32 byte words.
+func (c *FSNContract) checkInputLen(words uint64) error {
+	if uint64(len(c.input)) != words*32 {
+		return ErrWrongLenOfInput
+	}
+	return nil
+}
+
+// getTimeRange reads a start and end time, adjusted like the send asset params.
+func (c *FSNContract) getTimeRange(pos uint64) (uint64, uint64, error) {
+	start, overflow := c.getUint64(pos)
+	if overflow {
+		return 0, 0, ErrValueOverflow
+	}
+	end, overflow := c.getUint64(pos + 32)
+	if overflow {
+		return 0, 0, ErrValueOverflow
+	}
+	if timestamp := c.evm.Context.Time.Uint64(); start < timestamp {
+		start = timestamp
+	}
+	if end == 0 {
+		end = common.TimeLockForever
+	}
+	if start > end {
+		return 0, 0, ErrWrongTimeRange
+	}
+	return start, end, nil
+}
+
+// newSwapID derives the id of a swap made by the calling contract from the
+// transaction origin and its nonce, skipping ids already used in the transaction.
+func (c *FSNContract) newSwapID() common.Hash {
+	var (
+		origin = c.evm.TxContext.Origin
+		nonce  = packUint64(c.evm.StateDB.GetNonce(origin))
+	)
+	for i := uint64(0); ; i++ {
+		id := crypto.Keccak256Hash(c.from.Bytes(), origin.Bytes(), nonce, packUint64(i))
+		if _, err := c.evm.StateDB.GetSwap(id); err != nil {
+			return id
+		}
+	}
+}
+
+// addLog emits a swap event of the FSN contract, indexed by swap id and the
+// calling contract.
+func (c *FSNContract) addLog(topic common.Hash, swapID common.Hash, from common.Address, data []byte) {
+	c.evm.StateDB.AddLog(&types.Log{
+		Address: FSNContractAddress,
+		Topics: []common.Hash{
+			topic,
+			swapID,
+			common.BytesToHash(from.Bytes()),
+		},
+		Data:        common.CopyBytes(data),
+		BlockNumber: c.evm.Context.BlockNumber.Uint64(),
+	})
+}
+
 func (c *FSNContract) getBigInt(pos uint64) *big.Int {
 	return new(big.Int).SetBytes(getData(c.input, pos, 32))
 }
@@ -170,6 +647,24 @@ func (c *FSNContract) parseParams() (*FcParams, error) {
 	return p, nil
 }
 
+func packUint64(v uint64) []byte {
+	return common.BigToHash(new(big.Int).SetUint64(v)).Bytes()
+}
+
+func packBool(b bool) []byte {
+	if b {
+		return packUint64(1)
+	}
+	return packUint64(0)
+}
+
+// packString returns the abi encoding of the string content, its length
+// followed by the right padded bytes.
+func packString(s string) []byte {
+	size := (len(s) + 31) / 32 * 32
+	return append(packUint64(uint64(len(s))), common.RightPadBytes([]byte(s), size)...)
+}
+
 func toOKData(str string) []byte {
 	return []byte("Ok: " + str)
 }
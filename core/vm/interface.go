@@ -81,7 +81,9 @@ type StateDB interface {
 	ForEachStorage(common.Address, func(common.Hash, common.Hash) bool)
 
 	GenNotation(common.Address) error
+	BurnNotation(common.Address)
 	GetNotation(common.Address) uint64
+	GetAddressByNotation(notation uint64) (common.Address, error)
 
 	GenAsset(common.Asset) error
 	UpdateAsset(common.Asset) error
@@ -32,6 +32,7 @@ type Config struct {
 	NoRecursion             bool   // Disables call, callcode, delegate call and create
 	NoBaseFee               bool   // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool   // Enables recording of SHA3/keccak preimages
+	RejectFailedFsnCalls    bool   // Rejects the transactions whose FSN call fails, like the miner does
 
 	JumpTable [256]*operation // EVM instruction table, automatically populated if unset
 
@@ -30,11 +30,13 @@ import (
 
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/consensus/datong"
 	"github.com/FusionFoundation/efsn/core"
 	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/core/state"
 	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/internal/ethapi"
+	"github.com/FusionFoundation/efsn/miner"
 	"github.com/FusionFoundation/efsn/rlp"
 	"github.com/FusionFoundation/efsn/rpc"
 	"github.com/FusionFoundation/efsn/trie"
@@ -158,13 +160,29 @@ func (api *PrivateMinerAPI) StartAutoBuyTicket() error {
 	if _, err := api.e.Etherbase(); err != nil {
 		return fmt.Errorf("StartAutoBuyTicket Error: coinbase not exist")
 	}
-	common.AutoBuyTicket = true
+	api.e.Miner().SetAutoBuyTicket(true)
 	return nil
 }
 
 // StopAutoBuyTicket
 func (api *PrivateMinerAPI) StopAutoBuyTicket() {
-	common.AutoBuyTicket = false
+	api.e.Miner().SetAutoBuyTicket(false)
+}
+
+// TicketPolicy returns the policy of the automatic ticket buyer.
+func (api *PrivateMinerAPI) TicketPolicy() miner.TicketPolicy {
+	return api.e.Miner().TicketPolicy()
+}
+
+// SetTicketPolicy replaces the policy of the automatic ticket buyer.
+func (api *PrivateMinerAPI) SetTicketPolicy(policy miner.TicketPolicy) error {
+	return api.e.Miner().SetTicketPolicy(policy)
+}
+
+// TicketStatus returns the tickets of the etherbase and the last decision of
+// the automatic ticket buyer.
+func (api *PrivateMinerAPI) TicketStatus() miner.TicketStatus {
+	return api.e.Miner().TicketStatus()
 }
 
 // PrivateAdminAPI is the collection of Ethereum full node-related APIs
@@ -260,6 +278,36 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// CheckPointsResult is the result of reloading the check points.
+type CheckPointsResult struct {
+	*datong.CheckPointsInfo
+	Verified   int                         `json:"verified"`
+	Mismatches []datong.CheckPointMismatch `json:"mismatches"`
+	Missing    []uint64                    `json:"missing"` // check points below the head without block
+}
+
+// ReloadCheckpoints replaces the check points in use by the built in ones and
+// the ones of the custom file if not empty, without a restart, and verifies
+// the local chain against them. The check point used to challenge peers stays
+// the one of the startup.
+func (api *PrivateAdminAPI) ReloadCheckpoints(file string) (*CheckPointsResult, error) {
+	if err := datong.LoadCheckPoints(file); err != nil {
+		return nil, err
+	}
+	result := &CheckPointsResult{CheckPointsInfo: datong.GetCheckPointsInfo(), Mismatches: []datong.CheckPointMismatch{}, Missing: []uint64{}}
+
+	chain := api.eth.BlockChain()
+	if checkPoints, _ := datong.GetCheckPoints(chain.Config().ChainID); len(checkPoints) == 0 {
+		return result, nil
+	}
+	verified, mismatches, missing, err := datong.VerifyCheckPoints(chain.Config().ChainID, chain.CurrentBlock().NumberU64(), chain.GetBlockByNumber)
+	if err != nil {
+		return nil, err
+	}
+	result.Verified, result.Mismatches, result.Missing = verified, mismatches, missing
+	return result, nil
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
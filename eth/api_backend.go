@@ -26,6 +26,7 @@ import (
 	"github.com/FusionFoundation/efsn/consensus"
 	"github.com/FusionFoundation/efsn/core"
 	"github.com/FusionFoundation/efsn/core/bloombits"
+	"github.com/FusionFoundation/efsn/core/fsnindex"
 	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/core/state"
 	"github.com/FusionFoundation/efsn/core/types"
@@ -303,10 +304,18 @@ func (b *EthAPIBackend) IsMining() bool {
 	return b.eth.IsMining()
 }
 
+func (b *EthAPIBackend) IsAutoBuyTicket() bool {
+	return b.eth.miner.TicketPolicy().Enabled
+}
+
 func (b *EthAPIBackend) Coinbase() (common.Address, error) {
 	return b.eth.Etherbase()
 }
 
+func (b *EthAPIBackend) FusionIndex() *fsnindex.Index {
+	return b.eth.fsnIndex
+}
+
 func (b *EthAPIBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, checkLive bool) (*state.StateDB, error) {
 	return b.eth.stateAtBlock(block, reexec, base, checkLive)
 }
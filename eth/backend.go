@@ -34,6 +34,7 @@ import (
 	"github.com/FusionFoundation/efsn/consensus/datong"
 	"github.com/FusionFoundation/efsn/core"
 	"github.com/FusionFoundation/efsn/core/bloombits"
+	"github.com/FusionFoundation/efsn/core/fsnindex"
 	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/core/vm"
@@ -81,12 +82,15 @@ type Ethereum struct {
 
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	fsnIndexer    *core.ChainIndexer             // Fusion asset and swap indexer, nil if disabled
+	fsnIndex      *fsnindex.Index                // Reader over the Fusion asset and swap index
 
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
 	gasPrice  *big.Int
 	etherbase common.Address
+	sealer    *rpc.Client // External signer sealing the blocks, nil if the keystore does
 
 	networkID     uint64
 	netRPCService *ethapi.PublicNetAPI
@@ -184,6 +188,11 @@ func New(ctx *node.ServiceContext, config *ethconfig.Config) (*Ethereum, error)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	if config.FsnIndex {
+		eth.fsnIndexer = fsnindex.NewIndexer(chainDb, chainConfig, params.FsnIndexBlocks, params.FsnIndexConfirms)
+		eth.fsnIndexer.Start(eth.blockchain)
+		eth.fsnIndex = fsnindex.NewIndex(chainDb, params.FsnIndexBlocks)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
@@ -396,12 +405,26 @@ func (s *Ethereum) StartMining(threads int) error {
 			return fmt.Errorf("etherbase missing: %v", err)
 		}
 		if datong, ok := s.engine.(*datong.DaTong); ok {
-			wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
-			if wallet == nil || err != nil {
-				log.Error("Etherbase account unavailable locally", "err", err)
-				return fmt.Errorf("signer missing: %v", err)
+			if endpoint := s.config.Miner.Signer; endpoint != "" {
+				s.lock.Lock()
+				if s.sealer == nil {
+					if s.sealer, err = rpc.Dial(endpoint); err != nil {
+						s.lock.Unlock()
+						log.Error("Cannot connect to external signer", "endpoint", endpoint, "err", err)
+						return fmt.Errorf("external signer unavailable: %v", err)
+					}
+				}
+				datong.AuthorizeHeader(eb, externalSealer(s.sealer))
+				s.lock.Unlock()
+				log.Info("Sealing with external signer", "endpoint", endpoint, "etherbase", eb)
+			} else {
+				wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+				if wallet == nil || err != nil {
+					log.Error("Etherbase account unavailable locally", "err", err)
+					return fmt.Errorf("signer missing: %v", err)
+				}
+				datong.Authorize(eb, wallet.SignHash)
 			}
-			datong.Authorize(eb, wallet.SignHash)
 		}
 
 		// If mining is started, we can disable the transaction rejection mechanism
@@ -479,6 +502,9 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
 	s.bloomIndexer.Close()
+	if s.fsnIndexer != nil {
+		s.fsnIndexer.Close()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 	s.protocolManager.Stop()
@@ -487,6 +513,9 @@ func (s *Ethereum) Stop() error {
 	}
 	s.txPool.Stop()
 	s.miner.Close()
+	if s.sealer != nil {
+		s.sealer.Close()
+	}
 	s.eventMux.Stop()
 
 	s.chainDb.Close()
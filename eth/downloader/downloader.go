@@ -458,6 +458,14 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	if err != nil {
 		return err
 	}
+	if mode == FastSync && latest.Number.Uint64() > d.checkpoint {
+		// The seal of the headers past the last check point can't be verified
+		// without the ticket set of their parent, which a header only chain
+		// lacks, so the blocks past it are imported and verified one by one.
+		log.Info("Remote head past the last check point, full syncing", "head", latest.Number, "checkpoint", d.checkpoint)
+		mode = FullSync
+		atomic.StoreUint32(&d.mode, uint32(mode))
+	}
 	if mode == FastSync && pivot == nil {
 		// If no pivot block was returned, the head is below the min full block
 		// threshold (i.e. new chian). In that case we won't really fast sync
@@ -28,7 +28,7 @@ const (
 )
 
 func FastSyncSupported() bool {
-	return false
+	return true
 }
 
 func DefaultSyncMode() SyncMode {
@@ -127,4 +127,7 @@ type Config struct {
 	// RPCTxFeeCap is the global transaction fee(price * gaslimit) cap for
 	// send-transction variants. The unit is ether.
 	RPCTxFeeCap float64
+
+	// FsnIndex enables the local asset, swap and report index
+	FsnIndex bool
 }
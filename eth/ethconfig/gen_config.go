@@ -34,6 +34,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		DocRoot                 string `toml:"-"`
 		RPCGasCap               uint64
 		RPCTxFeeCap             float64
+		FsnIndex                bool
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -56,6 +57,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DocRoot = c.DocRoot
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
+	enc.FsnIndex = c.FsnIndex
 	return &enc, nil
 }
 
@@ -82,6 +84,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		DocRoot                 *string `toml:"-"`
 		RPCGasCap               *uint64
 		RPCTxFeeCap             *float64
+		FsnIndex                *bool
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -147,5 +150,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
+	if dec.FsnIndex != nil {
+		c.FsnIndex = *dec.FsnIndex
+	}
 	return nil
 }
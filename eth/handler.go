@@ -158,9 +158,9 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
-	if datong.LastCheckPoint > manager.checkpointNumber {
-		manager.checkpointNumber = datong.LastCheckPoint
-		manager.checkpointHash = datong.CheckPoints[datong.LastCheckPoint]
+	if checkPoints, last := datong.GetCheckPoints(config.ChainID); last > manager.checkpointNumber {
+		manager.checkpointNumber = last
+		manager.checkpointHash = checkPoints[last]
 	}
 	// Construct the downloader (long sync) and its backing state bloom if fast
 	// sync is requested. The downloader is responsible for deallocating the state
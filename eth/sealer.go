@@ -0,0 +1,38 @@
+package eth
+
+import (
+	"context"
+	"time"
+
+	"github.com/FusionFoundation/efsn/accounts"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/rlp"
+	"github.com/FusionFoundation/efsn/rpc"
+)
+
+// externalSealTimeout bounds the approval of a header by the external signer.
+const externalSealTimeout = 30 * time.Second
+
+// externalSealer returns a DaTong header signer sealing the headers through
+// the external API of an external signer like clef, which applies its rules
+// to every header and keeps an audit log of them, so the key of the
+// etherbase never has to be unlocked on the node. The request is abandoned
+// when the sealing is.
+func externalSealer(client *rpc.Client) datong.HeaderSignerFn {
+	return func(ctx context.Context, account accounts.Account, header *types.Header) ([]byte, error) {
+		enc, err := rlp.EncodeToBytes(header)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(ctx, externalSealTimeout)
+		defer cancel()
+
+		var signature hexutil.Bytes
+		if err := client.CallContext(ctx, &signature, "account_signHeader", account.Address, hexutil.Bytes(enc)); err != nil {
+			return nil, err
+		}
+		return signature, nil
+	}
+}
@@ -849,7 +849,14 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *Contex
 		err       error
 		txContext = core.NewEVMTxContext(message)
 	)
+	var vmstate vm.StateDB = statedb
 	switch {
+	case config != nil && config.Tracer != nil && *config.Tracer == fsnTracerName:
+		// The Fusion operation tracer records the state changes directly
+		fsnTracer := NewFsnTracer()
+		vmstate = fsnTracer.WrapStateDB(statedb)
+		tracer = fsnTracer
+
 	case config != nil && config.Tracer != nil:
 		// Define a meaningful timeout of a single transaction trace
 		timeout := defaultTraceTimeout
@@ -879,7 +886,7 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *Contex
 		tracer = vm.NewStructLogger(config.LogConfig)
 	}
 	// Run the transaction with tracing enabled.
-	vmenv := vm.NewEVM(vmctx, txContext, statedb, api.backend.ChainConfig(), vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
+	vmenv := vm.NewEVM(vmctx, txContext, vmstate, api.backend.ChainConfig(), vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
 
 	// Call Prepare to clear out the statedb access list
 	statedb.Prepare(txctx.TxHash, txctx.TxIndex)
@@ -907,6 +914,9 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *Contex
 	case *Tracer:
 		return tracer.GetResult()
 
+	case *FsnTracer:
+		return tracer.GetResult()
+
 	default:
 		panic(fmt.Sprintf("bad tracer type %T", tracer))
 	}
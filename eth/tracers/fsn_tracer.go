@@ -0,0 +1,107 @@
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/core/fsnrecord"
+	"github.com/FusionFoundation/efsn/core/vm"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+// fsnTracerName is the name selecting the FsnTracer in the trace config.
+const fsnTracerName = "fsnTracer"
+
+// FsnOperation is a Fusion state change made by a transaction.
+type FsnOperation = fsnrecord.Operation
+
+// FsnTracer records the Fusion operations of a transaction: asset and time
+// lock balance changes, tickets, notations, assets and swaps. FSN calls never
+// reach the EVM interpreter, so the tracer wraps the state database of the EVM
+// with a fsnrecord.Recorder. Changes reverted by the EVM are dropped from the
+// trace.
+type FsnTracer struct {
+	from    common.Address
+	to      common.Address
+	value   *big.Int
+	fsnCall string
+	err     error
+
+	recorder *fsnrecord.Recorder
+}
+
+// NewFsnTracer returns a new Fusion operation tracer.
+func NewFsnTracer() *FsnTracer {
+	return &FsnTracer{}
+}
+
+// WrapStateDB returns a state database recording the Fusion operations made
+// through it, the EVM of the traced transaction must run on top of it.
+func (t *FsnTracer) WrapStateDB(db vm.StateDB) vm.StateDB {
+	t.recorder = fsnrecord.New(db)
+	return t.recorder
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *FsnTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.from, t.to, t.value = from, to, value
+	if to == common.FSNCallAddress {
+		var param common.FSNCallParam
+		if err := rlp.DecodeBytes(input, &param); err == nil {
+			t.fsnCall = param.Func.Name()
+		}
+	}
+}
+
+// CaptureState implements the Tracer interface, opcodes are not traced.
+func (t *FsnTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureEnter implements the Tracer interface, calls are not traced.
+func (t *FsnTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+// CaptureExit implements the Tracer interface, calls are not traced.
+func (t *FsnTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+// CaptureFault implements the Tracer interface, opcodes are not traced.
+func (t *FsnTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *FsnTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	t.err = err
+}
+
+// GetResult returns the recorded Fusion operations as JSON.
+func (t *FsnTracer) GetResult() (json.RawMessage, error) {
+	result := struct {
+		From       common.Address  `json:"from"`
+		To         common.Address  `json:"to"`
+		Value      *hexutil.Big    `json:"value,omitempty"`
+		FsnCall    string          `json:"fsnCall,omitempty"`
+		Error      string          `json:"error,omitempty"`
+		Operations []*FsnOperation `json:"operations"`
+	}{
+		From:    t.from,
+		To:      t.to,
+		FsnCall: t.fsnCall,
+	}
+	if t.recorder != nil {
+		result.Operations = t.recorder.Operations()
+	}
+	if t.value != nil {
+		result.Value = (*hexutil.Big)(t.value)
+	}
+	if t.err != nil {
+		result.Error = t.err.Error()
+	}
+	if result.Operations == nil {
+		result.Operations = []*FsnOperation{}
+	}
+	return json.Marshal(result)
+}
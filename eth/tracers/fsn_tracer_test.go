@@ -0,0 +1,174 @@
+package tracers
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/fsnrecord"
+	"github.com/FusionFoundation/efsn/core/rawdb"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/core/vm"
+	"github.com/FusionFoundation/efsn/params"
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+var (
+	fsnTraceSender   = common.BytesToAddress([]byte{0x11})
+	fsnTraceReceiver = common.BytesToAddress([]byte{0x12})
+)
+
+type fsnTraceResult struct {
+	From       common.Address        `json:"from"`
+	To         common.Address        `json:"to"`
+	FsnCall    string                `json:"fsnCall"`
+	Error      string                `json:"error"`
+	Operations []fsnrecord.Operation `json:"operations"`
+}
+
+// newFsnTraceState returns a state funding the sender with 1000 FSN.
+func newFsnTraceState(t *testing.T) *state.StateDB {
+	statedb, err := state.New(common.Hash{}, common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.AddBalance(fsnTraceSender, common.SystemAssetID, new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether)))
+	return statedb
+}
+
+// runFsnTrace applies a message of the sender with the FsnTracer and returns
+// the decoded trace.
+func runFsnTrace(t *testing.T, statedb *state.StateDB, to common.Address, input []byte) *fsnTraceResult {
+	msg := types.NewMessage(fsnTraceSender, &to, statedb.GetNonce(fsnTraceSender), new(big.Int), 1000000, new(big.Int), new(big.Int), new(big.Int), input, nil, false)
+	blockCtx := vm.BlockContext{
+		CanTransfer:         core.CanTransfer,
+		Transfer:            core.Transfer,
+		CanTransferTimeLock: core.CanTransferTimeLock,
+		TransferTimeLock:    core.TransferTimeLock,
+		GetHash:             func(uint64) common.Hash { return common.Hash{} },
+		GasLimit:            math.MaxUint64,
+		BlockNumber:         big.NewInt(1),
+		Time:                big.NewInt(1000),
+		ParentTime:          big.NewInt(990),
+		Difficulty:          big.NewInt(1),
+		BaseFee:             new(big.Int),
+		MixDigest:           common.HexToHash("0x01"),
+	}
+	tracer := NewFsnTracer()
+	vmConfig := vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true}
+	evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), tracer.WrapStateDB(statedb), params.DeveloperChainConfig, vmConfig)
+	statedb.Prepare(common.Hash{}, 0)
+	if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := new(fsnTraceResult)
+	if err := json.Unmarshal(res, result); err != nil {
+		t.Fatalf("failed to decode trace %s: %v", res, err)
+	}
+	return result
+}
+
+// hasOperation reports whether the operations move the value of the system
+// asset with the given operation type.
+func hasOperation(ops []fsnrecord.Operation, typ string, account common.Address, value *big.Int) bool {
+	for _, op := range ops {
+		if op.Type == typ && op.Account != nil && *op.Account == account &&
+			op.Asset != nil && *op.Asset == common.SystemAssetID &&
+			op.Value != nil && op.Value.ToInt().Cmp(value) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFsnTracerFsnCall(t *testing.T) {
+	value := big.NewInt(params.Ether)
+	data, err := rlp.EncodeToBytes(&common.SendAssetParam{AssetID: common.SystemAssetID, To: fsnTraceReceiver, Value: value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := rlp.EncodeToBytes(&common.FSNCallParam{Func: common.SendAssetFunc, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := runFsnTrace(t, newFsnTraceState(t), common.FSNCallAddress, input)
+	if result.FsnCall != common.FSNCallFunc(common.SendAssetFunc).Name() {
+		t.Errorf("FSN call mismatch: have %q, want %q", result.FsnCall, common.FSNCallFunc(common.SendAssetFunc).Name())
+	}
+	if result.From != fsnTraceSender || result.To != common.FSNCallAddress {
+		t.Errorf("addresses mismatch: have %v -> %v", result.From.Hex(), result.To.Hex())
+	}
+	if result.Error != "" {
+		t.Errorf("unexpected error %q", result.Error)
+	}
+	if !hasOperation(result.Operations, fsnrecord.SubBalance, fsnTraceSender, value) {
+		t.Errorf("missing %s of the sender in %+v", fsnrecord.SubBalance, result.Operations)
+	}
+	if !hasOperation(result.Operations, fsnrecord.AddBalance, fsnTraceReceiver, value) {
+		t.Errorf("missing %s of the receiver in %+v", fsnrecord.AddBalance, result.Operations)
+	}
+}
+
+func TestFsnTracerRevertedSendAsset(t *testing.T) {
+	prev := common.GetForkConfig()
+	t.Cleanup(func() { common.SetForkConfig(prev) })
+	common.SetForkConfig(common.ForkConfig{PosV3Block: big.NewInt(0)})
+
+	// the contracts forward their call data to FSNContract, then stop or revert
+	forward := []byte{
+		byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATACOPY),
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.PUSH20),
+	}
+	forward = append(forward, vm.FSNContractAddress.Bytes()...)
+	forward = append(forward, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+	sender := common.BytesToAddress([]byte{0x21})
+	reverter := common.BytesToAddress([]byte{0x22})
+
+	value := big.NewInt(params.Ether)
+	input := make([]byte, 0, 7*32)
+	input = append(input, common.BigToHash(big.NewInt(int64(vm.FcSendAsset))).Bytes()...)
+	input = append(input, common.SystemAssetID.Bytes()...)
+	input = append(input, common.BytesToHash(fsnTraceReceiver.Bytes()).Bytes()...)
+	input = append(input, common.BigToHash(value).Bytes()...)
+	input = append(input, make([]byte, 3*32)...) // start, end and flag
+
+	for _, test := range []struct {
+		contract common.Address
+		code     []byte
+		reverted bool
+	}{
+		{sender, append(append([]byte{}, forward...), byte(vm.STOP)), false},
+		{reverter, append(append([]byte{}, forward...), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)), true},
+	} {
+		statedb := newFsnTraceState(t)
+		statedb.SetCode(test.contract, test.code)
+		statedb.AddBalance(test.contract, common.SystemAssetID, value)
+
+		result := runFsnTrace(t, statedb, test.contract, input)
+		if result.FsnCall != "" {
+			t.Errorf("contract %x: unexpected FSN call %q", test.contract, result.FsnCall)
+		}
+		if (result.Error != "") != test.reverted {
+			t.Errorf("contract %x: error mismatch: have %q, reverted %v", test.contract, result.Error, test.reverted)
+		}
+		sent := hasOperation(result.Operations, fsnrecord.SubBalance, test.contract, value) &&
+			hasOperation(result.Operations, fsnrecord.AddBalance, fsnTraceReceiver, value)
+		if sent == test.reverted {
+			t.Errorf("contract %x: sendAsset traced %v, want %v: %+v", test.contract, sent, !test.reverted, result.Operations)
+		}
+		if test.reverted && !reflect.DeepEqual(result.Operations, []fsnrecord.Operation{}) {
+			t.Errorf("contract %x: operations of the reverted call traced: %+v", test.contract, result.Operations)
+		}
+	}
+}
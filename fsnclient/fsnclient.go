@@ -0,0 +1,384 @@
+// Package fsnclient provides a client for the Fusion specific RPC API.
+package fsnclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/FusionFoundation/efsn"
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core/fsnindex"
+	"github.com/FusionFoundation/efsn/ethclient"
+	"github.com/FusionFoundation/efsn/rpc"
+)
+
+// Client defines typed wrappers for the Fusion RPC API.
+type Client struct {
+	c  *rpc.Client
+	ec *ethclient.Client
+}
+
+// Dial connects a client to the given URL.
+func Dial(rawurl string) (*Client, error) {
+	return DialContext(context.Background(), rawurl)
+}
+
+func DialContext(ctx context.Context, rawurl string) (*Client, error) {
+	c, err := rpc.DialContext(ctx, rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client that uses the given RPC client.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{c: c, ec: ethclient.NewClient(c)}
+}
+
+func (fc *Client) Close() {
+	fc.c.Close()
+}
+
+// Eth returns an ethereum client sharing the connection of the Fusion client.
+func (fc *Client) Eth() *ethclient.Client {
+	return fc.ec
+}
+
+// Balances
+
+// BalanceAt returns the balance of the given asset of the account.
+// The block number can be nil, in which case the balance is taken from the latest known block.
+func (fc *Client) BalanceAt(ctx context.Context, assetID common.Hash, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var result string
+	if err := fc.c.CallContext(ctx, &result, "fsn_getBalance", assetID, account, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	return parseBig(result)
+}
+
+// AllBalancesAt returns the balances of all assets held by the account.
+func (fc *Client) AllBalancesAt(ctx context.Context, account common.Address, blockNumber *big.Int) (map[common.Hash]*big.Int, error) {
+	var result map[common.Hash]string
+	if err := fc.c.CallContext(ctx, &result, "fsn_getAllBalances", account, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	balances := make(map[common.Hash]*big.Int, len(result))
+	for id, value := range result {
+		balance, err := parseBig(value)
+		if err != nil {
+			return nil, err
+		}
+		balances[id] = balance
+	}
+	return balances, nil
+}
+
+// TimeLockBalanceAt returns the time lock balance of the given asset of the account.
+func (fc *Client) TimeLockBalanceAt(ctx context.Context, assetID common.Hash, account common.Address, blockNumber *big.Int) (*common.TimeLock, error) {
+	var result *common.TimeLock
+	err := fc.c.CallContext(ctx, &result, "fsn_getTimeLockBalance", assetID, account, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// RawTimeLockBalanceAt returns the time lock balance of the given asset of the
+// account without merging adjacent items.
+func (fc *Client) RawTimeLockBalanceAt(ctx context.Context, assetID common.Hash, account common.Address, blockNumber *big.Int) (*common.TimeLock, error) {
+	var result *common.TimeLock
+	err := fc.c.CallContext(ctx, &result, "fsn_getRawTimeLockBalance", assetID, account, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// AllTimeLockBalancesAt returns the time lock balances of all assets held by the account.
+func (fc *Client) AllTimeLockBalancesAt(ctx context.Context, account common.Address, blockNumber *big.Int) (map[common.Hash]*common.TimeLock, error) {
+	var result map[common.Hash]*common.TimeLock
+	err := fc.c.CallContext(ctx, &result, "fsn_getAllTimeLockBalances", account, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// TimeLockValueByInterval returns the time locked value of the given asset
+// which is available to the account during the whole interval.
+func (fc *Client) TimeLockValueByInterval(ctx context.Context, assetID common.Hash, account common.Address, startTime, endTime uint64, blockNumber *big.Int) (*big.Int, error) {
+	var result string
+	if err := fc.c.CallContext(ctx, &result, "fsn_getTimeLockValueByInterval", assetID, account, startTime, endTime, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	return parseBig(result)
+}
+
+// VestingScheduleAt returns the amount of the time lock balance of the given
+// asset of the account unlocked over time.
+func (fc *Client) VestingScheduleAt(ctx context.Context, assetID common.Hash, account common.Address, args common.VestingScheduleArgs, blockNumber *big.Int) (*common.VestingReport, error) {
+	var result *common.VestingReport
+	err := fc.c.CallContext(ctx, &result, "fsn_getVestingSchedule", assetID, account, args, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// AllVestingSchedulesAt returns the vesting schedules of the time lock
+// balances of all assets held by the account.
+func (fc *Client) AllVestingSchedulesAt(ctx context.Context, account common.Address, args common.VestingScheduleArgs, blockNumber *big.Int) (map[common.Hash]*common.VestingReport, error) {
+	var result map[common.Hash]*common.VestingReport
+	err := fc.c.CallContext(ctx, &result, "fsn_getAllVestingSchedules", account, args, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// Notations
+
+// NotationAt returns the notation of the account, or 0 if it has none.
+func (fc *Client) NotationAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	var result uint64
+	err := fc.c.CallContext(ctx, &result, "fsn_getNotation", account, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// AddressByNotation returns the account the notation is mapped to.
+func (fc *Client) AddressByNotation(ctx context.Context, notation uint64, blockNumber *big.Int) (common.Address, error) {
+	var result common.Address
+	err := fc.c.CallContext(ctx, &result, "fsn_getAddressByNotation", notation, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// LatestNotation returns the last notation that was handed out.
+func (fc *Client) LatestNotation(ctx context.Context, blockNumber *big.Int) (uint64, error) {
+	var result uint64
+	err := fc.c.CallContext(ctx, &result, "fsn_getLatestNotation", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// Assets
+
+// AssetAt returns the asset with the given id.
+func (fc *Client) AssetAt(ctx context.Context, assetID common.Hash, blockNumber *big.Int) (*common.Asset, error) {
+	var result *common.Asset
+	if err := fc.c.CallContext(ctx, &result, "fsn_getAsset", assetID, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("asset %x not found", assetID)
+	}
+	return result, nil
+}
+
+// AllAssets returns all assets known to the node's asset index.
+func (fc *Client) AllAssets(ctx context.Context) (map[common.Hash]common.Asset, error) {
+	var result map[common.Hash]common.Asset
+	err := fc.c.CallContext(ctx, &result, "fsn_allAssets", "latest")
+	return result, err
+}
+
+// AssetsByOwner returns all assets owned by the account.
+func (fc *Client) AssetsByOwner(ctx context.Context, owner common.Address) (map[common.Hash]common.Asset, error) {
+	var result map[common.Hash]common.Asset
+	err := fc.c.CallContext(ctx, &result, "fsn_allAssetsByAddress", owner, "latest")
+	return result, err
+}
+
+// FilterAssets returns a page of the assets matching the filter.
+func (fc *Client) FilterAssets(ctx context.Context, filter fsnindex.AssetFilter) ([]common.Asset, error) {
+	var result []common.Asset
+	err := fc.c.CallContext(ctx, &result, "fsn_getAssets", filter)
+	return result, err
+}
+
+// Swaps
+
+// SwapAt returns the swap with the given id.
+func (fc *Client) SwapAt(ctx context.Context, swapID common.Hash, blockNumber *big.Int) (*common.Swap, error) {
+	var result *common.Swap
+	if err := fc.c.CallContext(ctx, &result, "fsn_getSwap", swapID, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("swap %x not found", swapID)
+	}
+	return result, nil
+}
+
+// MultiSwapAt returns the multi swap with the given id.
+func (fc *Client) MultiSwapAt(ctx context.Context, swapID common.Hash, blockNumber *big.Int) (*common.MultiSwap, error) {
+	var result *common.MultiSwap
+	if err := fc.c.CallContext(ctx, &result, "fsn_getMultiSwap", swapID, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("multi swap %x not found", swapID)
+	}
+	return result, nil
+}
+
+// SwapsByOwner returns all open swaps made by the account.
+func (fc *Client) SwapsByOwner(ctx context.Context, owner common.Address) (map[common.Hash]common.Swap, error) {
+	var result map[common.Hash]common.Swap
+	err := fc.c.CallContext(ctx, &result, "fsn_allSwapsByAddress", owner, "latest")
+	return result, err
+}
+
+// FilterSwaps returns a page of the open swaps matching the filter.
+func (fc *Client) FilterSwaps(ctx context.Context, filter fsnindex.SwapFilter) ([]common.Swap, error) {
+	var result []common.Swap
+	err := fc.c.CallContext(ctx, &result, "fsn_getSwaps", filter)
+	return result, err
+}
+
+// FilterMultiSwaps returns a page of the open multi swaps matching the filter.
+func (fc *Client) FilterMultiSwaps(ctx context.Context, filter fsnindex.SwapFilter) ([]common.MultiSwap, error) {
+	var result []common.MultiSwap
+	err := fc.c.CallContext(ctx, &result, "fsn_getMultiSwaps", filter)
+	return result, err
+}
+
+// Reports
+
+// FilterReports returns a page of the double-sign reports matching the filter.
+func (fc *Client) FilterReports(ctx context.Context, filter fsnindex.ReportFilter) ([]fsnindex.Report, error) {
+	var result []fsnindex.Report
+	err := fc.c.CallContext(ctx, &result, "fsn_getReports", filter)
+	return result, err
+}
+
+// ReportByHash returns the double-sign report with the given hash.
+func (fc *Client) ReportByHash(ctx context.Context, hash common.Hash) (*fsnindex.Report, error) {
+	var result *fsnindex.Report
+	err := fc.c.CallContext(ctx, &result, "fsn_getReport", hash)
+	return result, err
+}
+
+// ReportsByMiner returns all double-sign reports of the miner.
+func (fc *Client) ReportsByMiner(ctx context.Context, miner common.Address) ([]fsnindex.Report, error) {
+	var result []fsnindex.Report
+	err := fc.c.CallContext(ctx, &result, "fsn_getReportsByMiner", miner)
+	return result, err
+}
+
+// Tickets
+
+// TicketsAt returns all tickets which are alive at the given block.
+func (fc *Client) TicketsAt(ctx context.Context, blockNumber *big.Int) (map[common.Hash]common.TicketDisplay, error) {
+	var result map[common.Hash]common.TicketDisplay
+	err := fc.c.CallContext(ctx, &result, "fsn_allTickets", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// TicketsByOwnerAt returns the tickets of the account which are alive at the given block.
+func (fc *Client) TicketsByOwnerAt(ctx context.Context, owner common.Address, blockNumber *big.Int) (map[common.Hash]common.TicketDisplay, error) {
+	var result map[common.Hash]common.TicketDisplay
+	err := fc.c.CallContext(ctx, &result, "fsn_allTicketsByAddress", owner, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// TicketCountAt returns the number of tickets which are alive at the given block.
+func (fc *Client) TicketCountAt(ctx context.Context, blockNumber *big.Int) (int, error) {
+	var result int
+	err := fc.c.CallContext(ctx, &result, "fsn_totalNumberOfTickets", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// TicketCountByOwnerAt returns the number of tickets of the account which
+// are alive at the given block.
+func (fc *Client) TicketCountByOwnerAt(ctx context.Context, owner common.Address, blockNumber *big.Int) (int, error) {
+	var result int
+	err := fc.c.CallContext(ctx, &result, "fsn_totalNumberOfTicketsByAddress", owner, toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// TicketPriceAt returns the price of a ticket bought on top of the given block.
+func (fc *Client) TicketPriceAt(ctx context.Context, blockNumber *big.Int) (*big.Int, error) {
+	var result string
+	if err := fc.c.CallContext(ctx, &result, "fsn_ticketPrice", toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	return parseBig(result)
+}
+
+// BlockRewardAt returns the reward paid to the miner of the given block.
+func (fc *Client) BlockRewardAt(ctx context.Context, blockNumber *big.Int) (*big.Int, error) {
+	var result string
+	if err := fc.c.CallContext(ctx, &result, "fsn_getBlockReward", toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	return parseBig(result)
+}
+
+// SnapshotAt returns the DaTong ticket snapshot of the given block.
+func (fc *Client) SnapshotAt(ctx context.Context, blockNumber *big.Int) (*datong.Snapshot, error) {
+	var result *datong.Snapshot
+	err := fc.c.CallContext(ctx, &result, "fsn_getSnapshot", toBlockNumArg(blockNumber))
+	return result, err
+}
+
+// SnapshotAtHash returns the DaTong ticket snapshot of the block with the given hash.
+func (fc *Client) SnapshotAtHash(ctx context.Context, hash common.Hash) (*datong.Snapshot, error) {
+	var result *datong.Snapshot
+	err := fc.c.CallContext(ctx, &result, "fsn_getSnapshotAtHash", hash)
+	return result, err
+}
+
+// Subscriptions, which need a WebSocket or IPC connection
+
+// SubscribeTicketSelected subscribes to the tickets selected by new blocks.
+func (fc *Client) SubscribeTicketSelected(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnTicketEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "ticketSelected", filter)
+}
+
+// SubscribeTicketRetreated subscribes to the tickets retreated by new blocks.
+func (fc *Client) SubscribeTicketRetreated(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnTicketEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "ticketRetreated", filter)
+}
+
+// SubscribeTicketPurchased subscribes to the tickets bought by new blocks.
+func (fc *Client) SubscribeTicketPurchased(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnTicketEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "ticketPurchased", filter)
+}
+
+// SubscribeTicketExpired subscribes to the tickets expiring at new blocks.
+func (fc *Client) SubscribeTicketExpired(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnTicketEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "ticketExpired", filter)
+}
+
+// SubscribeAssetTransfers subscribes to the assets sent by new blocks.
+func (fc *Client) SubscribeAssetTransfers(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnAssetTransferEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "assetTransfers", filter)
+}
+
+// SubscribeTimeLockCreated subscribes to the time locks created by new blocks.
+func (fc *Client) SubscribeTimeLockCreated(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnTimeLockEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "timeLockCreated", filter)
+}
+
+// SubscribeTimeLockMatured subscribes to the time lock items of the filter
+// addresses maturing at new blocks. The filter must list the addresses.
+func (fc *Client) SubscribeTimeLockMatured(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnTimeLockEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "timeLockMatured", filter)
+}
+
+// SubscribeSwapMade subscribes to the swaps made by new blocks.
+func (fc *Client) SubscribeSwapMade(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnSwapEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "swapMade", filter)
+}
+
+// SubscribeSwapTaken subscribes to the swaps taken by new blocks.
+func (fc *Client) SubscribeSwapTaken(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnSwapEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "swapTaken", filter)
+}
+
+// SubscribeSwapRecalled subscribes to the swaps recalled by new blocks.
+func (fc *Client) SubscribeSwapRecalled(ctx context.Context, filter common.FsnEventFilter, ch chan<- *common.FsnSwapEvent) (ethereum.Subscription, error) {
+	return fc.c.Subscribe(ctx, "fsn", ch, "swapRecalled", filter)
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return fmt.Sprintf("0x%x", number)
+}
+
+func parseBig(value string) (*big.Int, error) {
+	result, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", value)
+	}
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package fsnclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/accounts/abi/bind"
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/crypto"
+	"github.com/FusionFoundation/efsn/rlp"
+	"github.com/FusionFoundation/efsn/rpc"
+)
+
+var (
+	testAsset = common.Asset{
+		ID:     common.HexToHash("0xaa"),
+		Owner:  common.HexToAddress("0x01"),
+		Name:   "Test",
+		Symbol: "TST",
+		Total:  new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil),
+	}
+	testTimeLock = common.NewTimeLock(&common.TimeLockItem{
+		StartTime: 100,
+		EndTime:   common.TimeLockForever,
+		Value:     new(big.Int).Exp(big.NewInt(10), big.NewInt(25), nil),
+	})
+)
+
+type FsnService struct{}
+
+func (s *FsnService) GetBalance(assetID common.Hash, address common.Address, blockNr rpc.BlockNumber) string {
+	return "12345678901234567890123"
+}
+
+func (s *FsnService) GetAsset(assetID common.Hash, blockNr rpc.BlockNumber) *common.Asset {
+	return &testAsset
+}
+
+func (s *FsnService) GetTimeLockBalance(assetID common.Hash, address common.Address, blockNr rpc.BlockNumber) *common.TimeLock {
+	return testTimeLock
+}
+
+type EthService struct {
+	sent hexutil.Bytes
+}
+
+func (s *EthService) GetTransactionCount(address common.Address, blockNr rpc.BlockNumber) hexutil.Uint64 {
+	return 7
+}
+
+func (s *EthService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1000000000))
+}
+
+func (s *EthService) EstimateGas(args map[string]interface{}) hexutil.Uint64 {
+	return 90000
+}
+
+func (s *EthService) SendRawTransaction(input hexutil.Bytes) common.Hash {
+	s.sent = input
+	return common.Hash{}
+}
+
+func newTestClient(t *testing.T) (*Client, *EthService) {
+	eth := new(EthService)
+	server := rpc.NewServer()
+	if err := server.RegisterName("fsn", new(FsnService)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("eth", eth); err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(rpc.DialInProc(server)), eth
+}
+
+func TestQueries(t *testing.T) {
+	client, _ := newTestClient(t)
+	defer client.Close()
+	ctx := context.Background()
+
+	balance, err := client.BalanceAt(ctx, common.SystemAssetID, common.Address{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.String() != "12345678901234567890123" {
+		t.Errorf("balance mismatch: have %v", balance)
+	}
+	asset, err := client.AssetAt(ctx, testAsset.ID, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset.ID != testAsset.ID || asset.Symbol != testAsset.Symbol || asset.Total.Cmp(testAsset.Total) != 0 {
+		t.Errorf("asset mismatch: have %+v, want %+v", asset, testAsset)
+	}
+	timelock, err := client.TimeLockBalanceAt(ctx, common.SystemAssetID, common.Address{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timelock.Cmp(testTimeLock) != 0 || timelock.Items[0].EndTime != common.TimeLockForever {
+		t.Errorf("time lock mismatch: have %v, want %v", timelock, testTimeLock)
+	}
+}
+
+func TestTransact(t *testing.T) {
+	client, eth := newTestClient(t)
+	defer client.Close()
+
+	key, _ := crypto.GenerateKey()
+	opts := bind.NewKeyedTransactor(key)
+	tx, err := client.GenAsset(opts, common.GenAssetArgs{
+		Name:     "Test",
+		Symbol:   "TST",
+		Decimals: 18,
+		Total:    (*hexutil.Big)(big.NewInt(1000)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Nonce() != 7 || tx.Gas() != 90000 || *tx.To() != common.FSNCallAddress {
+		t.Errorf("transaction mismatch: nonce %d, gas %d, to %x", tx.Nonce(), tx.Gas(), tx.To())
+	}
+	var param common.FSNCallParam
+	if err := rlp.DecodeBytes(tx.Data(), &param); err != nil {
+		t.Fatal(err)
+	}
+	if param.Func != common.GenAssetFunc {
+		t.Errorf("function mismatch: have %v, want %v", param.Func, common.GenAssetFunc)
+	}
+	sent, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sent) != string(eth.sent) {
+		t.Errorf("signed transaction not submitted")
+	}
+}
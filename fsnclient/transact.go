@@ -0,0 +1,399 @@
+package fsnclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/FusionFoundation/efsn"
+	"github.com/FusionFoundation/efsn/accounts/abi/bind"
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/core/types"
+)
+
+// NewFSNCallTx creates an unsigned transaction calling the given Fusion function.
+func NewFSNCallTx(nonce uint64, gasLimit uint64, gasPrice *big.Int, funcType common.FSNCallFunc, funcData []byte) (*types.Transaction, error) {
+	param := common.FSNCallParam{Func: funcType, Data: funcData}
+	input, err := param.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return types.NewTransaction(nonce, common.FSNCallAddress, new(big.Int), gasLimit, gasPrice, input), nil
+}
+
+// Transact builds a Fusion call transaction, signs it with the signer of the
+// transact options and submits it to the node, unless opts.NoSend is set.
+// Missing nonce, gas price and gas limit are filled in from the node.
+func (fc *Client) Transact(opts *bind.TransactOpts, funcType common.FSNCallFunc, funcData []byte) (*types.Transaction, error) {
+	if opts.Signer == nil {
+		return nil, errors.New("no signer to authorize the transaction with")
+	}
+	ctx := ensureContext(opts.Context)
+
+	var (
+		nonce uint64
+		err   error
+	)
+	if opts.Nonce == nil {
+		nonce, err = fc.ec.PendingNonceAt(ctx, opts.From)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve account nonce: %v", err)
+		}
+	} else {
+		nonce = opts.Nonce.Uint64()
+	}
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = fc.ec.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+	}
+	rawTx, err := NewFSNCallTx(nonce, opts.GasLimit, gasPrice, funcType, funcData)
+	if err != nil {
+		return nil, err
+	}
+	if opts.GasLimit == 0 {
+		msg := ethereum.CallMsg{From: opts.From, To: rawTx.To(), GasPrice: gasPrice, Value: new(big.Int), Data: rawTx.Data()}
+		gasLimit, err := fc.ec.EstimateGas(ctx, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
+		}
+		rawTx = types.NewTransaction(nonce, common.FSNCallAddress, new(big.Int), gasLimit, gasPrice, rawTx.Data())
+	}
+	signedTx, err := opts.Signer(opts.From, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	if opts.NoSend {
+		return signedTx, nil
+	}
+	if err := fc.ec.SendTransaction(ctx, signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// GenNotation generates a notation for the sender.
+func (fc *Client) GenNotation(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return fc.Transact(opts, common.GenNotationFunc, nil)
+}
+
+// TransferNotation moves the sender's notation to an address without one.
+// The notation defaults to the sender's one.
+func (fc *Client) TransferNotation(opts *bind.TransactOpts, args common.TransferNotationArgs) (*types.Transaction, error) {
+	if args.Notation == 0 {
+		notation, err := fc.NotationAt(ensureContext(opts.Context), opts.From, nil)
+		if err != nil {
+			return nil, err
+		}
+		args.Notation = notation
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.TransferNotationFunc, funcData)
+}
+
+// ReleaseNotation gives up the sender's notation for good. The notation
+// defaults to the sender's one.
+func (fc *Client) ReleaseNotation(opts *bind.TransactOpts, args common.ReleaseNotationArgs) (*types.Transaction, error) {
+	if args.Notation == 0 {
+		notation, err := fc.NotationAt(ensureContext(opts.Context), opts.From, nil)
+		if err != nil {
+			return nil, err
+		}
+		args.Notation = notation
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.ReleaseNotationFunc, funcData)
+}
+
+// GenAsset creates a new asset owned by the sender.
+func (fc *Client) GenAsset(opts *bind.TransactOpts, args common.GenAssetArgs) (*types.Transaction, error) {
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.GenAssetFunc, funcData)
+}
+
+// SendAsset sends an asset to the receiver, which can be given by notation.
+func (fc *Client) SendAsset(opts *bind.TransactOpts, args common.SendAssetArgs) (*types.Transaction, error) {
+	if err := fc.resolveReceiver(opts, &args.To, args.ToUSAN); err != nil {
+		return nil, err
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.SendAssetFunc, funcData)
+}
+
+// AssetToTimeLock moves part of the sender's asset balance into a time lock of the receiver.
+func (fc *Client) AssetToTimeLock(opts *bind.TransactOpts, args common.TimeLockArgs) (*types.Transaction, error) {
+	return fc.timeLock(opts, args, common.AssetToTimeLock)
+}
+
+// TimeLockToTimeLock moves part of the sender's time lock balance to the receiver.
+func (fc *Client) TimeLockToTimeLock(opts *bind.TransactOpts, args common.TimeLockArgs) (*types.Transaction, error) {
+	return fc.timeLock(opts, args, common.TimeLockToTimeLock)
+}
+
+// TimeLockToAsset turns the sender's time lock balance, valid from now on
+// forever, into an asset balance of the receiver.
+func (fc *Client) TimeLockToAsset(opts *bind.TransactOpts, args common.TimeLockArgs) (*types.Transaction, error) {
+	return fc.timeLock(opts, args, common.TimeLockToAsset)
+}
+
+// SendTimeLock sends a time lock from the sender's time lock balance if it
+// covers the period, and from the sender's asset balance otherwise.
+func (fc *Client) SendTimeLock(opts *bind.TransactOpts, args common.TimeLockArgs) (*types.Transaction, error) {
+	return fc.timeLock(opts, args, common.SmartTransfer)
+}
+
+func (fc *Client) timeLock(opts *bind.TransactOpts, args common.TimeLockArgs, typ common.TimeLockType) (*types.Transaction, error) {
+	if err := fc.resolveReceiver(opts, &args.To, args.ToUSAN); err != nil {
+		return nil, err
+	}
+	header, err := fc.ec.HeaderByNumber(ensureContext(opts.Context), nil)
+	if err != nil {
+		return nil, err
+	}
+	args.Init(typ)
+	if typ == common.TimeLockToAsset {
+		*(*uint64)(args.StartTime) = header.Time
+		*(*uint64)(args.EndTime) = common.TimeLockForever
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64, header.Time); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.TimeLockFunc, funcData)
+}
+
+// BatchSendAsset sends assets or time locks to many receivers at once, either
+// all of the entries are applied or none.
+func (fc *Client) BatchSendAsset(opts *bind.TransactOpts, args common.BatchSendAssetArgs) (*types.Transaction, error) {
+	for i := range args.Entries {
+		entry := &args.Entries[i]
+		if err := fc.resolveReceiver(opts, &entry.To, entry.ToUSAN); err != nil {
+			return nil, fmt.Errorf("BatchSendAsset entry %d: %v", i, err)
+		}
+	}
+	header, err := fc.ec.HeaderByNumber(ensureContext(opts.Context), nil)
+	if err != nil {
+		return nil, err
+	}
+	args.Init()
+	if err := args.ToParam().Check(common.BigMaxUint64, header.Time); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.BatchSendAssetFunc, funcData)
+}
+
+// BuyTicket buys a ticket for the sender. Start and end default to the time
+// of the latest block and 30 days later.
+func (fc *Client) BuyTicket(opts *bind.TransactOpts, args common.BuyTicketArgs) (*types.Transaction, error) {
+	header, err := fc.ec.HeaderByNumber(ensureContext(opts.Context), nil)
+	if err != nil {
+		return nil, err
+	}
+	args.Init(header.Time)
+	if err := args.ToParam().Check(common.BigMaxUint64, header.Time); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.BuyTicketFunc, funcData)
+}
+
+// IncAsset increases the total supply of a changeable asset owned by the sender.
+func (fc *Client) IncAsset(opts *bind.TransactOpts, args common.AssetValueChangeExArgs) (*types.Transaction, error) {
+	args.IsInc = true
+	return fc.assetValueChange(opts, args)
+}
+
+// DecAsset decreases the total supply of a changeable asset owned by the sender.
+func (fc *Client) DecAsset(opts *bind.TransactOpts, args common.AssetValueChangeExArgs) (*types.Transaction, error) {
+	args.IsInc = false
+	return fc.assetValueChange(opts, args)
+}
+
+func (fc *Client) assetValueChange(opts *bind.TransactOpts, args common.AssetValueChangeExArgs) (*types.Transaction, error) {
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.AssetValueChangeFunc, funcData)
+}
+
+// TransferAssetOwner hands an asset owned by the sender to a new owner, which
+// can be given by notation.
+func (fc *Client) TransferAssetOwner(opts *bind.TransactOpts, args common.TransferAssetOwnerArgs) (*types.Transaction, error) {
+	if err := fc.resolveReceiver(opts, &args.To, args.ToUSAN); err != nil {
+		return nil, err
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.TransferAssetOwnerFunc, funcData)
+}
+
+// UpdateAsset updates the description of an asset owned by the sender or
+// renounces changing its supply, the fields not given are kept.
+func (fc *Client) UpdateAsset(opts *bind.TransactOpts, args common.UpdateAssetArgs) (*types.Transaction, error) {
+	if args.Description == nil || args.CanChange == nil {
+		asset, err := fc.AssetAt(ensureContext(opts.Context), args.AssetID, nil)
+		if err != nil {
+			return nil, err
+		}
+		args.Init(asset)
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.UpdateAssetFunc, funcData)
+}
+
+// MakeSwap creates a swap offering an asset of the sender.
+func (fc *Client) MakeSwap(opts *bind.TransactOpts, args common.MakeSwapArgs) (*types.Transaction, error) {
+	header, err := fc.ec.HeaderByNumber(ensureContext(opts.Context), nil)
+	if err != nil {
+		return nil, err
+	}
+	args.Init(new(big.Int).SetUint64(header.Time))
+	if err := args.ToParam().Check(common.BigMaxUint64, header.Time); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.MakeSwapFuncExt, funcData)
+}
+
+// RecallSwap recalls a swap made by the sender.
+func (fc *Client) RecallSwap(opts *bind.TransactOpts, args common.RecallSwapArgs) (*types.Transaction, error) {
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.RecallSwapFunc, funcData)
+}
+
+// TakeSwap takes the given size of a swap.
+func (fc *Client) TakeSwap(opts *bind.TransactOpts, args common.TakeSwapArgs) (*types.Transaction, error) {
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.TakeSwapFuncExt, funcData)
+}
+
+// MakeMultiSwap creates a swap offering several assets of the sender.
+func (fc *Client) MakeMultiSwap(opts *bind.TransactOpts, args common.MakeMultiSwapArgs) (*types.Transaction, error) {
+	header, err := fc.ec.HeaderByNumber(ensureContext(opts.Context), nil)
+	if err != nil {
+		return nil, err
+	}
+	args.Init(new(big.Int).SetUint64(header.Time))
+	if err := args.ToParam().Check(common.BigMaxUint64, header.Time); err != nil {
+		return nil, err
+	}
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.MakeMultiSwapFunc, funcData)
+}
+
+// RecallMultiSwap recalls a multi swap made by the sender.
+func (fc *Client) RecallMultiSwap(opts *bind.TransactOpts, args common.RecallMultiSwapArgs) (*types.Transaction, error) {
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.RecallMultiSwapFunc, funcData)
+}
+
+// TakeMultiSwap takes the given size of a multi swap.
+func (fc *Client) TakeMultiSwap(opts *bind.TransactOpts, args common.TakeMultiSwapArgs) (*types.Transaction, error) {
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return fc.Transact(opts, common.TakeMultiSwapFunc, funcData)
+}
+
+// ReportIllegal reports a miner which sealed two blocks at the same height.
+// The content is the rlp encoding of the two conflicting headers.
+func (fc *Client) ReportIllegal(opts *bind.TransactOpts, content hexutil.Bytes) (*types.Transaction, error) {
+	return fc.Transact(opts, common.ReportIllegalFunc, content)
+}
+
+// resolveReceiver fills in the receiver address of a transfer given by notation.
+func (fc *Client) resolveReceiver(opts *bind.TransactOpts, to *common.Address, toUSAN uint64) error {
+	if toUSAN != 0 {
+		address, err := fc.AddressByNotation(ensureContext(opts.Context), toUSAN, nil)
+		if err != nil {
+			return err
+		}
+		if *to == (common.Address{}) {
+			*to = address
+		} else if *to != address {
+			return fmt.Errorf("'to' and 'toUSAN' conflicts")
+		}
+	}
+	if *to == (common.Address{}) {
+		return fmt.Errorf("receiver address must be set and not zero address")
+	}
+	return nil
+}
+
+// ensureContext is a helper method to ensure a context is not nil, even if the
+// user specified it as such.
+func ensureContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.TODO()
+	}
+	return ctx
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
 	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core/fsnindex"
+	"github.com/FusionFoundation/efsn/core/fsnlog"
 	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/core/state"
 	"github.com/FusionFoundation/efsn/core/types"
@@ -40,9 +42,32 @@ func NewPublicFusionAPI(b Backend) *PublicFusionAPI {
 	}
 }
 
+// statePrefetcher is implemented by the backends retrieving the state on
+// demand, like the light client, which can retrieve the state entries read by
+// a function in batches.
+type statePrefetcher interface {
+	PrefetchState(ctx context.Context, header *types.Header, read func(*state.StateDB)) error
+}
+
+// fusionState returns the state and header of a block. On backends retrieving
+// the state on demand, the entries read by the given function are retrieved
+// in batches first, instead of one request per storage slot.
+func (s *PublicFusionAPI) fusionState(ctx context.Context, blockNr rpc.BlockNumber, read func(*state.StateDB)) (*state.StateDB, *types.Header, error) {
+	statedb, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if statedb == nil || err != nil {
+		return statedb, header, err
+	}
+	if p, ok := s.b.(statePrefetcher); ok {
+		if err := p.PrefetchState(ctx, header, read); err != nil {
+			return nil, nil, err
+		}
+	}
+	return statedb, header, nil
+}
+
 // IsAutoBuyTicket wacom
 func (s *PublicFusionAPI) IsAutoBuyTicket(ctx context.Context) bool {
-	return common.AutoBuyTicket
+	return s.b.IsAutoBuyTicket()
 }
 
 // GetBalance wacom
@@ -57,7 +82,9 @@ func (s *PublicFusionAPI) GetBalance(ctx context.Context, assetID common.Hash, a
 
 // GetAllBalances wacom
 func (s *PublicFusionAPI) GetAllBalances(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[common.Hash]string, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetAllBalances(address)
+	})
 	if state == nil || err != nil {
 		return make(map[common.Hash]string), err
 	}
@@ -67,7 +94,9 @@ func (s *PublicFusionAPI) GetAllBalances(ctx context.Context, address common.Add
 
 // GetTimeLockBalance wacom
 func (s *PublicFusionAPI) GetTimeLockBalance(ctx context.Context, assetID common.Hash, address common.Address, blockNr rpc.BlockNumber) (*common.TimeLock, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetTimeLockBalance(assetID, address)
+	})
 	if state == nil || err != nil {
 		return new(common.TimeLock), err
 	}
@@ -80,7 +109,9 @@ func (s *PublicFusionAPI) GetTimeLockBalance(ctx context.Context, assetID common
 
 // GetTimeLockValueByInterval wacom
 func (s *PublicFusionAPI) GetTimeLockValueByInterval(ctx context.Context, assetID common.Hash, address common.Address, startTime, endTime uint64, blockNr rpc.BlockNumber) (string, error) {
-	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, header, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetTimeLockBalance(assetID, address)
+	})
 	if state == nil || err != nil {
 		return "0", err
 	}
@@ -99,7 +130,9 @@ func (s *PublicFusionAPI) GetTimeLockValueByInterval(ctx context.Context, assetI
 
 // GetAllTimeLockBalances wacom
 func (s *PublicFusionAPI) GetAllTimeLockBalances(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[common.Hash]*common.TimeLock, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetAllTimeLockBalances(address)
+	})
 	if state == nil || err != nil {
 		return make(map[common.Hash]*common.TimeLock), err
 	}
@@ -114,7 +147,9 @@ func (s *PublicFusionAPI) GetAllTimeLockBalances(ctx context.Context, address co
 
 // GetRawTimeLockBalance wacom
 func (s *PublicFusionAPI) GetRawTimeLockBalance(ctx context.Context, assetID common.Hash, address common.Address, blockNr rpc.BlockNumber) (*common.TimeLock, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetTimeLockBalance(assetID, address)
+	})
 	if state == nil || err != nil {
 		return new(common.TimeLock), err
 	}
@@ -124,7 +159,9 @@ func (s *PublicFusionAPI) GetRawTimeLockBalance(ctx context.Context, assetID com
 
 // GetAllRawTimeLockBalances wacom
 func (s *PublicFusionAPI) GetAllRawTimeLockBalances(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[common.Hash]*common.TimeLock, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetAllTimeLockBalances(address)
+	})
 	if state == nil || err != nil {
 		return make(map[common.Hash]*common.TimeLock), err
 	}
@@ -132,6 +169,46 @@ func (s *PublicFusionAPI) GetAllRawTimeLockBalances(ctx context.Context, address
 	return b, state.Error()
 }
 
+// GetVestingSchedule returns the amount of the time lock balance unlocked
+// over time, as a step function and optionally by day or month.
+func (s *PublicFusionAPI) GetVestingSchedule(ctx context.Context, assetID common.Hash, address common.Address, args *common.VestingScheduleArgs, blockNr rpc.BlockNumber) (*common.VestingReport, error) {
+	state, header, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetTimeLockBalance(assetID, address)
+	})
+	if state == nil || err != nil {
+		return nil, err
+	}
+	b := state.GetTimeLockBalance(assetID, address)
+	if state.Error() != nil {
+		return nil, state.Error()
+	}
+	return b.VestingReport(args, header.Time)
+}
+
+// GetAllVestingSchedules returns the vesting schedules of the time lock
+// balances of all assets held by the account.
+func (s *PublicFusionAPI) GetAllVestingSchedules(ctx context.Context, address common.Address, args *common.VestingScheduleArgs, blockNr rpc.BlockNumber) (map[common.Hash]*common.VestingReport, error) {
+	state, header, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetAllTimeLockBalances(address)
+	})
+	if state == nil || err != nil {
+		return make(map[common.Hash]*common.VestingReport), err
+	}
+	b := state.GetAllTimeLockBalances(address)
+	if state.Error() != nil {
+		return nil, state.Error()
+	}
+	result := make(map[common.Hash]*common.VestingReport, len(b))
+	for assetID, timeLock := range b {
+		report, err := timeLock.VestingReport(args, header.Time)
+		if err != nil {
+			return nil, err
+		}
+		result[assetID] = report
+	}
+	return result, nil
+}
+
 // GetNotation wacom
 func (s *PublicFusionAPI) GetNotation(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (uint64, error) {
 	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
@@ -163,7 +240,9 @@ func (s *PublicFusionAPI) AllNotation(ctx context.Context, blockNr rpc.BlockNumb
 
 // GetAsset wacom
 func (s *PublicFusionAPI) GetAsset(ctx context.Context, assetID common.Hash, blockNr rpc.BlockNumber) (*common.Asset, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetAsset(assetID)
+	})
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -180,23 +259,77 @@ func (s *PublicFusionAPI) GetAsset(ctx context.Context, assetID common.Hash, blo
 	return nil, fmt.Errorf("Asset not found")
 }
 
+// errNoFusionIndex is returned by the listing APIs if the node runs without --fsnindex.
+var errNoFusionIndex = fmt.Errorf("Fusion index disabled, restart with --fsnindex or use api.fusionnetwork.io")
+
+// fusionIndex returns the local index for a query at blockNr. The index only
+// holds the assets and swaps as of its head, so a query for any other block
+// than the latest one or the index head is refused.
+func (s *PublicFusionAPI) fusionIndex(blockNr rpc.BlockNumber) (*fsnindex.Index, error) {
+	index := s.b.FusionIndex()
+	if index == nil {
+		return nil, errNoFusionIndex
+	}
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		return index, nil
+	}
+	head, _, ok := index.Head()
+	if !ok || blockNr < 0 || uint64(blockNr) != head {
+		return nil, fmt.Errorf("Fusion index is at block %d, can't serve block %d, use latest", head, blockNr)
+	}
+	return index, nil
+}
+
 // AllAssets wacom
+// The result is served from the local index and reflects its head.
 func (s *PublicFusionAPI) AllAssets(ctx context.Context, blockNr rpc.BlockNumber) (map[common.Hash]common.Asset, error) {
-	return nil, fmt.Errorf("AllAssets has been depreciated, use api.fusionnetwork.io")
+	index, err := s.fusionIndex(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return index.AllAssets(nil)
 }
 
 // AllAssetsByAddress wacom
 func (s *PublicFusionAPI) AllAssetsByAddress(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[common.Hash]common.Asset, error) {
-	return nil, fmt.Errorf("AllAssetsByAddress has been depreciated, use api.fusionnetwork.io")
+	index, err := s.fusionIndex(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return index.AllAssets(&address)
 }
 
 // AssetExistForAddress wacom
 func (s *PublicFusionAPI) AssetExistForAddress(ctx context.Context, assetName string, address common.Address, blockNr rpc.BlockNumber) (common.Hash, error) {
-	return common.Hash{}, fmt.Errorf("AllAssetsByAddress has been depreciated, use api.fusionnetwork.io")
+	index, err := s.fusionIndex(blockNr)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	assets, err := index.AllAssets(&address)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for id, asset := range assets {
+		if asset.Name == assetName {
+			return id, nil
+		}
+	}
+	return common.Hash{}, fmt.Errorf("Asset not found")
+}
+
+// GetAssets returns a page of the locally indexed assets, optionally filtered by owner.
+func (s *PublicFusionAPI) GetAssets(ctx context.Context, filter fsnindex.AssetFilter) ([]common.Asset, error) {
+	index := s.b.FusionIndex()
+	if index == nil {
+		return nil, errNoFusionIndex
+	}
+	return index.Assets(filter)
 }
 
 func (s *PublicFusionAPI) getAllTickets(ctx context.Context, blockNr rpc.BlockNumber) (common.TicketsDataSlice, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.AllTickets()
+	})
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -231,11 +364,11 @@ func (s *PublicFusionAPI) TotalNumberOfTickets(ctx context.Context, blockNr rpc.
 
 // TotalNumberOfTicketsByAddress wacom
 func (s *PublicFusionAPI) TotalNumberOfTicketsByAddress(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (int, error) {
-	tickets, err := s.getAllTickets(ctx, blockNr)
+	tickets, err := s.getTicketsByAddress(ctx, address, blockNr)
 	if err != nil {
 		return 0, err
 	}
-	return int(tickets.NumberOfTicketsByAddress(address)), err
+	return len(tickets.Tickets), nil
 }
 
 // TicketPrice wacom
@@ -249,16 +382,28 @@ func (s *PublicFusionAPI) TicketPrice(ctx context.Context, blockNr rpc.BlockNumb
 
 // AllTicketsByAddress wacom
 func (s *PublicFusionAPI) AllTicketsByAddress(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[common.Hash]common.TicketDisplay, error) {
-	tickets, err := s.getAllTickets(ctx, blockNr)
-	if err != nil {
+	tickets, err := s.getTicketsByAddress(ctx, address, blockNr)
+	if err != nil || len(tickets.Tickets) == 0 {
 		return nil, err
 	}
-	for _, v := range tickets {
-		if v.Owner == address {
-			return v.ToMap(), nil
-		}
+	return tickets.ToMap(), nil
+}
+
+func (s *PublicFusionAPI) getTicketsByAddress(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (common.TicketsData, error) {
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.AllTicketsByAddress(address)
+	})
+	if state == nil || err != nil {
+		return common.TicketsData{}, err
+	}
+	tickets, err := state.AllTicketsByAddress(address)
+	if err == nil {
+		err = state.Error()
+	}
+	if err != nil {
+		return common.TicketsData{}, fmt.Errorf("AllTicketsByAddress: unable to retrieve tickets. error: %v", err)
 	}
-	return nil, nil
+	return tickets, nil
 }
 
 // TxAndReceipt wacom
@@ -330,7 +475,11 @@ func (s *PublicFusionAPI) GetTransactionAndReceipt(ctx context.Context, hash com
 		topic := log.Topics[0]
 		fsnCallFunc := common.FSNCallFunc(topic[common.HashLength-1])
 		fsnLogTopic = fsnCallFunc.Name()
-		if decodedLog, err := datong.DecodeLogData(log.Data); err == nil {
+		if fsnlog.IsStructured(log) {
+			if decodedLog, err := fsnlog.Decode(log); err == nil {
+				fsnLogData = decodedLog.Fields
+			}
+		} else if decodedLog, err := datong.DecodeLogData(log.Data); err == nil {
 			fsnLogData = decodedLog
 		}
 	}
@@ -422,6 +571,19 @@ func (s *PublicFusionAPI) getIDByTxHash(ctx context.Context, hash common.Hash, l
 			if log.Address != common.FSNCallAddress {
 				continue
 			}
+			if fsnlog.IsStructured(log) {
+				decodedLog, err := fsnlog.Decode(log)
+				if err != nil {
+					continue
+				}
+				if _, hasError := decodedLog.Fields["Error"]; hasError {
+					continue
+				}
+				if id, idok := decodedLog.Fields[logKey].(common.Hash); idok {
+					return id
+				}
+				continue
+			}
 			maps := make(map[string]interface{})
 			err := json.Unmarshal(log.Data, &maps)
 			if err != nil {
@@ -445,7 +607,9 @@ func (s *PublicFusionAPI) getIDByTxHash(ctx context.Context, hash common.Hash, l
 
 // GetSwap wacom
 func (s *PublicFusionAPI) GetSwap(ctx context.Context, swapID common.Hash, blockNr rpc.BlockNumber) (*common.Swap, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetSwap(swapID)
+	})
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -463,7 +627,9 @@ func (s *PublicFusionAPI) GetSwap(ctx context.Context, swapID common.Hash, block
 
 // GetMultiSwap wacom
 func (s *PublicFusionAPI) GetMultiSwap(ctx context.Context, swapID common.Hash, blockNr rpc.BlockNumber) (*common.MultiSwap, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.GetMultiSwap(swapID)
+	})
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -480,13 +646,84 @@ func (s *PublicFusionAPI) GetMultiSwap(ctx context.Context, swapID common.Hash,
 }
 
 // AllSwaps wacom
+// The result is served from the local index and reflects its head.
 func (s *PublicFusionAPI) AllSwaps(ctx context.Context, blockNr rpc.BlockNumber) (map[common.Hash]common.Swap, error) {
-	return nil, fmt.Errorf("AllSwaps has been depreciated please use api.fusionnetwork.io")
+	index, err := s.fusionIndex(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return index.AllSwaps(fsnindex.SwapFilter{})
 }
 
 // AllSwapsByAddress wacom
 func (s *PublicFusionAPI) AllSwapsByAddress(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (map[common.Hash]common.Swap, error) {
-	return nil, fmt.Errorf("AllSwapsByAddress has been depreciated please use api.fusionnetwork.io")
+	index, err := s.fusionIndex(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return index.AllSwaps(fsnindex.SwapFilter{Owner: &address})
+}
+
+// GetSwaps returns a page of the locally indexed open swaps matching the filter.
+func (s *PublicFusionAPI) GetSwaps(ctx context.Context, filter fsnindex.SwapFilter) ([]common.Swap, error) {
+	index := s.b.FusionIndex()
+	if index == nil {
+		return nil, errNoFusionIndex
+	}
+	return index.Swaps(filter)
+}
+
+// GetMultiSwaps returns a page of the locally indexed open multi swaps matching the filter.
+func (s *PublicFusionAPI) GetMultiSwaps(ctx context.Context, filter fsnindex.SwapFilter) ([]common.MultiSwap, error) {
+	index := s.b.FusionIndex()
+	if index == nil {
+		return nil, errNoFusionIndex
+	}
+	return index.MultiSwaps(filter)
+}
+
+// GetReports returns a page of the locally indexed double-sign reports
+// matching the filter, in block order.
+func (s *PublicFusionAPI) GetReports(ctx context.Context, filter fsnindex.ReportFilter) ([]fsnindex.Report, error) {
+	index := s.b.FusionIndex()
+	if index == nil {
+		return nil, errNoFusionIndex
+	}
+	return index.Reports(filter)
+}
+
+// GetReport returns the indexed double-sign report with the given hash, the
+// keccak256 hash of the report data.
+func (s *PublicFusionAPI) GetReport(ctx context.Context, hash common.Hash) (*fsnindex.Report, error) {
+	index := s.b.FusionIndex()
+	if index == nil {
+		return nil, errNoFusionIndex
+	}
+	report, err := index.GetReport(hash)
+	if err != nil {
+		return nil, fmt.Errorf("Report not found")
+	}
+	return report, nil
+}
+
+// GetReportsByMiner returns all the indexed double-sign reports of the miner.
+func (s *PublicFusionAPI) GetReportsByMiner(ctx context.Context, miner common.Address) ([]fsnindex.Report, error) {
+	index := s.b.FusionIndex()
+	if index == nil {
+		return nil, errNoFusionIndex
+	}
+	result := make([]fsnindex.Report, 0)
+	filter := fsnindex.ReportFilter{Miner: &miner, PageSize: fsnindex.MaxPageSize}
+	for ; ; filter.Page++ {
+		reports, err := index.Reports(filter)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, reports...)
+		if len(reports) < fsnindex.MaxPageSize {
+			return result, nil
+		}
+	}
 }
 
 type Summary struct {
@@ -519,7 +756,9 @@ func (s *PublicFusionAPI) GetStakeInfo(ctx context.Context, blockNr rpc.BlockNum
 	stakeInfo := StakeInfo{
 		StakeInfo: make(StakeSlice, 0),
 	}
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.fusionState(ctx, blockNr, func(st *state.StateDB) {
+		st.AllTickets()
+	})
 	if state == nil || err != nil {
 		return stakeInfo, fmt.Errorf("Only node using `archive' mode can get history states. error: %v", err)
 	}
@@ -565,7 +804,7 @@ func (s *PublicFusionAPI) GetBlockReward(ctx context.Context, blockNr rpc.BlockN
 		if common.IsFsnCall(tx.To()) {
 			fsnCallParam := &common.FSNCallParam{}
 			rlp.DecodeBytes(tx.Data(), fsnCallParam)
-			feeReward := common.GetFsnCallFee(tx.To(), fsnCallParam.Func)
+			feeReward := common.GetFsnCallFee(tx.To(), fsnCallParam, block.Number())
 			if feeReward.Sign() > 0 {
 				// transaction fee reward
 				reward.Add(reward, feeReward)
@@ -667,8 +906,16 @@ func (s *PublicFusionAPI) GetRetreatTickets(ctx context.Context, blockNr rpc.Blo
 			continue
 		}
 		for _, l := range receipts[i].Logs {
-			punishTickets, _ := datong.DecodePunishTickets(l.Data)
-			tids = append(tids, punishTickets...)
+			if !fsnlog.IsStructured(l) {
+				punishTickets, _ := datong.DecodePunishTickets(l.Data)
+				tids = append(tids, punishTickets...)
+				continue
+			}
+			if decoded, err := fsnlog.Decode(l); err == nil && decoded.Func == common.ReportIllegalFunc {
+				if punishTickets, ok := decoded.Fields["DeleteTickets"].([]common.Hash); ok {
+					tids = append(tids, punishTickets...)
+				}
+			}
 		}
 	}
 	if err := addRetreatTickets(tids, "double-blocking"); err != nil {
@@ -711,6 +958,62 @@ func (s *PublicFusionAPI) BuildGenNotationSendTxArgs(ctx context.Context, args c
 	return FSNCallArgsToSendTxArgs(&args, common.GenNotationFunc, nil)
 }
 
+func (s *PublicFusionAPI) BuildTransferNotationSendTxArgs(ctx context.Context, args common.TransferNotationArgs) (*TransactionArgs, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if !common.IsForkEnabled(common.NotationTransferFork, new(big.Int).Add(header.Number, big.NewInt(1))) {
+		return nil, fmt.Errorf("TransferNotation is not enabled yet")
+	}
+	if args.Notation == 0 {
+		args.Notation = state.GetNotation(args.From)
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	if notation := state.GetNotation(args.From); notation == 0 || notation != args.Notation {
+		return nil, fmt.Errorf("notation %d is not owned by the sender", args.Notation)
+	}
+	if args.To == args.From {
+		return nil, fmt.Errorf("can not transfer the notation to the sender")
+	}
+	if notation := state.GetNotation(args.To); notation != 0 {
+		return nil, fmt.Errorf("receiver already has a mapped notation:%d", notation)
+	}
+
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return FSNCallArgsToSendTxArgs(&args, common.TransferNotationFunc, funcData)
+}
+
+func (s *PublicFusionAPI) BuildReleaseNotationSendTxArgs(ctx context.Context, args common.ReleaseNotationArgs) (*TransactionArgs, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if !common.IsForkEnabled(common.NotationTransferFork, new(big.Int).Add(header.Number, big.NewInt(1))) {
+		return nil, fmt.Errorf("ReleaseNotation is not enabled yet")
+	}
+	if args.Notation == 0 {
+		args.Notation = state.GetNotation(args.From)
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	if notation := state.GetNotation(args.From); notation != args.Notation {
+		return nil, fmt.Errorf("notation %d is not owned by the sender", args.Notation)
+	}
+
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return FSNCallArgsToSendTxArgs(&args, common.ReleaseNotationFunc, funcData)
+}
+
 func (s *PublicFusionAPI) BuildGenAssetSendTxArgs(ctx context.Context, args common.GenAssetArgs) (*TransactionArgs, error) {
 	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
 		return nil, err
@@ -724,18 +1027,24 @@ func (s *PublicFusionAPI) BuildGenAssetSendTxArgs(ctx context.Context, args comm
 }
 
 func CheckAndSetToAddress(args *common.SendAssetArgs, state *state.StateDB) error {
-	if args.ToUSAN != 0 {
-		address, err := state.GetAddressByNotation(args.ToUSAN)
+	return checkAndSetReceiver(&args.To, args.ToUSAN, state)
+}
+
+// checkAndSetReceiver sets the receiver address to the owner of the notation
+// if given, the two must not conflict.
+func checkAndSetReceiver(to *common.Address, toUSAN uint64, state *state.StateDB) error {
+	if toUSAN != 0 {
+		address, err := state.GetAddressByNotation(toUSAN)
 		if err != nil {
 			return err
 		}
-		if args.To == (common.Address{}) {
-			args.To = address
-		} else if args.To != address {
+		if *to == (common.Address{}) {
+			*to = address
+		} else if *to != address {
 			return fmt.Errorf("'to' and 'toUSAN' conflicts")
 		}
 	}
-	if args.To == (common.Address{}) {
+	if *to == (common.Address{}) {
 		return fmt.Errorf("receiver address must be set and not zero address")
 	}
 	return nil
@@ -888,6 +1197,32 @@ func (s *PublicFusionAPI) BuildSendTimeLockSendTxArgs(ctx context.Context, args
 	return FSNCallArgsToSendTxArgs(&args, common.TimeLockFunc, funcData)
 }
 
+func (s *PublicFusionAPI) BuildBatchSendAssetSendTxArgs(ctx context.Context, args common.BatchSendAssetArgs) (*TransactionArgs, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if !common.IsForkEnabled(common.BatchSendAssetFork, new(big.Int).Add(header.Number, big.NewInt(1))) {
+		return nil, fmt.Errorf("BatchSendAsset is not enabled yet")
+	}
+	for i := range args.Entries {
+		entry := &args.Entries[i]
+		if err = checkAndSetReceiver(&entry.To, entry.ToUSAN, state); err != nil {
+			return nil, fmt.Errorf("BatchSendAsset entry %d: %v", i, err)
+		}
+	}
+	args.Init()
+	if err := args.ToParam().Check(common.BigMaxUint64, header.Time); err != nil {
+		return nil, err
+	}
+
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return FSNCallArgsToSendTxArgs(&args, common.BatchSendAssetFunc, funcData)
+}
+
 func (s *PublicFusionAPI) BuildBuyTicketSendTxArgs(ctx context.Context, args common.BuyTicketArgs) (*TransactionArgs, error) {
 	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if state == nil || err != nil {
@@ -971,6 +1306,67 @@ func (s *PublicFusionAPI) BuildAssetValueChangeSendTxArgs(ctx context.Context, a
 	return FSNCallArgsToSendTxArgs(&args, common.AssetValueChangeFunc, funcData)
 }
 
+func (s *PublicFusionAPI) BuildTransferAssetOwnerSendTxArgs(ctx context.Context, args common.TransferAssetOwnerArgs) (*TransactionArgs, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if !common.IsForkEnabled(common.AssetUpdateFork, new(big.Int).Add(header.Number, big.NewInt(1))) {
+		return nil, fmt.Errorf("TransferAssetOwner is not enabled yet")
+	}
+	if err = checkAndSetReceiver(&args.To, args.ToUSAN, state); err != nil {
+		return nil, err
+	}
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+
+	asset, err := state.GetAsset(args.AssetID)
+	if err != nil {
+		return nil, fmt.Errorf("asset not found")
+	}
+	if asset.Owner != args.From {
+		return nil, fmt.Errorf("can only be changed by owner")
+	}
+
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return FSNCallArgsToSendTxArgs(&args, common.TransferAssetOwnerFunc, funcData)
+}
+
+func (s *PublicFusionAPI) BuildUpdateAssetSendTxArgs(ctx context.Context, args common.UpdateAssetArgs) (*TransactionArgs, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if !common.IsForkEnabled(common.AssetUpdateFork, new(big.Int).Add(header.Number, big.NewInt(1))) {
+		return nil, fmt.Errorf("UpdateAsset is not enabled yet")
+	}
+
+	asset, err := state.GetAsset(args.AssetID)
+	if err != nil {
+		return nil, fmt.Errorf("asset not found")
+	}
+	if asset.Owner != args.From {
+		return nil, fmt.Errorf("can only be changed by owner")
+	}
+	args.Init(&asset)
+	if err := args.ToParam().Check(common.BigMaxUint64); err != nil {
+		return nil, err
+	}
+	if *args.CanChange && !asset.CanChange {
+		return nil, fmt.Errorf("asset can't be made changeable again")
+	}
+
+	funcData, err := args.ToData()
+	if err != nil {
+		return nil, err
+	}
+	return FSNCallArgsToSendTxArgs(&args, common.UpdateAssetFunc, funcData)
+}
+
 func (s *PublicFusionAPI) BuildMakeSwapSendTxArgs(ctx context.Context, args common.MakeSwapArgs) (*TransactionArgs, error) {
 	state, header, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if state == nil || err != nil {
@@ -1245,6 +1641,24 @@ func (s *PrivateFusionAPI) GenNotation(ctx context.Context, args common.FusionBa
 	return s.papi.SendTransaction(ctx, *sendArgs, passwd)
 }
 
+// TransferNotation ss
+func (s *PrivateFusionAPI) TransferNotation(ctx context.Context, args common.TransferNotationArgs, passwd string) (common.Hash, error) {
+	sendArgs, err := s.BuildTransferNotationSendTxArgs(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.papi.SendTransaction(ctx, *sendArgs, passwd)
+}
+
+// ReleaseNotation ss
+func (s *PrivateFusionAPI) ReleaseNotation(ctx context.Context, args common.ReleaseNotationArgs, passwd string) (common.Hash, error) {
+	sendArgs, err := s.BuildReleaseNotationSendTxArgs(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.papi.SendTransaction(ctx, *sendArgs, passwd)
+}
+
 // GenAsset ss
 func (s *PrivateFusionAPI) GenAsset(ctx context.Context, args common.GenAssetArgs, passwd string) (common.Hash, error) {
 	sendArgs, err := s.BuildGenAssetSendTxArgs(ctx, args)
@@ -1299,6 +1713,15 @@ func (s *PrivateFusionAPI) SendTimeLock(ctx context.Context, args common.TimeLoc
 	return s.papi.SendTransaction(ctx, *sendArgs, passwd)
 }
 
+// BatchSendAsset ss
+func (s *PrivateFusionAPI) BatchSendAsset(ctx context.Context, args common.BatchSendAssetArgs, passwd string) (common.Hash, error) {
+	sendArgs, err := s.BuildBatchSendAssetSendTxArgs(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.papi.SendTransaction(ctx, *sendArgs, passwd)
+}
+
 /** on our public gateways too many buyTickets are past through
 this cache of purchase on block will stop multiple purchase
 attempt on a block (which state_transistion also flags).
@@ -1357,6 +1780,24 @@ func (s *PrivateFusionAPI) DecAsset(ctx context.Context, args common.AssetValueC
 	return s.papi.SendTransaction(ctx, *sendArgs, passwd)
 }
 
+// TransferAssetOwner ss
+func (s *PrivateFusionAPI) TransferAssetOwner(ctx context.Context, args common.TransferAssetOwnerArgs, passwd string) (common.Hash, error) {
+	sendArgs, err := s.BuildTransferAssetOwnerSendTxArgs(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.papi.SendTransaction(ctx, *sendArgs, passwd)
+}
+
+// UpdateAsset ss
+func (s *PrivateFusionAPI) UpdateAsset(ctx context.Context, args common.UpdateAssetArgs, passwd string) (common.Hash, error) {
+	sendArgs, err := s.BuildUpdateAssetSendTxArgs(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.papi.SendTransaction(ctx, *sendArgs, passwd)
+}
+
 // MakeSwap ss
 func (s *PrivateFusionAPI) MakeSwap(ctx context.Context, args common.MakeSwapArgs, passwd string) (common.Hash, error) {
 	sendArgs, err := s.BuildMakeSwapSendTxArgs(ctx, args)
@@ -1434,43 +1875,18 @@ func NewFusionTransactionAPI(b Backend, nonceLock *AddrLocker, txapi *PublicTran
 	return fusionTransactionAPI
 }
 
-// auto buy ticket
-func AutoBuyTicket(enable bool) {
-	if enable {
-		_, err := fusionTransactionAPI.b.Coinbase()
-		if err != nil {
-			log.Warn("AutoBuyTicket not enabled as no coinbase account exist")
-			enable = false
-		}
+// BuyTicketForMiner sends a transaction buying a ticket valid from start to
+// end, it is the ticket purchase function of the miner ticket buyer.
+func BuyTicketForMiner(from common.Address, start, end uint64) (common.Hash, error) {
+	if fusionTransactionAPI == nil {
+		return common.Hash{}, fmt.Errorf("fusion transaction api not available")
 	}
-	common.AutoBuyTicket = enable
-
-	for {
-		<-common.AutoBuyTicketChan
-	COMSUMEALL:
-		for {
-			select {
-			case <-common.AutoBuyTicketChan:
-			default:
-				break COMSUMEALL
-			}
-		}
-
-		// prevent auto buy ticket in syncing
-		if !fusionTransactionAPI.b.IsMining() {
-			common.DebugInfo("ignore AutoBuyTicket as isMining is false")
-			continue
-		}
-
-		coinbase, err := fusionTransactionAPI.b.Coinbase()
-		if err == nil {
-			fbase := common.FusionBaseArgs{From: coinbase}
-			args := common.BuyTicketArgs{FusionBaseArgs: fbase}
-			if _, err := fusionTransactionAPI.BuyTicket(context.TODO(), args); err != nil {
-				log.Warn("Failed Buying Ticket", "err", err)
-			}
-		}
+	args := common.BuyTicketArgs{
+		FusionBaseArgs: common.FusionBaseArgs{From: from},
+		Start:          (*hexutil.Uint64)(&start),
+		End:            (*hexutil.Uint64)(&end),
 	}
+	return fusionTransactionAPI.BuyTicket(context.TODO(), args)
 }
 
 // report illegal
@@ -1581,6 +1997,42 @@ func (s *FusionTransactionAPI) GenNotation(ctx context.Context, args common.Fusi
 	return s.sendTransaction(ctx, args.From, tx)
 }
 
+// BuildTransferNotationTx ss
+func (s *FusionTransactionAPI) BuildTransferNotationTx(ctx context.Context, args common.TransferNotationArgs) (*types.Transaction, error) {
+	sendArgs, err := s.pubapi.BuildTransferNotationSendTxArgs(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildTransaction(ctx, *sendArgs)
+}
+
+// TransferNotation ss
+func (s *FusionTransactionAPI) TransferNotation(ctx context.Context, args common.TransferNotationArgs) (common.Hash, error) {
+	tx, err := s.BuildTransferNotationTx(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendTransaction(ctx, args.From, tx)
+}
+
+// BuildReleaseNotationTx ss
+func (s *FusionTransactionAPI) BuildReleaseNotationTx(ctx context.Context, args common.ReleaseNotationArgs) (*types.Transaction, error) {
+	sendArgs, err := s.pubapi.BuildReleaseNotationSendTxArgs(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildTransaction(ctx, *sendArgs)
+}
+
+// ReleaseNotation ss
+func (s *FusionTransactionAPI) ReleaseNotation(ctx context.Context, args common.ReleaseNotationArgs) (common.Hash, error) {
+	tx, err := s.BuildReleaseNotationTx(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendTransaction(ctx, args.From, tx)
+}
+
 // BuildGenAssetTx ss
 func (s *FusionTransactionAPI) BuildGenAssetTx(ctx context.Context, args common.GenAssetArgs) (*types.Transaction, error) {
 	sendArgs, err := s.pubapi.BuildGenAssetSendTxArgs(ctx, args)
@@ -1689,6 +2141,24 @@ func (s *FusionTransactionAPI) SendTimeLock(ctx context.Context, args common.Tim
 	return s.sendTransaction(ctx, args.From, tx)
 }
 
+// BuildBatchSendAssetTx ss
+func (s *FusionTransactionAPI) BuildBatchSendAssetTx(ctx context.Context, args common.BatchSendAssetArgs) (*types.Transaction, error) {
+	sendArgs, err := s.pubapi.BuildBatchSendAssetSendTxArgs(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildTransaction(ctx, *sendArgs)
+}
+
+// BatchSendAsset ss
+func (s *FusionTransactionAPI) BatchSendAsset(ctx context.Context, args common.BatchSendAssetArgs) (common.Hash, error) {
+	tx, err := s.BuildBatchSendAssetTx(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendTransaction(ctx, args.From, tx)
+}
+
 // BuildBuyTicketTx ss
 func (s *FusionTransactionAPI) BuildBuyTicketTx(ctx context.Context, args common.BuyTicketArgs) (*types.Transaction, error) {
 	sendArgs, err := s.pubapi.BuildBuyTicketSendTxArgs(ctx, args)
@@ -1750,6 +2220,42 @@ func (s *FusionTransactionAPI) DecAsset(ctx context.Context, args common.AssetVa
 	return s.sendTransaction(ctx, args.From, tx)
 }
 
+// BuildTransferAssetOwnerTx ss
+func (s *FusionTransactionAPI) BuildTransferAssetOwnerTx(ctx context.Context, args common.TransferAssetOwnerArgs) (*types.Transaction, error) {
+	sendArgs, err := s.pubapi.BuildTransferAssetOwnerSendTxArgs(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildTransaction(ctx, *sendArgs)
+}
+
+// TransferAssetOwner ss
+func (s *FusionTransactionAPI) TransferAssetOwner(ctx context.Context, args common.TransferAssetOwnerArgs) (common.Hash, error) {
+	tx, err := s.BuildTransferAssetOwnerTx(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendTransaction(ctx, args.From, tx)
+}
+
+// BuildUpdateAssetTx ss
+func (s *FusionTransactionAPI) BuildUpdateAssetTx(ctx context.Context, args common.UpdateAssetArgs) (*types.Transaction, error) {
+	sendArgs, err := s.pubapi.BuildUpdateAssetSendTxArgs(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildTransaction(ctx, *sendArgs)
+}
+
+// UpdateAsset ss
+func (s *FusionTransactionAPI) UpdateAsset(ctx context.Context, args common.UpdateAssetArgs) (common.Hash, error) {
+	tx, err := s.BuildUpdateAssetTx(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.sendTransaction(ctx, args.From, tx)
+}
+
 // BuildMakeSwapTx ss
 func (s *FusionTransactionAPI) BuildMakeSwapTx(ctx context.Context, args common.MakeSwapArgs) (*types.Transaction, error) {
 	sendArgs, err := s.pubapi.BuildMakeSwapSendTxArgs(ctx, args)
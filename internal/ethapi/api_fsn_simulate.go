@@ -0,0 +1,205 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/fsnlog"
+	"github.com/FusionFoundation/efsn/core/fsnrecord"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/core/vm"
+	"github.com/FusionFoundation/efsn/rlp"
+	"github.com/FusionFoundation/efsn/rpc"
+)
+
+// fsnSimulateTimeout bounds the execution of a simulated FSN call.
+const fsnSimulateTimeout = 5 * time.Second
+
+// FsnSimulation is the outcome of an FSN call run against a block state.
+type FsnSimulation struct {
+	Func      string               `json:"func"`
+	Error     string               `json:"error,omitempty"` // why the call would be rejected
+	GasUsed   hexutil.Uint64       `json:"gasUsed"`
+	Fee       string               `json:"fee"` // FSN call fee on top of the gas
+	LogTopic  string               `json:"fsnLogTopic,omitempty"`
+	LogData   interface{}          `json:"fsnLogData,omitempty"`
+	Logs      []*types.Log         `json:"logs"`
+	Balances  []*FsnBalanceChange  `json:"balances"`
+	TimeLocks []*FsnTimeLockChange `json:"timeLocks"`
+	Input     interface{}          `json:"fsnTxInput,omitempty"`
+}
+
+// FsnBalanceChange is a balance changed by a simulated FSN call. The changes
+// include the gas and fee paid by the sender and received by the coinbase.
+type FsnBalanceChange struct {
+	Account common.Address `json:"account"`
+	AssetID common.Hash    `json:"assetID"`
+	Before  string         `json:"before"`
+	After   string         `json:"after"`
+	Delta   string         `json:"delta"`
+}
+
+// FsnTimeLockChange is a time lock balance changed by a simulated FSN call.
+type FsnTimeLockChange struct {
+	Account common.Address   `json:"account"`
+	AssetID common.Hash      `json:"assetID"`
+	Before  *common.TimeLock `json:"before"`
+	After   *common.TimeLock `json:"after"`
+}
+
+// Simulate runs an FSN call on a copy of the state at the given block, the
+// pending block by default, and returns its outcome without sending it.
+// Unlike eth_call the call is applied like a miner would, so a call which
+// would fail is reported with the error rejecting it.
+func (s *PublicFusionAPI) Simulate(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*FsnSimulation, error) {
+	statedb, header, err := s.simulationState(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := args.ToMessage(s.b.RPCGasCap(), header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	return simulateFsnCall(ctx, s.b, msg, msg.AsTransaction().Hash(), statedb, header)
+}
+
+// DryRun runs a signed FSN call transaction on a copy of the state at the
+// given block, the pending block by default, checking its nonce and funds
+// like a miner would, and returns its outcome without sending it.
+func (s *FusionTransactionAPI) DryRun(ctx context.Context, input hexutil.Bytes, blockNrOrHash *rpc.BlockNumberOrHash) (*FsnSimulation, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return nil, err
+	}
+	statedb, header, err := s.pubapi.simulationState(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := tx.AsMessage(types.MakeSigner(s.b.ChainConfig(), header.Number), header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	return simulateFsnCall(ctx, s.b, msg, tx.Hash(), statedb, header)
+}
+
+func (s *PublicFusionAPI) simulationState(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if blockNrOrHash == nil {
+		pending := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+		blockNrOrHash = &pending
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		if err == nil {
+			err = errors.New("state not found")
+		}
+		return nil, nil, err
+	}
+	return statedb, header, nil
+}
+
+// simulateFsnCall applies the FSN call message on the state and reports the
+// FSN call log and the balances and time locks it changes.
+func simulateFsnCall(ctx context.Context, b Backend, msg types.Message, txHash common.Hash, statedb *state.StateDB, header *types.Header) (*FsnSimulation, error) {
+	if !common.IsFsnCall(msg.To()) {
+		return nil, errors.New("not an FSN call")
+	}
+	var param common.FSNCallParam
+	if err := rlp.DecodeBytes(msg.Data(), &param); err != nil {
+		return nil, fmt.Errorf("invalid FSN call data: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, fsnSimulateTimeout)
+	defer cancel()
+
+	before := statedb.Copy()
+	// apply the call as a miner, which rejects the transaction if the FSN
+	// call fails instead of only logging the error
+	evm, vmError, err := b.GetEVM(ctx, msg, statedb, header, &vm.Config{NoBaseFee: true, RejectFailedFsnCalls: true})
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	recorder := fsnrecord.New(statedb)
+	evm.StateDB = recorder
+	statedb.Prepare(txHash, 0)
+
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if err := vmError(); err != nil {
+		return nil, err
+	}
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", fsnSimulateTimeout)
+	}
+
+	sim := &FsnSimulation{
+		Func:      param.Func.Name(),
+		Fee:       common.GetFsnCallFee(msg.To(), &param, evm.Context.BlockNumber).String(),
+		Logs:      statedb.GetLogs(txHash, header.Hash()),
+		Balances:  []*FsnBalanceChange{},
+		TimeLocks: []*FsnTimeLockChange{},
+	}
+	if sim.Logs == nil {
+		sim.Logs = []*types.Log{}
+	}
+	if input, err := datong.DecodeTxInput(msg.Data()); err == nil {
+		sim.Input = input
+	}
+	for _, l := range sim.Logs {
+		if l.Address != common.FSNCallAddress || len(l.Topics) == 0 {
+			continue
+		}
+		sim.LogTopic = common.FSNCallFunc(l.Topics[0][common.HashLength-1]).Name()
+		if fsnlog.IsStructured(l) {
+			if decoded, err := fsnlog.Decode(l); err == nil {
+				sim.LogData = decoded.Fields
+			}
+		} else if decoded, err := datong.DecodeLogData(l.Data); err == nil {
+			sim.LogData = decoded
+		}
+		break
+	}
+	if err != nil {
+		// the transaction is rejected, nothing changes
+		sim.Error = err.Error()
+		return sim, nil
+	}
+	sim.GasUsed = hexutil.Uint64(result.UsedGas)
+	if result.Err != nil {
+		sim.Error = result.Err.Error()
+	}
+	for _, key := range recorder.Balances() {
+		old, cur := before.GetBalance(key.Asset, key.Account), statedb.GetBalance(key.Asset, key.Account)
+		if delta := new(big.Int).Sub(cur, old); delta.Sign() != 0 {
+			sim.Balances = append(sim.Balances, &FsnBalanceChange{
+				Account: key.Account,
+				AssetID: key.Asset,
+				Before:  old.String(),
+				After:   cur.String(),
+				Delta:   delta.String(),
+			})
+		}
+	}
+	for _, key := range recorder.TimeLocks() {
+		old, cur := before.GetTimeLockBalance(key.Asset, key.Account), statedb.GetTimeLockBalance(key.Asset, key.Account)
+		if !old.EqualTo(cur) {
+			sim.TimeLocks = append(sim.TimeLocks, &FsnTimeLockChange{
+				Account: key.Account,
+				AssetID: key.Asset,
+				Before:  old,
+				After:   cur,
+			})
+		}
+	}
+	return sim, statedb.Error()
+}
@@ -0,0 +1,660 @@
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/consensus/datong"
+	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/fsnlog"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/log"
+	"github.com/FusionFoundation/efsn/rlp"
+	"github.com/FusionFoundation/efsn/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// The Fusion event subscriptions, available as fsn_subscribe("<name>", filter)
+// over WebSocket and IPC. The events are derived from every new canonical
+// block: the FSN call logs, the ticket snapshot in the header and the state
+// of the parent block. Blocks dropped by a reorg aren't reported again, so the
+// consumers should wait for confirmations like with eth_subscribe("logs").
+// The state read for a block is shared by all the subscriptions.
+
+// TicketSelected notifies the ticket selected to seal each new block.
+func (s *PublicFusionAPI) TicketSelected(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		snap, err := eb.snapshot()
+		if err != nil {
+			return nil, err
+		}
+		return eb.ticketEvents(filter, func(t *common.Ticket) bool { return t.ID == snap.Selected })
+	})
+}
+
+// TicketRetreated notifies the tickets retreated by each new block, the ones
+// selected before the selected ticket which missed their turn.
+func (s *PublicFusionAPI) TicketRetreated(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		snap, err := eb.snapshot()
+		if err != nil || len(snap.Retreat) == 0 {
+			return nil, err
+		}
+		retreated := make(map[common.Hash]bool, len(snap.Retreat))
+		for _, id := range snap.Retreat {
+			retreated[id] = true
+		}
+		return eb.ticketEvents(filter, func(t *common.Ticket) bool { return retreated[t.ID] })
+	})
+}
+
+// TicketExpired notifies the tickets expiring at each new block.
+func (s *PublicFusionAPI) TicketExpired(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		snap, err := eb.snapshot()
+		if err != nil {
+			return nil, err
+		}
+		removed := make(map[common.Hash]bool, len(snap.Retreat)+1)
+		removed[snap.Selected] = true
+		for _, id := range snap.Retreat {
+			removed[id] = true
+		}
+		now := eb.block.Time()
+		return eb.ticketEvents(filter, func(t *common.Ticket) bool { return t.ExpireTime <= now && !removed[t.ID] })
+	})
+}
+
+// TicketPurchased notifies the tickets bought by each new block.
+func (s *PublicFusionAPI) TicketPurchased(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		var events []interface{}
+		for _, call := range eb.calls(common.BuyTicketFunc) {
+			var l struct {
+				TicketID    common.Hash
+				TicketOwner common.Address
+				StartTime   uint64
+				ExpireTime  uint64
+				Base        []byte // legacy logs hold the rlp encoded param
+				Error       string
+			}
+			if err := json.Unmarshal(call.data, &l); err != nil || l.Error != "" {
+				continue
+			}
+			if l.Base != nil {
+				var param common.BuyTicketParam
+				if err := rlp.DecodeBytes(l.Base, &param); err == nil {
+					l.StartTime, l.ExpireTime = param.Start, param.End
+				}
+			}
+			if !filter.MatchAddress(l.TicketOwner) || !filter.MatchAsset(common.SystemAssetID) {
+				continue
+			}
+			body := common.TicketBody{ID: l.TicketID, Height: eb.block.NumberU64(), StartTime: l.StartTime, ExpireTime: l.ExpireTime}
+			events = append(events, &common.FsnTicketEvent{
+				FsnEventBlock: eb.eventBlock(),
+				TxHash:        &call.txHash,
+				TicketID:      l.TicketID,
+				Owner:         l.TicketOwner,
+				StartTime:     l.StartTime,
+				ExpireTime:    l.ExpireTime,
+				Value:         body.Value().String(),
+			})
+		}
+		return events, nil
+	})
+}
+
+// AssetTransfers notifies the assets sent by the FSN calls of each new block.
+func (s *PublicFusionAPI) AssetTransfers(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		var events []interface{}
+		for _, call := range eb.calls(common.SendAssetFunc) {
+			var l struct {
+				AssetID common.Hash
+				To      common.Address
+				Value   *big.Int
+				Error   string
+			}
+			if err := json.Unmarshal(call.data, &l); err != nil || l.Error != "" || l.Value == nil {
+				continue
+			}
+			if !filter.MatchAddress(call.from, l.To) || !filter.MatchAsset(l.AssetID) {
+				continue
+			}
+			events = append(events, &common.FsnAssetTransferEvent{
+				FsnEventBlock: eb.eventBlock(),
+				TxHash:        call.txHash,
+				From:          call.from,
+				To:            l.To,
+				AssetID:       l.AssetID,
+				Value:         l.Value.String(),
+			})
+		}
+		for _, entry := range eb.batchEntries() {
+			if entry.timeLocked(eb.block.Time()) {
+				continue
+			}
+			if !filter.MatchAddress(entry.from, entry.To) || !filter.MatchAsset(entry.AssetID) {
+				continue
+			}
+			events = append(events, &common.FsnAssetTransferEvent{
+				FsnEventBlock: eb.eventBlock(),
+				TxHash:        entry.txHash,
+				From:          entry.from,
+				To:            entry.To,
+				AssetID:       entry.AssetID,
+				Value:         entry.Value.String(),
+			})
+		}
+		return events, nil
+	})
+}
+
+// TimeLockCreated notifies the time locks created by the FSN calls of each new
+// block, all the time lock calls but the ones converting time locks to assets.
+func (s *PublicFusionAPI) TimeLockCreated(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		var events []interface{}
+		for _, call := range eb.calls(common.TimeLockFunc) {
+			var l struct {
+				Type      common.TimeLockType
+				LockType  string
+				AssetID   common.Hash
+				To        common.Address
+				StartTime uint64
+				EndTime   uint64
+				Value     *big.Int
+				Error     string
+			}
+			if err := json.Unmarshal(call.data, &l); err != nil || l.Error != "" || l.Value == nil {
+				continue
+			}
+			if l.Type == common.TimeLockToAsset {
+				continue
+			}
+			if !filter.MatchAddress(call.from, l.To) || !filter.MatchAsset(l.AssetID) {
+				continue
+			}
+			from := call.from
+			events = append(events, &common.FsnTimeLockEvent{
+				FsnEventBlock: eb.eventBlock(),
+				TxHash:        &call.txHash,
+				LockType:      l.LockType,
+				From:          &from,
+				Owner:         l.To,
+				AssetID:       l.AssetID,
+				StartTime:     l.StartTime,
+				EndTime:       l.EndTime,
+				Value:         l.Value.String(),
+			})
+		}
+		for _, entry := range eb.batchEntries() {
+			if !entry.timeLocked(eb.block.Time()) {
+				continue
+			}
+			if !filter.MatchAddress(entry.from, entry.To) || !filter.MatchAsset(entry.AssetID) {
+				continue
+			}
+			from := entry.from
+			events = append(events, &common.FsnTimeLockEvent{
+				FsnEventBlock: eb.eventBlock(),
+				TxHash:        &entry.txHash,
+				From:          &from,
+				Owner:         entry.To,
+				AssetID:       entry.AssetID,
+				StartTime:     common.MaxUint64(entry.StartTime, eb.block.Time()),
+				EndTime:       entry.EndTime,
+				Value:         entry.Value.String(),
+			})
+		}
+		return events, nil
+	})
+}
+
+// TimeLockMatured notifies the time lock items of the filter addresses whose
+// end time passed with each new block. The addresses are mandatory, as the
+// time locks can't be enumerated.
+func (s *PublicFusionAPI) TimeLockMatured(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	if filter == nil || len(filter.Addresses) == 0 {
+		return nil, errors.New("timeLockMatured needs the addresses to watch")
+	}
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		statedb, parent, err := eb.parentState()
+		if err != nil {
+			return nil, err
+		}
+		var events []interface{}
+		for _, addr := range filter.Addresses {
+			for assetID, timeLock := range statedb.GetAllTimeLockBalances(addr) {
+				if timeLock == nil || !filter.MatchAsset(assetID) {
+					continue
+				}
+				for _, item := range timeLock.Items {
+					if item.EndTime < parent.Time || item.EndTime >= eb.block.Time() {
+						continue
+					}
+					events = append(events, &common.FsnTimeLockEvent{
+						FsnEventBlock: eb.eventBlock(),
+						Owner:         addr,
+						AssetID:       assetID,
+						StartTime:     item.StartTime,
+						EndTime:       item.EndTime,
+						Value:         item.Value.String(),
+					})
+				}
+			}
+		}
+		return events, statedb.Error()
+	})
+}
+
+// SwapMade notifies the swaps and multi swaps made by each new block. The
+// addresses match the owner and the targets of the swaps.
+func (s *PublicFusionAPI) SwapMade(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		var events []interface{}
+		for _, call := range eb.calls(common.MakeSwapFunc, common.MakeSwapFuncExt, common.MakeMultiSwapFunc) {
+			var l struct {
+				SwapID      common.Hash
+				FromAssetID json.RawMessage
+				ToAssetID   json.RawMessage
+				SwapSize    *big.Int
+				Targes      []common.Address
+				Error       string
+			}
+			if err := json.Unmarshal(call.data, &l); err != nil || l.Error != "" {
+				continue
+			}
+			ev := &common.FsnSwapEvent{
+				FsnEventBlock: eb.eventBlock(),
+				TxHash:        call.txHash,
+				SwapID:        l.SwapID,
+				Owner:         call.from,
+				FromAssetIDs:  logHashes(l.FromAssetID),
+				ToAssetIDs:    logHashes(l.ToAssetID),
+				Targets:       l.Targes,
+			}
+			if l.SwapSize != nil {
+				ev.Size = l.SwapSize.String()
+			}
+			if matchSwap(filter, ev, ev.Targets...) {
+				events = append(events, ev)
+			}
+		}
+		return events, nil
+	})
+}
+
+// SwapTaken notifies the swaps and multi swaps taken by each new block. The
+// addresses match the owner and the taker.
+func (s *PublicFusionAPI) SwapTaken(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		var events []interface{}
+		for _, call := range eb.calls(common.TakeSwapFunc, common.TakeSwapFuncExt, common.TakeMultiSwapFunc) {
+			var l struct {
+				SwapID  common.Hash
+				Size    *big.Int
+				Deleted json.RawMessage // logged as a string by the legacy logs
+				Error   string
+			}
+			if err := json.Unmarshal(call.data, &l); err != nil || l.Error != "" {
+				continue
+			}
+			taker := call.from
+			ev := eb.swapEvent(call, l.SwapID)
+			ev.Taker = &taker
+			ev.Deleted = string(l.Deleted) == "true" || string(l.Deleted) == `"true"`
+			if l.Size != nil {
+				ev.Size = l.Size.String()
+			}
+			if matchSwap(filter, ev, taker) {
+				events = append(events, ev)
+			}
+		}
+		return events, nil
+	})
+}
+
+// SwapRecalled notifies the swaps and multi swaps recalled by each new block.
+func (s *PublicFusionAPI) SwapRecalled(ctx context.Context, filter *common.FsnEventFilter) (*rpc.Subscription, error) {
+	return s.subscribeFsnEvents(ctx, filter, func(eb *fsnEventBlock) ([]interface{}, error) {
+		var events []interface{}
+		for _, call := range eb.calls(common.RecallSwapFunc, common.RecallMultiSwapFunc) {
+			var l struct {
+				SwapID common.Hash
+				Error  string
+			}
+			if err := json.Unmarshal(call.data, &l); err != nil || l.Error != "" {
+				continue
+			}
+			ev := eb.swapEvent(call, l.SwapID)
+			ev.Deleted = true
+			if matchSwap(filter, ev) {
+				events = append(events, ev)
+			}
+		}
+		return events, nil
+	})
+}
+
+// matchSwap reports whether the filter selects the swap event, which involves
+// its owner and the other addresses.
+func matchSwap(filter *common.FsnEventFilter, ev *common.FsnSwapEvent, addrs ...common.Address) bool {
+	assets := append(append([]common.Hash{}, ev.FromAssetIDs...), ev.ToAssetIDs...)
+	return filter.MatchAddress(append(addrs, ev.Owner)...) && filter.MatchAsset(assets...)
+}
+
+// logHashes decodes an asset ID of a swap log, a single one or a list of them.
+func logHashes(data json.RawMessage) []common.Hash {
+	var hashes []common.Hash
+	if err := json.Unmarshal(data, &hashes); err == nil {
+		return hashes
+	}
+	var hash common.Hash
+	if err := json.Unmarshal(data, &hash); err == nil {
+		return []common.Hash{hash}
+	}
+	return []common.Hash{}
+}
+
+// subscribeFsnEvents notifies the events collected from every new canonical
+// block until the subscription ends.
+func (s *PublicFusionAPI) subscribeFsnEvents(ctx context.Context, filter *common.FsnEventFilter, collect func(*fsnEventBlock) ([]interface{}, error)) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		chainCh := make(chan core.ChainEvent, 16)
+		chainSub := s.b.SubscribeChainEvent(chainCh)
+		defer chainSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-chainCh:
+				if ev.Block == nil || ev.Block.NumberU64() == 0 {
+					continue
+				}
+				eb := sharedEventBlock(s.b, ev)
+				eb.lock.Lock()
+				events, err := collect(eb)
+				eb.lock.Unlock()
+				if err != nil {
+					log.Debug("Failed collecting Fusion events", "number", ev.Block.Number(), "err", err)
+					continue
+				}
+				for _, event := range events {
+					notifier.Notify(rpcSub.ID, event)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// fsnEventBlockCacheLimit is the number of recent blocks whose events are
+// shared by the subscriptions, enough for the slowest of them to catch up.
+const fsnEventBlockCacheLimit = 16
+
+var (
+	fsnEventBlocks, _ = lru.New(fsnEventBlockCacheLimit)
+	fsnEventBlocksMu  sync.Mutex
+)
+
+// sharedEventBlock returns the event block of a chain event, the same one for
+// all the subscriptions so that the state of a block is read once.
+func sharedEventBlock(b Backend, ev core.ChainEvent) *fsnEventBlock {
+	fsnEventBlocksMu.Lock()
+	defer fsnEventBlocksMu.Unlock()
+
+	if eb, ok := fsnEventBlocks.Get(ev.Block.Hash()); ok {
+		return eb.(*fsnEventBlock)
+	}
+	eb := &fsnEventBlock{b: b, block: ev.Block, logs: ev.Logs}
+	fsnEventBlocks.Add(ev.Block.Hash(), eb)
+	return eb
+}
+
+// fsnEventBlock is a new canonical block events are collected from. The
+// subscriptions collect their events holding the lock, which guards the
+// states and the decoded data loaded on first use.
+type fsnEventBlock struct {
+	b     Backend
+	block *types.Block
+	logs  []*types.Log
+
+	lock         sync.Mutex
+	snap         *datong.Snapshot
+	parent       *state.StateDB
+	parentHeader *types.Header
+	state        *state.StateDB
+	tickets      []common.Ticket // tickets of the parent state
+	fsnCalls     []fsnCall       // FSN calls of the block, nil until decoded
+}
+
+// fsnCall is a logged FSN call, with its log data in the legacy JSON format.
+type fsnCall struct {
+	funcType common.FSNCallFunc
+	txHash   common.Hash
+	from     common.Address
+	data     []byte
+}
+
+// fsnBatchEntry is an entry applied by a batch send.
+type fsnBatchEntry struct {
+	txHash    common.Hash
+	from      common.Address
+	To        common.Address
+	AssetID   common.Hash
+	StartTime uint64
+	EndTime   uint64
+	Value     *big.Int
+	Error     string
+}
+
+// timeLocked reports whether the entry sent a time lock instead of the asset
+// in a block of the given time.
+func (e *fsnBatchEntry) timeLocked(blockTime uint64) bool {
+	return e.StartTime > blockTime || e.EndTime != common.TimeLockForever
+}
+
+// batchEntries returns the entries applied by the batch sends of the block. A
+// failing entry reverts the whole batch, so only its own log remains.
+func (eb *fsnEventBlock) batchEntries() []*fsnBatchEntry {
+	var entries []*fsnBatchEntry
+	for _, call := range eb.calls(common.BatchSendAssetFunc) {
+		entry := &fsnBatchEntry{txHash: call.txHash, from: call.from}
+		if err := json.Unmarshal(call.data, entry); err != nil || entry.Error != "" || entry.Value == nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (eb *fsnEventBlock) eventBlock() common.FsnEventBlock {
+	return common.FsnEventBlock{BlockNumber: eb.block.NumberU64(), BlockHash: eb.block.Hash()}
+}
+
+func (eb *fsnEventBlock) parentState() (*state.StateDB, *types.Header, error) {
+	if eb.parent == nil {
+		statedb, header, err := eb.b.StateAndHeaderByNumberOrHash(context.Background(), rpc.BlockNumberOrHashWithHash(eb.block.ParentHash(), false))
+		if err != nil {
+			return nil, nil, err
+		}
+		if statedb == nil || header == nil {
+			return nil, nil, errors.New("parent state not found")
+		}
+		eb.parent, eb.parentHeader = statedb, header
+	}
+	return eb.parent, eb.parentHeader, nil
+}
+
+func (eb *fsnEventBlock) snapshot() (*datong.Snapshot, error) {
+	if eb.snap == nil {
+		snap, err := datong.NewSnapshotFromHeader(eb.block.Header())
+		if err != nil {
+			return nil, err
+		}
+		eb.snap = snap
+	}
+	return eb.snap, nil
+}
+
+// blockState returns the state of the block itself.
+func (eb *fsnEventBlock) blockState() (*state.StateDB, error) {
+	if eb.state == nil {
+		statedb, _, err := eb.b.StateAndHeaderByNumberOrHash(context.Background(), rpc.BlockNumberOrHashWithHash(eb.block.Hash(), false))
+		if err != nil {
+			return nil, err
+		}
+		if statedb == nil {
+			return nil, errors.New("block state not found")
+		}
+		eb.state = statedb
+	}
+	return eb.state, nil
+}
+
+// parentTickets returns the tickets of the parent state.
+func (eb *fsnEventBlock) parentTickets() ([]common.Ticket, error) {
+	if eb.tickets == nil {
+		statedb, _, err := eb.parentState()
+		if err != nil {
+			return nil, err
+		}
+		tickets, err := statedb.AllTickets()
+		if err != nil {
+			return nil, err
+		}
+		eb.tickets = tickets.ToTicketSlice()
+	}
+	return eb.tickets, nil
+}
+
+// calls returns the logged FSN calls of the block with the given func types.
+func (eb *fsnEventBlock) calls(funcs ...common.FSNCallFunc) []fsnCall {
+	if eb.fsnCalls == nil {
+		eb.fsnCalls = eb.decodeCalls()
+	}
+	var calls []fsnCall
+	for _, call := range eb.fsnCalls {
+		for _, f := range funcs {
+			if f == call.funcType {
+				calls = append(calls, call)
+				break
+			}
+		}
+	}
+	return calls
+}
+
+// decodeCalls decodes all the logged FSN calls of the block.
+func (eb *fsnEventBlock) decodeCalls() []fsnCall {
+	var (
+		calls  = []fsnCall{}
+		txs    = eb.block.Transactions()
+		signer = types.MakeSigner(eb.b.ChainConfig(), eb.block.Number())
+	)
+	for _, l := range eb.logs {
+		if l.Address != common.FSNCallAddress || len(l.Topics) == 0 || l.Removed {
+			continue
+		}
+		funcType := common.FSNCallFunc(l.Topics[0][common.HashLength-1])
+		call := fsnCall{funcType: funcType, txHash: l.TxHash, data: l.Data}
+		if fsnlog.IsStructured(l) {
+			decoded, err := fsnlog.Decode(l)
+			if err != nil {
+				continue
+			}
+			if call.data, err = json.Marshal(decoded.Fields); err != nil {
+				continue
+			}
+			call.from = decoded.From
+		} else {
+			if int(l.TxIndex) >= len(txs) || txs[l.TxIndex].Hash() != l.TxHash {
+				continue
+			}
+			from, err := types.Sender(signer, txs[l.TxIndex])
+			if err != nil {
+				continue
+			}
+			call.from = from
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// ticketEvents returns the events of the tickets of the parent block selected
+// by the match function.
+func (eb *fsnEventBlock) ticketEvents(filter *common.FsnEventFilter, match func(*common.Ticket) bool) ([]interface{}, error) {
+	if !filter.MatchAsset(common.SystemAssetID) {
+		return nil, nil
+	}
+	tickets, err := eb.parentTickets()
+	if err != nil {
+		return nil, err
+	}
+	var events []interface{}
+	for _, ticket := range tickets {
+		ticket := ticket
+		if !match(&ticket) || !filter.MatchAddress(ticket.Owner) {
+			continue
+		}
+		events = append(events, &common.FsnTicketEvent{
+			FsnEventBlock: eb.eventBlock(),
+			TicketID:      ticket.ID,
+			Owner:         ticket.Owner,
+			StartTime:     ticket.StartTime,
+			ExpireTime:    ticket.ExpireTime,
+			Value:         ticket.Value().String(),
+		})
+	}
+	return events, nil
+}
+
+// swapEvent returns the event of a call on an existing swap, filled with the
+// swap in the parent state, or in the block state for the swaps made and
+// taken or recalled by the same block.
+func (eb *fsnEventBlock) swapEvent(call fsnCall, swapID common.Hash) *common.FsnSwapEvent {
+	ev := &common.FsnSwapEvent{
+		FsnEventBlock: eb.eventBlock(),
+		TxHash:        call.txHash,
+		SwapID:        swapID,
+		FromAssetIDs:  []common.Hash{},
+		ToAssetIDs:    []common.Hash{},
+	}
+	var states []*state.StateDB
+	if statedb, _, err := eb.parentState(); err == nil {
+		states = append(states, statedb)
+	}
+	if statedb, err := eb.blockState(); err == nil {
+		states = append(states, statedb)
+	}
+	for _, statedb := range states {
+		if swap, err := statedb.GetSwap(swapID); err == nil && swap.Owner != (common.Address{}) {
+			ev.Owner, ev.Targets = swap.Owner, swap.Targes
+			ev.FromAssetIDs, ev.ToAssetIDs = []common.Hash{swap.FromAssetID}, []common.Hash{swap.ToAssetID}
+			break
+		}
+		if swap, err := statedb.GetMultiSwap(swapID); err == nil && swap.Owner != (common.Address{}) {
+			ev.Owner, ev.Targets = swap.Owner, swap.Targes
+			ev.FromAssetIDs, ev.ToAssetIDs = swap.FromAssetID, swap.ToAssetID
+			break
+		}
+	}
+	return ev
+}
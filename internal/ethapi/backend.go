@@ -25,6 +25,7 @@ import (
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/consensus"
 	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/fsnindex"
 	"github.com/FusionFoundation/efsn/core/state"
 	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/core/vm"
@@ -84,7 +85,11 @@ type Backend interface {
 	Engine() consensus.Engine
 
 	IsMining() bool
+	IsAutoBuyTicket() bool
 	Coinbase() (common.Address, error)
+
+	// FusionIndex returns the local asset, swap and report index, or nil if disabled.
+	FusionIndex() *fsnindex.Index
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -143,6 +143,11 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'reloadCheckpoints',
+			call: 'admin_reloadCheckpoints',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -528,6 +533,21 @@ web3._extend({
 			call: 'miner_stopAutoBuyTicket',
 			params: 0
 		}),
+		new web3._extend.Method({
+			name: 'ticketPolicy',
+			call: 'miner_ticketPolicy',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setTicketPolicy',
+			call: 'miner_setTicketPolicy',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'ticketStatus',
+			call: 'miner_ticketStatus',
+			params: 0
+		}),
 	],
 	properties: []
 });
@@ -670,6 +690,16 @@ web3._extend({
 			call: 'fsn_getSnapshotAtHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'exportSealingHistory',
+			call: 'fsn_exportSealingHistory',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'importSealingHistory',
+			call: 'fsn_importSealingHistory',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getBlockReward',
 			call: 'fsn_getBlockReward',
@@ -747,6 +777,27 @@ web3._extend({
 				web3._extend.formatters.inputDefaultBlockNumberFormatter
 			]
 		}),
+		new web3._extend.Method({
+			name: 'getVestingSchedule',
+			call: 'fsn_getVestingSchedule',
+			params: 4,
+			inputFormatter: [
+				null,
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+				web3._extend.formatters.inputDefaultBlockNumberFormatter
+			]
+		}),
+		new web3._extend.Method({
+			name: 'getAllVestingSchedules',
+			call: 'fsn_getAllVestingSchedules',
+			params: 3,
+			inputFormatter: [
+				web3._extend.formatters.inputAddressFormatter,
+				null,
+				web3._extend.formatters.inputDefaultBlockNumberFormatter
+			]
+		}),
 		new web3._extend.Method({
 			name: 'getNotation',
 			call: 'fsn_getNotation',
@@ -782,6 +833,24 @@ web3._extend({
 				null
 			]
 		}),
+		new web3._extend.Method({
+			name: 'transferNotation',
+			call: 'fsn_transferNotation',
+			params: 2,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter,
+				null
+			]
+		}),
+		new web3._extend.Method({
+			name: 'releaseNotation',
+			call: 'fsn_releaseNotation',
+			params: 2,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter,
+				null
+			]
+		}),
 		new web3._extend.Method({
 			name: 'genAsset',
 			call: 'fsn_genAsset',
@@ -873,6 +942,15 @@ web3._extend({
 				null
 			]
 		}),
+		new web3._extend.Method({
+			name: 'batchSendAsset',
+			call: 'fsn_batchSendAsset',
+			params: 2,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter,
+				null
+			]
+		}),
 		new web3._extend.Method({
 			name: 'allTickets',
 			call: 'fsn_allTickets',
@@ -975,6 +1053,24 @@ web3._extend({
 				null
 			]
 		}),
+		new web3._extend.Method({
+			name: 'transferAssetOwner',
+			call: 'fsn_transferAssetOwner',
+			params: 2,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter,
+				null
+			]
+		}),
+		new web3._extend.Method({
+			name: 'updateAsset',
+			call: 'fsn_updateAsset',
+			params: 2,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter,
+				null
+			]
+		}),
 		new web3._extend.Method({
 			name: 'allSwaps',
 			call: 'fsn_allSwaps',
@@ -992,6 +1088,37 @@ web3._extend({
 				web3._extend.formatters.inputDefaultBlockNumberFormatter
 			]
 		}),
+		new web3._extend.Method({
+			name: 'getAssets',
+			call: 'fsn_getAssets',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getSwaps',
+			call: 'fsn_getSwaps',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getMultiSwaps',
+			call: 'fsn_getMultiSwaps',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getReports',
+			call: 'fsn_getReports',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getReport',
+			call: 'fsn_getReport',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getReportsByMiner',
+			call: 'fsn_getReportsByMiner',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'makeSwap',
 			call: 'fsn_makeSwap',
@@ -1093,6 +1220,15 @@ web3._extend({
 				web3._extend.formatters.inputDefaultBlockNumberFormatter
 			]
 		}),
+		new web3._extend.Method({
+			name: 'simulate',
+			call: 'fsn_simulate',
+			params: 2,
+			inputFormatter: [
+				web3._extend.formatters.inputCallFormatter,
+				web3._extend.formatters.inputBlockNumberFormatter
+			]
+		}),
 	],
 	properties:[
 		new web3._extend.Property({
@@ -1264,6 +1400,38 @@ web3._extend({
 				web3._extend.formatters.inputTransactionFormatter,
 			]
 		}),
+		new web3._extend.Method({
+			name: 'buildTransferNotationTx',
+			call: 'fsntx_buildTransferNotationTx',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
+		new web3._extend.Method({
+			name: 'transferNotation',
+			call: 'fsntx_transferNotation',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
+		new web3._extend.Method({
+			name: 'buildReleaseNotationTx',
+			call: 'fsntx_buildReleaseNotationTx',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
+		new web3._extend.Method({
+			name: 'releaseNotation',
+			call: 'fsntx_releaseNotation',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
 		new web3._extend.Method({
 			name: 'buildGenAssetTx',
 			call: 'fsntx_buildGenAssetTx',
@@ -1400,6 +1568,22 @@ web3._extend({
 				web3._extend.formatters.inputTransactionFormatter
 			]
 		}),
+		new web3._extend.Method({
+			name: 'buildBatchSendAssetTx',
+			call: 'fsntx_buildBatchSendAssetTx',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
+		new web3._extend.Method({
+			name: 'batchSendAsset',
+			call: 'fsntx_batchSendAsset',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
 		new web3._extend.Method({
 			name: 'buildBuyTicketTx',
 			call: 'fsntx_buildBuyTicketTx',
@@ -1448,6 +1632,38 @@ web3._extend({
 				web3._extend.formatters.inputTransactionFormatter
 			]
 		}),
+		new web3._extend.Method({
+			name: 'buildTransferAssetOwnerTx',
+			call: 'fsntx_buildTransferAssetOwnerTx',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
+		new web3._extend.Method({
+			name: 'transferAssetOwner',
+			call: 'fsntx_transferAssetOwner',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
+		new web3._extend.Method({
+			name: 'buildUpdateAssetTx',
+			call: 'fsntx_buildUpdateAssetTx',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
+		new web3._extend.Method({
+			name: 'updateAsset',
+			call: 'fsntx_updateAsset',
+			params: 1,
+			inputFormatter: [
+				web3._extend.formatters.inputTransactionFormatter
+			]
+		}),
 		new web3._extend.Method({
 			name: 'buildMakeSwapTx',
 			call: 'fsntx_buildMakeSwapTx',
@@ -1544,6 +1760,15 @@ web3._extend({
 				web3._extend.formatters.inputTransactionFormatter
 			]
 		}),
+		new web3._extend.Method({
+			name: 'dryRun',
+			call: 'fsntx_dryRun',
+			params: 2,
+			inputFormatter: [
+				null,
+				web3._extend.formatters.inputBlockNumberFormatter
+			]
+		}),
 	]
 });
 `
@@ -26,6 +26,7 @@ import (
 	"github.com/FusionFoundation/efsn/consensus"
 	"github.com/FusionFoundation/efsn/core"
 	"github.com/FusionFoundation/efsn/core/bloombits"
+	"github.com/FusionFoundation/efsn/core/fsnindex"
 	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/core/state"
 	"github.com/FusionFoundation/efsn/core/types"
@@ -280,6 +281,20 @@ func (b *LesApiBackend) IsMining() bool {
 	return true
 }
 
+func (b *LesApiBackend) IsAutoBuyTicket() bool {
+	return false
+}
+
 func (b *LesApiBackend) Coinbase() (common.Address, error) {
 	return common.Address{}, nil
 }
+
+func (b *LesApiBackend) FusionIndex() *fsnindex.Index {
+	return nil
+}
+
+// PrefetchState retrieves the state entries read by the function in batches,
+// so the Fusion APIs don't need a round trip per storage slot.
+func (b *LesApiBackend) PrefetchState(ctx context.Context, header *types.Header, read func(*state.StateDB)) error {
+	return light.PrefetchState(ctx, header, b.eth.odr, read)
+}
@@ -62,6 +62,8 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*ReceiptsRequest)(r)
 	case *light.TrieRequest:
 		return (*TrieRequest)(r)
+	case *light.StorageRequest:
+		return (*StorageRequest)(r)
 	case *light.CodeRequest:
 		return (*CodeRequest)(r)
 	case *light.ChtRequest:
@@ -253,6 +255,87 @@ func (r *TrieRequest) Validate(db ethdb.Database, msg *Msg) error {
 	}
 }
 
+// StorageRequest is the ODR request type for a batch of state/storage trie entries
+type StorageRequest light.StorageRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *StorageRequest) GetCost(peer *peer) uint64 {
+	switch peer.version {
+	case lpv1:
+		return peer.GetRequestCost(GetProofsV1Msg, len(r.Keys))
+	case lpv2:
+		return peer.GetRequestCost(GetProofsV2Msg, len(r.Keys))
+	default:
+		panic(nil)
+	}
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *StorageRequest) CanSend(peer *peer) bool {
+	return peer.HasBlock(r.Id.BlockHash, r.Id.BlockNumber)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *StorageRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting trie proofs", "root", r.Id.Root, "keys", len(r.Keys))
+	reqs := make([]ProofReq, len(r.Keys))
+	for i, key := range r.Keys {
+		reqs[i] = ProofReq{
+			BHash:  r.Id.BlockHash,
+			AccKey: r.Id.AccKey,
+			Key:    key,
+		}
+	}
+	return peer.RequestProofs(reqID, r.GetCost(peer), reqs)
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *StorageRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating trie proofs", "root", r.Id.Root, "keys", len(r.Keys))
+
+	switch msg.MsgType {
+	case MsgProofsV1:
+		proofs := msg.Obj.([]light.NodeList)
+		if len(proofs) != len(r.Keys) {
+			return errInvalidEntryCount
+		}
+		nodeSet := light.NewNodeSet()
+		for i, proof := range proofs {
+			// Verify each proof and merge them if they check out
+			proofSet := proof.NodeSet()
+			if _, err := trie.VerifyProof(r.Id.Root, r.Keys[i], proofSet); err != nil {
+				return fmt.Errorf("merkle proof verification failed: %v", err)
+			}
+			proof.Store(nodeSet)
+		}
+		r.Proof = nodeSet
+		return nil
+
+	case MsgProofsV2:
+		proofs := msg.Obj.(light.NodeList)
+		// Verify the proofs of all keys against the merged node set
+		nodeSet := proofs.NodeSet()
+		reads := &readTraceDB{db: nodeSet}
+		for _, key := range r.Keys {
+			if _, err := trie.VerifyProof(r.Id.Root, key, reads); err != nil {
+				return fmt.Errorf("merkle proof verification failed: %v", err)
+			}
+		}
+		// check if all nodes have been read by VerifyProof
+		if len(reads.reads) != nodeSet.KeyCount() {
+			return errUselessNodes
+		}
+		r.Proof = nodeSet
+		return nil
+
+	default:
+		return errInvalidMessageType
+	}
+}
+
 type CodeReq struct {
 	BHash  common.Hash
 	AccKey []byte
@@ -26,6 +26,7 @@ import (
 
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/consensus"
+	"github.com/FusionFoundation/efsn/consensus/datong"
 	"github.com/FusionFoundation/efsn/core"
 	"github.com/FusionFoundation/efsn/core/rawdb"
 	"github.com/FusionFoundation/efsn/core/state"
@@ -41,6 +42,8 @@ import (
 var (
 	bodyCacheLimit  = 256
 	blockCacheLimit = 256
+
+	ticketsFetchTimeout = time.Minute // maximum time to retrieve the ticket set of a header
 )
 
 // LightChain represents a canonical chain that by default only handles block
@@ -76,6 +79,9 @@ type LightChain struct {
 // available in the database. It initialises the default Ethereum header
 // validator.
 func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.Engine) (*LightChain, error) {
+	// Fusion fork checks follow the schedule of the chain being processed
+	common.SetForkConfig(config.ForkConfig())
+
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
@@ -90,6 +96,9 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 		blockCache:    blockCache,
 		engine:        engine,
 	}
+	if datong, ok := engine.(*datong.DaTong); ok {
+		datong.SetTicketsFetcher(bc.fetchTickets)
+	}
 	var err error
 	bc.hc, err = core.NewHeaderChain(odr.Database(), config, bc.engine, bc.getProcInterrupt)
 	if err != nil {
@@ -116,6 +125,21 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	return bc, nil
 }
 
+// fetchTickets retrieves the ticket set at a header, which the consensus
+// engine needs to verify the seal of its child.
+func (lc *LightChain) fetchTickets(header *types.Header) (common.TicketsDataSlice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ticketsFetchTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-lc.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return GetTickets(ctx, lc.odr, header)
+}
+
 // addTrustedCheckpoint adds a trusted checkpoint to the blockchain
 func (lc *LightChain) addTrustedCheckpoint(cp *params.TrustedCheckpoint) {
 	if lc.odr.ChtIndexer() != nil {
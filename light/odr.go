@@ -95,6 +95,20 @@ func (req *TrieRequest) StoreResult(db ethdb.Database) {
 	req.Proof.Store(db)
 }
 
+// StorageRequest is the ODR request type for a batch of entries of the same
+// state or storage trie, proven together
+type StorageRequest struct {
+	OdrRequest
+	Id    *TrieID
+	Keys  [][]byte
+	Proof *NodeSet
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *StorageRequest) StoreResult(db ethdb.Database) {
+	req.Proof.Store(db)
+}
+
 // CodeRequest is the ODR request type for retrieving contract code
 type CodeRequest struct {
 	OdrRequest
@@ -86,6 +86,13 @@ func (odr *testOdr) Retrieve(ctx context.Context, req OdrRequest) error {
 		nodes := NewNodeSet()
 		t.Prove(req.Key, 0, nodes)
 		req.Proof = nodes
+	case *StorageRequest:
+		t, _ := trie.New(req.Id.Root, trie.NewDatabase(odr.sdb))
+		nodes := NewNodeSet()
+		for _, key := range req.Keys {
+			t.Prove(key, 0, nodes)
+		}
+		req.Proof = nodes
 	case *CodeRequest:
 		req.Data, _ = odr.sdb.Get(req.Hash[:])
 	}
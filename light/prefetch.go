@@ -0,0 +1,94 @@
+package light
+
+import (
+	"context"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core/state"
+	"github.com/FusionFoundation/efsn/core/types"
+)
+
+// MaxStorageBatch is the maximum number of trie entries of a StorageRequest.
+const MaxStorageBatch = 64
+
+// missingEntries collects the trie entries read through an odrDatabase which
+// are not available locally, grouped by trie.
+type missingEntries struct {
+	ids  map[string]*TrieID
+	keys map[string][][]byte
+	seen map[string]struct{}
+}
+
+func newMissingEntries() *missingEntries {
+	return &missingEntries{
+		ids:  make(map[string]*TrieID),
+		keys: make(map[string][][]byte),
+		seen: make(map[string]struct{}),
+	}
+}
+
+func (m *missingEntries) add(id *TrieID, key []byte) {
+	trieKey := string(id.AccKey) + string(id.Root[:])
+	if _, ok := m.seen[trieKey+string(key)]; ok {
+		return
+	}
+	m.seen[trieKey+string(key)] = struct{}{}
+	m.ids[trieKey] = id
+	m.keys[trieKey] = append(m.keys[trieKey], key)
+}
+
+// PrefetchState retrieves the state entries read by fn in batches, instead
+// of one request per entry. The Fusion state keeps balances, time locks,
+// assets, swaps and tickets in many storage slots of a few accounts, which
+// would otherwise take a round trip each.
+//
+// fn is run against a state of the header reading the missing entries as
+// empty, then the missing entries are retrieved and fn runs again, until a
+// run reads no missing entry. Every run resolves one level of dependent
+// reads, like the chunks of a struct data after its size, so only the last
+// run sees the actual state. Afterwards the entries are stored locally and
+// the state returned by NewState reads them without retrieval.
+func PrefetchState(ctx context.Context, head *types.Header, odr OdrBackend, fn func(*state.StateDB)) error {
+	for {
+		missing := newMissingEntries()
+		db := &odrDatabase{ctx: ctx, id: StateTrieID(head), backend: odr, missing: missing}
+		statedb, err := state.New(head.Root, head.MixDigest, db)
+		if err != nil {
+			return err
+		}
+		fn(statedb)
+		if len(missing.ids) == 0 {
+			return nil
+		}
+		for trieKey, id := range missing.ids {
+			keys := missing.keys[trieKey]
+			for len(keys) > 0 {
+				batch := keys
+				if len(batch) > MaxStorageBatch {
+					batch = batch[:MaxStorageBatch]
+				}
+				keys = keys[len(batch):]
+				if err := odr.Retrieve(ctx, &StorageRequest{Id: id, Keys: batch}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// GetTickets retrieves the ticket set at the header, prefetching the storage
+// entries of the ticket layout in batches.
+func GetTickets(ctx context.Context, odr OdrBackend, head *types.Header) (common.TicketsDataSlice, error) {
+	err := PrefetchState(ctx, head, odr, func(statedb *state.StateDB) {
+		statedb.AllTickets()
+	})
+	if err != nil {
+		return nil, err
+	}
+	statedb := NewState(ctx, head, odr)
+	tickets, err := statedb.AllTickets()
+	if err != nil {
+		return nil, err
+	}
+	return tickets, statedb.Error()
+}
@@ -35,13 +35,14 @@ func NewState(ctx context.Context, head *types.Header, odr OdrBackend) *state.St
 }
 
 func NewStateDatabase(ctx context.Context, head *types.Header, odr OdrBackend) state.Database {
-	return &odrDatabase{ctx, StateTrieID(head), odr}
+	return &odrDatabase{ctx: ctx, id: StateTrieID(head), backend: odr}
 }
 
 type odrDatabase struct {
 	ctx     context.Context
 	id      *TrieID
 	backend OdrBackend
+	missing *missingEntries // collects missing entries instead of retrieving them, if set
 }
 
 func (db *odrDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
@@ -159,6 +160,10 @@ func (t *odrTrie) do(key []byte, fn func() error) error {
 		if _, ok := err.(*trie.MissingNodeError); !ok {
 			return err
 		}
+		if t.db.missing != nil {
+			t.db.missing.add(t.id, key)
+			return nil
+		}
 		r := &TrieRequest{Id: t.id, Key: key}
 		if err := t.db.backend.Retrieve(t.db.ctx, r); err != nil {
 			return err
@@ -51,6 +51,8 @@ type Config struct {
 	GasPrice  *big.Int       // Minimum gas price for mining a transaction
 	Recommit  time.Duration  // The time interval for miner to re-create mining work.
 	Noverify  bool           // Disable remote mining solution verification(only useful in ethash).
+	Signer    string         `toml:",omitempty"` // External signer (clef) endpoint sealing the blocks instead of the keystore
+	Tickets   TicketPolicy   // Automatic ticket buying of the etherbase
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -63,6 +65,7 @@ type Miner struct {
 	exitCh   chan struct{}
 	startCh  chan common.Address
 	stopCh   chan struct{}
+	tickets  *ticketBuyer
 
 	wg sync.WaitGroup
 }
@@ -77,6 +80,7 @@ func New(eth Backend, config *Config, chainConfig *params.ChainConfig, mux *even
 		stopCh:  make(chan struct{}),
 		worker:  newWorker(config, chainConfig, engine, eth, mux, isLocalBlock),
 	}
+	miner.tickets = newTicketBuyer(config.Tickets, eth.BlockChain(), miner.worker.etherbase, miner.Mining)
 	miner.wg.Add(1)
 	go miner.update()
 	return miner
@@ -142,6 +146,7 @@ func (miner *Miner) update() {
 			shouldStart = false
 			miner.worker.stop()
 		case <-miner.exitCh:
+			miner.tickets.close()
 			miner.worker.close()
 			return
 		}
@@ -204,6 +209,33 @@ func (miner *Miner) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
 	return miner.worker.pendingBlockAndReceipts()
 }
 
+// SetTicketBuyFunc sets the function sending the ticket purchases of the
+// ticket buyer, no ticket is bought until it's set.
+func (miner *Miner) SetTicketBuyFunc(buy BuyTicketFunc) {
+	miner.tickets.setBuyFunc(buy)
+}
+
+// TicketPolicy returns the policy of the ticket buyer.
+func (miner *Miner) TicketPolicy() TicketPolicy {
+	return miner.tickets.getPolicy()
+}
+
+// SetTicketPolicy replaces the policy of the ticket buyer.
+func (miner *Miner) SetTicketPolicy(policy TicketPolicy) error {
+	return miner.tickets.setPolicy(policy)
+}
+
+// SetAutoBuyTicket enables or disables the ticket buyer, keeping its policy.
+func (miner *Miner) SetAutoBuyTicket(enabled bool) {
+	miner.tickets.setEnabled(enabled)
+}
+
+// TicketStatus returns the tickets of the etherbase and the last decision of
+// the ticket buyer.
+func (miner *Miner) TicketStatus() TicketStatus {
+	return miner.tickets.getStatus()
+}
+
 func (miner *Miner) SetEtherbase(addr common.Address) {
 	miner.coinbase = addr
 	miner.worker.setEtherbase(addr)
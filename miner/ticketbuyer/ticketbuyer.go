@@ -0,0 +1,157 @@
+// Package ticketbuyer implements the policy of the automatic ticket buyer of
+// the miner, which decides on every new head whether the etherbase buys a
+// ticket.
+package ticketbuyer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/FusionFoundation/efsn/common"
+)
+
+// Funding sources of the ticket buyer.
+const (
+	FundAny      = "any"      // time locks covering the ticket lifetime first, then free balance
+	FundTimeLock = "timelock" // time locks covering the ticket lifetime only
+	FundBalance  = "balance"  // free balance only, skipping the purchases covered by time locks
+)
+
+// MinDuration is the shortest ticket lifetime accepted by BuyTicket.
+const MinDuration = 30 * 24 * 3600
+
+// Policy is the configuration of the ticket buyer, which buys tickets for the
+// etherbase while mining.
+type Policy struct {
+	Enabled   bool     // Buy tickets automatically
+	Target    uint64   // Number of tickets to hold, 0 buys a ticket every block
+	MaxCommit *big.Int `toml:",omitempty"` // Maximum FSN (in wei) locked in the tickets held, nil = no limit
+	Fund      string   // Funding source of the tickets: any, timelock or balance
+	Duration  uint64   // Lifetime of the tickets bought in seconds, 0 = 30 days
+	RenewLead uint64   // Seconds before their expiration the tickets are replaced
+}
+
+// Sanitize validates the policy and fills in the defaults.
+func (p Policy) Sanitize() (Policy, error) {
+	switch p.Fund {
+	case "":
+		p.Fund = FundAny
+	case FundAny, FundTimeLock, FundBalance:
+	default:
+		return p, fmt.Errorf("invalid ticket funding source %q", p.Fund)
+	}
+	if p.Duration == 0 {
+		p.Duration = MinDuration
+	}
+	if p.Duration < MinDuration {
+		return p, fmt.Errorf("ticket duration %ds is shorter than 30 days", p.Duration)
+	}
+	if p.RenewLead >= p.Duration {
+		return p, errors.New("ticket renewal lead time must be shorter than the ticket duration")
+	}
+	if p.MaxCommit != nil {
+		if p.MaxCommit.Sign() < 0 {
+			return p, errors.New("negative maximum ticket commitment")
+		}
+		p.MaxCommit = new(big.Int).Set(p.MaxCommit)
+	}
+	return p, nil
+}
+
+// Status reports the tickets of the etherbase and the last decision of the
+// ticket buyer.
+type Status struct {
+	Policy    Policy         `json:"policy"`
+	Etherbase common.Address `json:"etherbase"`
+	Number    uint64         `json:"number"`    // last block checked
+	Tickets   uint64         `json:"tickets"`   // tickets held
+	Renewing  uint64         `json:"renewing"`  // tickets held expiring within the renewal lead time
+	InFlight  uint64         `json:"inFlight"`  // tickets bought but not yet mined
+	Committed *big.Int       `json:"committed"` // FSN locked in the tickets held and in flight
+	Bought    uint64         `json:"bought"`    // tickets bought since the node started
+	LastBuy   uint64         `json:"lastBuy"`   // block number of the last purchase
+	LastTx    common.Hash    `json:"lastTx"`
+	Skipped   string         `json:"skipped,omitempty"` // why the last block bought no ticket
+}
+
+// Purchase is a ticket bought by the ticket buyer whose transaction isn't
+// mined yet.
+type Purchase struct {
+	Tx     common.Hash
+	Owner  common.Address
+	Number uint64   // head the purchase was sent on
+	Value  *big.Int // ticket price
+}
+
+// State is the part of the state the ticket buyer decides on.
+type State interface {
+	AllTickets() (common.TicketsDataSlice, error)
+	GetBalance(assetID common.Hash, addr common.Address) *big.Int
+	GetTimeLockBalance(assetID common.Hash, addr common.Address) *common.TimeLock
+}
+
+// Decide returns the tickets status of the etherbase in the state of the
+// block number at time now, and the lifetime of the ticket to buy or the
+// reason not to buy one. The purchases of the etherbase in flight count as
+// held tickets, and their funds as spent.
+func Decide(policy Policy, etherbase common.Address, number *big.Int, now uint64, statedb State, inFlight []Purchase) (Status, uint64, uint64, error) {
+	status := Status{
+		Etherbase: etherbase,
+		Number:    number.Uint64(),
+		Committed: new(big.Int),
+	}
+	tickets, err := statedb.AllTickets()
+	if err != nil {
+		return status, 0, 0, err
+	}
+	for _, data := range tickets {
+		if data.Owner != etherbase {
+			continue
+		}
+		for _, ticket := range data.Tickets {
+			status.Tickets++
+			status.Committed.Add(status.Committed, ticket.Value())
+			if ticket.ExpireTime <= now+policy.RenewLead {
+				status.Renewing++
+			}
+		}
+	}
+	spent := new(big.Int)
+	for _, purchase := range inFlight {
+		if purchase.Owner == etherbase {
+			status.InFlight++
+			spent.Add(spent, purchase.Value)
+		}
+	}
+	status.Committed.Add(status.Committed, spent)
+
+	held := status.Tickets - status.Renewing + status.InFlight
+	if policy.Target > 0 && held >= policy.Target {
+		return status, 0, 0, fmt.Errorf("holding %d of %d tickets", held, policy.Target)
+	}
+
+	price := common.TicketPrice(new(big.Int).Add(number, common.Big1))
+	if policy.MaxCommit != nil && new(big.Int).Add(status.Committed, price).Cmp(policy.MaxCommit) > 0 {
+		return status, 0, 0, fmt.Errorf("committed %v of at most %v", status.Committed, policy.MaxCommit)
+	}
+
+	start, end := now, now+policy.Duration
+	need := new(big.Int).Add(spent, price)
+	needValue := common.NewTimeLock(&common.TimeLockItem{
+		StartTime: start,
+		EndTime:   end,
+		Value:     need,
+	})
+	timeLocked := statedb.GetTimeLockBalance(common.SystemAssetID, etherbase).Cmp(needValue) >= 0
+	balance := statedb.GetBalance(common.SystemAssetID, etherbase).Cmp(need) >= 0
+	switch {
+	case policy.Fund == FundTimeLock && !timeLocked:
+		return status, 0, 0, errors.New("not enough time lock balance")
+	case policy.Fund == FundBalance && timeLocked:
+		return status, 0, 0, errors.New("ticket would be funded by time locks")
+	case !timeLocked && !balance:
+		return status, 0, 0, errors.New("not enough time lock or asset balance")
+	}
+	return status, start, end, nil
+}
@@ -0,0 +1,245 @@
+package ticketbuyer
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
+)
+
+var (
+	etherbase = common.HexToAddress("0x01")
+	other     = common.HexToAddress("0x02")
+	price     = common.TicketPrice(common.Big1)
+)
+
+// testState holds the tickets and the FSN of the etherbase.
+type testState struct {
+	tickets  common.TicketsDataSlice
+	balance  *big.Int
+	timeLock *common.TimeLock
+}
+
+func (s *testState) AllTickets() (common.TicketsDataSlice, error) {
+	return s.tickets, nil
+}
+
+func (s *testState) GetBalance(assetID common.Hash, addr common.Address) *big.Int {
+	if addr != etherbase || s.balance == nil {
+		return new(big.Int)
+	}
+	return s.balance
+}
+
+func (s *testState) GetTimeLockBalance(assetID common.Hash, addr common.Address) *common.TimeLock {
+	if addr != etherbase || s.timeLock == nil {
+		return new(common.TimeLock)
+	}
+	return s.timeLock
+}
+
+func prices(n int64) *big.Int {
+	return new(big.Int).Mul(price, big.NewInt(n))
+}
+
+func heldTickets(owner common.Address, expires ...uint64) common.TicketsData {
+	data := common.TicketsData{Owner: owner}
+	for i, expire := range expires {
+		data.Tickets = append(data.Tickets, common.TicketBody{
+			ID:         common.BigToHash(big.NewInt(int64(i + 1))),
+			Height:     1,
+			ExpireTime: expire,
+		})
+	}
+	return data
+}
+
+func inFlight(owner common.Address, n int) []Purchase {
+	purchases := make([]Purchase, n)
+	for i := range purchases {
+		purchases[i] = Purchase{Tx: common.BigToHash(big.NewInt(int64(i + 1))), Owner: owner, Value: price}
+	}
+	return purchases
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		want   Policy
+		err    string
+	}{
+		{policy: Policy{}, want: Policy{Fund: FundAny, Duration: MinDuration}},
+		{policy: Policy{Fund: FundBalance, Duration: 2 * MinDuration, RenewLead: 3600}, want: Policy{Fund: FundBalance, Duration: 2 * MinDuration, RenewLead: 3600}},
+		{policy: Policy{Fund: "credit"}, err: "invalid ticket funding source"},
+		{policy: Policy{Duration: MinDuration - 1}, err: "shorter than 30 days"},
+		{policy: Policy{RenewLead: MinDuration}, err: "renewal lead time"},
+		{policy: Policy{MaxCommit: big.NewInt(-1)}, err: "negative maximum"},
+	}
+	for i, test := range tests {
+		have, err := test.policy.Sanitize()
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("test %d: error mismatch: have %v, want %q", i, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if have != test.want {
+			t.Errorf("test %d: policy mismatch: have %+v, want %+v", i, have, test.want)
+		}
+	}
+
+	// the maximum commitment is copied
+	limit := big.NewInt(10)
+	have, _ := Policy{MaxCommit: limit}.Sanitize()
+	limit.SetInt64(20)
+	if have.MaxCommit.Int64() != 10 {
+		t.Errorf("maximum commitment shared with the caller")
+	}
+}
+
+func TestDecide(t *testing.T) {
+	const now = 1000000
+	var (
+		forever  = common.NewTimeLock(&common.TimeLockItem{StartTime: 0, EndTime: common.TimeLockForever, Value: prices(2)})
+		tooShort = common.NewTimeLock(&common.TimeLockItem{StartTime: 0, EndTime: now + 10, Value: prices(2)})
+	)
+	tests := []struct {
+		name     string
+		policy   Policy
+		state    testState
+		inFlight []Purchase
+		buy      bool
+		err      string
+		tickets  uint64
+		renewing uint64
+		pending  uint64
+	}{
+		{
+			name:   "buy from balance",
+			policy: Policy{},
+			state:  testState{balance: price},
+			buy:    true,
+		},
+		{
+			name:   "nothing to pay with",
+			policy: Policy{},
+			state:  testState{},
+			err:    "not enough time lock or asset balance",
+		},
+		{
+			name:    "target reached",
+			policy:  Policy{Target: 2},
+			state:   testState{balance: price, tickets: common.TicketsDataSlice{heldTickets(etherbase, now*2, now*2)}},
+			err:     "holding 2 of 2 tickets",
+			tickets: 2,
+		},
+		{
+			name:     "renewing tickets don't count",
+			policy:   Policy{Target: 2, RenewLead: 3600},
+			state:    testState{balance: price, tickets: common.TicketsDataSlice{heldTickets(etherbase, now*2, now+60)}},
+			buy:      true,
+			tickets:  2,
+			renewing: 1,
+		},
+		{
+			name:    "tickets of others don't count",
+			policy:  Policy{Target: 2},
+			state:   testState{balance: price, tickets: common.TicketsDataSlice{heldTickets(other, now*2, now*2), heldTickets(etherbase, now*2)}},
+			buy:     true,
+			tickets: 1,
+		},
+		{
+			name:     "purchases in flight count towards the target",
+			policy:   Policy{Target: 2},
+			state:    testState{balance: prices(3), tickets: common.TicketsDataSlice{heldTickets(etherbase, now*2)}},
+			inFlight: inFlight(etherbase, 1),
+			err:      "holding 2 of 2 tickets",
+			tickets:  1,
+			pending:  1,
+		},
+		{
+			name:     "purchases in flight of another etherbase are ignored",
+			policy:   Policy{Target: 2},
+			state:    testState{balance: price, tickets: common.TicketsDataSlice{heldTickets(etherbase, now*2)}},
+			inFlight: inFlight(other, 1),
+			buy:      true,
+			tickets:  1,
+		},
+		{
+			name:     "purchases in flight count towards the commitment",
+			policy:   Policy{MaxCommit: prices(2)},
+			state:    testState{balance: prices(3), tickets: common.TicketsDataSlice{heldTickets(etherbase, now*2)}},
+			inFlight: inFlight(etherbase, 1),
+			err:      "committed",
+			tickets:  1,
+			pending:  1,
+		},
+		{
+			name:     "purchases in flight spend the balance",
+			policy:   Policy{},
+			state:    testState{balance: prices(2)},
+			inFlight: inFlight(etherbase, 2),
+			err:      "not enough time lock or asset balance",
+			pending:  2,
+		},
+		{
+			name:     "balance left after the purchases in flight",
+			policy:   Policy{},
+			state:    testState{balance: prices(3)},
+			inFlight: inFlight(etherbase, 2),
+			buy:      true,
+			pending:  2,
+		},
+		{
+			name:   "time lock funding",
+			policy: Policy{Fund: FundTimeLock},
+			state:  testState{timeLock: forever},
+			buy:    true,
+		},
+		{
+			name:   "time lock not covering the ticket lifetime",
+			policy: Policy{Fund: FundTimeLock},
+			state:  testState{balance: price, timeLock: tooShort},
+			err:    "not enough time lock balance",
+		},
+		{
+			name:   "balance funding skips time locked purchases",
+			policy: Policy{Fund: FundBalance},
+			state:  testState{balance: price, timeLock: forever},
+			err:    "funded by time locks",
+		},
+		{
+			name:     "time lock spent by the purchases in flight",
+			policy:   Policy{Fund: FundBalance},
+			state:    testState{balance: prices(2), timeLock: common.NewTimeLock(&common.TimeLockItem{StartTime: 0, EndTime: common.TimeLockForever, Value: price})},
+			inFlight: inFlight(etherbase, 1),
+			buy:      true,
+			pending:  1,
+		},
+	}
+	for _, test := range tests {
+		policy, err := test.policy.Sanitize()
+		if err != nil {
+			t.Fatalf("%s: invalid policy: %v", test.name, err)
+		}
+		status, start, end, err := Decide(policy, etherbase, common.Big1, now, &test.state, test.inFlight)
+		switch {
+		case test.buy && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		case !test.buy && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: error mismatch: have %v, want %q", test.name, err, test.err)
+		}
+		if test.buy && (start != now || end != now+policy.Duration) {
+			t.Errorf("%s: lifetime mismatch: have %d-%d, want %d-%d", test.name, start, end, now, now+policy.Duration)
+		}
+		if status.Tickets != test.tickets || status.Renewing != test.renewing || status.InFlight != test.pending {
+			t.Errorf("%s: status mismatch: have %d tickets, %d renewing, %d in flight, want %d, %d, %d",
+				test.name, status.Tickets, status.Renewing, status.InFlight, test.tickets, test.renewing, test.pending)
+		}
+	}
+}
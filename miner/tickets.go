@@ -0,0 +1,203 @@
+package miner
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/FusionFoundation/efsn/common"
+	"github.com/FusionFoundation/efsn/core"
+	"github.com/FusionFoundation/efsn/core/types"
+	"github.com/FusionFoundation/efsn/log"
+	"github.com/FusionFoundation/efsn/miner/ticketbuyer"
+)
+
+// Funding sources of the ticket buyer.
+const (
+	FundAny      = ticketbuyer.FundAny
+	FundTimeLock = ticketbuyer.FundTimeLock
+	FundBalance  = ticketbuyer.FundBalance
+)
+
+// purchaseTimeout is the number of blocks after which a ticket purchase that
+// isn't mined is assumed to be dropped.
+const purchaseTimeout = 16
+
+// TicketPolicy is the configuration of the ticket buyer, which buys tickets
+// for the etherbase while mining.
+type TicketPolicy = ticketbuyer.Policy
+
+// BuyTicketFunc sends a transaction buying a ticket from the account, valid
+// from start to end.
+type BuyTicketFunc func(from common.Address, start, end uint64) (common.Hash, error)
+
+// TicketStatus reports the tickets of the etherbase and the last decision of
+// the ticket buyer.
+type TicketStatus = ticketbuyer.Status
+
+// ticketBuyer buys tickets for the etherbase on every new head, as long as
+// the miner is running and the policy asks for them.
+type ticketBuyer struct {
+	chain     *core.BlockChain
+	etherbase func() common.Address
+	mining    func() bool
+
+	mu       sync.Mutex
+	policy   TicketPolicy
+	buy      BuyTicketFunc
+	status   TicketStatus
+	inFlight []ticketbuyer.Purchase // purchases of the etherbase not mined yet
+
+	exitCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newTicketBuyer(policy TicketPolicy, chain *core.BlockChain, etherbase func() common.Address, mining func() bool) *ticketBuyer {
+	sanitized, err := policy.Sanitize()
+	if err != nil {
+		log.Warn("Invalid ticket policy, auto buying disabled", "err", err)
+		sanitized, _ = TicketPolicy{}.Sanitize()
+	}
+	tb := &ticketBuyer{
+		chain:     chain,
+		etherbase: etherbase,
+		mining:    mining,
+		policy:    sanitized,
+		exitCh:    make(chan struct{}),
+	}
+	tb.wg.Add(1)
+	go tb.loop()
+	return tb
+}
+
+func (tb *ticketBuyer) close() {
+	close(tb.exitCh)
+	tb.wg.Wait()
+}
+
+func (tb *ticketBuyer) loop() {
+	defer tb.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := tb.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			// only the latest head matters when falling behind
+			for len(headCh) > 0 {
+				ev = <-headCh
+			}
+			tb.update(ev.Block)
+		case <-sub.Err():
+			return
+		case <-tb.exitCh:
+			return
+		}
+	}
+}
+
+func (tb *ticketBuyer) setPolicy(policy TicketPolicy) error {
+	sanitized, err := policy.Sanitize()
+	if err != nil {
+		return err
+	}
+	tb.mu.Lock()
+	tb.policy = sanitized
+	tb.mu.Unlock()
+	return nil
+}
+
+func (tb *ticketBuyer) getPolicy() TicketPolicy {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.policy
+}
+
+func (tb *ticketBuyer) setEnabled(enabled bool) {
+	tb.mu.Lock()
+	tb.policy.Enabled = enabled
+	tb.mu.Unlock()
+}
+
+func (tb *ticketBuyer) setBuyFunc(buy BuyTicketFunc) {
+	tb.mu.Lock()
+	tb.buy = buy
+	tb.mu.Unlock()
+}
+
+func (tb *ticketBuyer) getStatus() TicketStatus {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	status := tb.status
+	status.Policy = tb.policy
+	if status.Committed != nil {
+		status.Committed = new(big.Int).Set(status.Committed)
+	}
+	return status
+}
+
+// update decides whether to buy a ticket on top of the new head.
+func (tb *ticketBuyer) update(head *types.Block) {
+	tb.mu.Lock()
+	policy, buy, prev := tb.policy, tb.buy, tb.status
+	inFlight := tb.settle(head.NumberU64())
+	tb.mu.Unlock()
+
+	if !policy.Enabled || buy == nil || !tb.mining() {
+		return
+	}
+	etherbase := tb.etherbase()
+	status, start, end, err := tb.decide(policy, etherbase, head, inFlight)
+	status.Bought, status.LastBuy, status.LastTx = prev.Bought, prev.LastBuy, prev.LastTx
+	var purchase *ticketbuyer.Purchase
+	if err == nil {
+		var hash common.Hash
+		if hash, err = buy(etherbase, start, end); err == nil {
+			log.Info("Bought ticket", "number", head.NumberU64(), "etherbase", etherbase, "tx", hash)
+			status.Bought++
+			status.LastBuy, status.LastTx = head.NumberU64(), hash
+			purchase = &ticketbuyer.Purchase{
+				Tx:     hash,
+				Owner:  etherbase,
+				Number: head.NumberU64(),
+				Value:  common.TicketPrice(new(big.Int).Add(head.Number(), common.Big1)),
+			}
+		} else {
+			log.Warn("Failed buying ticket", "err", err)
+		}
+	}
+	if err != nil {
+		status.Skipped = err.Error()
+	}
+	tb.mu.Lock()
+	tb.status = status
+	if purchase != nil {
+		tb.inFlight = append(tb.inFlight, *purchase)
+	}
+	tb.mu.Unlock()
+}
+
+// settle drops the purchases mined up to the head or timed out, and returns
+// the ones still in flight. It must be called with the lock held.
+func (tb *ticketBuyer) settle(number uint64) []ticketbuyer.Purchase {
+	pending := tb.inFlight[:0]
+	for _, purchase := range tb.inFlight {
+		if tb.chain.GetTransactionLookup(purchase.Tx) != nil || number >= purchase.Number+purchaseTimeout {
+			continue
+		}
+		pending = append(pending, purchase)
+	}
+	tb.inFlight = pending
+	return append([]ticketbuyer.Purchase(nil), pending...)
+}
+
+// decide returns the tickets status of the etherbase at the head, and the
+// lifetime of the ticket to buy or the reason not to buy one.
+func (tb *ticketBuyer) decide(policy TicketPolicy, etherbase common.Address, head *types.Block, inFlight []ticketbuyer.Purchase) (TicketStatus, uint64, uint64, error) {
+	statedb, err := tb.chain.StateAt(head.Root(), head.MixDigest())
+	if err != nil {
+		return TicketStatus{Etherbase: etherbase, Number: head.NumberU64()}, 0, 0, err
+	}
+	return ticketbuyer.Decide(policy, etherbase, head.Number(), head.Time(), statedb, inFlight)
+}
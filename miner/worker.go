@@ -18,6 +18,7 @@ package miner
 
 import (
 	"bytes"
+	"errors"
 	"math/big"
 	"sync"
 	"sync/atomic"
@@ -236,6 +237,13 @@ func (w *worker) setEtherbase(addr common.Address) {
 	w.coinbase = addr
 }
 
+// etherbase returns the etherbase used to initialize the block coinbase field.
+func (w *worker) etherbase() common.Address {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.coinbase
+}
+
 // setExtra sets the content used to initialize the block extra field.
 func (w *worker) setExtra(extra []byte) {
 	w.mu.Lock()
@@ -293,6 +301,15 @@ func (w *worker) isRunning() bool {
 	return atomic.LoadInt32(&w.running) == 1
 }
 
+// instantSeal returns whether blocks are sealed as soon as transactions
+// arrive instead of periodically, as on developer chains.
+func (w *worker) instantSeal() bool {
+	if w.chainConfig.Clique != nil && w.chainConfig.Clique.Period == 0 {
+		return true
+	}
+	return w.chainConfig.DaTong != nil && w.chainConfig.DaTong.InstantSeal
+}
+
 // close terminates all background threads maintained by the worker.
 // Note the worker does not support being closed multiple times.
 func (w *worker) close() {
@@ -319,7 +336,11 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 			atomic.StoreInt32(interrupt, s)
 		}
 		interrupt = new(int32)
-		w.newWorkCh <- &newWorkReq{interrupt: interrupt, noempty: noempty, timestamp: timestamp}
+		select {
+		case w.newWorkCh <- &newWorkReq{interrupt: interrupt, noempty: noempty, timestamp: timestamp}:
+		case <-w.exitCh:
+			return
+		}
 		timer.Reset(recommit)
 		atomic.StoreInt32(&w.newTxs, 0)
 	}
@@ -374,7 +395,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 		case <-timer.C:
 			// If mining is running resubmit a new work cycle periodically to pull in
 			// higher priced transactions. Disable this overhead for pending blocks.
-			if w.isRunning() && (w.chainConfig.Clique == nil || w.chainConfig.Clique.Period > 0) {
+			if w.isRunning() && !w.instantSeal() {
 				// Short circuit if no new transaction arrives.
 				if atomic.LoadInt32(&w.newTxs) == 0 {
 					timer.Reset(recommit)
@@ -475,7 +496,7 @@ func (w *worker) mainLoop() {
 				}
 			} else {
 				// If we're mining, but nothing is being processed, wake on new transactions
-				if w.chainConfig.Clique != nil && w.chainConfig.Clique.Period == 0 {
+				if w.instantSeal() {
 					w.commitNewWork(nil, false, time.Now().Unix())
 				}
 			}
@@ -543,7 +564,15 @@ func (w *worker) taskLoop() {
 			w.pendingMu.Unlock()
 
 			if err := w.engine.Seal(w.chain, task.block, w.resultCh, stopCh); err != nil {
-				log.Warn("Block sealing failed", "err", err)
+				if w.instantSeal() && errors.Is(err, datong.ErrConflictingSeal) {
+					// the new head and the transactions arriving with it both commit work
+					log.Debug("Duplicate block not sealed", "err", err)
+				} else {
+					log.Warn("Block sealing failed", "err", err)
+				}
+				w.pendingMu.Lock()
+				delete(w.pendingTasks, sealHash)
+				w.pendingMu.Unlock()
 			}
 		case <-w.exitCh:
 			interrupt()
@@ -831,7 +860,7 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 
 	tstart := time.Now()
 	parent := w.chain.CurrentBlock()
-	minTime := parent.Time() + datong.MinBlockTime
+	minTime := parent.Time() + datong.MinBlockInterval(w.chainConfig.DaTong)
 
 	if minTime > uint64(timestamp) {
 		timestamp = int64(minTime)
@@ -47,6 +47,19 @@ var (
 		IstanbulBlock:       big.NewInt(5_800_000),
 		BerlinBlock:         big.NewInt(5_800_000),
 		LondonBlock:         big.NewInt(5_800_000),
+		PosV2Block:          big.NewInt(739_500),
+		PosV3Block:          big.NewInt(1_818_300),
+		SmartTransferBlock:  big.NewInt(1_818_300),
+		Vote1FreezeRange: &Vote1FreezeRange{
+			Start:         739_500,
+			End:           786_000,
+			RefundAddress: common.HexToAddress("0xff948d492c31814dEde4CAA0af8824eF02Eb48D2"),
+			DrainList: []common.Address{
+				common.HexToAddress("0xb66cce16736feb5a50a9883675708027d3427c3c"),
+				common.HexToAddress("0x782da6fb0562074ec21942a7829064a8c2bb05c4"),
+				common.HexToAddress("0x6deed6878d062cd8754b3284306be814b215e332"),
+			},
+		},
 		DaTong: &DaTongConfig{
 			Period: 15,
 		},
@@ -77,6 +90,19 @@ var (
 		IstanbulBlock:       big.NewInt(5_480_000),
 		BerlinBlock:         big.NewInt(5_480_000),
 		LondonBlock:         big.NewInt(5_480_000),
+		PosV2Block:          big.NewInt(534_500),
+		PosV3Block:          big.NewInt(1_577_000),
+		SmartTransferBlock:  big.NewInt(1_577_000),
+		Vote1FreezeRange: &Vote1FreezeRange{
+			Start:         640_100,
+			End:           646_700,
+			RefundAddress: common.HexToAddress("0xf97a9980808a2cae0d09ff693f02a4f80abb22c4"),
+			DrainList: []common.Address{
+				common.HexToAddress("0x07f35aba9555a532c0edc2bd6350c891b6f2c8d0"),
+				common.HexToAddress("0x3dfaef310a1044fd7d96750b42b44cf3775c00bf"),
+				common.HexToAddress("0x32095bb7f699a139036d68defd4f12b682795fb6"),
+			},
+		},
 		DaTong: &DaTongConfig{
 			Period: 15,
 		},
@@ -120,40 +146,80 @@ var (
 
 	// DevnetChainConfig is the chain parameters to run a node on the develop network.
 	DevnetChainConfig = &ChainConfig{
-		ChainID:             big.NewInt(55555),
-		HomesteadBlock:      big.NewInt(0),
-		DAOForkBlock:        big.NewInt(0),
-		DAOForkSupport:      false,
-		EIP150Block:         big.NewInt(0),
-		EIP150Hash:          common.HexToHash("0x0"),
-		EIP155Block:         big.NewInt(0),
-		EIP158Block:         big.NewInt(0),
-		ByzantiumBlock:      big.NewInt(0),
-		ConstantinopleBlock: big.NewInt(10),
-		PetersburgBlock:     big.NewInt(10),
-		IstanbulBlock:       big.NewInt(10),
-		BerlinBlock:         big.NewInt(10),
-		LondonBlock:         big.NewInt(10),
+		ChainID:               big.NewInt(55555),
+		HomesteadBlock:        big.NewInt(0),
+		DAOForkBlock:          big.NewInt(0),
+		DAOForkSupport:        false,
+		EIP150Block:           big.NewInt(0),
+		EIP150Hash:            common.HexToHash("0x0"),
+		EIP155Block:           big.NewInt(0),
+		EIP158Block:           big.NewInt(0),
+		ByzantiumBlock:        big.NewInt(0),
+		ConstantinopleBlock:   big.NewInt(10),
+		PetersburgBlock:       big.NewInt(10),
+		IstanbulBlock:         big.NewInt(10),
+		BerlinBlock:           big.NewInt(10),
+		LondonBlock:           big.NewInt(10),
+		PosV2Block:            big.NewInt(0),
+		PosV3Block:            big.NewInt(0),
+		SmartTransferBlock:    big.NewInt(0),
+		TicketStorageBlock:    big.NewInt(10),
+		FsnContractV2Block:    big.NewInt(10),
+		FsnLogV2Block:         big.NewInt(10),
+		BatchSendAssetBlock:   big.NewInt(10),
+		AssetUpdateBlock:      big.NewInt(10),
+		NotationTransferBlock: big.NewInt(10),
 		DaTong: &DaTongConfig{
 			Period: 15,
 		},
 	}
 
+	// DeveloperChainConfig is the chain parameters of the 'efsn --dev' chain,
+	// with every fork enabled from the genesis.
+	DeveloperChainConfig = &ChainConfig{
+		ChainID:               big.NewInt(1337),
+		HomesteadBlock:        big.NewInt(0),
+		DAOForkBlock:          nil,
+		DAOForkSupport:        false,
+		EIP150Block:           big.NewInt(0),
+		EIP150Hash:            common.Hash{},
+		EIP155Block:           big.NewInt(0),
+		EIP158Block:           big.NewInt(0),
+		ByzantiumBlock:        big.NewInt(0),
+		ConstantinopleBlock:   big.NewInt(0),
+		PetersburgBlock:       big.NewInt(0),
+		IstanbulBlock:         big.NewInt(0),
+		BerlinBlock:           big.NewInt(0),
+		LondonBlock:           big.NewInt(0),
+		PosV2Block:            big.NewInt(0),
+		PosV3Block:            big.NewInt(0),
+		SmartTransferBlock:    big.NewInt(0),
+		TicketStorageBlock:    big.NewInt(0),
+		FsnContractV2Block:    big.NewInt(0),
+		FsnLogV2Block:         big.NewInt(0),
+		BatchSendAssetBlock:   big.NewInt(0),
+		AssetUpdateBlock:      big.NewInt(0),
+		NotationTransferBlock: big.NewInt(0),
+		DaTong: &DaTongConfig{
+			InstantSeal: true,
+		},
+	}
+
 	// AllEthashProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Ethash consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -196,12 +262,34 @@ type ChainConfig struct {
 	BerlinBlock         *big.Int `json:"berlinBlock,omitempty"`         // Berlin switch block (nil = no fork, 0 = already on berlin)
 	LondonBlock         *big.Int `json:"londonBlock,omitempty"`         // London switch block (nil = no fork, 0 = already on london)
 
+	// Fusion hard forks
+	PosV2Block            *big.Int          `json:"posV2Block,omitempty"`            // PoS hash v2, header snapshot and multiple mining checks (nil = no fork)
+	PosV3Block            *big.Int          `json:"posV3Block,omitempty"`            // PoS hash v3, FSN contract and stricter ticket lifetime (nil = no fork)
+	SmartTransferBlock    *big.Int          `json:"smartTransferBlock,omitempty"`    // Smart transfer switch block (nil = no fork)
+	TicketStorageBlock    *big.Int          `json:"ticketStorageBlock,omitempty"`    // Per ticket storage layout switch block (nil = no fork)
+	FsnContractV2Block    *big.Int          `json:"fsnContractV2Block,omitempty"`    // FSN contract query and swap functions (nil = no fork)
+	FsnLogV2Block         *big.Int          `json:"fsnLogV2Block,omitempty"`         // ABI encoded FSN call logs with indexed topics (nil = no fork)
+	BatchSendAssetBlock   *big.Int          `json:"batchSendAssetBlock,omitempty"`   // Batch send asset FSN call (nil = no fork)
+	AssetUpdateBlock      *big.Int          `json:"assetUpdateBlock,omitempty"`      // Asset owner transfer and update FSN calls (nil = no fork)
+	NotationTransferBlock *big.Int          `json:"notationTransferBlock,omitempty"` // Notation transfer and release FSN calls (nil = no fork)
+	Vote1FreezeRange      *Vote1FreezeRange `json:"vote1FreezeRange,omitempty"`      // Vote1 transaction freeze and account drain (nil = no fork)
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
 	DaTong *DaTongConfig `json:"datong,omitempty"`
 }
 
+// Vote1FreezeRange is the schedule of the vote1 hard fork. Within the range
+// all transactions except ticket purchases are frozen, and at its end block the
+// accounts of the drain list are transferred to the refund address.
+type Vote1FreezeRange struct {
+	Start         uint64           `json:"start"`
+	End           uint64           `json:"end"`
+	RefundAddress common.Address   `json:"refundAddress"`
+	DrainList     []common.Address `json:"drainList"`
+}
+
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
 type EthashConfig struct{}
 
@@ -309,6 +397,122 @@ func (c *ChainConfig) IsLondon(num *big.Int) bool {
 	return isForked(c.LondonBlock, num)
 }
 
+// IsPosV2 returns whether num is either equal to the PoS v2 fork block or greater.
+func (c *ChainConfig) IsPosV2(num *big.Int) bool {
+	return isForked(c.PosV2Block, num)
+}
+
+// IsPosV3 returns whether num is either equal to the PoS v3 fork block or greater.
+func (c *ChainConfig) IsPosV3(num *big.Int) bool {
+	return isForked(c.PosV3Block, num)
+}
+
+// IsSmartTransfer returns whether num is either equal to the smart transfer fork block or greater.
+func (c *ChainConfig) IsSmartTransfer(num *big.Int) bool {
+	return isForked(c.SmartTransferBlock, num)
+}
+
+// IsTicketStorage returns whether num is either equal to the ticket storage fork block or greater.
+func (c *ChainConfig) IsTicketStorage(num *big.Int) bool {
+	return isForked(c.TicketStorageBlock, num)
+}
+
+// IsFsnContractV2 returns whether num is either equal to the FSN contract v2 fork block or greater.
+func (c *ChainConfig) IsFsnContractV2(num *big.Int) bool {
+	return isForked(c.FsnContractV2Block, num)
+}
+
+// IsFsnLogV2 returns whether num is either equal to the FSN log v2 fork block or greater.
+func (c *ChainConfig) IsFsnLogV2(num *big.Int) bool {
+	return isForked(c.FsnLogV2Block, num)
+}
+
+// IsBatchSendAsset returns whether num is either equal to the batch send asset fork block or greater.
+func (c *ChainConfig) IsBatchSendAsset(num *big.Int) bool {
+	return isForked(c.BatchSendAssetBlock, num)
+}
+
+// IsAssetUpdate returns whether num is either equal to the asset update fork block or greater.
+func (c *ChainConfig) IsAssetUpdate(num *big.Int) bool {
+	return isForked(c.AssetUpdateBlock, num)
+}
+
+// IsNotationTransfer returns whether num is either equal to the notation transfer fork block or greater.
+func (c *ChainConfig) IsNotationTransfer(num *big.Int) bool {
+	return isForked(c.NotationTransferBlock, num)
+}
+
+// IsVote1ForkBlock returns whether num is the block draining the vote1 accounts.
+func (c *ChainConfig) IsVote1ForkBlock(num *big.Int) bool {
+	return c.Vote1FreezeRange != nil && num != nil && num.Uint64() == c.Vote1FreezeRange.End
+}
+
+// GetPoSHashVersion returns the version of the DaTong PoS hash used at num.
+func (c *ChainConfig) GetPoSHashVersion(num *big.Int) int {
+	if c.IsPosV3(num) {
+		return common.PosV3
+	}
+	if c.IsPosV2(num) {
+		return common.PosV2
+	}
+	return common.PosV1
+}
+
+// ForkConfig returns the Fusion hard fork schedule of the chain config, for
+// the fork checks of the common package.
+func (c *ChainConfig) ForkConfig() common.ForkConfig {
+	cfg := common.ForkConfig{
+		PosV2Block:            c.PosV2Block,
+		PosV3Block:            c.PosV3Block,
+		SmartTransferBlock:    c.SmartTransferBlock,
+		TicketStorageBlock:    c.TicketStorageBlock,
+		FsnContractV2Block:    c.FsnContractV2Block,
+		BatchSendAssetBlock:   c.BatchSendAssetBlock,
+		AssetUpdateBlock:      c.AssetUpdateBlock,
+		NotationTransferBlock: c.NotationTransferBlock,
+	}
+	if c.Vote1FreezeRange != nil {
+		cfg.Vote1FreezeEnd = new(big.Int).SetUint64(c.Vote1FreezeRange.End)
+	}
+	return cfg
+}
+
+// WithBuiltinForks fills in the Fusion hard forks of the built-in networks
+// which used to be hard coded, for genesis files and stored configs written
+// before the schedule became part of the chain config. Mainnet and testnet
+// are recognized by their genesis hash, the devnet genesis depends on the
+// --devnetaddr flag and is recognized by its chain id.
+func WithBuiltinForks(ghash common.Hash, config *ChainConfig) *ChainConfig {
+	var builtin *ChainConfig
+	switch {
+	case ghash == MainnetGenesisHash:
+		builtin = MainnetChainConfig
+	case ghash == TestnetGenesisHash:
+		builtin = TestnetChainConfig
+	case config != nil && config.ChainID != nil && config.ChainID.Cmp(DevnetChainConfig.ChainID) == 0:
+		builtin = DevnetChainConfig
+	default:
+		return config
+	}
+	if config == nil || config == builtin {
+		return builtin
+	}
+	cpy := *config
+	if cpy.PosV2Block == nil {
+		cpy.PosV2Block = builtin.PosV2Block
+	}
+	if cpy.PosV3Block == nil {
+		cpy.PosV3Block = builtin.PosV3Block
+	}
+	if cpy.SmartTransferBlock == nil {
+		cpy.SmartTransferBlock = builtin.SmartTransferBlock
+	}
+	if cpy.Vote1FreezeRange == nil {
+		cpy.Vote1FreezeRange = builtin.Vote1FreezeRange
+	}
+	return &cpy
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -371,9 +575,47 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.LondonBlock, newcfg.LondonBlock, head) {
 		return newCompatError("London fork block", c.LondonBlock, newcfg.LondonBlock)
 	}
+	if isForkIncompatible(c.PosV2Block, newcfg.PosV2Block, head) {
+		return newCompatError("PoS v2 fork block", c.PosV2Block, newcfg.PosV2Block)
+	}
+	if isForkIncompatible(c.PosV3Block, newcfg.PosV3Block, head) {
+		return newCompatError("PoS v3 fork block", c.PosV3Block, newcfg.PosV3Block)
+	}
+	if isForkIncompatible(c.SmartTransferBlock, newcfg.SmartTransferBlock, head) {
+		return newCompatError("Smart transfer fork block", c.SmartTransferBlock, newcfg.SmartTransferBlock)
+	}
+	if isForkIncompatible(c.TicketStorageBlock, newcfg.TicketStorageBlock, head) {
+		return newCompatError("Ticket storage fork block", c.TicketStorageBlock, newcfg.TicketStorageBlock)
+	}
+	if isForkIncompatible(c.FsnContractV2Block, newcfg.FsnContractV2Block, head) {
+		return newCompatError("FSN contract v2 fork block", c.FsnContractV2Block, newcfg.FsnContractV2Block)
+	}
+	if isForkIncompatible(c.FsnLogV2Block, newcfg.FsnLogV2Block, head) {
+		return newCompatError("FSN log v2 fork block", c.FsnLogV2Block, newcfg.FsnLogV2Block)
+	}
+	if isForkIncompatible(c.BatchSendAssetBlock, newcfg.BatchSendAssetBlock, head) {
+		return newCompatError("Batch send asset fork block", c.BatchSendAssetBlock, newcfg.BatchSendAssetBlock)
+	}
+	if isForkIncompatible(c.AssetUpdateBlock, newcfg.AssetUpdateBlock, head) {
+		return newCompatError("Asset update fork block", c.AssetUpdateBlock, newcfg.AssetUpdateBlock)
+	}
+	if isForkIncompatible(c.NotationTransferBlock, newcfg.NotationTransferBlock, head) {
+		return newCompatError("Notation transfer fork block", c.NotationTransferBlock, newcfg.NotationTransferBlock)
+	}
+	if start, newStart := c.vote1FreezeStart(), newcfg.vote1FreezeStart(); isForkIncompatible(start, newStart, head) {
+		return newCompatError("Vote1 freeze range", start, newStart)
+	}
 	return nil
 }
 
+// vote1FreezeStart returns the first block of the vote1 freeze range, if scheduled.
+func (c *ChainConfig) vote1FreezeStart() *big.Int {
+	if c.Vote1FreezeRange == nil {
+		return nil
+	}
+	return new(big.Int).SetUint64(c.Vote1FreezeRange.Start)
+}
+
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
 // block s2 because head is already past the fork.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
@@ -464,7 +706,8 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 
 // DaTongConfig is the consensus engine configs for proof-of-stake based sealing.
 type DaTongConfig struct {
-	Period uint64 `json:"period"`
+	Period      uint64 `json:"period"`
+	InstantSeal bool   `json:"instantSeal,omitempty"` // Seal blocks as soon as transactions arrive, for developer chains
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -17,9 +17,12 @@
 package params
 
 import (
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/FusionFoundation/efsn/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -79,3 +82,105 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckCompatibleFusionForks(t *testing.T) {
+	stored := &ChainConfig{TicketStorageBlock: big.NewInt(10), BatchSendAssetBlock: big.NewInt(20)}
+
+	// moving a fork which is already passed requires a rewind
+	err := stored.CheckCompatible(&ChainConfig{TicketStorageBlock: big.NewInt(15), BatchSendAssetBlock: big.NewInt(20)}, 12)
+	want := &ConfigCompatError{What: "Ticket storage fork block", StoredConfig: big.NewInt(10), NewConfig: big.NewInt(15), RewindTo: 9}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("error mismatch: have %v, want %v", err, want)
+	}
+	// a fork ahead of the head can be rescheduled
+	if err := stored.CheckCompatible(&ChainConfig{TicketStorageBlock: big.NewInt(10), BatchSendAssetBlock: big.NewInt(30)}, 12); err != nil {
+		t.Errorf("rescheduling a future fork failed: %v", err)
+	}
+}
+
+func TestFusionForksPersistence(t *testing.T) {
+	// chain configs are stored as JSON, the Fusion fork schedule must survive it
+	for _, config := range []*ChainConfig{MainnetChainConfig, TestnetChainConfig, DevnetChainConfig, DeveloperChainConfig} {
+		data, err := json.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var loaded ChainConfig
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(loaded.ForkConfig(), config.ForkConfig()) {
+			t.Errorf("chain %v: fork schedule mismatch after reload:\nhave %+v\nwant %+v", config.ChainID, loaded.ForkConfig(), config.ForkConfig())
+		}
+		if !reflect.DeepEqual(loaded.Vote1FreezeRange, config.Vote1FreezeRange) {
+			t.Errorf("chain %v: vote1 range mismatch after reload: have %+v, want %+v", config.ChainID, loaded.Vote1FreezeRange, config.Vote1FreezeRange)
+		}
+	}
+}
+
+func TestFusionForksLoading(t *testing.T) {
+	prev := common.GetForkConfig()
+	defer common.SetForkConfig(prev)
+
+	common.SetForkConfig(DevnetChainConfig.ForkConfig())
+	for _, number := range []int64{0, 9, 10, 100} {
+		n := big.NewInt(number)
+		if have, want := common.IsForkEnabled(common.TicketStorageFork, n), DevnetChainConfig.IsTicketStorage(n); have != want {
+			t.Errorf("block %d: ticket storage %v, want %v", number, have, want)
+		}
+		if have, want := common.IsForkEnabled(common.SmartTransferFork, n), DevnetChainConfig.IsSmartTransfer(n); have != want {
+			t.Errorf("block %d: smart transfer %v, want %v", number, have, want)
+		}
+		if have, want := common.GetPoSHashVersion(n), DevnetChainConfig.GetPoSHashVersion(n); have != want {
+			t.Errorf("block %d: PoS hash version %v, want %v", number, have, want)
+		}
+	}
+	common.SetForkConfig(MainnetChainConfig.ForkConfig())
+	if common.IsForkEnabled(common.TicketStorageFork, big.NewInt(1 << 40)) {
+		t.Error("unscheduled ticket storage fork enabled")
+	}
+	if !common.IsVote1ForkBlock(big.NewInt(int64(MainnetChainConfig.Vote1FreezeRange.End))) {
+		t.Error("vote1 fork block not recognized")
+	}
+}
+
+func TestWithBuiltinForks(t *testing.T) {
+	// a config stored before the Fusion forks were part of it
+	legacy := func(chainID int64) *ChainConfig {
+		return &ChainConfig{ChainID: big.NewInt(chainID), HomesteadBlock: big.NewInt(0)}
+	}
+	tests := []struct {
+		ghash   common.Hash
+		config  *ChainConfig
+		builtin *ChainConfig
+	}{
+		{MainnetGenesisHash, legacy(MainnetChainConfig.ChainID.Int64()), MainnetChainConfig},
+		{TestnetGenesisHash, legacy(TestnetChainConfig.ChainID.Int64()), TestnetChainConfig},
+		{common.HexToHash("0x01"), legacy(DevnetChainConfig.ChainID.Int64()), DevnetChainConfig},
+		{common.HexToHash("0x01"), legacy(1), nil},
+	}
+	for i, test := range tests {
+		filled := WithBuiltinForks(test.ghash, test.config)
+		if test.builtin == nil {
+			if filled != test.config {
+				t.Errorf("test %d: unknown chain config changed", i)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(filled.PosV2Block, test.builtin.PosV2Block) ||
+			!reflect.DeepEqual(filled.PosV3Block, test.builtin.PosV3Block) ||
+			!reflect.DeepEqual(filled.SmartTransferBlock, test.builtin.SmartTransferBlock) ||
+			!reflect.DeepEqual(filled.Vote1FreezeRange, test.builtin.Vote1FreezeRange) {
+			t.Errorf("test %d: forks not filled in: %+v", i, filled)
+		}
+		if test.config.PosV2Block != nil {
+			t.Errorf("test %d: stored config modified", i)
+		}
+	}
+	// forks set explicitly are kept
+	config := legacy(MainnetChainConfig.ChainID.Int64())
+	config.PosV2Block = big.NewInt(5)
+	if filled := WithBuiltinForks(MainnetGenesisHash, config); filled.PosV2Block.Int64() != 5 {
+		t.Errorf("explicit fork overridden: have %v", filled.PosV2Block)
+	}
+}
@@ -32,6 +32,14 @@ const (
 	// considered probably final and its rotated bits are calculated.
 	BloomConfirms = 256
 
+	// FsnIndexBlocks is the number of blocks a single Fusion asset and swap
+	// index section contains.
+	FsnIndexBlocks uint64 = 64
+
+	// FsnIndexConfirms is the number of confirmation blocks before a Fusion
+	// index section is considered final and gets indexed.
+	FsnIndexConfirms = 16
+
 	// CHTFrequencyClient is the block frequency for creating CHTs on the client side.
 	CHTFrequencyClient = 32768
 
@@ -19,7 +19,10 @@ package params
 import "math/big"
 
 const (
-	FsnContractGas uint64 = 10000
+	FsnContractGas      uint64 = 10000 // Per FSN contract call, and per sendAsset call after the FSN contract v2 fork.
+	FsnContractReadGas  uint64 = 800   // Per FSN contract query function call.
+	FsnContractWriteGas uint64 = 5000  // Per balance, time lock or swap written by an FSN contract function.
+	FsnContractSwapGas  uint64 = 20000 // Per swap created by the FSN contract.
 
 	GasLimitBoundDivisor uint64 = 1024    // The bound divisor of the gas limit, used in update calculations.
 	MinGasLimit          uint64 = 5000    // Minimum the gas limit may ever be.
@@ -30,9 +30,12 @@ import (
 	"github.com/FusionFoundation/efsn/accounts/usbwallet"
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/consensus/datong/sighash"
+	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/crypto"
 	"github.com/FusionFoundation/efsn/internal/ethapi"
 	"github.com/FusionFoundation/efsn/log"
+	"github.com/FusionFoundation/efsn/rlp"
 )
 
 // ExternalAPI defines the external API through which signing requests are made.
@@ -45,6 +48,8 @@ type ExternalAPI interface {
 	SignTransaction(ctx context.Context, args SendTxArgs, methodSelector *string) (*ethapi.SignTransactionResult, error)
 	// Sign - request to sign the given data (plus prefix)
 	Sign(ctx context.Context, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error)
+	// SignHeader - request to seal the given DaTong block header
+	SignHeader(ctx context.Context, addr common.MixedcaseAddress, header hexutil.Bytes) (hexutil.Bytes, error)
 	// EcRecover - request to perform ecrecover
 	EcRecover(ctx context.Context, data, sig hexutil.Bytes) (common.Address, error)
 	// Export - request to export an account
@@ -59,6 +64,8 @@ type SignerUI interface {
 	ApproveTx(request *SignTxRequest) (SignTxResponse, error)
 	// ApproveSignData prompt the user for confirmation to request to sign data
 	ApproveSignData(request *SignDataRequest) (SignDataResponse, error)
+	// ApproveSealing prompt the user for confirmation to request to seal a block header
+	ApproveSealing(request *SignHeaderRequest) (SignHeaderResponse, error)
 	// ApproveExport prompt the user for confirmation to export encrypted Account json
 	ApproveExport(request *ExportRequest) (ExportResponse, error)
 	// ApproveImport prompt the user for confirmation to import Account json
@@ -164,6 +171,17 @@ type (
 		Approved bool `json:"approved"`
 		Password string
 	}
+	// SignHeaderRequest contains info about a block header to seal
+	SignHeaderRequest struct {
+		Address common.MixedcaseAddress `json:"address"`
+		Header  *types.Header           `json:"header"`
+		Hash    common.Hash             `json:"hash"`
+		Meta    Metadata                `json:"meta"`
+	}
+	SignHeaderResponse struct {
+		Approved bool `json:"approved"`
+		Password string
+	}
 	NewAccountRequest struct {
 		Meta Metadata `json:"meta"`
 	}
@@ -403,6 +421,49 @@ func (api *SignerAPI) Sign(ctx context.Context, addr common.MixedcaseAddress, da
 	return signature, nil
 }
 
+// SignHeader seals the given RLP encoded DaTong block header, returning the
+// signature to be placed at the end of its extra data. Only the coinbase of
+// the header may seal it.
+//
+// Note, unlike Sign, the V value of the signature is 0 or 1, as expected by
+// the consensus engine.
+func (api *SignerAPI) SignHeader(ctx context.Context, addr common.MixedcaseAddress, header hexutil.Bytes) (hexutil.Bytes, error) {
+	h := new(types.Header)
+	if err := rlp.DecodeBytes(header, h); err != nil {
+		return nil, fmt.Errorf("invalid header: %v", err)
+	}
+	if h.Number == nil || h.Difficulty == nil {
+		return nil, errors.New("invalid header: missing number or difficulty")
+	}
+	if h.Coinbase != addr.Address() {
+		return nil, fmt.Errorf("header coinbase %v is not the signer", h.Coinbase.Hex())
+	}
+	hash, err := sighash.Hash(h)
+	if err != nil {
+		return nil, err
+	}
+	req := &SignHeaderRequest{Address: addr, Header: h, Hash: hash, Meta: MetadataFromContext(ctx)}
+	res, err := api.UI.ApproveSealing(req)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Approved {
+		return nil, ErrRequestDenied
+	}
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr.Address()}
+	wallet, err := api.am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashWithPassphrase(account, res.Password, hash.Bytes())
+	if err != nil {
+		api.UI.ShowError(err.Error())
+		return nil, err
+	}
+	return signature, nil
+}
+
 // EcRecover returns the address for the Account that was used to create the signature.
 // Note, this function is compatible with eth_sign and personal_sign. As such it recovers
 // the address of:
@@ -68,6 +68,12 @@ func (ui *HeadlessUI) ApproveSignData(request *SignDataRequest) (SignDataRespons
 	}
 	return SignDataResponse{false, ""}, nil
 }
+func (ui *HeadlessUI) ApproveSealing(request *SignHeaderRequest) (SignHeaderResponse, error) {
+	if "Y" == <-ui.controller {
+		return SignHeaderResponse{true, <-ui.controller}, nil
+	}
+	return SignHeaderResponse{false, ""}, nil
+}
 func (ui *HeadlessUI) ApproveExport(request *ExportRequest) (ExportResponse, error) {
 
 	return ExportResponse{<-ui.controller == "Y"}, nil
@@ -24,8 +24,10 @@ import (
 	"github.com/FusionFoundation/efsn/accounts"
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/internal/ethapi"
 	"github.com/FusionFoundation/efsn/log"
+	"github.com/FusionFoundation/efsn/rlp"
 )
 
 type AuditLogger struct {
@@ -72,6 +74,19 @@ func (l *AuditLogger) Sign(ctx context.Context, addr common.MixedcaseAddress, da
 	return b, e
 }
 
+func (l *AuditLogger) SignHeader(ctx context.Context, addr common.MixedcaseAddress, header hexutil.Bytes) (hexutil.Bytes, error) {
+	var number, parent string
+	h := new(types.Header)
+	if err := rlp.DecodeBytes(header, h); err == nil && h.Number != nil {
+		number, parent = h.Number.String(), h.ParentHash.Hex()
+	}
+	l.log.Info("SignHeader", "type", "request", "metadata", MetadataFromContext(ctx).String(),
+		"addr", addr.String(), "number", number, "parent", parent, "header", common.Bytes2Hex(header))
+	b, e := l.api.SignHeader(ctx, addr, header)
+	l.log.Info("SignHeader", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	return b, e
+}
+
 func (l *AuditLogger) EcRecover(ctx context.Context, data, sig hexutil.Bytes) (common.Address, error) {
 	l.log.Info("EcRecover", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"data", common.Bytes2Hex(data))
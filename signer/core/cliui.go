@@ -154,6 +154,26 @@ func (ui *CommandlineUI) ApproveSignData(request *SignDataRequest) (SignDataResp
 	return SignDataResponse{true, ui.readPassword()}, nil
 }
 
+// ApproveSealing prompt the user for confirmation to request to seal a block header
+func (ui *CommandlineUI) ApproveSealing(request *SignHeaderRequest) (SignHeaderResponse, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Printf("-------- Seal header request--------------\n")
+	fmt.Printf("Account:  %s\n", request.Address.String())
+	fmt.Printf("number:   %v\n", request.Header.Number)
+	fmt.Printf("parent:   %v\n", request.Header.ParentHash.Hex())
+	fmt.Printf("time:     %v\n", request.Header.Time)
+	fmt.Printf("difficulty: %v\n", request.Header.Difficulty)
+	fmt.Printf("seal hash:  %v\n", request.Hash.Hex())
+	fmt.Printf("-------------------------------------------\n")
+	showMetadata(request.Meta)
+	if !ui.confirm() {
+		return SignHeaderResponse{false, ""}, nil
+	}
+	return SignHeaderResponse{true, ui.readPassword()}, nil
+}
+
 // ApproveExport prompt the user for confirmation to export encrypted Account json
 func (ui *CommandlineUI) ApproveExport(request *ExportRequest) (ExportResponse, error) {
 	ui.mu.Lock()
@@ -61,6 +61,12 @@ func (ui *StdIOUI) ApproveSignData(request *SignDataRequest) (SignDataResponse,
 	return result, err
 }
 
+func (ui *StdIOUI) ApproveSealing(request *SignHeaderRequest) (SignHeaderResponse, error) {
+	var result SignHeaderResponse
+	err := ui.dispatch("ApproveSealing", request, &result)
+	return result, err
+}
+
 func (ui *StdIOUI) ApproveExport(request *ExportRequest) (ExportResponse, error) {
 	var result ExportResponse
 	err := ui.dispatch("ApproveExport", request, &result)
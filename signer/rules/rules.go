@@ -175,6 +175,21 @@ func (r *rulesetUI) ApproveSignData(request *core.SignDataRequest) (core.SignDat
 	return core.SignDataResponse{Approved: false, Password: ""}, err
 }
 
+// ApproveSealing asks the rules whether to seal a block header, a separate
+// hook from ApproveSignData as a sealer is mostly unattended.
+func (r *rulesetUI) ApproveSealing(request *core.SignHeaderRequest) (core.SignHeaderResponse, error) {
+	jsonreq, err := json.Marshal(request)
+	approved, err := r.checkApproval("ApproveSealing", jsonreq, err)
+	if err != nil {
+		log.Info("Rule-based approval error, going to manual", "error", err)
+		return r.next.ApproveSealing(request)
+	}
+	if approved {
+		return core.SignHeaderResponse{Approved: true, Password: r.lookupPassword(request.Address.Address())}, nil
+	}
+	return core.SignHeaderResponse{Approved: false, Password: ""}, err
+}
+
 func (r *rulesetUI) ApproveExport(request *core.ExportRequest) (core.ExportResponse, error) {
 	jsonreq, err := json.Marshal(request)
 	approved, err := r.checkApproval("ApproveExport", jsonreq, err)
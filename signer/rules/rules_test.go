@@ -17,16 +17,22 @@
 package rules
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/FusionFoundation/efsn/accounts"
+	"github.com/FusionFoundation/efsn/accounts/keystore"
 	"github.com/FusionFoundation/efsn/common"
 	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/consensus/datong/sighash"
 	"github.com/FusionFoundation/efsn/core/types"
 	"github.com/FusionFoundation/efsn/internal/ethapi"
+	"github.com/FusionFoundation/efsn/rlp"
 	"github.com/FusionFoundation/efsn/signer/core"
 	"github.com/FusionFoundation/efsn/signer/storage"
 )
@@ -85,6 +91,10 @@ func (alwaysDenyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDat
 	return core.SignDataResponse{Approved: false, Password: ""}, nil
 }
 
+func (alwaysDenyUI) ApproveSealing(request *core.SignHeaderRequest) (core.SignHeaderResponse, error) {
+	return core.SignHeaderResponse{Approved: false, Password: ""}, nil
+}
+
 func (alwaysDenyUI) ApproveExport(request *core.ExportRequest) (core.ExportResponse, error) {
 	return core.ExportResponse{Approved: false}, nil
 }
@@ -210,6 +220,11 @@ func (d *dummyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataR
 	return core.SignDataResponse{}, core.ErrRequestDenied
 }
 
+func (d *dummyUI) ApproveSealing(request *core.SignHeaderRequest) (core.SignHeaderResponse, error) {
+	d.calls = append(d.calls, "ApproveSealing")
+	return core.SignHeaderResponse{}, core.ErrRequestDenied
+}
+
 func (d *dummyUI) ApproveExport(request *core.ExportRequest) (core.ExportResponse, error) {
 	d.calls = append(d.calls, "ApproveExport")
 	return core.ExportResponse{}, core.ErrRequestDenied
@@ -259,6 +274,7 @@ func TestForwarding(t *testing.T) {
 		t.Fatalf("Failed to load bootstrap js: %v", err)
 	}
 	r.ApproveSignData(nil)
+	r.ApproveSealing(nil)
 	r.ApproveTx(nil)
 	r.ApproveImport(nil)
 	r.ApproveNewAccount(nil)
@@ -270,7 +286,7 @@ func TestForwarding(t *testing.T) {
 	//This one is not forwarded
 	r.OnApprovedTx(ethapi.SignTransactionResult{})
 
-	expCalls := 8
+	expCalls := 9
 	if len(ui.calls) != expCalls {
 
 		t.Errorf("Expected %d forwarded calls, got %d: %s", expCalls, len(ui.calls), strings.Join(ui.calls, ","))
@@ -522,6 +538,11 @@ func (d *dontCallMe) ApproveSignData(request *core.SignDataRequest) (core.SignDa
 	return core.SignDataResponse{}, core.ErrRequestDenied
 }
 
+func (d *dontCallMe) ApproveSealing(request *core.SignHeaderRequest) (core.SignHeaderResponse, error) {
+	d.t.Fatalf("Did not expect next-handler to be called")
+	return core.SignHeaderResponse{}, core.ErrRequestDenied
+}
+
 func (d *dontCallMe) ApproveExport(request *core.ExportRequest) (core.ExportResponse, error) {
 	d.t.Fatalf("Did not expect next-handler to be called")
 	return core.ExportResponse{}, core.ErrRequestDenied
@@ -629,3 +650,120 @@ function ApproveSignData(r){
 		t.Fatalf("Expected approved")
 	}
 }
+
+func TestSealing(t *testing.T) {
+
+	js := `function ApproveSealing(r){
+    if( r.address.toLowerCase() != "0x694267f14675d7e1b9494fd8d72fefe1755710fa"){
+        return "Reject"
+    }
+    // Never seal below the last sealed header
+    var number = parseInt(r.header.number, 16)
+    var last = parseInt(storage.Get("lastSealed") || "0")
+    if(number < last){
+        return "Reject"
+    }
+    storage.Put("lastSealed", number.toString())
+    return "Approve"
+}`
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Errorf("Couldn't create evaluator %v", err)
+		return
+	}
+	addr, _ := mixAddr("0x694267f14675d7e1b9494fd8d72fefe1755710fa")
+	seal := func(number int64) bool {
+		header := &types.Header{
+			Coinbase:   addr.Address(),
+			Number:     big.NewInt(number),
+			Difficulty: big.NewInt(1),
+			Extra:      make([]byte, 97),
+		}
+		resp, err := r.ApproveSealing(&core.SignHeaderRequest{
+			Address: *addr,
+			Header:  header,
+			Hash:    header.Hash(),
+			Meta:    core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return resp.Approved
+	}
+	if !seal(5) {
+		t.Errorf("Expected header 5 to be approved")
+	}
+	if !seal(7) {
+		t.Errorf("Expected header 7 to be approved")
+	}
+	if seal(6) {
+		t.Errorf("Expected header 6 to be rejected")
+	}
+}
+
+func TestSignHeader(t *testing.T) {
+
+	js := `function ApproveSealing(r){
+    if(parseInt(r.header.number, 16) > 10){
+        return "Reject"
+    }
+    return "Approve"
+}`
+	dir, err := ioutil.TempDir("", "eth-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	account, err := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP).NewAccount("apassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := storage.NewEphemeralStorage()
+	creds.Put(strings.ToLower(account.Address.String()), "apassword")
+
+	r, err := NewRuleEvaluator(&alwaysDenyUI{}, storage.NewEphemeralStorage(), creds)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	if err := r.Init(js); err != nil {
+		t.Fatalf("Couldn't load rules %v", err)
+	}
+	api := core.NewSignerAPI(1, dir, true, r, nil, true)
+
+	addr := common.NewMixedcaseAddress(account.Address)
+	seal := func(number int64) (*types.Header, hexutil.Bytes, error) {
+		header := &types.Header{
+			Coinbase:   account.Address,
+			Number:     big.NewInt(number),
+			Difficulty: big.NewInt(1),
+			Extra:      make([]byte, 97),
+		}
+		enc, _ := rlp.EncodeToBytes(header)
+		sig, err := api.SignHeader(context.Background(), addr, enc)
+		return header, sig, err
+	}
+	header, sig, err := seal(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(header.Extra[len(header.Extra)-len(sig):], sig)
+	signer, err := sighash.Signer(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != account.Address {
+		t.Errorf("Header signer mismatch: have %x, want %x", signer, account.Address)
+	}
+
+	if _, sig, err := seal(11); sig != nil || err != core.ErrRequestDenied {
+		t.Errorf("Expected ErrRequestDenied, got %x %v", sig, err)
+	}
+
+	// Only the coinbase may seal the header
+	enc, _ := rlp.EncodeToBytes(header)
+	other := common.NewMixedcaseAddress(common.HexToAddress("0x01"))
+	if _, err := api.SignHeader(context.Background(), other, enc); err == nil {
+		t.Errorf("Expected error sealing a header of another coinbase")
+	}
+}